@@ -1,62 +1,154 @@
 package main
 
 import (
+	"context"
 	"log"
+	"log/slog"
 	"net/http"
 	"os"
 	"os/signal"
 	"path/filepath"
+	"sync"
 	"syscall"
 	"time"
 
-	// Import the docs package for Swagger
-	_ "github.com/do2024-2047/CalenDO/docs"
+	"github.com/do2024-2047/CalenDO/docs"
 	"github.com/do2024-2047/CalenDO/internal/database"
+	"github.com/do2024-2047/CalenDO/internal/dedupe"
 	"github.com/do2024-2047/CalenDO/internal/handlers"
+	"github.com/do2024-2047/CalenDO/internal/logging"
+	"github.com/do2024-2047/CalenDO/internal/metrics"
 	"github.com/do2024-2047/CalenDO/internal/middleware"
 	"github.com/do2024-2047/CalenDO/internal/repository"
-	"github.com/gorilla/mux"
+	"github.com/do2024-2047/CalenDO/internal/stream"
+	"github.com/do2024-2047/CalenDO/internal/tracing"
+	"github.com/do2024-2047/CalenDO/internal/webhook"
 	"github.com/spf13/viper"
 	httpSwagger "github.com/swaggo/http-swagger"
+	"go.opentelemetry.io/contrib/instrumentation/github.com/gorilla/mux/otelmux"
 )
 
 // @title CalenDO API
 // @version 1.0
 // @description This is the CalenDO API Server for calendar event management.
+// @description Errors are RFC 7807 problem details (application/problem+json) with a stable code field.
+// @description Routes are also served without the version prefix, under /api, for clients of the unversioned API.
+// @BasePath /api/v1
+// @securityDefinitions.apikey AdminToken
+// @in header
+// @name Authorization
+// @description "Bearer " followed by the admin token (auth.admin_token), required by the administration routes
 func main() {
 	// Initialize configuration
 	initConfig()
 
+	// Initialize logging
+	closeLogs, err := logging.Setup(logging.ConfigFromViper())
+	if err != nil {
+		log.Fatalf("Failed to initialize logging: %v", err)
+	}
+	defer closeLogs()
+	slog.Info("Using config file", "path", viper.ConfigFileUsed())
+
+	// Initialize tracing
+	tracingConfig := tracing.ConfigFromViper()
+	shutdownTracing, err := tracing.Setup(context.Background(), tracingConfig)
+	if err != nil {
+		log.Fatalf("Failed to initialize tracing: %v", err)
+	}
+	defer func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		if err := shutdownTracing(ctx); err != nil {
+			slog.Warn("Failed to flush traces", "error", err)
+		}
+	}()
+
 	// Initialize database
-	if err := database.Initialize(); err != nil {
+	db, err := database.Initialize()
+	if err != nil {
 		log.Fatalf("Failed to initialize database: %v", err)
 	}
-	defer database.Close()
+	defer database.Close(db)
 
 	// Initialize repositories
-	eventRepo := repository.NewEventRepository()
-	planningRepo := repository.NewPlanningRepository()
+	eventRepo := repository.NewEventRepository(db)
+	planningRepo := repository.NewPlanningRepository(db)
+	changeRepo := repository.NewChangeRepository(db)
+	webhookRepo := repository.NewWebhookRepository(db)
+	layoutRepo := repository.NewLayoutRepository(db)
+	healthRepo := repository.NewHealthRepository(db)
 
 	// Auto-migrate database tables
-	if err := planningRepo.InitTable(); err != nil {
-		log.Fatalf("Failed to initialize planning table: %v", err)
+	if err := repository.Migrate(db); err != nil {
+		log.Fatalf("Failed to migrate database: %v", err)
 	}
-	if err := eventRepo.InitTable(); err != nil {
-		log.Fatalf("Failed to initialize event table: %v", err)
+
+	// Start delivering importer changes to streaming clients
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	pollInterval := viper.GetDuration("stream.poll_interval")
+	if pollInterval <= 0 {
+		pollInterval = 30 * time.Second
 	}
+	var background sync.WaitGroup
+	broker := stream.NewBroker(changeRepo, pollInterval)
+	background.Add(1)
+	go func() {
+		defer background.Done()
+		broker.Run(ctx)
+	}()
 
-	// Create a new router
-	r := mux.NewRouter()
+	// Deliver changes to registered webhooks. The dispatcher is stopped on its own, before
+	// the broker it subscribes to, and its deliveries in flight are drained on shutdown.
+	dispatchCtx, stopDispatch := context.WithCancel(context.Background())
+	defer stopDispatch()
+	dispatcher := webhook.NewDispatcher(webhookRepo, changeRepo, eventRepo, planningRepo, broker, webhook.Config{
+		MaxAttempts:    viper.GetInt("webhooks.max_attempts"),
+		InitialBackoff: viper.GetDuration("webhooks.initial_backoff"),
+		Timeout:        viper.GetDuration("webhooks.timeout"),
+		PollInterval:   pollInterval,
 
-	// Register routes with repositories
-	handlers.InitializeHandlers(eventRepo)
-	handlers.InitializePlanningHandlers(planningRepo)
-	handlers.RegisterRoutes(r)
+		AllowPrivateNetworks: viper.GetBool("webhooks.allow_private_networks"),
+	})
+	background.Add(1)
+	go func() {
+		defer background.Done()
+		dispatcher.Run(dispatchCtx)
+	}()
 
-	// Serve the Swagger JSON file directly
-	r.HandleFunc("/swagger/swagger.json", func(w http.ResponseWriter, r *http.Request) {
-		http.ServeFile(w, r, "./docs/swagger.json")
+	// Create the API server and its router
+	duplicatePolicy, duplicateOptions := duplicateConfig()
+	server := handlers.NewServer(handlers.Dependencies{
+		Events:     eventRepo,
+		Plannings:  planningRepo,
+		Changes:    changeRepo,
+		Webhooks:   webhookRepo,
+		Layouts:    layoutRepo,
+		Health:     healthRepo,
+		Broker:     broker,
+		Dispatcher: dispatcher,
+	}, handlers.Config{
+		DuplicatePolicy:  duplicatePolicy,
+		DuplicateOptions: duplicateOptions,
+		PingTimeout:      viper.GetDuration("health.ping_timeout"),
+		SyncStaleAfter:   viper.GetDuration("health.sync_stale_after"),
+		AdminToken:       adminToken(),
 	})
+	r := server.Router()
+
+	sqlDB, err := db.DB()
+	if err != nil {
+		log.Fatalf("Failed to get SQL DB for metrics: %v", err)
+	}
+	metrics.Register(sqlDB, eventRepo, planningRepo)
+
+	// Serve the specification embedded in the binary
+	r.HandleFunc("/swagger/swagger.json", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Write(docs.SwaggerJSON)
+	}).Methods(http.MethodGet)
 
 	// Swagger endpoints
 	r.PathPrefix("/swagger/").Handler(httpSwagger.Handler(
@@ -66,9 +158,16 @@ func main() {
 		httpSwagger.DomID("swagger-ui"),
 	)).Methods(http.MethodGet)
 
+	// Prometheus metrics
+	r.Handle("/metrics", metrics.Handler()).Methods(http.MethodGet)
+
 	// Add middleware
+	r.Use(otelmux.Middleware(tracingConfig.ServiceName, otelmux.WithFilter(middleware.Traced)))
+	r.Use(middleware.RequestID)
+	r.Use(middleware.Metrics)
 	r.Use(middleware.Logger)
 	r.Use(middleware.CORS)
+	r.Use(middleware.Compress)
 
 	// Set up the server
 	port := viper.GetString("port")
@@ -87,7 +186,7 @@ func main() {
 
 	// Start the server in a goroutine
 	go func() {
-		log.Printf("Starting server on %s", addr)
+		slog.Info("Starting server", "addr", addr)
 		if err := srv.ListenAndServe(); err != nil && err != http.ErrServerClosed {
 			log.Fatalf("Error starting server: %v", err)
 		}
@@ -96,9 +195,80 @@ func main() {
 	// Wait for interrupt signal to gracefully shut down the server
 	c := make(chan os.Signal, 1)
 	signal.Notify(c, os.Interrupt, syscall.SIGTERM)
-	<-c
+	sig := <-c
+
+	shutdownDelay, shutdownTimeout := shutdownConfig()
+	slog.Info("Server shutting down", "signal", sig.String(), "delay", shutdownDelay, "timeout", shutdownTimeout)
+
+	// Fail the readiness probe and keep serving until load balancers stop sending requests
+	server.SetShuttingDown()
+	time.Sleep(shutdownDelay)
 
-	log.Println("Server shutting down...")
+	// No webhook delivery starts from now on; those in flight are finished below
+	stopDispatch()
+
+	// Stopping the broker ends the event streams, which would otherwise hold the drain
+	// until its timeout; clients reconnect to another replica and resume from their last
+	// event ID.
+	cancel()
+
+	drainCtx, cancelDrain := context.WithTimeout(context.Background(), shutdownTimeout)
+	defer cancelDrain()
+	if err := srv.Shutdown(drainCtx); err != nil {
+		slog.Warn("Requests still in flight after the shutdown timeout, closing their connections", "error", err)
+		srv.Close()
+	}
+	// Deliveries not finished by the same deadline are aborted, and made again after a restart
+	dispatcher.Drain(drainCtx)
+
+	// Wait for the broker and dispatcher, which use the database, before it is closed
+	background.Wait()
+
+	// The database pool, tracer and log file are closed by the deferred calls
+	slog.Info("Server stopped")
+}
+
+// shutdownConfig returns how long the server keeps serving once asked to stop, and how
+// long it then waits for requests in flight to finish
+func shutdownConfig() (time.Duration, time.Duration) {
+	delay := viper.GetDuration("server.shutdown_delay")
+	if !viper.IsSet("server.shutdown_delay") {
+		delay = 5 * time.Second
+	}
+	timeout := viper.GetDuration("server.shutdown_timeout")
+	if timeout <= 0 {
+		timeout = 20 * time.Second
+	}
+	return delay, timeout
+}
+
+// adminToken returns the token of the administration routes, warning when none is set
+func adminToken() string {
+	token := viper.GetString("auth.admin_token")
+	if token == "" {
+		slog.Warn("No admin token configured, the administration routes are closed", "setting", "auth.admin_token")
+	}
+	return token
+}
+
+// duplicateConfig returns the configured duplicate policy and matching options
+func duplicateConfig() (string, dedupe.Options) {
+	policy := viper.GetString("duplicates.policy")
+	if policy == "" {
+		policy = dedupe.PolicyShow
+	}
+	if !dedupe.ValidPolicy(policy) {
+		log.Fatalf("Invalid duplicates.policy %q, expected show, flag or merge", policy)
+	}
+
+	opts := dedupe.DefaultOptions()
+	if viper.IsSet("duplicates.summary_threshold") {
+		opts.SummaryThreshold = viper.GetFloat64("duplicates.summary_threshold")
+	}
+	if viper.IsSet("duplicates.time_tolerance") {
+		opts.TimeTolerance = viper.GetDuration("duplicates.time_tolerance")
+	}
+	return policy, opts
 }
 
 // initConfig reads in config file and ENV variables if set
@@ -120,6 +290,11 @@ func initConfig() {
 	viper.BindEnv("database.username", "DB_USER")
 	viper.BindEnv("database.dbname", "DB_NAME")
 	viper.BindEnv("database.port", "DB_PORT")
+	viper.BindEnv("logging.level", "LOG_LEVEL")
+	viper.BindEnv("logging.format", "LOG_FORMAT")
+	viper.BindEnv("tracing.exporter", "TRACING_EXPORTER")
+	viper.BindEnv("tracing.endpoint", "TRACING_ENDPOINT")
+	viper.BindEnv("auth.admin_token", "ADMIN_TOKEN")
 
 	// Read the config
 	if err := viper.ReadInConfig(); err != nil {
@@ -142,6 +317,4 @@ func initConfig() {
 	if dbPort := os.Getenv("DB_PORT"); dbPort != "" {
 		viper.Set("database.port", dbPort)
 	}
-
-	log.Printf("Using config file: %s", viper.ConfigFileUsed())
 }
@@ -0,0 +1,431 @@
+// Command tsclient generates the typed TypeScript client of the frontend from the OpenAPI
+// specification embedded in the API, so that the frontend cannot drift from the routes and
+// models the API serves. Run it with make client after make swagger.
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/do2024-2047/CalenDO/docs"
+	"github.com/do2024-2047/CalenDO/internal/handlers"
+	"github.com/do2024-2047/CalenDO/internal/models"
+)
+
+// methodOrder is the order in which the operations of a path are generated
+var methodOrder = []string{"get", "post", "put", "patch", "delete"}
+
+// skippedHeaders are header parameters the client does not expose. Conditional requests
+// are left to the browser's HTTP cache.
+var skippedHeaders = map[string]bool{
+	"If-None-Match": true,
+	"Last-Event-ID": true,
+}
+
+var (
+	identifierPattern = regexp.MustCompile(`^[A-Za-z_$][A-Za-z0-9_$]*$`)
+	pathParamPattern  = regexp.MustCompile(`\{([^}]+)\}`)
+)
+
+// spec is the part of a Swagger 2.0 specification the client is generated from
+type spec struct {
+	BasePath    string                          `json:"basePath"`
+	Paths       map[string]map[string]operation `json:"paths"`
+	Definitions map[string]schema               `json:"definitions"`
+}
+
+type operation struct {
+	ID         string              `json:"operationId"`
+	Summary    string              `json:"summary"`
+	Produces   []string            `json:"produces"`
+	Parameters []parameter         `json:"parameters"`
+	Responses  map[string]response `json:"responses"`
+}
+
+type parameter struct {
+	Name        string  `json:"name"`
+	In          string  `json:"in"`
+	Type        string  `json:"type"`
+	Description string  `json:"description"`
+	Required    bool    `json:"required"`
+	Items       *schema `json:"items"`
+	Schema      *schema `json:"schema"`
+}
+
+type response struct {
+	Schema *schema `json:"schema"`
+}
+
+type schema struct {
+	Ref                  string            `json:"$ref"`
+	Type                 string            `json:"type"`
+	Description          string            `json:"description"`
+	Items                *schema           `json:"items"`
+	Properties           map[string]schema `json:"properties"`
+	AdditionalProperties *schema           `json:"additionalProperties"`
+	Required             []string          `json:"required"`
+	Nullable             bool              `json:"x-nullable"`
+}
+
+func main() {
+	output := flag.String("o", "", "File to write the client to (default: standard output)")
+	flag.Parse()
+
+	client, err := generate(docs.SwaggerJSON)
+	if err != nil {
+		log.Fatalf("Failed to generate the client: %v", err)
+	}
+
+	if *output == "" {
+		os.Stdout.Write(client)
+		return
+	}
+	if err := os.WriteFile(*output, client, 0o644); err != nil {
+		log.Fatalf("Failed to write the client: %v", err)
+	}
+}
+
+// generate returns the TypeScript client of a specification: an interface per definition,
+// a parameters interface per operation taking query or header parameters, and createClient
+func generate(specJSON []byte) ([]byte, error) {
+	var s spec
+	if err := json.Unmarshal(specJSON, &s); err != nil {
+		return nil, fmt.Errorf("invalid specification: %v", err)
+	}
+	if s.BasePath != handlers.APIPrefix {
+		return nil, fmt.Errorf("specification is served under %q, not %q", s.BasePath, handlers.APIPrefix)
+	}
+	if _, ok := s.Definitions["models.Problem"]; !ok {
+		return nil, fmt.Errorf("specification does not define models.Problem")
+	}
+
+	var out bytes.Buffer
+	out.WriteString("// Code generated by backend/cmd/tsclient from backend/docs/swagger.json. DO NOT EDIT.\n")
+	out.WriteString("// Regenerate with make client in backend/ after changing the API.\n\n")
+	fmt.Fprintf(&out, "export const API_PREFIX = '%s';\n", s.BasePath)
+
+	names := make([]string, 0, len(s.Definitions))
+	for name := range s.Definitions {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		out.WriteString("\n")
+		writeInterface(&out, typeName(name), s.Definitions[name])
+	}
+
+	out.WriteString(runtime)
+
+	var paths []string
+	for path := range s.Paths {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+
+	var methods bytes.Buffer
+	for _, path := range paths {
+		for _, method := range methodOrder {
+			op, ok := s.Paths[path][method]
+			if !ok || !producesJSON(op) {
+				continue
+			}
+			if op.ID == "" {
+				return nil, fmt.Errorf("%s %s has no @ID", strings.ToUpper(method), path)
+			}
+			if err := writeOperation(&out, &methods, method, path, op); err != nil {
+				return nil, fmt.Errorf("%s %s: %v", strings.ToUpper(method), path, err)
+			}
+		}
+	}
+
+	out.WriteString("\n/** createClient returns the operations of the API, sending their requests through fetcher */\n")
+	out.WriteString("export const createClient = (fetcher: Fetcher) => ({\n")
+	out.Write(methods.Bytes())
+	out.WriteString("});\n\nexport type Client = ReturnType<typeof createClient>;\n")
+	return out.Bytes(), nil
+}
+
+// runtime is the code shared by the operations of the client
+var runtime = `
+/** ApiError is thrown for responses outside 2xx, with the problem details the API sent */
+export class ApiError extends Error {
+  readonly status: number;
+  readonly problem: Problem | null;
+
+  constructor(status: number, problem: Problem | null) {
+    super(problem?.detail ?? problem?.title ?? ` + "`HTTP error! Status: ${status}`" + `);
+    this.name = 'ApiError';
+    this.status = status;
+    this.problem = problem;
+  }
+}
+
+/** Fetcher sends a request to a path starting with API_PREFIX, like fetch */
+export type Fetcher = (path: string, init: RequestInit) => Promise<Response>;
+
+type Values = Record<string, string | number | boolean | undefined>;
+
+interface RequestOptions {
+  query?: Values;
+  headers?: Values;
+  body?: unknown;
+}
+
+const readProblem = async (response: Response): Promise<Problem | null> => {
+  if (!response.headers.get('Content-Type')?.startsWith('` + models.ProblemContentType + `')) {
+    return null;
+  }
+  try {
+    return (await response.json()) as Problem;
+  } catch {
+    return null;
+  }
+};
+
+const request = async <T>(fetcher: Fetcher, method: string, path: string, options: RequestOptions = {}): Promise<T> => {
+  const query = new URLSearchParams();
+  Object.entries(options.query ?? {}).forEach(([name, value]) => {
+    if (value !== undefined) {
+      query.set(name, String(value));
+    }
+  });
+  const headers: Record<string, string> = {};
+  Object.entries(options.headers ?? {}).forEach(([name, value]) => {
+    if (value !== undefined) {
+      headers[name] = String(value);
+    }
+  });
+  if (options.body !== undefined) {
+    headers['Content-Type'] = 'application/json';
+  }
+
+  const search = query.toString();
+  const response = await fetcher(` + "`${API_PREFIX}${path}${search ? `?${search}` : ''}`" + `, {
+    method,
+    headers,
+    body: options.body === undefined ? undefined : JSON.stringify(options.body),
+  });
+  if (!response.ok) {
+    throw new ApiError(response.status, await readProblem(response));
+  }
+  if (response.status === 204) {
+    return undefined as T;
+  }
+  return (await response.json()) as T;
+};
+`
+
+// writeInterface writes the interface of an object definition. Properties the API may omit
+// are optional, and nullable ones also accept null.
+func writeInterface(out *bytes.Buffer, name string, s schema) {
+	required := make(map[string]bool, len(s.Required))
+	for _, property := range s.Required {
+		required[property] = true
+	}
+
+	properties := make([]string, 0, len(s.Properties))
+	for property := range s.Properties {
+		properties = append(properties, property)
+	}
+	sort.Strings(properties)
+
+	fmt.Fprintf(out, "export interface %s {\n", name)
+	for _, property := range properties {
+		field := s.Properties[property]
+		writeComment(out, "  ", field.Description)
+		optional := "?"
+		if required[property] {
+			optional = ""
+		}
+		fmt.Fprintf(out, "  %s%s: %s;\n", propertyName(property), optional, tsType(field))
+	}
+	out.WriteString("}\n")
+}
+
+// writeOperation writes the parameters interface of an operation, when it has query or
+// header parameters, and adds its method to methods
+func writeOperation(out, methods *bytes.Buffer, method, path string, op operation) error {
+	var args, query, headers []string
+	var options []parameter
+	paramsRequired := false
+	paramsType := upperFirst(op.ID) + "Params"
+
+	// Path parameters come first, in the order of the path
+	byName := make(map[string]parameter)
+	for _, p := range op.Parameters {
+		byName[p.In+":"+p.Name] = p
+	}
+	for _, match := range pathParamPattern.FindAllStringSubmatch(path, -1) {
+		p, ok := byName["path:"+match[1]]
+		if !ok {
+			return fmt.Errorf("path parameter %s is not declared", match[1])
+		}
+		args = append(args, fmt.Sprintf("%s: %s", p.Name, paramType(p)))
+	}
+	template := pathParamPattern.ReplaceAllString(path, "$${encodeURIComponent(String($1))}")
+
+	for _, p := range op.Parameters {
+		switch {
+		case p.In == "body":
+			if p.Schema == nil {
+				return fmt.Errorf("body parameter %s has no schema", p.Name)
+			}
+			args = append(args, "body: "+tsType(*p.Schema))
+		case p.In == "query" || (p.In == "header" && !skippedHeaders[p.Name]):
+			options = append(options, p)
+			access := "params." + p.Name
+			if !identifierPattern.MatchString(p.Name) {
+				access = fmt.Sprintf("params['%s']", p.Name)
+			}
+			entry := fmt.Sprintf("%s: %s", propertyName(p.Name), access)
+			if p.In == "query" {
+				query = append(query, entry)
+			} else {
+				headers = append(headers, entry)
+			}
+			paramsRequired = paramsRequired || p.Required
+		}
+	}
+
+	if len(options) > 0 {
+		out.WriteString("\n")
+		writeComment(out, "", "Query and header parameters of "+op.ID)
+		fmt.Fprintf(out, "export interface %s {\n", paramsType)
+		for _, p := range options {
+			writeComment(out, "  ", p.Description)
+			optional := "?"
+			if p.Required {
+				optional = ""
+			}
+			fmt.Fprintf(out, "  %s%s: %s;\n", propertyName(p.Name), optional, paramType(p))
+		}
+		out.WriteString("}\n")
+
+		if paramsRequired {
+			args = append(args, "params: "+paramsType)
+		} else {
+			args = append(args, "params: "+paramsType+" = {}")
+		}
+	}
+
+	var fields []string
+	if len(query) > 0 {
+		fields = append(fields, fmt.Sprintf("query: { %s }", strings.Join(query, ", ")))
+	}
+	if len(headers) > 0 {
+		fields = append(fields, fmt.Sprintf("headers: { %s }", strings.Join(headers, ", ")))
+	}
+	if strings.Contains(strings.Join(args, ","), "body: ") {
+		fields = append(fields, "body")
+	}
+	result := resultType(op)
+	call := fmt.Sprintf("request<%s>(fetcher, '%s', `%s`", result, strings.ToUpper(method), template)
+	if len(fields) > 0 {
+		call += ", { " + strings.Join(fields, ", ") + " }"
+	}
+	call += ")"
+
+	writeComment(methods, "  ", fmt.Sprintf("%s (%s %s)", op.Summary, strings.ToUpper(method), path))
+	fmt.Fprintf(methods, "  %s: (%s): Promise<%s> =>\n    %s,\n", op.ID, strings.Join(args, ", "), result, call)
+	return nil
+}
+
+// resultType returns the type of the body of the successful response of an operation
+func resultType(op operation) string {
+	for _, status := range []string{"200", "201"} {
+		if r, ok := op.Responses[status]; ok && r.Schema != nil {
+			return tsType(*r.Schema)
+		}
+	}
+	return "void"
+}
+
+// producesJSON reports whether an operation responds with JSON, or without a body
+func producesJSON(op operation) bool {
+	if len(op.Produces) == 0 {
+		return true
+	}
+	for _, mediaType := range op.Produces {
+		if mediaType == "application/json" {
+			return true
+		}
+	}
+	return false
+}
+
+// tsType returns the TypeScript type of a schema
+func tsType(s schema) string {
+	var t string
+	switch {
+	case s.Ref != "":
+		t = typeName(strings.TrimPrefix(s.Ref, "#/definitions/"))
+	case s.Type == "array" && s.Items != nil:
+		t = tsType(*s.Items)
+		if strings.Contains(t, " ") {
+			t = "(" + t + ")"
+		}
+		t += "[]"
+	case s.Type == "integer" || s.Type == "number":
+		t = "number"
+	case s.Type == "string" || s.Type == "boolean":
+		t = s.Type
+	case s.Type == "object" && s.AdditionalProperties != nil:
+		t = "Record<string, " + tsType(*s.AdditionalProperties) + ">"
+	default:
+		t = "unknown"
+	}
+	if s.Nullable {
+		t += " | null"
+	}
+	return t
+}
+
+// paramType returns the TypeScript type of a path, query or header parameter
+func paramType(p parameter) string {
+	return tsType(schema{Type: p.Type, Items: p.Items})
+}
+
+// typeName strips the Go package from the name of a definition
+func typeName(definition string) string {
+	return definition[strings.LastIndex(definition, ".")+1:]
+}
+
+// propertyName quotes the names that are not identifiers, like header names
+func propertyName(name string) string {
+	if identifierPattern.MatchString(name) {
+		return name
+	}
+	return "'" + name + "'"
+}
+
+func upperFirst(s string) string {
+	if s == "" {
+		return s
+	}
+	return strings.ToUpper(s[:1]) + s[1:]
+}
+
+// writeComment writes a doc comment, when there is anything to say
+func writeComment(out *bytes.Buffer, indent, text string) {
+	text = strings.TrimSpace(strings.ReplaceAll(text, "*/", "*\\/"))
+	if text == "" {
+		return
+	}
+	lines := strings.Split(text, "\n")
+	if len(lines) == 1 {
+		fmt.Fprintf(out, "%s/** %s */\n", indent, text)
+		return
+	}
+	fmt.Fprintf(out, "%s/**\n", indent)
+	for _, line := range lines {
+		fmt.Fprintf(out, "%s * %s\n", indent, strings.TrimSpace(line))
+	}
+	fmt.Fprintf(out, "%s */\n", indent)
+}
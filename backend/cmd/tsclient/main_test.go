@@ -0,0 +1,45 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"testing"
+
+	"github.com/do2024-2047/CalenDO/docs"
+)
+
+// clientPath is the generated client, relative to this package
+const clientPath = "../../../frontend/src/services/generated/client.ts"
+
+// TestClientIsUpToDate fails when the committed client was not regenerated after a change
+// to the specification
+func TestClientIsUpToDate(t *testing.T) {
+	generated, err := generate(docs.SwaggerJSON)
+	if err != nil {
+		t.Fatalf("failed to generate the client: %v", err)
+	}
+
+	committed, err := os.ReadFile(clientPath)
+	if err != nil {
+		t.Fatalf("failed to read the committed client: %v", err)
+	}
+	if !bytes.Equal(generated, committed) {
+		t.Fatal("frontend/src/services/generated/client.ts is out of date with docs/swagger.json, run make client")
+	}
+}
+
+func TestEveryJSONOperationIsGenerated(t *testing.T) {
+	generated, err := generate(docs.SwaggerJSON)
+	if err != nil {
+		t.Fatalf("failed to generate the client: %v", err)
+	}
+
+	for _, method := range []string{"getEvents:", "getChanges:", "getPlannings:", "setPlanningPreference:", "findSlots:"} {
+		if !bytes.Contains(generated, []byte("  "+method+" (")) {
+			t.Errorf("client has no %s method", method)
+		}
+	}
+	if bytes.Contains(generated, []byte("streamChanges")) {
+		t.Error("client has a method for the event stream, which is not JSON")
+	}
+}
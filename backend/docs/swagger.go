@@ -1,5 +1,13 @@
-// Package docs implements documentation generation for the CalenDO API.
+// Package docs holds the OpenAPI (Swagger 2.0) specification of the CalenDO API.
+//
+// The specification is generated from the annotations of the handlers with make swagger,
+// committed, and embedded in the binary. It is the contract the API tests validate requests
+// and responses against, and the frontend's TypeScript client is generated from.
 package docs
 
-// This file is a placeholder for Swagger documentation
-// The actual registration happens in docs.go
+import _ "embed"
+
+// SwaggerJSON is the specification of the API
+//
+//go:embed swagger.json
+var SwaggerJSON []byte
@@ -0,0 +1,48 @@
+package docs
+
+import (
+	"bytes"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/swaggo/swag"
+	"github.com/swaggo/swag/gen"
+)
+
+// TestSpecificationIsUpToDate regenerates the specification from the annotations, like
+// make swagger, and fails when the committed one differs
+func TestSpecificationIsUpToDate(t *testing.T) {
+	if testing.Short() {
+		t.Skip("generating the specification is skipped in short mode")
+	}
+
+	output := t.TempDir()
+	err := gen.New().Build(&gen.Config{
+		SearchDir:          "..",
+		MainAPIFile:        "api/main.go",
+		PropNamingStrategy: swag.CamelCase,
+		OutputDir:          output,
+		OutputTypes:        []string{"json"},
+		ParseDepth:         100,
+		RequiredByDefault:  true,
+		ParseGoList:        true,
+		LeftTemplateDelim:  "{{",
+		RightTemplateDelim: "}}",
+		CollectionFormat:   "csv",
+		Debugger:           log.New(io.Discard, "", 0),
+	})
+	if err != nil {
+		t.Fatalf("failed to generate the specification: %v", err)
+	}
+
+	generated, err := os.ReadFile(filepath.Join(output, "swagger.json"))
+	if err != nil {
+		t.Fatalf("failed to read the generated specification: %v", err)
+	}
+	if !bytes.Equal(generated, SwaggerJSON) {
+		t.Fatal("docs/swagger.json is out of date with the handler annotations, run make swagger")
+	}
+}
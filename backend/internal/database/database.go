@@ -1,14 +1,17 @@
 package database
 
 import (
+	"context"
 	"fmt"
-	"log"
+	"log/slog"
 	"time"
 
+	"github.com/do2024-2047/CalenDO/internal/logging"
+	"github.com/do2024-2047/CalenDO/internal/tracing"
+	"github.com/jackc/pgx/v5"
 	"github.com/spf13/viper"
 	"gorm.io/driver/postgres"
 	"gorm.io/gorm"
-	"gorm.io/gorm/logger"
 )
 
 // Config represents database configuration
@@ -25,14 +28,11 @@ type Config struct {
 	ConnMaxLifetime time.Duration `mapstructure:"conn_max_lifetime"`
 }
 
-// DB is the global database connection
-var DB *gorm.DB
-
-// Initialize initializes the database connection
-func Initialize() error {
+// Initialize opens the database connection described by the configuration
+func Initialize() (*gorm.DB, error) {
 	var dbConfig Config
 	if err := viper.UnmarshalKey("database", &dbConfig); err != nil {
-		return fmt.Errorf("failed to parse database configuration: %v", err)
+		return nil, fmt.Errorf("failed to parse database configuration: %v", err)
 	}
 
 	// Create PostgreSQL connection string
@@ -46,45 +46,85 @@ func Initialize() error {
 		dbConfig.SSLMode,
 	)
 
-	// Set up GORM logger
-	gormLogger := logger.Default.LogMode(logger.Info)
+	// Log SQL through slog, at the configured level
+	level, err := logging.ParseLevel(viper.GetString("logging.level"))
+	if err != nil {
+		return nil, err
+	}
 
-	// Connect to the database
-	db, err := gorm.Open(postgres.Open(dsn), &gorm.Config{
-		Logger: gormLogger,
-	})
+	db, err := Open(dsn, level)
 	if err != nil {
-		return fmt.Errorf("failed to connect to database: %v", err)
+		return nil, err
 	}
 
 	// Configure connection pool
 	sqlDB, err := db.DB()
 	if err != nil {
-		return fmt.Errorf("failed to get SQL DB: %v", err)
+		return nil, fmt.Errorf("failed to get SQL DB: %v", err)
 	}
 
 	sqlDB.SetMaxOpenConns(dbConfig.MaxOpenConns)
 	sqlDB.SetMaxIdleConns(dbConfig.MaxIdleConns)
 	sqlDB.SetConnMaxLifetime(dbConfig.ConnMaxLifetime)
 
-	DB = db
-	log.Println("Database connection established successfully")
-	return nil
+	slog.Info("Database connection established")
+	return db, nil
+}
+
+// Open connects to the database at dsn, logging SQL at the given level and tracing statements
+func Open(dsn string, level slog.Level) (*gorm.DB, error) {
+	gormLogger := logging.GormLogger(level)
+
+	// Connect to the database
+	db, err := gorm.Open(postgres.Open(dsn), &gorm.Config{
+		Logger: gormLogger,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to database: %v", err)
+	}
+
+	// Record statements run within a request as child spans of the request
+	if err := db.Use(tracing.GormPlugin()); err != nil {
+		return nil, fmt.Errorf("failed to register tracing plugin: %v", err)
+	}
+
+	return db, nil
+}
+
+// Listen opens a dedicated connection to the database of db, subscribed to a Postgres
+// notification channel. LISTEN needs a connection of its own, outside of the GORM pool.
+func Listen(ctx context.Context, db *gorm.DB, channel string) (*pgx.Conn, error) {
+	dialector, ok := db.Dialector.(*postgres.Dialector)
+	if !ok {
+		return nil, fmt.Errorf("cannot listen on a %s database", db.Dialector.Name())
+	}
+
+	conn, err := pgx.Connect(ctx, dialector.Config.DSN)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open listener connection: %v", err)
+	}
+
+	if _, err := conn.Exec(ctx, "LISTEN "+pgx.Identifier{channel}.Sanitize()); err != nil {
+		conn.Close(ctx)
+		return nil, fmt.Errorf("failed to listen on %s: %v", channel, err)
+	}
+
+	return conn, nil
 }
 
 // Close closes the database connection
-func Close() {
-	if DB != nil {
-		sqlDB, err := DB.DB()
+func Close(db *gorm.DB) {
+	if db != nil {
+		sqlDB, err := db.DB()
 		if err != nil {
-			log.Printf("Error getting SQL DB: %v", err)
+			slog.Error("Failed to get SQL DB", "error", err)
 			return
 		}
 
 		if err := sqlDB.Close(); err != nil {
-			log.Printf("Error closing database: %v", err)
+			slog.Error("Failed to close database", "error", err)
 		} else {
-			log.Println("Database connection closed")
+			slog.Info("Database connection closed")
 		}
 	}
 }
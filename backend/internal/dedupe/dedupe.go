@@ -0,0 +1,270 @@
+// Package dedupe finds events that appear in several plannings under different UIDs,
+// such as a meeting present in both a personal and a team calendar.
+package dedupe
+
+import (
+	"sort"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/do2024-2047/CalenDO/internal/models"
+)
+
+// Policies for how aggregate views handle duplicates
+const (
+	// PolicyShow returns every event unchanged
+	PolicyShow = "show"
+	// PolicyFlag returns every event, marking duplicates with the event they duplicate
+	PolicyFlag = "flag"
+	// PolicyMerge returns only the primary event of each group of duplicates
+	PolicyMerge = "merge"
+)
+
+// ValidPolicy reports whether policy is a known duplicate policy
+func ValidPolicy(policy string) bool {
+	switch policy {
+	case PolicyShow, PolicyFlag, PolicyMerge:
+		return true
+	}
+	return false
+}
+
+// Options controls when two events are considered duplicates
+type Options struct {
+	// SummaryThreshold is the minimum summary similarity, between 0 and 1
+	SummaryThreshold float64
+	// TimeTolerance is the largest difference allowed between starts and between ends
+	TimeTolerance time.Duration
+}
+
+// DefaultOptions returns the options used when none are configured
+func DefaultOptions() Options {
+	return Options{SummaryThreshold: 0.8, TimeTolerance: 5 * time.Minute}
+}
+
+// Group is a set of events from different plannings that describe the same occurrence
+type Group struct {
+	// Events starts with the primary event, the one kept when duplicates are merged
+	Events []*models.Event
+	// Score is the lowest summary similarity between the primary event and the others
+	Score float64
+}
+
+// Primary returns the event kept when the group is merged
+func (g Group) Primary() *models.Event {
+	return g.Events[0]
+}
+
+// FindDuplicates groups events from different plannings whose summaries are similar and
+// whose start and end times are within the tolerance of each other. Matches chain, but a group
+// never holds two events of the same planning: the closest matches are grouped first, and a
+// match that would join two events of one planning is left out. Groups are ordered by the
+// start of their primary event, which is the earliest created event of the group.
+func FindDuplicates(events []*models.Event, opts Options) []Group {
+	type entry struct {
+		event   *models.Event
+		summary string
+	}
+
+	var entries []entry
+	seen := make(map[string]bool)
+	for _, event := range events {
+		if seen[event.ID] {
+			continue
+		}
+		seen[event.ID] = true
+		entries = append(entries, entry{event: event, summary: normalize(event.Summary)})
+	}
+
+	sort.Slice(entries, func(a, b int) bool {
+		return entries[a].event.StartTime.Before(entries[b].event.StartTime)
+	})
+
+	type match struct {
+		a, b       int
+		similarity float64
+		offset     time.Duration
+	}
+	var matches []match
+	for i := range entries {
+		for j := i + 1; j < len(entries); j++ {
+			a, b := entries[i].event, entries[j].event
+			// Entries are sorted by start, so later ones can only be further away
+			if b.StartTime.Sub(a.StartTime) > opts.TimeTolerance {
+				break
+			}
+			if a.PlanningID == b.PlanningID || !within(a.EndTime, b.EndTime, opts.TimeTolerance) {
+				continue
+			}
+			if similarity := Similarity(entries[i].summary, entries[j].summary); similarity >= opts.SummaryThreshold {
+				offset := distance(a.StartTime, b.StartTime) + distance(a.EndTime, b.EndTime)
+				matches = append(matches, match{a: i, b: j, similarity: similarity, offset: offset})
+			}
+		}
+	}
+	sort.SliceStable(matches, func(a, b int) bool {
+		if matches[a].similarity != matches[b].similarity {
+			return matches[a].similarity > matches[b].similarity
+		}
+		return matches[a].offset < matches[b].offset
+	})
+
+	// Union-find over matches, so that chains of matches form one group, tracking the
+	// plannings of each group to keep a single event per planning
+	parent := make([]int, len(entries))
+	plannings := make([]map[string]bool, len(entries))
+	for i, e := range entries {
+		parent[i] = i
+		plannings[i] = map[string]bool{e.event.PlanningID: true}
+	}
+	var find func(int) int
+	find = func(i int) int {
+		if parent[i] != i {
+			parent[i] = find(parent[i])
+		}
+		return parent[i]
+	}
+
+	for _, m := range matches {
+		ra, rb := find(m.a), find(m.b)
+		if ra == rb || sharePlanning(plannings[ra], plannings[rb]) {
+			continue
+		}
+		parent[rb] = ra
+		for planningID := range plannings[rb] {
+			plannings[ra][planningID] = true
+		}
+	}
+
+	members := make(map[int][]*models.Event)
+	var roots []int
+	for i, e := range entries {
+		root := find(i)
+		if _, ok := members[root]; !ok {
+			roots = append(roots, root)
+		}
+		members[root] = append(members[root], e.event)
+	}
+
+	groups := []Group{}
+	for _, root := range roots {
+		grouped := members[root]
+		if len(grouped) < 2 {
+			continue
+		}
+
+		sort.SliceStable(grouped, func(a, b int) bool {
+			if !grouped[a].Created.Equal(grouped[b].Created) {
+				return grouped[a].Created.Before(grouped[b].Created)
+			}
+			return grouped[a].ID < grouped[b].ID
+		})
+
+		score := 1.0
+		primary := normalize(grouped[0].Summary)
+		for _, event := range grouped[1:] {
+			if s := Similarity(primary, normalize(event.Summary)); s < score {
+				score = s
+			}
+		}
+		groups = append(groups, Group{Events: grouped, Score: score})
+	}
+
+	sort.SliceStable(groups, func(a, b int) bool {
+		return groups[a].Primary().StartTime.Before(groups[b].Primary().StartTime)
+	})
+	return groups
+}
+
+// Index maps the ID of every event that duplicates another to the ID of its group's primary event
+func Index(groups []Group) map[string]string {
+	index := make(map[string]string)
+	for _, group := range groups {
+		for _, event := range group.Events[1:] {
+			index[event.ID] = group.Primary().ID
+		}
+	}
+	return index
+}
+
+// Similarity compares two normalized summaries, returning 1 for identical ones and 0 for
+// completely different ones. Summaries with the same words in another order are identical.
+func Similarity(a, b string) float64 {
+	if a == b {
+		return 1
+	}
+	if a == "" || b == "" {
+		return 0
+	}
+	if sortedWords(a) == sortedWords(b) {
+		return 1
+	}
+
+	ra, rb := []rune(a), []rune(b)
+	longest := len(ra)
+	if len(rb) > longest {
+		longest = len(rb)
+	}
+	return 1 - float64(levenshtein(ra, rb))/float64(longest)
+}
+
+// normalize lowercases a summary and reduces punctuation and spacing to single spaces
+func normalize(summary string) string {
+	fields := strings.FieldsFunc(strings.ToLower(summary), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+	return strings.Join(fields, " ")
+}
+
+func sortedWords(s string) string {
+	words := strings.Fields(s)
+	sort.Strings(words)
+	return strings.Join(words, " ")
+}
+
+// levenshtein returns the edit distance between two strings
+func levenshtein(a, b []rune) int {
+	previous := make([]int, len(b)+1)
+	current := make([]int, len(b)+1)
+	for j := range previous {
+		previous[j] = j
+	}
+
+	for i := 1; i <= len(a); i++ {
+		current[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			current[j] = min(previous[j]+1, current[j-1]+1, previous[j-1]+cost)
+		}
+		previous, current = current, previous
+	}
+	return previous[len(b)]
+}
+
+// within reports whether two times are at most tolerance apart
+func within(a, b time.Time, tolerance time.Duration) bool {
+	return distance(a, b) <= tolerance
+}
+
+// distance returns how far apart two times are
+func distance(a, b time.Time) time.Duration {
+	diff := a.Sub(b)
+	if diff < 0 {
+		diff = -diff
+	}
+	return diff
+}
+
+// sharePlanning reports whether two sets of planning IDs have one in common
+func sharePlanning(a, b map[string]bool) bool {
+	for planningID := range a {
+		if b[planningID] {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,187 @@
+package dedupe
+
+import (
+	"math"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/do2024-2047/CalenDO/internal/models"
+)
+
+// base is the start of the events of the tests
+var base = time.Date(2026, time.March, 2, 9, 0, 0, 0, time.UTC)
+
+// event returns an hour-long event starting offset after base. Events share their creation
+// time, so the primary of a group is the one with the smallest ID.
+func event(id, planningID, summary string, offset time.Duration) *models.Event {
+	return &models.Event{
+		ID:         id,
+		PlanningID: planningID,
+		Summary:    summary,
+		StartTime:  base.Add(offset),
+		EndTime:    base.Add(offset + time.Hour),
+		Created:    base.Add(-24 * time.Hour),
+	}
+}
+
+// ids returns the event IDs of each group, joined by commas
+func ids(groups []Group) []string {
+	var result []string
+	for _, group := range groups {
+		var members []string
+		for _, e := range group.Events {
+			members = append(members, e.ID)
+		}
+		result = append(result, strings.Join(members, ","))
+	}
+	return result
+}
+
+func TestSimilarity(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name string
+		a, b string
+		want float64
+	}{
+		{"identical", "team sync", "team sync", 1},
+		{"both empty", "", "", 1},
+		{"one empty", "team sync", "", 0},
+		{"words reordered", "sync team", "team sync", 1},
+		{"one letter added", "standup", "standups", 0.875},
+		{"one letter changed", "review", "reviex", 1 - 1.0/6},
+		{"nothing in common", "abc", "xyz", 0},
+		{"normalized punctuation", normalize("Team-Sync!"), normalize("team sync"), 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Similarity(tt.a, tt.b); math.Abs(got-tt.want) > 1e-9 {
+				t.Errorf("Similarity(%q, %q) = %v, want %v", tt.a, tt.b, got, tt.want)
+			}
+			if got := Similarity(tt.b, tt.a); math.Abs(got-tt.want) > 1e-9 {
+				t.Errorf("Similarity(%q, %q) = %v, want %v", tt.b, tt.a, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFindDuplicatesTolerance(t *testing.T) {
+	t.Parallel()
+
+	opts := Options{SummaryThreshold: 0.8, TimeTolerance: 5 * time.Minute}
+	tests := []struct {
+		name      string
+		other     *models.Event
+		duplicate bool
+	}{
+		{"same times", event("b", "team", "Team sync", 0), true},
+		{"start at the tolerance", event("b", "team", "Team sync", 5*time.Minute), true},
+		{"start past the tolerance", event("b", "team", "Team sync", 5*time.Minute+time.Second), false},
+		{"start before, at the tolerance", event("b", "team", "Team sync", -5*time.Minute), true},
+		{"end past the tolerance", func() *models.Event {
+			e := event("b", "team", "Team sync", 0)
+			e.EndTime = e.EndTime.Add(6 * time.Minute)
+			return e
+		}(), false},
+		{"summary with punctuation", event("b", "team", "Team-sync.", 0), true},
+		{"summary above the threshold", event("b", "team", "Team synced", 0), true},
+		{"summary below the threshold", event("b", "team", "Team lunch", 0), false},
+		{"same planning", event("b", "personal", "Team sync", 0), false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			groups := FindDuplicates([]*models.Event{event("a", "personal", "Team sync", 0), tt.other}, opts)
+			if got := len(groups) == 1; got != tt.duplicate {
+				t.Errorf("duplicate = %v, want %v (groups %v)", got, tt.duplicate, ids(groups))
+			}
+		})
+	}
+}
+
+func TestFindDuplicatesGrouping(t *testing.T) {
+	t.Parallel()
+
+	created := func(e *models.Event, hours int) *models.Event {
+		e.Created = base.Add(time.Duration(hours) * time.Hour)
+		return e
+	}
+
+	tests := []struct {
+		name   string
+		events []*models.Event
+		want   []string
+	}{
+		{
+			name: "unrelated events",
+			events: []*models.Event{
+				event("a", "personal", "Dentist", 0),
+				event("b", "team", "Team sync", 0),
+			},
+			want: nil,
+		},
+		{
+			name: "primary is the earliest created",
+			events: []*models.Event{
+				created(event("a", "personal", "Team sync", 0), 2),
+				created(event("b", "team", "Team sync", 0), 1),
+			},
+			want: []string{"b,a"},
+		},
+		{
+			name: "chain across three plannings",
+			events: []*models.Event{
+				event("a", "personal", "Team sync", 0),
+				event("b", "team", "Team sync", 4*time.Minute),
+				event("c", "company", "Team sync", 8*time.Minute),
+			},
+			want: []string{"a,b,c"},
+		},
+		{
+			name: "chain through two events of one planning",
+			events: []*models.Event{
+				event("a1", "personal", "Team sync", 0),
+				event("b", "team", "Team sync", 3*time.Minute),
+				event("a2", "personal", "Team sync", 5*time.Minute),
+			},
+			want: []string{"a2,b"},
+		},
+		{
+			name: "closest summary wins over closest time",
+			events: []*models.Event{
+				event("a1", "personal", "Team syncs", 0),
+				event("b", "team", "Team sync", 4*time.Minute),
+				event("a2", "personal", "Team sync", 5*time.Minute),
+			},
+			want: []string{"a2,b"},
+		},
+		{
+			name: "events listed twice",
+			events: []*models.Event{
+				event("a", "personal", "Team sync", 0),
+				event("a", "personal", "Team sync", 0),
+				event("b", "team", "Team sync", 0),
+			},
+			want: []string{"a,b"},
+		},
+		{
+			name: "groups ordered by start",
+			events: []*models.Event{
+				event("c", "personal", "Review", 2*time.Hour),
+				event("d", "team", "Review", 2*time.Hour),
+				event("a", "personal", "Team sync", 0),
+				event("b", "team", "Team sync", 0),
+			},
+			want: []string{"a,b", "c,d"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := ids(FindDuplicates(tt.events, DefaultOptions()))
+			if strings.Join(got, " ") != strings.Join(tt.want, " ") {
+				t.Errorf("groups = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,23 @@
+package handlers
+
+import (
+	"crypto/subtle"
+	"net/http"
+	"strings"
+
+	"github.com/do2024-2047/CalenDO/internal/models"
+)
+
+// requireAdmin serves next only to requests carrying the admin token as a bearer token.
+// Without a configured token, the routes it guards are closed to every request.
+func (s *Server) requireAdmin(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		token, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+		if !ok || s.adminToken == "" || subtle.ConstantTimeCompare([]byte(token), []byte(s.adminToken)) != 1 {
+			w.Header().Set("WWW-Authenticate", `Bearer realm="calendo"`)
+			writeProblem(w, r, http.StatusUnauthorized, models.ProblemUnauthorized, "This route requires the admin token as a bearer token")
+			return
+		}
+		next(w, r)
+	}
+}
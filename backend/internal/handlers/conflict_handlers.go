@@ -0,0 +1,119 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/do2024-2047/CalenDO/internal/models"
+	"github.com/do2024-2047/CalenDO/internal/scheduling"
+)
+
+// GetConflictsHandler godoc
+// @Summary Get overlapping events
+// @Description List the pairs of events that overlap in time within a window, either inside the same planning,
+// @Description across different plannings, or both. Cancelled and transparent events are ignored.
+// @ID getConflicts
+// @Tags conflicts
+// @Produce json
+// @Param plannings query string false "Comma-separated planning IDs (all plannings when omitted)"
+// @Param start query string false "Window start, RFC 3339 or YYYY-MM-DD (default: today)"
+// @Param end query string false "Window end, RFC 3339 or YYYY-MM-DD (default: start + 7 days)"
+// @Param scope query string false "Which pairs to report: all, same or cross (default: all)"
+// @Param tz query string false "IANA timezone used for dates, all-day events and local times (default: X-Timezone header, then UTC)"
+// @Param X-Timezone header string false "Preferred display timezone"
+// @Param include_all_day query bool false "Whether all-day events can conflict (default: false)"
+// @Success 200 {object} models.ConflictReportResponse
+// @Failure 400 {object} models.Problem "Bad request (invalid_parameter)"
+// @Failure 500 {object} models.Problem "Internal server error (internal_error)"
+// @Router /conflicts [get]
+func (s *Server) GetConflictsHandler(w http.ResponseWriter, r *http.Request) {
+	loc, err := parseLocation(r)
+	if err != nil {
+		invalidParameter(w, r, err)
+		return
+	}
+
+	start, end, err := parseTimeRange(r, loc)
+	if err != nil {
+		invalidParameter(w, r, err)
+		return
+	}
+
+	opts, err := parseOverlapOptions(r, loc)
+	if err != nil {
+		invalidParameter(w, r, err)
+		return
+	}
+
+	planningIDs := parsePlanningIDs(r.URL.Query().Get("plannings"))
+
+	events, err := s.events.WithContext(r.Context()).FindInRange(planningIDs, start.AddDate(0, 0, -1), end.AddDate(0, 0, 1))
+	if err != nil {
+		internalError(w, r, err)
+		return
+	}
+
+	window := scheduling.Interval{Start: start, End: end}
+	response := models.ConflictReportResponse{
+		Start:       start,
+		End:         end,
+		PlanningIDs: planningIDs,
+		Scope:       opts.Scope,
+		Conflicts:   []models.ConflictResponse{},
+	}
+	if response.PlanningIDs == nil {
+		response.PlanningIDs = []string{}
+	}
+
+	for _, overlap := range scheduling.FindOverlaps(events, opts) {
+		common, ok := overlap.Interval.Clip(window)
+		if !ok {
+			continue
+		}
+		response.Conflicts = append(response.Conflicts, models.ConflictResponse{
+			Start:        common.Start.In(loc),
+			End:          common.End.In(loc),
+			SamePlanning: overlap.First.PlanningID == overlap.Second.PlanningID,
+			Events:       []models.EventResponse{overlap.First.ToResponseIn(loc), overlap.Second.ToResponseIn(loc)},
+		})
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(response)
+}
+
+// parseOverlapOptions reads the scope and include_all_day query parameters.
+// A nil loc places all-day events on UTC days.
+func parseOverlapOptions(r *http.Request, loc *time.Location) (scheduling.OverlapOptions, error) {
+	opts := scheduling.OverlapOptions{Scope: r.URL.Query().Get("scope"), Location: loc}
+	if !scheduling.ValidScope(opts.Scope) {
+		return opts, fmt.Errorf("invalid scope %q, expected all, same or cross", opts.Scope)
+	}
+	if opts.Scope == "" {
+		opts.Scope = scheduling.ScopeAll
+	}
+
+	includeAllDay, err := parseBoolParam(r, "include_all_day", false)
+	if err != nil {
+		return opts, err
+	}
+	opts.IncludeAllDay = includeAllDay
+
+	return opts, nil
+}
+
+// annotateConflicts fills the Conflicts field of each response with the IDs of
+// the events it overlaps among the given events and the extra events to compare with
+func annotateConflicts(responses []models.EventResponse, events, compareWith []*models.Event, opts scheduling.OverlapOptions) {
+	all := make([]*models.Event, 0, len(events)+len(compareWith))
+	all = append(all, events...)
+	all = append(all, compareWith...)
+
+	index := scheduling.ConflictIndex(scheduling.FindOverlaps(all, opts))
+	for i := range responses {
+		responses[i].Conflicts = index[responses[i].ID]
+	}
+}
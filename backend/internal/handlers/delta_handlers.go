@@ -0,0 +1,245 @@
+package handlers
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/do2024-2047/CalenDO/internal/models"
+)
+
+const (
+	// deltaPageSize is the number of change log entries folded into a single delta response
+	deltaPageSize = 1000
+	// syncTokenPrefix versions the format of sync tokens
+	syncTokenPrefix = "v1."
+)
+
+// GetChangesHandler godoc
+// @Summary Get changes since a sync token
+// @Description Without since, return a full snapshot of events and plannings and a sync token. With since, return
+// @Description the events created or updated since the token (in their current state), the IDs of deleted events,
+// @Description changed plannings, the IDs of deleted plannings and a new token. When has_more is true, request again with the new token.
+// @Description A token older than the retained change log gets 410 Gone; the client must then fetch a new snapshot.
+// @ID getChanges
+// @Tags changes
+// @Produce json
+// @Param since query string false "Sync token from a previous response (full snapshot when omitted)"
+// @Param plannings query string false "Comma-separated planning IDs (all plannings when omitted)"
+// @Param tz query string false "IANA timezone for local times (default: X-Timezone header, then the planning's timezone, then UTC)"
+// @Param X-Timezone header string false "Preferred display timezone"
+// @Success 200 {object} models.DeltaResponse
+// @Failure 400 {object} models.Problem "Bad request (invalid_parameter)"
+// @Failure 410 {object} models.Problem "Sync token expired (sync_token_expired)"
+// @Failure 500 {object} models.Problem "Internal server error (internal_error)"
+// @Router /changes [get]
+func (s *Server) GetChangesHandler(w http.ResponseWriter, r *http.Request) {
+	loc, err := parseDisplayLocation(r)
+	if err != nil {
+		invalidParameter(w, r, err)
+		return
+	}
+
+	planningIDs := parsePlanningIDs(r.URL.Query().Get("plannings"))
+
+	since := r.URL.Query().Get("since")
+	if since == "" {
+		s.writeSnapshot(w, r, planningIDs, loc)
+		return
+	}
+
+	afterID, err := decodeSyncToken(since)
+	if err != nil {
+		invalidParameter(w, r, err)
+		return
+	}
+
+	purged, head, err := s.changeLogBounds(r)
+	if err != nil {
+		internalError(w, r, err)
+		return
+	}
+	// Either the log was purged past the token, or the token comes from another database
+	if afterID < purged || afterID > head {
+		writeProblem(w, r, http.StatusGone, models.ProblemSyncTokenExpired, "Sync token expired, fetch a full snapshot without since")
+		return
+	}
+
+	changes, err := s.changes.WithContext(r.Context()).FindSince(afterID, planningIDs, deltaPageSize)
+	if err != nil {
+		internalError(w, r, err)
+		return
+	}
+
+	response := models.DeltaResponse{
+		Token:            encodeSyncToken(afterID),
+		HasMore:          len(changes) == deltaPageSize,
+		Events:           []models.EventResponse{},
+		Deleted:          []string{},
+		Plannings:        []models.PlanningResponse{},
+		DeletedPlannings: []string{},
+	}
+	if len(changes) > 0 {
+		response.Token = encodeSyncToken(changes[len(changes)-1].ID)
+	}
+
+	// Only the last change of each event matters, since its current state is sent
+	lastType := make(map[string]string)
+	var eventIDs, changedPlannings []string
+	seenPlannings := make(map[string]bool)
+	for _, change := range changes {
+		if change.Type == models.ChangePlanningChanged {
+			if !seenPlannings[change.PlanningID] {
+				seenPlannings[change.PlanningID] = true
+				changedPlannings = append(changedPlannings, change.PlanningID)
+			}
+			continue
+		}
+		if change.EventID == "" {
+			continue
+		}
+		if _, seen := lastType[change.EventID]; !seen {
+			eventIDs = append(eventIDs, change.EventID)
+		}
+		lastType[change.EventID] = change.Type
+	}
+
+	var upserted []string
+	for _, id := range eventIDs {
+		if lastType[id] == models.ChangeEventDeleted {
+			response.Deleted = append(response.Deleted, id)
+		} else {
+			upserted = append(upserted, id)
+		}
+	}
+
+	events, err := s.events.WithContext(r.Context()).FindByIDs(upserted)
+	if err != nil {
+		internalError(w, r, err)
+		return
+	}
+	found := make(map[string]bool, len(events))
+	for _, event := range events {
+		found[event.ID] = true
+		response.Events = append(response.Events, event.ToResponseIn(loc))
+	}
+	// An event missing here was deleted by a change beyond this page
+	for _, id := range upserted {
+		if !found[id] {
+			response.Deleted = append(response.Deleted, id)
+		}
+	}
+
+	plannings, err := s.plannings.WithContext(r.Context()).FindByIDs(changedPlannings)
+	if err != nil {
+		internalError(w, r, err)
+		return
+	}
+	foundPlannings := make(map[string]bool, len(plannings))
+	for _, planning := range plannings {
+		foundPlannings[planning.ID] = true
+		response.Plannings = append(response.Plannings, planning.ToResponse())
+	}
+	// A changed planning missing here was deleted since the token
+	for _, id := range changedPlannings {
+		if !foundPlannings[id] {
+			response.DeletedPlannings = append(response.DeletedPlannings, id)
+		}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(response)
+}
+
+// changeLogBounds returns the ID of the newest change purged from the log and the ID of the
+// newest change ever logged. Tokens between the two are valid: change IDs may have gaps, so
+// the oldest change left in the log cannot tell whether a token was purged past.
+func (s *Server) changeLogBounds(r *http.Request) (purged, head uint64, err error) {
+	changes := s.changes.WithContext(r.Context())
+	if purged, err = changes.PurgedThroughID(); err != nil {
+		return 0, 0, err
+	}
+	if head, err = changes.LatestID(); err != nil {
+		return 0, 0, err
+	}
+	// A log purged entirely still remembers its newest change
+	return purged, max(head, purged), nil
+}
+
+// writeSnapshot writes every event and planning of the selected plannings with a token for later deltas
+func (s *Server) writeSnapshot(w http.ResponseWriter, r *http.Request, planningIDs []string, loc *time.Location) {
+	// Read the token first: the importer writes rows before logging their change,
+	// so anything missed by the snapshot is logged after the token
+	_, latest, err := s.changeLogBounds(r)
+	if err != nil {
+		internalError(w, r, err)
+		return
+	}
+
+	var events []*models.Event
+	var plannings []*models.Planning
+	if len(planningIDs) == 0 {
+		if events, err = s.events.WithContext(r.Context()).FindAll(); err == nil {
+			plannings, err = s.plannings.WithContext(r.Context()).FindAll()
+		}
+	} else {
+		for _, planningID := range planningIDs {
+			planningEvents, findErr := s.events.WithContext(r.Context()).FindByPlanningID(planningID)
+			if findErr != nil {
+				err = findErr
+				break
+			}
+			events = append(events, planningEvents...)
+		}
+		if err == nil {
+			plannings, err = s.plannings.WithContext(r.Context()).FindByIDs(planningIDs)
+		}
+	}
+	if err != nil {
+		internalError(w, r, err)
+		return
+	}
+
+	response := models.DeltaResponse{
+		Token:            encodeSyncToken(latest),
+		Full:             true,
+		Events:           make([]models.EventResponse, 0, len(events)),
+		Deleted:          []string{},
+		Plannings:        make([]models.PlanningResponse, 0, len(plannings)),
+		DeletedPlannings: []string{},
+	}
+	for _, event := range events {
+		response.Events = append(response.Events, event.ToResponseIn(loc))
+	}
+	for _, planning := range plannings {
+		response.Plannings = append(response.Plannings, planning.ToResponse())
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(response)
+}
+
+// encodeSyncToken turns a change log position into an opaque token
+func encodeSyncToken(changeID uint64) string {
+	return base64.RawURLEncoding.EncodeToString([]byte(syncTokenPrefix + strconv.FormatUint(changeID, 10)))
+}
+
+// decodeSyncToken returns the change log position of a token
+func decodeSyncToken(token string) (uint64, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(token)
+	if err != nil || !strings.HasPrefix(string(raw), syncTokenPrefix) {
+		return 0, fmt.Errorf("invalid sync token")
+	}
+
+	id, err := strconv.ParseUint(strings.TrimPrefix(string(raw), syncTokenPrefix), 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid sync token")
+	}
+	return id, nil
+}
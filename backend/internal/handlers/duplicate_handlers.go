@@ -0,0 +1,159 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/do2024-2047/CalenDO/internal/dedupe"
+	"github.com/do2024-2047/CalenDO/internal/models"
+)
+
+// GetDuplicatesHandler godoc
+// @Summary Get suspected duplicate events
+// @Description List groups of events from different plannings that look like the same occurrence: similar summaries
+// @Description (ignoring case, punctuation and word order) and start and end times within a tolerance. The first event
+// @Description of each group is the primary one, kept when duplicates are merged.
+// @ID getDuplicates
+// @Tags duplicates
+// @Produce json
+// @Param plannings query string false "Comma-separated planning IDs (all plannings when omitted)"
+// @Param start query string false "Window start, RFC 3339 or YYYY-MM-DD (default: today)"
+// @Param end query string false "Window end, RFC 3339 or YYYY-MM-DD (default: start + 7 days)"
+// @Param threshold query number false "Minimum summary similarity between 0 and 1 (default: configured, 0.8)"
+// @Param tolerance query string false "Largest difference between start times and between end times, e.g. 10m (default: configured, 5m)"
+// @Param tz query string false "IANA timezone used for dates and local times (default: X-Timezone header, then UTC)"
+// @Param X-Timezone header string false "Preferred display timezone"
+// @Success 200 {object} models.DuplicateReportResponse
+// @Failure 400 {object} models.Problem "Bad request (invalid_parameter)"
+// @Failure 500 {object} models.Problem "Internal server error (internal_error)"
+// @Router /duplicates [get]
+func (s *Server) GetDuplicatesHandler(w http.ResponseWriter, r *http.Request) {
+	loc, err := parseLocation(r)
+	if err != nil {
+		invalidParameter(w, r, err)
+		return
+	}
+
+	start, end, err := parseTimeRange(r, loc)
+	if err != nil {
+		invalidParameter(w, r, err)
+		return
+	}
+
+	opts, err := s.parseDuplicateOptions(r)
+	if err != nil {
+		invalidParameter(w, r, err)
+		return
+	}
+
+	planningIDs := parsePlanningIDs(r.URL.Query().Get("plannings"))
+
+	events, err := s.events.WithContext(r.Context()).FindInRange(planningIDs, start, end)
+	if err != nil {
+		internalError(w, r, err)
+		return
+	}
+
+	response := models.DuplicateReportResponse{
+		Start:       start,
+		End:         end,
+		PlanningIDs: planningIDs,
+		Groups:      []models.DuplicateGroupResponse{},
+	}
+	if response.PlanningIDs == nil {
+		response.PlanningIDs = []string{}
+	}
+
+	for _, group := range dedupe.FindDuplicates(events, opts) {
+		groupResponse := models.DuplicateGroupResponse{
+			Primary: group.Primary().ID,
+			Score:   group.Score,
+			Events:  make([]models.EventResponse, 0, len(group.Events)),
+		}
+		for _, event := range group.Events {
+			groupResponse.Events = append(groupResponse.Events, event.ToResponseIn(loc))
+		}
+		response.Groups = append(response.Groups, groupResponse)
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(response)
+}
+
+// parseDuplicateOptions reads the threshold and tolerance query parameters over the configured options
+func (s *Server) parseDuplicateOptions(r *http.Request) (dedupe.Options, error) {
+	opts := s.duplicateOptions
+
+	if value := r.URL.Query().Get("threshold"); value != "" {
+		threshold, err := strconv.ParseFloat(value, 64)
+		if err != nil || threshold < 0 || threshold > 1 {
+			return opts, fmt.Errorf("invalid threshold, expected a number between 0 and 1")
+		}
+		opts.SummaryThreshold = threshold
+	}
+
+	if value := r.URL.Query().Get("tolerance"); value != "" {
+		tolerance, err := time.ParseDuration(value)
+		if err != nil || tolerance < 0 {
+			return opts, fmt.Errorf("invalid tolerance, expected a duration such as 10m")
+		}
+		opts.TimeTolerance = tolerance
+	}
+
+	return opts, nil
+}
+
+// parseDuplicatePolicy reads the duplicates query parameter, falling back to the configured policy
+func (s *Server) parseDuplicatePolicy(r *http.Request) (string, error) {
+	policy := r.URL.Query().Get("duplicates")
+	if policy == "" {
+		return s.duplicatePolicy, nil
+	}
+	if !dedupe.ValidPolicy(policy) {
+		return "", fmt.Errorf("invalid duplicates %q, expected show, flag or merge", policy)
+	}
+	return policy, nil
+}
+
+// applyDuplicatePolicy marks or removes the duplicates among responses built from events, in the same order.
+// With PolicyFlag, duplicates get DuplicateOf; with PolicyMerge, they are dropped and listed in the
+// Duplicates field of their primary event.
+func applyDuplicatePolicy(responses []models.EventResponse, events []*models.Event, policy string, opts dedupe.Options) []models.EventResponse {
+	if policy == dedupe.PolicyShow {
+		return responses
+	}
+
+	groups := dedupe.FindDuplicates(events, opts)
+	if len(groups) == 0 {
+		return responses
+	}
+	index := dedupe.Index(groups)
+
+	if policy == dedupe.PolicyFlag {
+		for i := range responses {
+			responses[i].DuplicateOf = index[responses[i].ID]
+		}
+		return responses
+	}
+
+	merged := make(map[string][]string)
+	for _, group := range groups {
+		for _, event := range group.Events[1:] {
+			merged[group.Primary().ID] = append(merged[group.Primary().ID], event.ID)
+		}
+	}
+
+	kept := responses[:0]
+	for _, response := range responses {
+		if _, duplicate := index[response.ID]; duplicate {
+			continue
+		}
+		response.Duplicates = merged[response.ID]
+		kept = append(kept, response)
+	}
+	return kept
+}
@@ -0,0 +1,63 @@
+package handlers
+
+import (
+	"encoding/json"
+	"log/slog"
+	"net/http"
+
+	"github.com/do2024-2047/CalenDO/internal/logging"
+	"github.com/do2024-2047/CalenDO/internal/models"
+)
+
+// internalErrorDetail is the detail of every 500 response; the error itself is only logged
+const internalErrorDetail = "The request could not be completed. Quote the request ID when reporting the problem."
+
+// writeProblem writes an RFC 7807 problem response with a stable error code
+func writeProblem(w http.ResponseWriter, r *http.Request, status int, code, detail string) {
+	problem := models.Problem{
+		Type:      models.ProblemTypePrefix + code,
+		Title:     http.StatusText(status),
+		Status:    status,
+		Detail:    detail,
+		Instance:  r.URL.Path,
+		Code:      code,
+		RequestID: logging.RequestID(r.Context()),
+	}
+
+	w.Header().Set("Content-Type", models.ProblemContentType)
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(problem)
+}
+
+// invalidParameter writes a 400 response for a query or path parameter that err rejected
+func invalidParameter(w http.ResponseWriter, r *http.Request, err error) {
+	writeProblem(w, r, http.StatusBadRequest, models.ProblemInvalidParameter, err.Error())
+}
+
+// invalidBody writes a 400 response for a request body that could not be decoded or
+// failed validation
+func invalidBody(w http.ResponseWriter, r *http.Request, detail string) {
+	writeProblem(w, r, http.StatusBadRequest, models.ProblemInvalidBody, detail)
+}
+
+// internalError logs err with the request's ID and writes a 500 response that does not
+// reveal it, since store errors may carry SQL and connection details
+func internalError(w http.ResponseWriter, r *http.Request, err error) {
+	slog.ErrorContext(r.Context(), "Request failed",
+		"method", r.Method,
+		"path", r.URL.Path,
+		"error", err,
+	)
+	writeProblem(w, r, http.StatusInternalServerError, models.ProblemInternalError, internalErrorDetail)
+}
+
+// routeNotFound answers requests that match no route
+func routeNotFound(w http.ResponseWriter, r *http.Request) {
+	writeProblem(w, r, http.StatusNotFound, models.ProblemRouteNotFound, "No route matches "+r.URL.Path)
+}
+
+// methodNotAllowed answers requests whose path matches a route but not their method
+func methodNotAllowed(w http.ResponseWriter, r *http.Request) {
+	writeProblem(w, r, http.StatusMethodNotAllowed, models.ProblemMethodNotAllowed, r.Method+" is not allowed on "+r.URL.Path)
+}
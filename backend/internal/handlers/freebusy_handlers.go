@@ -0,0 +1,134 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/do2024-2047/CalenDO/internal/models"
+	"github.com/do2024-2047/CalenDO/internal/scheduling"
+	"github.com/google/uuid"
+)
+
+// icalTimeFormat is the UTC date-time format used in iCalendar output
+const icalTimeFormat = "20060102T150405Z"
+
+// GetFreeBusyHandler godoc
+// @Summary Get free/busy information
+// @Description Merge the events of the selected plannings into busy intervals without exposing event details.
+// @Description Cancelled and transparent events are ignored. All-day events block whole days in the requested timezone.
+// @ID getFreeBusy
+// @Tags freebusy
+// @Produce json
+// @Produce text/calendar
+// @Param plannings query string false "Comma-separated planning IDs (all plannings when omitted)"
+// @Param start query string false "Window start, RFC 3339 or YYYY-MM-DD (default: today)"
+// @Param end query string false "Window end, RFC 3339 or YYYY-MM-DD (default: start + 7 days)"
+// @Param tz query string false "IANA timezone used for dates and all-day events (default: X-Timezone header, then UTC)"
+// @Param X-Timezone header string false "Preferred display timezone"
+// @Param include_all_day query bool false "Whether all-day events count as busy (default: true)"
+// @Param format query string false "Response format: json or ics (default: json, or ics when Accept is text/calendar)"
+// @Success 200 {object} models.FreeBusyResponse
+// @Failure 400 {object} models.Problem "Bad request (invalid_parameter)"
+// @Failure 500 {object} models.Problem "Internal server error (internal_error)"
+// @Router /freebusy [get]
+func (s *Server) GetFreeBusyHandler(w http.ResponseWriter, r *http.Request) {
+	loc, err := parseLocation(r)
+	if err != nil {
+		invalidParameter(w, r, err)
+		return
+	}
+
+	start, end, err := parseTimeRange(r, loc)
+	if err != nil {
+		invalidParameter(w, r, err)
+		return
+	}
+
+	includeAllDay, err := parseBoolParam(r, "include_all_day", true)
+	if err != nil {
+		invalidParameter(w, r, err)
+		return
+	}
+
+	planningIDs := parsePlanningIDs(r.URL.Query().Get("plannings"))
+
+	// All-day events are stored as UTC dates, so widen the query by a day on
+	// each side to catch the ones that land inside the window once localised.
+	events, err := s.events.WithContext(r.Context()).FindInRange(planningIDs, start.AddDate(0, 0, -1), end.AddDate(0, 0, 1))
+	if err != nil {
+		internalError(w, r, err)
+		return
+	}
+
+	window := scheduling.Interval{Start: start, End: end}
+	busy := scheduling.BusyIntervals(events, window, scheduling.BusyOptions{
+		IncludeAllDay: includeAllDay,
+		Location:      loc,
+	})
+
+	response := models.FreeBusyResponse{
+		Start:       start,
+		End:         end,
+		PlanningIDs: planningIDs,
+		Busy:        make([]models.BusyPeriod, 0, len(busy)),
+	}
+	if response.PlanningIDs == nil {
+		response.PlanningIDs = []string{}
+	}
+	for _, interval := range busy {
+		response.Busy = append(response.Busy, models.BusyPeriod{
+			Start: interval.Start.In(loc),
+			End:   interval.End.In(loc),
+		})
+	}
+
+	if wantsICalendar(r) {
+		w.Header().Set("Content-Type", "text/calendar; charset=utf-8")
+		w.WriteHeader(http.StatusOK)
+		w.Write([]byte(formatVFreeBusy(response, time.Now())))
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(response)
+}
+
+// wantsICalendar reports whether the client asked for iCalendar output
+func wantsICalendar(r *http.Request) bool {
+	switch strings.ToLower(r.URL.Query().Get("format")) {
+	case "ics", "ical", "icalendar":
+		return true
+	case "json":
+		return false
+	}
+	return strings.Contains(r.Header.Get("Accept"), "text/calendar")
+}
+
+// formatVFreeBusy renders a free/busy response as an iCalendar VFREEBUSY component
+func formatVFreeBusy(response models.FreeBusyResponse, stamp time.Time) string {
+	lines := []string{
+		"BEGIN:VCALENDAR",
+		"VERSION:2.0",
+		"PRODID:-//CalenDO//Free Busy//EN",
+		"METHOD:PUBLISH",
+		"BEGIN:VFREEBUSY",
+		"UID:" + uuid.NewString(),
+		"DTSTAMP:" + stamp.UTC().Format(icalTimeFormat),
+		"DTSTART:" + response.Start.UTC().Format(icalTimeFormat),
+		"DTEND:" + response.End.UTC().Format(icalTimeFormat),
+	}
+
+	for _, period := range response.Busy {
+		lines = append(lines, fmt.Sprintf("FREEBUSY;FBTYPE=BUSY:%s/%s",
+			period.Start.UTC().Format(icalTimeFormat),
+			period.End.UTC().Format(icalTimeFormat),
+		))
+	}
+
+	lines = append(lines, "END:VFREEBUSY", "END:VCALENDAR")
+	return strings.Join(lines, "\r\n") + "\r\n"
+}
@@ -3,6 +3,7 @@ package handlers
 import (
 	"encoding/json"
 	"net/http"
+	"strings"
 	"time"
 
 	"github.com/do2024-2047/CalenDO/internal/models"
@@ -10,39 +11,87 @@ import (
 	"github.com/gorilla/mux"
 )
 
-var (
-	// eventRepo is the event repository used for database operations
-	eventRepo *repository.EventRepository
+// Path prefixes of the API. The routes were first served under LegacyPrefix, which is
+// kept as an alias of the current version.
+const (
+	APIPrefix    = "/api/v1"
+	LegacyPrefix = "/api"
 )
 
-// InitializeHandlers initializes the handlers with dependencies
-func InitializeHandlers(er *repository.EventRepository) {
-	eventRepo = er
+// RegisterRoutes sets up all API routes, under APIPrefix and their aliases under LegacyPrefix.
+// The routes are registered on r itself rather than on subrouters, which mux would answer
+// with 404 instead of 405 when only the method does not match.
+func (s *Server) RegisterRoutes(r *mux.Router) {
+	s.registerRoutes(r, APIPrefix, nil)
+	s.registerRoutes(r, LegacyPrefix, successorVersion)
 }
 
-// RegisterRoutes sets up all API routes
-func RegisterRoutes(r *mux.Router) {
-	r.HandleFunc("/api/health", HealthCheckHandler).Methods("GET")
+// registerRoutes sets up the API routes under a prefix, wrapping their handlers with wrap
+// when it is not nil
+func (s *Server) registerRoutes(r *mux.Router, prefix string, wrap func(http.Handler) http.Handler) {
+	handle := func(path string, handler http.HandlerFunc) *mux.Route {
+		if wrap != nil {
+			return r.Handle(prefix+path, wrap(handler))
+		}
+		return r.Handle(prefix+path, handler)
+	}
+
+	handle("/health", s.HealthCheckHandler).Methods("GET")
+	handle("/health/live", s.LivenessHandler).Methods("GET")
+	handle("/health/ready", s.ReadinessHandler).Methods("GET")
+
+	handle("/plannings", s.GetPlanningsHandler).Methods("GET")
+	handle("/plannings/default", s.GetDefaultPlanningHandler).Methods("GET")
+	handle("/plannings/{id}", s.GetPlanningHandler).Methods("GET")
+	handle("/plannings/{id}/settings", s.requireAdmin(s.UpdatePlanningSettingsHandler)).Methods("PUT", "OPTIONS")
+	handle("/plannings/{id}/preferences", s.UpdatePlanningPreferenceHandler).Methods("PUT", "OPTIONS")
+	handle("/plannings/{id}/preferences", s.DeletePlanningPreferenceHandler).Methods("DELETE")
+
+	handle("/planning-groups", s.GetPlanningGroupsHandler).Methods("GET")
+	handle("/planning-groups", s.requireAdmin(s.CreatePlanningGroupHandler)).Methods("POST", "OPTIONS")
+	handle("/planning-groups/{id}", s.requireAdmin(s.UpdatePlanningGroupHandler)).Methods("PUT", "OPTIONS")
+	handle("/planning-groups/{id}", s.requireAdmin(s.DeletePlanningGroupHandler)).Methods("DELETE", "OPTIONS")
+
+	handle("/events", s.GetEventsHandler).Methods("GET")
+	handle("/events/{id}", s.GetEventHandler).Methods("GET")
 
-	r.HandleFunc("/api/plannings", GetPlanningsHandler).Methods("GET")
-	r.HandleFunc("/api/plannings/default", GetDefaultPlanningHandler).Methods("GET")
-	r.HandleFunc("/api/plannings/{id}", GetPlanningHandler).Methods("GET")
+	handle("/plannings/{id}/events", s.GetPlanningEventsHandler).Methods("GET")
+	handle("/plannings/{planningId}/events/{uid}", s.GetPlanningEventHandler).Methods("GET")
 
-	r.HandleFunc("/api/events", GetEventsHandler).Methods("GET")
-	r.HandleFunc("/api/events/{id}", GetEventHandler).Methods("GET")
+	handle("/freebusy", s.GetFreeBusyHandler).Methods("GET")
+	handle("/scheduling/find-slots", s.FindSlotsHandler).Methods("POST", "OPTIONS")
+	handle("/conflicts", s.GetConflictsHandler).Methods("GET")
+	handle("/duplicates", s.GetDuplicatesHandler).Methods("GET")
 
-	r.HandleFunc("/api/plannings/{id}/events", GetPlanningEventsHandler).Methods("GET")
-	r.HandleFunc("/api/plannings/{planningId}/events/{uid}", GetPlanningEventHandler).Methods("GET")
+	handle("/stream", s.StreamHandler).Methods("GET")
+	handle("/changes", s.GetChangesHandler).Methods("GET")
+
+	handle("/webhooks", s.requireAdmin(s.GetWebhooksHandler)).Methods("GET")
+	handle("/webhooks", s.requireAdmin(s.CreateWebhookHandler)).Methods("POST", "OPTIONS")
+	handle("/webhooks/{id}", s.requireAdmin(s.GetWebhookHandler)).Methods("GET")
+	handle("/webhooks/{id}", s.requireAdmin(s.DeleteWebhookHandler)).Methods("DELETE", "OPTIONS")
+	handle("/webhooks/{id}/test", s.requireAdmin(s.TestWebhookHandler)).Methods("POST", "OPTIONS")
+	handle("/webhooks/{id}/deliveries", s.requireAdmin(s.GetWebhookDeliveriesHandler)).Methods("GET")
+}
+
+// successorVersion links the responses of legacy routes to the same route under APIPrefix
+func successorVersion(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		successor := APIPrefix + strings.TrimPrefix(r.URL.Path, LegacyPrefix)
+		w.Header().Add("Link", "<"+successor+`>; rel="successor-version"`)
+		next.ServeHTTP(w, r)
+	})
 }
 
 // HealthCheckHandler godoc
 // @Summary Get API health status
 // @Description Check if the API is up and running
+// @ID getHealth
 // @Tags health
 // @Produce json
 // @Success 200 {object} map[string]string
-// @Router /api/health [get]
-func HealthCheckHandler(w http.ResponseWriter, r *http.Request) {
+// @Router /health [get]
+func (s *Server) HealthCheckHandler(w http.ResponseWriter, r *http.Request) {
 	response := map[string]string{
 		"status": "ok",
 		"time":   time.Now().Format(time.RFC3339),
@@ -55,23 +104,69 @@ func HealthCheckHandler(w http.ResponseWriter, r *http.Request) {
 // GetEventsHandler godoc
 // @Summary Get all events
 // @Description Retrieve all calendar events
+// @ID getEvents
 // @Tags events
 // @Produce json
+// @Param If-None-Match header string false "ETag of a cached copy"
+// @Param tz query string false "IANA timezone for local times (default: X-Timezone header, then the planning's timezone, then UTC)"
+// @Param X-Timezone header string false "Preferred display timezone"
+// @Param include_conflicts query bool false "List the IDs of overlapping events in each event's conflicts field"
+// @Param scope query string false "Conflicts to report: all, same or cross planning (default: all)"
+// @Param include_all_day query bool false "Whether all-day events can conflict (default: false)"
+// @Param duplicates query string false "Duplicates across plannings: show, flag (set duplicate_of) or merge (keep the primary event only) (default: duplicates.policy)"
 // @Success 200 {array} models.EventResponse
-// @Failure 500 {object} string "Internal server error"
-// @Router /api/events [get]
-func GetEventsHandler(w http.ResponseWriter, r *http.Request) {
-	events, err := eventRepo.FindAll()
+// @Success 304 "Not modified"
+// @Failure 400 {object} models.Problem "Bad request (invalid_parameter)"
+// @Failure 500 {object} models.Problem "Internal server error (internal_error)"
+// @Router /events [get]
+func (s *Server) GetEventsHandler(w http.ResponseWriter, r *http.Request) {
+	loc, err := parseDisplayLocation(r)
+	if err != nil {
+		invalidParameter(w, r, err)
+		return
+	}
+
+	includeConflicts, err := parseBoolParam(r, "include_conflicts", false)
+	if err != nil {
+		invalidParameter(w, r, err)
+		return
+	}
+	opts, err := parseOverlapOptions(r, loc)
+	if err != nil {
+		invalidParameter(w, r, err)
+		return
+	}
+	policy, err := s.parseDuplicatePolicy(r)
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		invalidParameter(w, r, err)
+		return
+	}
+
+	fp, err := s.events.WithContext(r.Context()).Fingerprint(nil)
+	if err != nil {
+		internalError(w, r, err)
+		return
+	}
+	if s.checkNotModified(w, r, fp) {
+		return
+	}
+
+	events, err := s.events.WithContext(r.Context()).FindAll()
+	if err != nil {
+		internalError(w, r, err)
 		return
 	}
 
 	// Convert to response format
-	var responses []models.EventResponse
+	responses := make([]models.EventResponse, 0, len(events))
 	for _, event := range events {
-		responses = append(responses, event.ToResponse())
+		responses = append(responses, event.ToResponseIn(loc))
+	}
+
+	if includeConflicts {
+		annotateConflicts(responses, events, nil, opts)
 	}
+	responses = applyDuplicatePolicy(responses, events, policy, s.duplicateOptions)
 
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(http.StatusOK)
@@ -81,55 +176,132 @@ func GetEventsHandler(w http.ResponseWriter, r *http.Request) {
 // GetEventHandler godoc
 // @Summary Get a specific event
 // @Description Get a calendar event by its composite ID (uid_planningid)
+// @ID getEvent
 // @Tags events
 // @Produce json
+// @Param If-None-Match header string false "ETag of a cached copy"
 // @Param id path string true "Event composite ID"
+// @Param tz query string false "IANA timezone for local times (default: X-Timezone header, then the planning's timezone, then UTC)"
+// @Param X-Timezone header string false "Preferred display timezone"
 // @Success 200 {object} models.EventResponse
-// @Failure 404 {object} string "Event not found"
-// @Failure 500 {object} string "Internal server error"
-// @Router /api/events/{id} [get]
-func GetEventHandler(w http.ResponseWriter, r *http.Request) {
+// @Success 304 "Not modified"
+// @Failure 400 {object} models.Problem "Bad request (invalid_parameter)"
+// @Failure 404 {object} models.Problem "Event not found (event_not_found)"
+// @Failure 500 {object} models.Problem "Internal server error (internal_error)"
+// @Router /events/{id} [get]
+func (s *Server) GetEventHandler(w http.ResponseWriter, r *http.Request) {
 	vars := mux.Vars(r)
 	eventID := vars["id"]
 
-	event, err := eventRepo.FindByID(eventID)
+	loc, err := parseDisplayLocation(r)
+	if err != nil {
+		invalidParameter(w, r, err)
+		return
+	}
+
+	fp, err := s.events.WithContext(r.Context()).Fingerprint(nil)
+	if err != nil {
+		internalError(w, r, err)
+		return
+	}
+	if s.checkNotModified(w, r, fp) {
+		return
+	}
+
+	event, err := s.events.WithContext(r.Context()).FindByID(eventID)
 	if err == repository.ErrNotFound {
-		http.Error(w, "Event not found", http.StatusNotFound)
+		writeProblem(w, r, http.StatusNotFound, models.ProblemEventNotFound, "Event not found")
 		return
 	} else if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		internalError(w, r, err)
 		return
 	}
 
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(http.StatusOK)
-	json.NewEncoder(w).Encode(event.ToResponse())
+	json.NewEncoder(w).Encode(event.ToResponseIn(loc))
 }
 
 // GetPlanningEventsHandler godoc
 // @Summary Get events for a specific planning
 // @Description Retrieve all events for a specific planning
+// @ID getPlanningEvents
 // @Tags events
 // @Produce json
+// @Param If-None-Match header string false "ETag of a cached copy"
 // @Param id path string true "Planning ID"
+// @Param tz query string false "IANA timezone for local times (default: X-Timezone header, then the planning's timezone, then UTC)"
+// @Param X-Timezone header string false "Preferred display timezone"
+// @Param include_conflicts query bool false "List the IDs of overlapping events in each event's conflicts field"
+// @Param conflicts_with query string false "Comma-separated planning IDs whose events are also checked for conflicts"
+// @Param include_all_day query bool false "Whether all-day events can conflict (default: false)"
 // @Success 200 {array} models.EventResponse
-// @Failure 400 {object} string "Bad request"
-// @Failure 500 {object} string "Internal server error"
-// @Router /api/plannings/{id}/events [get]
-func GetPlanningEventsHandler(w http.ResponseWriter, r *http.Request) {
+// @Success 304 "Not modified"
+// @Failure 400 {object} models.Problem "Bad request (invalid_parameter)"
+// @Failure 500 {object} models.Problem "Internal server error (internal_error)"
+// @Router /plannings/{id}/events [get]
+func (s *Server) GetPlanningEventsHandler(w http.ResponseWriter, r *http.Request) {
 	vars := mux.Vars(r)
 	planningID := vars["id"]
 
-	events, err := eventRepo.FindByPlanningID(planningID)
+	loc, err := parseDisplayLocation(r)
+	if err != nil {
+		invalidParameter(w, r, err)
+		return
+	}
+
+	includeConflicts, err := parseBoolParam(r, "include_conflicts", false)
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		invalidParameter(w, r, err)
+		return
+	}
+	opts, err := parseOverlapOptions(r, loc)
+	if err != nil {
+		invalidParameter(w, r, err)
+		return
+	}
+
+	// Conflicts may involve the events of other plannings
+	fingerprinted := []string{planningID}
+	if includeConflicts {
+		fingerprinted = append(fingerprinted, parsePlanningIDs(r.URL.Query().Get("conflicts_with"))...)
+	}
+	fp, err := s.events.WithContext(r.Context()).Fingerprint(fingerprinted)
+	if err != nil {
+		internalError(w, r, err)
+		return
+	}
+	if s.checkNotModified(w, r, fp) {
+		return
+	}
+
+	events, err := s.events.WithContext(r.Context()).FindByPlanningID(planningID)
+	if err != nil {
+		internalError(w, r, err)
 		return
 	}
 
 	// Convert to response format
-	var responses []models.EventResponse
+	responses := make([]models.EventResponse, 0, len(events))
 	for _, event := range events {
-		responses = append(responses, event.ToResponse())
+		responses = append(responses, event.ToResponseIn(loc))
+	}
+
+	if includeConflicts {
+		// Events of the other plannings only count when they overlap this planning's events
+		var others []*models.Event
+		for _, otherID := range parsePlanningIDs(r.URL.Query().Get("conflicts_with")) {
+			if otherID == planningID {
+				continue
+			}
+			otherEvents, err := s.events.WithContext(r.Context()).FindByPlanningID(otherID)
+			if err != nil {
+				internalError(w, r, err)
+				return
+			}
+			others = append(others, otherEvents...)
+		}
+		annotateConflicts(responses, events, others, opts)
 	}
 
 	w.Header().Set("Content-Type", "application/json")
@@ -140,29 +312,50 @@ func GetPlanningEventsHandler(w http.ResponseWriter, r *http.Request) {
 // GetPlanningEventHandler godoc
 // @Summary Get a specific event from a planning
 // @Description Get a calendar event by its UID from a specific planning
+// @ID getPlanningEventByUid
 // @Tags events
 // @Produce json
+// @Param If-None-Match header string false "ETag of a cached copy"
 // @Param planningId path string true "Planning ID"
 // @Param uid path string true "Event UID"
+// @Param tz query string false "IANA timezone for local times (default: X-Timezone header, then the planning's timezone, then UTC)"
+// @Param X-Timezone header string false "Preferred display timezone"
 // @Success 200 {object} models.EventResponse
-// @Failure 404 {object} string "Event not found"
-// @Failure 500 {object} string "Internal server error"
-// @Router /api/plannings/{planningId}/events/{uid} [get]
-func GetPlanningEventHandler(w http.ResponseWriter, r *http.Request) {
+// @Success 304 "Not modified"
+// @Failure 400 {object} models.Problem "Bad request (invalid_parameter)"
+// @Failure 404 {object} models.Problem "Event not found (event_not_found)"
+// @Failure 500 {object} models.Problem "Internal server error (internal_error)"
+// @Router /plannings/{planningId}/events/{uid} [get]
+func (s *Server) GetPlanningEventHandler(w http.ResponseWriter, r *http.Request) {
 	vars := mux.Vars(r)
 	planningID := vars["planningId"]
 	eventUID := vars["uid"]
 
-	event, err := eventRepo.FindByUIDAndPlanningID(eventUID, planningID)
+	loc, err := parseDisplayLocation(r)
+	if err != nil {
+		invalidParameter(w, r, err)
+		return
+	}
+
+	fp, err := s.events.WithContext(r.Context()).Fingerprint([]string{planningID})
+	if err != nil {
+		internalError(w, r, err)
+		return
+	}
+	if s.checkNotModified(w, r, fp) {
+		return
+	}
+
+	event, err := s.events.WithContext(r.Context()).FindByUIDAndPlanningID(eventUID, planningID)
 	if err == repository.ErrNotFound {
-		http.Error(w, "Event not found", http.StatusNotFound)
+		writeProblem(w, r, http.StatusNotFound, models.ProblemEventNotFound, "Event not found")
 		return
 	} else if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		internalError(w, r, err)
 		return
 	}
 
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(http.StatusOK)
-	json.NewEncoder(w).Encode(event.ToResponse())
+	json.NewEncoder(w).Encode(event.ToResponseIn(loc))
 }
@@ -0,0 +1,146 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"github.com/do2024-2047/CalenDO/internal/models"
+	"github.com/do2024-2047/CalenDO/internal/repository"
+)
+
+const (
+	// defaultPingTimeout bounds the database checks of the readiness probe
+	defaultPingTimeout = 2 * time.Second
+	// defaultSyncStaleAfter is how long a planning may go without a successful sync;
+	// the importer runs hourly by default
+	defaultSyncStaleAfter = 3 * time.Hour
+)
+
+// SetShuttingDown makes the readiness probe fail from now on, so that the replica is
+// taken out of rotation before it stops accepting connections
+func (s *Server) SetShuttingDown() {
+	s.shuttingDown.Store(true)
+}
+
+// LivenessHandler godoc
+// @Summary Liveness probe
+// @Description Answers as long as the process serves HTTP requests. It does not check the database, so that
+// @Description a database outage does not get every replica restarted.
+// @ID getLiveness
+// @Tags health
+// @Produce json
+// @Success 200 {object} map[string]string
+// @Router /health/live [get]
+func (s *Server) LivenessHandler(w http.ResponseWriter, r *http.Request) {
+	s.HealthCheckHandler(w, r)
+}
+
+// ReadinessHandler godoc
+// @Summary Readiness probe
+// @Description Checks that the database answers within a timeout and that its schema version is the one this API
+// @Description expects, and reports how long ago each planning was last synced by the importer. Stale plannings
+// @Description are reported without failing the probe. Once the server is shutting down, the probe fails with the
+// @Description shutting_down status.
+// @ID getReadiness
+// @Tags health
+// @Produce json
+// @Success 200 {object} models.Readiness
+// @Failure 503 {object} models.Readiness "Not ready"
+// @Router /health/ready [get]
+func (s *Server) ReadinessHandler(w http.ResponseWriter, r *http.Request) {
+	ctx, cancel := context.WithTimeout(r.Context(), s.pingTimeout)
+	defer cancel()
+	repo := s.health.WithContext(ctx)
+
+	now := time.Now()
+	readiness := models.Readiness{
+		Status:    models.ReadinessReady,
+		Time:      now.Format(time.RFC3339),
+		Database:  checkDatabase(r.Context(), repo),
+		Schema:    models.SchemaCheck{Expected: repository.SchemaVersion},
+		Plannings: []models.PlanningSync{},
+	}
+
+	if readiness.Database.Status == models.HealthOK {
+		readiness.Schema = checkSchema(r.Context(), repo)
+
+		syncs, err := repo.FindPlanningSyncs()
+		if err != nil {
+			slog.WarnContext(r.Context(), "Failed to read planning syncs", "error", err)
+		} else if len(syncs) > 0 {
+			readiness.Plannings = s.planningFreshness(syncs, now)
+		}
+	} else {
+		readiness.Schema.Status = models.HealthFailing
+		readiness.Schema.Error = "database unavailable"
+	}
+
+	status := http.StatusOK
+	if s.shuttingDown.Load() {
+		readiness.Status = models.ReadinessShuttingDown
+		status = http.StatusServiceUnavailable
+	} else if readiness.Database.Status != models.HealthOK || readiness.Schema.Status != models.HealthOK {
+		readiness.Status = models.ReadinessNotReady
+		status = http.StatusServiceUnavailable
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(readiness)
+}
+
+// checkDatabase pings the database. The probe is public, so the error is logged and the
+// response only says that the database is unreachable.
+func checkDatabase(ctx context.Context, repo repository.HealthStore) models.HealthCheck {
+	start := time.Now()
+	err := repo.Ping()
+	check := models.HealthCheck{Status: models.HealthOK, LatencyMs: time.Since(start).Milliseconds()}
+	if err != nil {
+		slog.ErrorContext(ctx, "Readiness database check failed", "error", err)
+		check.Status = models.HealthFailing
+		check.Error = "database unreachable"
+	}
+	return check
+}
+
+// checkSchema compares the schema version of the database with repository.SchemaVersion
+func checkSchema(ctx context.Context, repo repository.HealthStore) models.SchemaCheck {
+	start := time.Now()
+	version, err := repo.FindSchemaVersion()
+	check := models.SchemaCheck{
+		HealthCheck: models.HealthCheck{Status: models.HealthOK, LatencyMs: time.Since(start).Milliseconds()},
+		Version:     version,
+		Expected:    repository.SchemaVersion,
+	}
+
+	switch {
+	case err != nil:
+		slog.ErrorContext(ctx, "Readiness schema check failed", "error", err)
+		check.Status = models.HealthFailing
+		check.Error = "schema check failed"
+	case version != repository.SchemaVersion:
+		check.Status = models.HealthFailing
+		check.Error = fmt.Sprintf("database schema version %d does not match version %d of this API", version, repository.SchemaVersion)
+	}
+	return check
+}
+
+// planningFreshness fills in the age and staleness of each planning's last sync
+func (s *Server) planningFreshness(syncs []models.PlanningSync, now time.Time) []models.PlanningSync {
+	for i := range syncs {
+		if syncs[i].LastSync == nil {
+			syncs[i].Stale = true
+			continue
+		}
+		age := now.Sub(*syncs[i].LastSync)
+		seconds := int64(age.Seconds())
+		syncs[i].AgeSeconds = &seconds
+		syncs[i].Stale = age > s.syncStaleAfter
+	}
+	return syncs
+}
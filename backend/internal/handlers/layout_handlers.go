@@ -0,0 +1,406 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/do2024-2047/CalenDO/internal/models"
+	"github.com/do2024-2047/CalenDO/internal/repository"
+	"github.com/gorilla/mux"
+)
+
+// userIDHeader identifies the user whose planning preferences apply to a request
+const userIDHeader = "X-User-ID"
+
+// maxUserIDLength bounds the user IDs accepted in userIDHeader
+const maxUserIDLength = 128
+
+// colorPattern matches the #RRGGBB colours used by plannings
+var colorPattern = regexp.MustCompile(`^#[0-9A-Fa-f]{6}$`)
+
+// GetPlanningGroupsHandler godoc
+// @Summary Get all planning groups
+// @Description Retrieve the groups plannings can be sorted into, in display order
+// @ID getPlanningGroups
+// @Tags planning-groups
+// @Produce json
+// @Success 200 {array} models.PlanningGroup
+// @Failure 500 {object} models.Problem "Internal server error (internal_error)"
+// @Router /planning-groups [get]
+func (s *Server) GetPlanningGroupsHandler(w http.ResponseWriter, r *http.Request) {
+	groups, err := s.layouts.WithContext(r.Context()).FindGroups()
+	if err != nil {
+		internalError(w, r, err)
+		return
+	}
+	if groups == nil {
+		groups = []*models.PlanningGroup{}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(groups)
+}
+
+// CreatePlanningGroupHandler godoc
+// @Summary Create a planning group
+// @Description Create a group that plannings can be sorted into
+// @ID createPlanningGroup
+// @Tags planning-groups
+// @Accept json
+// @Produce json
+// @Param group body models.PlanningGroupRequest true "Planning group"
+// @Success 201 {object} models.PlanningGroup
+// @Failure 400 {object} models.Problem "Bad request (invalid_parameter or invalid_body)"
+// @Failure 401 {object} models.Problem "Admin token missing or invalid (unauthorized)"
+// @Failure 500 {object} models.Problem "Internal server error (internal_error)"
+// @Security AdminToken
+// @Router /planning-groups [post]
+func (s *Server) CreatePlanningGroupHandler(w http.ResponseWriter, r *http.Request) {
+	var request models.PlanningGroupRequest
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		invalidBody(w, r, "Invalid request body: "+err.Error())
+		return
+	}
+	request.Name = strings.TrimSpace(request.Name)
+	if request.Name == "" {
+		invalidBody(w, r, "name is required")
+		return
+	}
+
+	group := &models.PlanningGroup{Name: request.Name, SortOrder: request.SortOrder}
+	if err := s.layouts.WithContext(r.Context()).SaveGroup(group); err != nil {
+		internalError(w, r, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(group)
+}
+
+// UpdatePlanningGroupHandler godoc
+// @Summary Update a planning group
+// @Description Rename or reorder a planning group
+// @ID updatePlanningGroup
+// @Tags planning-groups
+// @Accept json
+// @Produce json
+// @Param id path int true "Planning group ID"
+// @Param group body models.PlanningGroupRequest true "Planning group"
+// @Success 200 {object} models.PlanningGroup
+// @Failure 400 {object} models.Problem "Bad request (invalid_parameter or invalid_body)"
+// @Failure 401 {object} models.Problem "Admin token missing or invalid (unauthorized)"
+// @Failure 404 {object} models.Problem "Planning group not found (planning_group_not_found)"
+// @Failure 500 {object} models.Problem "Internal server error (internal_error)"
+// @Security AdminToken
+// @Router /planning-groups/{id} [put]
+func (s *Server) UpdatePlanningGroupHandler(w http.ResponseWriter, r *http.Request) {
+	group, ok := s.findPlanningGroup(w, r, mux.Vars(r)["id"])
+	if !ok {
+		return
+	}
+
+	var request models.PlanningGroupRequest
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		invalidBody(w, r, "Invalid request body: "+err.Error())
+		return
+	}
+	request.Name = strings.TrimSpace(request.Name)
+	if request.Name == "" {
+		invalidBody(w, r, "name is required")
+		return
+	}
+
+	group.Name = request.Name
+	group.SortOrder = request.SortOrder
+	if err := s.layouts.WithContext(r.Context()).SaveGroup(group); err != nil {
+		internalError(w, r, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(group)
+}
+
+// DeletePlanningGroupHandler godoc
+// @Summary Delete a planning group
+// @Description Delete a planning group; its plannings become ungrouped
+// @ID deletePlanningGroup
+// @Tags planning-groups
+// @Param id path int true "Planning group ID"
+// @Success 204 "Deleted"
+// @Failure 400 {object} models.Problem "Bad request (invalid_parameter)"
+// @Failure 401 {object} models.Problem "Admin token missing or invalid (unauthorized)"
+// @Failure 404 {object} models.Problem "Planning group not found (planning_group_not_found)"
+// @Failure 500 {object} models.Problem "Internal server error (internal_error)"
+// @Security AdminToken
+// @Router /planning-groups/{id} [delete]
+func (s *Server) DeletePlanningGroupHandler(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.ParseUint(mux.Vars(r)["id"], 10, 64)
+	if err != nil {
+		writeProblem(w, r, http.StatusBadRequest, models.ProblemInvalidParameter, "Invalid planning group ID")
+		return
+	}
+
+	if err := s.layouts.WithContext(r.Context()).DeleteGroup(id); err == repository.ErrNotFound {
+		writeProblem(w, r, http.StatusNotFound, models.ProblemPlanningGroupNotFound, "Planning group not found")
+		return
+	} else if err != nil {
+		internalError(w, r, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// UpdatePlanningSettingsHandler godoc
+// @Summary Update the settings of a planning
+// @Description Set the group, sort order and default visibility of a planning for every user
+// @ID setPlanningSettings
+// @Tags plannings
+// @Accept json
+// @Produce json
+// @Param id path string true "Planning ID"
+// @Param settings body models.PlanningSettingsRequest true "Planning settings"
+// @Success 200 {object} models.PlanningSettings
+// @Failure 400 {object} models.Problem "Bad request (invalid_parameter or invalid_body)"
+// @Failure 401 {object} models.Problem "Admin token missing or invalid (unauthorized)"
+// @Failure 404 {object} models.Problem "Planning not found (planning_not_found)"
+// @Failure 500 {object} models.Problem "Internal server error (internal_error)"
+// @Security AdminToken
+// @Router /plannings/{id}/settings [put]
+func (s *Server) UpdatePlanningSettingsHandler(w http.ResponseWriter, r *http.Request) {
+	planningID := mux.Vars(r)["id"]
+	if !s.planningExists(w, r, planningID) {
+		return
+	}
+
+	var request models.PlanningSettingsRequest
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		invalidBody(w, r, "Invalid request body: "+err.Error())
+		return
+	}
+
+	if request.GroupID != nil {
+		if _, err := s.layouts.WithContext(r.Context()).FindGroupByID(*request.GroupID); err == repository.ErrNotFound {
+			invalidBody(w, r, "Planning group not found")
+			return
+		} else if err != nil {
+			internalError(w, r, err)
+			return
+		}
+	}
+
+	settings := &models.PlanningSettings{
+		PlanningID:      planningID,
+		GroupID:         request.GroupID,
+		SortOrder:       request.SortOrder,
+		HiddenByDefault: request.HiddenByDefault,
+	}
+	if err := s.layouts.WithContext(r.Context()).SaveSettings(settings); err != nil {
+		internalError(w, r, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(settings)
+}
+
+// UpdatePlanningPreferenceHandler godoc
+// @Summary Update a user's preference for a planning
+// @Description Override the colour or visibility of a planning for the user named in the X-User-ID header.
+// @Description A null field falls back to the planning's own value.
+// @ID setPlanningPreference
+// @Tags plannings
+// @Accept json
+// @Produce json
+// @Param id path string true "Planning ID"
+// @Param X-User-ID header string true "User the preference belongs to"
+// @Param preference body models.UserPlanningPreferenceRequest true "Planning preference"
+// @Success 200 {object} models.UserPlanningPreference
+// @Failure 400 {object} models.Problem "Bad request (invalid_parameter or invalid_body)"
+// @Failure 404 {object} models.Problem "Planning not found (planning_not_found)"
+// @Failure 500 {object} models.Problem "Internal server error (internal_error)"
+// @Router /plannings/{id}/preferences [put]
+func (s *Server) UpdatePlanningPreferenceHandler(w http.ResponseWriter, r *http.Request) {
+	userID, err := requireUserID(r)
+	if err != nil {
+		invalidParameter(w, r, err)
+		return
+	}
+
+	planningID := mux.Vars(r)["id"]
+	if !s.planningExists(w, r, planningID) {
+		return
+	}
+
+	var request models.UserPlanningPreferenceRequest
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		invalidBody(w, r, "Invalid request body: "+err.Error())
+		return
+	}
+	if request.Color != nil && !colorPattern.MatchString(*request.Color) {
+		invalidBody(w, r, "color must be formatted as #RRGGBB")
+		return
+	}
+
+	preference := &models.UserPlanningPreference{
+		UserID:     userID,
+		PlanningID: planningID,
+		Color:      request.Color,
+		Hidden:     request.Hidden,
+	}
+	if err := s.layouts.WithContext(r.Context()).SavePreference(preference); err != nil {
+		internalError(w, r, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(preference)
+}
+
+// DeletePlanningPreferenceHandler godoc
+// @Summary Reset a user's preference for a planning
+// @Description Remove the colour and visibility overrides of a planning for the user named in the X-User-ID header
+// @ID deletePlanningPreference
+// @Tags plannings
+// @Param id path string true "Planning ID"
+// @Param X-User-ID header string true "User the preference belongs to"
+// @Success 204 "Deleted"
+// @Failure 400 {object} models.Problem "Bad request (invalid_parameter)"
+// @Failure 404 {object} models.Problem "Preference not found (preference_not_found)"
+// @Failure 500 {object} models.Problem "Internal server error (internal_error)"
+// @Router /plannings/{id}/preferences [delete]
+func (s *Server) DeletePlanningPreferenceHandler(w http.ResponseWriter, r *http.Request) {
+	userID, err := requireUserID(r)
+	if err != nil {
+		invalidParameter(w, r, err)
+		return
+	}
+
+	if err := s.layouts.WithContext(r.Context()).DeletePreference(userID, mux.Vars(r)["id"]); err == repository.ErrNotFound {
+		writeProblem(w, r, http.StatusNotFound, models.ProblemPreferenceNotFound, "Preference not found")
+		return
+	} else if err != nil {
+		internalError(w, r, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// findPlanningGroup loads the planning group with the given ID, writing an error response when it cannot
+func (s *Server) findPlanningGroup(w http.ResponseWriter, r *http.Request, rawID string) (*models.PlanningGroup, bool) {
+	id, err := strconv.ParseUint(rawID, 10, 64)
+	if err != nil {
+		writeProblem(w, r, http.StatusBadRequest, models.ProblemInvalidParameter, "Invalid planning group ID")
+		return nil, false
+	}
+
+	group, err := s.layouts.WithContext(r.Context()).FindGroupByID(id)
+	if err == repository.ErrNotFound {
+		writeProblem(w, r, http.StatusNotFound, models.ProblemPlanningGroupNotFound, "Planning group not found")
+		return nil, false
+	} else if err != nil {
+		internalError(w, r, err)
+		return nil, false
+	}
+
+	return group, true
+}
+
+// planningExists reports whether a planning exists, writing an error response when it does not
+func (s *Server) planningExists(w http.ResponseWriter, r *http.Request, planningID string) bool {
+	if _, err := s.plannings.WithContext(r.Context()).FindByID(planningID); err == repository.ErrNotFound {
+		writeProblem(w, r, http.StatusNotFound, models.ProblemPlanningNotFound, "Planning not found")
+		return false
+	} else if err != nil {
+		internalError(w, r, err)
+		return false
+	}
+	return true
+}
+
+// userID returns the user named in the X-User-ID header, or an empty string
+func userID(r *http.Request) string {
+	id := strings.TrimSpace(r.Header.Get(userIDHeader))
+	if len(id) > maxUserIDLength {
+		return ""
+	}
+	return id
+}
+
+// requireUserID returns the user named in the X-User-ID header, which must be present
+func requireUserID(r *http.Request) (string, error) {
+	id := userID(r)
+	if id == "" {
+		return "", fmt.Errorf("the %s header is required, with at most %d characters", userIDHeader, maxUserIDLength)
+	}
+	return id, nil
+}
+
+// layoutFingerprint returns the fingerprint of the groups, settings and preferences
+// that applyLayouts uses for a request
+func (s *Server) layoutFingerprint(r *http.Request) (repository.Fingerprint, error) {
+	return s.layouts.WithContext(r.Context()).Fingerprint(userID(r))
+}
+
+// applyLayouts adds groups, settings and the requesting user's preferences to planning
+// responses, then orders them by group, then by sort order, keeping the given order for ties.
+// Ungrouped plannings come last.
+func (s *Server) applyLayouts(r *http.Request, responses []models.PlanningResponse) ([]models.PlanningResponse, error) {
+	groups, err := s.layouts.WithContext(r.Context()).FindGroups()
+	if err != nil {
+		return nil, err
+	}
+	settings, err := s.layouts.WithContext(r.Context()).FindSettings()
+	if err != nil {
+		return nil, err
+	}
+	preferences, err := s.layouts.WithContext(r.Context()).FindPreferences(userID(r))
+	if err != nil {
+		return nil, err
+	}
+
+	groupsByID := make(map[uint64]*models.PlanningGroup, len(groups))
+	groupRank := make(map[uint64]int, len(groups))
+	for rank, group := range groups {
+		groupsByID[group.ID] = group
+		groupRank[group.ID] = rank
+	}
+
+	for i := range responses {
+		setting := settings[responses[i].ID]
+		var group *models.PlanningGroup
+		if setting != nil && setting.GroupID != nil {
+			group = groupsByID[*setting.GroupID]
+		}
+		responses[i].ApplyLayout(setting, group, preferences[responses[i].ID])
+	}
+
+	rank := func(p models.PlanningResponse) int {
+		if p.GroupID != nil {
+			if groupIdx, ok := groupRank[*p.GroupID]; ok {
+				return groupIdx
+			}
+		}
+		return len(groups)
+	}
+	sort.SliceStable(responses, func(a, b int) bool {
+		if ra, rb := rank(responses[a]), rank(responses[b]); ra != rb {
+			return ra < rb
+		}
+		return responses[a].SortOrder < responses[b].SortOrder
+	})
+
+	return responses, nil
+}
@@ -0,0 +1,119 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/do2024-2047/CalenDO/internal/models"
+)
+
+const (
+	// defaultRangeDays is the window used when a request gives no end date
+	defaultRangeDays = 7
+	// maxRange is the widest window a single request may ask for
+	maxRange = 366 * 24 * time.Hour
+)
+
+// parsePlanningIDs splits a comma-separated list of planning IDs
+func parsePlanningIDs(value string) []string {
+	var ids []string
+	for _, id := range strings.Split(value, ",") {
+		if id = strings.TrimSpace(id); id != "" {
+			ids = append(ids, id)
+		}
+	}
+	return ids
+}
+
+// timezoneHeader lets clients state their preferred display timezone once for every request
+const timezoneHeader = "X-Timezone"
+
+// parseDisplayLocation resolves the display timezone from the tz query parameter,
+// then the X-Timezone header. It returns nil when the request names no timezone.
+func parseDisplayLocation(r *http.Request) (*time.Location, error) {
+	name := r.URL.Query().Get("tz")
+	if name == "" {
+		name = r.Header.Get(timezoneHeader)
+	}
+	if name == "" {
+		return nil, nil
+	}
+
+	loc := models.LoadLocation(name)
+	if loc == nil {
+		return nil, fmt.Errorf("invalid timezone %q", name)
+	}
+	return loc, nil
+}
+
+// parseLocation resolves the display timezone like parseDisplayLocation, defaulting to UTC
+func parseLocation(r *http.Request) (*time.Location, error) {
+	loc, err := parseDisplayLocation(r)
+	if err != nil || loc != nil {
+		return loc, err
+	}
+	return time.UTC, nil
+}
+
+// parseTimeParam parses an RFC 3339 timestamp or a plain YYYY-MM-DD date.
+// Plain dates are taken as midnight in loc.
+func parseTimeParam(value string, loc *time.Location) (time.Time, error) {
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return t, nil
+	}
+	if t, err := time.ParseInLocation("2006-01-02", value, loc); err == nil {
+		return t, nil
+	}
+	return time.Time{}, fmt.Errorf("invalid time %q, expected RFC 3339 or YYYY-MM-DD", value)
+}
+
+// parseTimeRange reads the start and end query parameters.
+// Without a start the window begins today in loc, and without an end it spans a week.
+func parseTimeRange(r *http.Request, loc *time.Location) (time.Time, time.Time, error) {
+	query := r.URL.Query()
+
+	var start, end time.Time
+	var err error
+
+	if value := query.Get("start"); value != "" {
+		if start, err = parseTimeParam(value, loc); err != nil {
+			return start, end, err
+		}
+	} else {
+		now := time.Now().In(loc)
+		start = time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, loc)
+	}
+
+	if value := query.Get("end"); value != "" {
+		if end, err = parseTimeParam(value, loc); err != nil {
+			return start, end, err
+		}
+	} else {
+		end = start.AddDate(0, 0, defaultRangeDays)
+	}
+
+	if !end.After(start) {
+		return start, end, fmt.Errorf("end must be after start")
+	}
+	if end.Sub(start) > maxRange {
+		return start, end, fmt.Errorf("time range must not exceed %d days", int(maxRange.Hours()/24))
+	}
+
+	return start, end, nil
+}
+
+// parseBoolParam reads a boolean query parameter, falling back to def when absent
+func parseBoolParam(r *http.Request, name string, def bool) (bool, error) {
+	switch strings.ToLower(r.URL.Query().Get(name)) {
+	case "":
+		return def, nil
+	case "1", "true", "yes":
+		return true, nil
+	case "0", "false", "no":
+		return false, nil
+	default:
+		return def, fmt.Errorf("invalid value for %s, expected true or false", name)
+	}
+}
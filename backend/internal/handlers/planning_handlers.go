@@ -9,37 +9,46 @@ import (
 	"github.com/gorilla/mux"
 )
 
-var (
-	// planningRepo is the planning repository used for database operations
-	planningRepo *repository.PlanningRepository
-)
-
-// InitializePlanningHandlers initializes the planning handlers with dependencies
-func InitializePlanningHandlers(pr *repository.PlanningRepository) {
-	planningRepo = pr
-}
-
 // GetPlanningsHandler godoc
 // @Summary Get all plannings
 // @Description Retrieve all calendar plannings
+// @ID getPlannings
 // @Tags plannings
 // @Produce json
+// @Param If-None-Match header string false "ETag of a cached copy"
+// @Param X-User-ID header string false "User whose planning preferences apply"
 // @Success 200 {array} models.PlanningResponse
-// @Failure 500 {object} string "Internal server error"
-// @Router /api/plannings [get]
-func GetPlanningsHandler(w http.ResponseWriter, r *http.Request) {
-	plannings, err := planningRepo.FindAll()
+// @Success 304 "Not modified"
+// @Failure 500 {object} models.Problem "Internal server error (internal_error)"
+// @Router /plannings [get]
+func (s *Server) GetPlanningsHandler(w http.ResponseWriter, r *http.Request) {
+	fp, err := s.planningLayoutFingerprint(r, s.plannings.WithContext(r.Context()).Fingerprint)
+	if err != nil {
+		internalError(w, r, err)
+		return
+	}
+	if s.checkNotModified(w, r, fp) {
+		return
+	}
+
+	plannings, err := s.plannings.WithContext(r.Context()).FindAll()
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		internalError(w, r, err)
 		return
 	}
 
 	// Convert to response format
-	var responses []models.PlanningResponse
+	responses := make([]models.PlanningResponse, 0, len(plannings))
 	for _, planning := range plannings {
 		responses = append(responses, planning.ToResponse())
 	}
 
+	responses, err = s.applyLayouts(r, responses)
+	if err != nil {
+		internalError(w, r, err)
+		return
+	}
+
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(http.StatusOK)
 	json.NewEncoder(w).Encode(responses)
@@ -48,27 +57,48 @@ func GetPlanningsHandler(w http.ResponseWriter, r *http.Request) {
 // GetPlanningHandler godoc
 // @Summary Get a specific planning
 // @Description Get a calendar planning by its ID
+// @ID getPlanning
 // @Tags plannings
 // @Produce json
+// @Param If-None-Match header string false "ETag of a cached copy"
+// @Param X-User-ID header string false "User whose planning preferences apply"
 // @Param id path string true "Planning ID"
 // @Success 200 {object} models.PlanningResponse
-// @Failure 404 {object} string "Planning not found"
-// @Failure 500 {object} string "Internal server error"
-// @Router /api/plannings/{id} [get]
-func GetPlanningHandler(w http.ResponseWriter, r *http.Request) {
+// @Success 304 "Not modified"
+// @Failure 404 {object} models.Problem "Planning not found (planning_not_found)"
+// @Failure 500 {object} models.Problem "Internal server error (internal_error)"
+// @Router /plannings/{id} [get]
+func (s *Server) GetPlanningHandler(w http.ResponseWriter, r *http.Request) {
 	vars := mux.Vars(r)
 	planningID := vars["id"]
 
-	planning, eventCount, err := planningRepo.FindByIDWithEventCount(planningID)
+	// The event count is part of the response
+	fp, err := s.planningLayoutFingerprint(r, func() (repository.Fingerprint, error) {
+		return s.events.WithContext(r.Context()).Fingerprint([]string{planningID})
+	})
+	if err != nil {
+		internalError(w, r, err)
+		return
+	}
+	if s.checkNotModified(w, r, fp) {
+		return
+	}
+
+	planning, eventCount, err := s.plannings.WithContext(r.Context()).FindByIDWithEventCount(planningID)
 	if err == repository.ErrNotFound {
-		http.Error(w, "Planning not found", http.StatusNotFound)
+		writeProblem(w, r, http.StatusNotFound, models.ProblemPlanningNotFound, "Planning not found")
 		return
 	} else if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		internalError(w, r, err)
 		return
 	}
 
-	response := planning.ToResponse()
+	responses, err := s.applyLayouts(r, []models.PlanningResponse{planning.ToResponse()})
+	if err != nil {
+		internalError(w, r, err)
+		return
+	}
+	response := responses[0]
 	response.EventCount = int(eventCount)
 
 	w.Header().Set("Content-Type", "application/json")
@@ -79,23 +109,56 @@ func GetPlanningHandler(w http.ResponseWriter, r *http.Request) {
 // GetDefaultPlanningHandler godoc
 // @Summary Get the default planning
 // @Description Get the default calendar planning
+// @ID getDefaultPlanning
 // @Tags plannings
 // @Produce json
+// @Param If-None-Match header string false "ETag of a cached copy"
+// @Param X-User-ID header string false "User whose planning preferences apply"
 // @Success 200 {object} models.PlanningResponse
-// @Failure 404 {object} string "No default planning found"
-// @Failure 500 {object} string "Internal server error"
-// @Router /api/plannings/default [get]
-func GetDefaultPlanningHandler(w http.ResponseWriter, r *http.Request) {
-	planning, err := planningRepo.GetDefault()
+// @Success 304 "Not modified"
+// @Failure 404 {object} models.Problem "No default planning found (planning_not_found)"
+// @Failure 500 {object} models.Problem "Internal server error (internal_error)"
+// @Router /plannings/default [get]
+func (s *Server) GetDefaultPlanningHandler(w http.ResponseWriter, r *http.Request) {
+	fp, err := s.planningLayoutFingerprint(r, s.plannings.WithContext(r.Context()).Fingerprint)
+	if err != nil {
+		internalError(w, r, err)
+		return
+	}
+	if s.checkNotModified(w, r, fp) {
+		return
+	}
+
+	planning, err := s.plannings.WithContext(r.Context()).GetDefault()
 	if err == repository.ErrNotFound {
-		http.Error(w, "No default planning found", http.StatusNotFound)
+		writeProblem(w, r, http.StatusNotFound, models.ProblemPlanningNotFound, "No default planning found")
 		return
 	} else if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		internalError(w, r, err)
+		return
+	}
+
+	responses, err := s.applyLayouts(r, []models.PlanningResponse{planning.ToResponse()})
+	if err != nil {
+		internalError(w, r, err)
 		return
 	}
 
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(http.StatusOK)
-	json.NewEncoder(w).Encode(planning.ToResponse())
+	json.NewEncoder(w).Encode(responses[0])
+}
+
+// planningLayoutFingerprint merges the fingerprint of the planning rows behind a response with
+// the fingerprint of the groups, settings and preferences applied to it
+func (s *Server) planningLayoutFingerprint(r *http.Request, rows func() (repository.Fingerprint, error)) (repository.Fingerprint, error) {
+	fp, err := rows()
+	if err != nil {
+		return repository.Fingerprint{}, err
+	}
+	layout, err := s.layoutFingerprint(r)
+	if err != nil {
+		return repository.Fingerprint{}, err
+	}
+	return fp.Merge(layout), nil
 }
@@ -0,0 +1,167 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/do2024-2047/CalenDO/internal/models"
+	"github.com/do2024-2047/CalenDO/internal/scheduling"
+)
+
+const (
+	// defaultMaxSlots is the number of slots returned when the request sets no limit
+	defaultMaxSlots = 10
+	// maxSlots caps the number of slots a single request may ask for
+	maxSlots = 100
+)
+
+// FindSlotsHandler godoc
+// @Summary Find free meeting slots
+// @Description Find free slots of a given duration across a set of plannings, within working hours and a search window.
+// @Description Busy time is widened by the buffer on both sides. Slots are ranked best first: earlier slots rank higher,
+// @Description and slots leaving a gap too short for another meeting of the same length rank lower.
+// @ID findSlots
+// @Tags scheduling
+// @Accept json
+// @Produce json
+// @Param request body models.FindSlotsRequest true "Slot search parameters"
+// @Success 200 {object} models.FindSlotsResponse
+// @Failure 400 {object} models.Problem "Bad request (invalid_parameter or invalid_body)"
+// @Failure 500 {object} models.Problem "Internal server error (internal_error)"
+// @Router /scheduling/find-slots [post]
+func (s *Server) FindSlotsHandler(w http.ResponseWriter, r *http.Request) {
+	var request models.FindSlotsRequest
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		invalidBody(w, r, "Invalid request body: "+err.Error())
+		return
+	}
+
+	query, err := buildSlotQuery(request)
+	if err != nil {
+		invalidBody(w, r, err.Error())
+		return
+	}
+
+	// Look a day beyond the buffered window so localised all-day events are caught
+	margin := 24*time.Hour + query.Buffer
+	events, err := s.events.WithContext(r.Context()).FindInRange(request.PlanningIDs, query.Window.Start.Add(-margin), query.Window.End.Add(margin))
+	if err != nil {
+		internalError(w, r, err)
+		return
+	}
+
+	includeAllDay := true
+	if request.IncludeAllDay != nil {
+		includeAllDay = *request.IncludeAllDay
+	}
+
+	busyWindow := scheduling.Interval{Start: query.Window.Start.Add(-query.Buffer), End: query.Window.End.Add(query.Buffer)}
+	busy := scheduling.BusyIntervals(events, busyWindow, scheduling.BusyOptions{
+		IncludeAllDay: includeAllDay,
+		Location:      query.WorkingHours.Location,
+	})
+
+	slots := scheduling.FindSlots(busy, query)
+
+	response := models.FindSlotsResponse{
+		PlanningIDs: request.PlanningIDs,
+		Timezone:    query.WorkingHours.Location.String(),
+		Slots:       make([]models.SlotResponse, 0, len(slots)),
+	}
+	if response.PlanningIDs == nil {
+		response.PlanningIDs = []string{}
+	}
+	for _, slot := range slots {
+		response.Slots = append(response.Slots, models.SlotResponse{
+			Start: slot.Start,
+			End:   slot.End,
+			Score: slot.Score,
+		})
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(response)
+}
+
+// buildSlotQuery validates a slot finder request and converts it into a query
+func buildSlotQuery(request models.FindSlotsRequest) (scheduling.SlotQuery, error) {
+	var query scheduling.SlotQuery
+
+	if request.DurationMinutes <= 0 {
+		return query, fmt.Errorf("duration_minutes must be positive")
+	}
+	if request.BufferMinutes < 0 {
+		return query, fmt.Errorf("buffer_minutes must not be negative")
+	}
+	if request.StepMinutes < 0 {
+		return query, fmt.Errorf("step_minutes must not be negative")
+	}
+	if request.MaxResults < 0 || request.MaxResults > maxSlots {
+		return query, fmt.Errorf("max_results must be between 0 (default) and %d", maxSlots)
+	}
+
+	if request.Window.Start.IsZero() || request.Window.End.IsZero() {
+		return query, fmt.Errorf("window.start and window.end are required")
+	}
+	if !request.Window.End.After(request.Window.Start) {
+		return query, fmt.Errorf("window.end must be after window.start")
+	}
+	if request.Window.End.Sub(request.Window.Start) > maxRange {
+		return query, fmt.Errorf("window must not exceed %d days", int(maxRange.Hours()/24))
+	}
+
+	loc := time.UTC
+	if request.WorkingHours.Timezone != "" {
+		var err error
+		if loc, err = time.LoadLocation(request.WorkingHours.Timezone); err != nil {
+			return query, fmt.Errorf("invalid timezone %q", request.WorkingHours.Timezone)
+		}
+	}
+
+	dayStart, dayEnd := "09:00", "17:00"
+	if request.WorkingHours.Start != "" {
+		dayStart = request.WorkingHours.Start
+	}
+	if request.WorkingHours.End != "" {
+		dayEnd = request.WorkingHours.End
+	}
+	startClock, err := scheduling.ParseClock(dayStart)
+	if err != nil {
+		return query, err
+	}
+	endClock, err := scheduling.ParseClock(dayEnd)
+	if err != nil {
+		return query, err
+	}
+	if endClock <= startClock {
+		return query, fmt.Errorf("working_hours.end must be after working_hours.start")
+	}
+
+	days, err := scheduling.ParseWeekdays(request.WorkingHours.Days)
+	if err != nil {
+		return query, err
+	}
+
+	maxResults := request.MaxResults
+	if maxResults == 0 {
+		maxResults = defaultMaxSlots
+	}
+
+	query = scheduling.SlotQuery{
+		Window:     scheduling.Interval{Start: request.Window.Start, End: request.Window.End},
+		Duration:   time.Duration(request.DurationMinutes) * time.Minute,
+		Buffer:     time.Duration(request.BufferMinutes) * time.Minute,
+		Step:       time.Duration(request.StepMinutes) * time.Minute,
+		MaxResults: maxResults,
+		WorkingHours: scheduling.WorkingHours{
+			DayStart: startClock,
+			DayEnd:   endClock,
+			Days:     days,
+			Location: loc,
+		},
+	}
+	return query, nil
+}
@@ -0,0 +1,107 @@
+package handlers
+
+import (
+	"net/http"
+	"sync/atomic"
+	"time"
+
+	"github.com/do2024-2047/CalenDO/internal/dedupe"
+	"github.com/do2024-2047/CalenDO/internal/repository"
+	"github.com/do2024-2047/CalenDO/internal/stream"
+	"github.com/do2024-2047/CalenDO/internal/webhook"
+	"github.com/gorilla/mux"
+)
+
+// Dependencies are the stores and background services the API is served from
+type Dependencies struct {
+	Events    repository.EventStore
+	Plannings repository.PlanningStore
+	Changes   repository.ChangeStore
+	Webhooks  repository.WebhookStore
+	Layouts   repository.LayoutStore
+	Health    repository.HealthStore
+
+	// Broker delivers new changes to connected streams
+	Broker *stream.Broker
+	// Dispatcher delivers payloads to webhooks
+	Dispatcher *webhook.Dispatcher
+}
+
+// Config holds the settings of the handlers. Zero values keep the defaults.
+type Config struct {
+	// DuplicatePolicy is how aggregate views handle duplicates when the request does not say
+	DuplicatePolicy string
+	// DuplicateOptions decides when two events are duplicates
+	DuplicateOptions dedupe.Options
+	// PingTimeout bounds the database checks of the readiness probe
+	PingTimeout time.Duration
+	// SyncStaleAfter is how long a planning may go without a successful sync
+	SyncStaleAfter time.Duration
+	// AdminToken is the bearer token of the administration routes, which are closed without it
+	AdminToken string
+}
+
+// Server serves the API from its dependencies
+type Server struct {
+	events     repository.EventStore
+	plannings  repository.PlanningStore
+	changes    repository.ChangeStore
+	webhooks   repository.WebhookStore
+	layouts    repository.LayoutStore
+	health     repository.HealthStore
+	broker     *stream.Broker
+	dispatcher *webhook.Dispatcher
+
+	duplicatePolicy  string
+	duplicateOptions dedupe.Options
+	pingTimeout      time.Duration
+	syncStaleAfter   time.Duration
+	adminToken       string
+
+	// shuttingDown fails the readiness probe once the server is stopping
+	shuttingDown atomic.Bool
+}
+
+// NewServer creates a server from its dependencies and configuration
+func NewServer(deps Dependencies, config Config) *Server {
+	s := &Server{
+		events:     deps.Events,
+		plannings:  deps.Plannings,
+		changes:    deps.Changes,
+		webhooks:   deps.Webhooks,
+		layouts:    deps.Layouts,
+		health:     deps.Health,
+		broker:     deps.Broker,
+		dispatcher: deps.Dispatcher,
+
+		duplicatePolicy:  dedupe.PolicyShow,
+		duplicateOptions: dedupe.DefaultOptions(),
+		pingTimeout:      defaultPingTimeout,
+		syncStaleAfter:   defaultSyncStaleAfter,
+		adminToken:       config.AdminToken,
+	}
+
+	if config.DuplicatePolicy != "" {
+		s.duplicatePolicy = config.DuplicatePolicy
+	}
+	if config.DuplicateOptions != (dedupe.Options{}) {
+		s.duplicateOptions = config.DuplicateOptions
+	}
+	if config.PingTimeout > 0 {
+		s.pingTimeout = config.PingTimeout
+	}
+	if config.SyncStaleAfter > 0 {
+		s.syncStaleAfter = config.SyncStaleAfter
+	}
+	return s
+}
+
+// Router returns a router serving the API routes, answering unknown routes and methods
+// with problem responses
+func (s *Server) Router() *mux.Router {
+	r := mux.NewRouter()
+	s.RegisterRoutes(r)
+	r.NotFoundHandler = http.HandlerFunc(routeNotFound)
+	r.MethodNotAllowedHandler = http.HandlerFunc(methodNotAllowed)
+	return r
+}
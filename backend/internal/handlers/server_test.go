@@ -0,0 +1,528 @@
+package handlers
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/do2024-2047/CalenDO/internal/logging"
+	"github.com/do2024-2047/CalenDO/internal/models"
+	"github.com/do2024-2047/CalenDO/internal/repository/memory"
+	"github.com/do2024-2047/CalenDO/internal/stream"
+	"github.com/do2024-2047/CalenDO/internal/webhook"
+)
+
+// newTestServer returns a server backed by a memory store holding two plannings and three events
+func newTestServer(t *testing.T) (*Server, *memory.Store) {
+	t.Helper()
+
+	store := memory.New()
+	store.AddPlanning(models.Planning{ID: "work", Name: "Work", Color: "#EF4444", IsDefault: true, Timezone: "Europe/Paris"})
+	store.AddPlanning(models.Planning{ID: "home", Name: "Home", Color: "#10B981"})
+
+	day := time.Date(2026, time.March, 2, 0, 0, 0, 0, time.UTC)
+	store.AddEvent(models.Event{UID: "standup", PlanningID: "work", Summary: "Standup", StartTime: day.Add(9 * time.Hour), EndTime: day.Add(9*time.Hour + 15*time.Minute)})
+	store.AddEvent(models.Event{UID: "review", PlanningID: "work", Summary: "Review", StartTime: day.Add(14 * time.Hour), EndTime: day.Add(15 * time.Hour)})
+	store.AddEvent(models.Event{UID: "dentist", PlanningID: "home", Summary: "Dentist", StartTime: day.Add(14*time.Hour + 30*time.Minute), EndTime: day.Add(15*time.Hour + 30*time.Minute)})
+
+	broker := stream.NewBroker(store.Changes(), time.Minute)
+	dispatcher := webhook.NewDispatcher(store.Webhooks(), store.Changes(), store.Events(), store.Plannings(), broker, webhook.Config{})
+	server := NewServer(Dependencies{
+		Events:     store.Events(),
+		Plannings:  store.Plannings(),
+		Changes:    store.Changes(),
+		Webhooks:   store.Webhooks(),
+		Layouts:    store.Layouts(),
+		Health:     store.Health(),
+		Broker:     broker,
+		Dispatcher: dispatcher,
+	}, Config{AdminToken: testAdminToken})
+	return server, store
+}
+
+// testAdminToken is the admin token of the test server
+const testAdminToken = "test-admin-token"
+
+// admin holds the header authenticating a request to an administration route
+var admin = http.Header{"Authorization": {"Bearer " + testAdminToken}}
+
+// serve runs a request through the router of server
+func serve(server *Server, method, target, body string, header http.Header) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(method, target, strings.NewReader(body))
+	for name, values := range header {
+		req.Header[name] = values
+	}
+	rec := httptest.NewRecorder()
+	server.Router().ServeHTTP(rec, req)
+	return rec
+}
+
+// decode unmarshals the body of a response, failing the test on invalid JSON
+func decode(t *testing.T, rec *httptest.ResponseRecorder, v any) {
+	t.Helper()
+	if err := json.Unmarshal(rec.Body.Bytes(), v); err != nil {
+		t.Fatalf("invalid JSON response %q: %v", rec.Body.String(), err)
+	}
+}
+
+func TestGetEventsHandlerListsEventsWithTheirPlanning(t *testing.T) {
+	t.Parallel()
+	server, _ := newTestServer(t)
+
+	rec := serve(server, http.MethodGet, "/api/events", "", nil)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status = %d, want %d: %s", rec.Code, http.StatusOK, rec.Body.String())
+	}
+
+	var events []models.EventResponse
+	decode(t, rec, &events)
+
+	var ids []string
+	for _, event := range events {
+		ids = append(ids, event.ID)
+		if event.Planning == nil || event.Planning.ID != event.PlanningID {
+			t.Errorf("event %s has planning %+v, want %s", event.ID, event.Planning, event.PlanningID)
+		}
+	}
+	want := []string{"dentist_home", "review_work", "standup_work"}
+	if strings.Join(ids, ",") != strings.Join(want, ",") {
+		t.Fatalf("event IDs = %v, want %v (latest first)", ids, want)
+	}
+}
+
+func TestGetEventsHandlerAnswersNotModified(t *testing.T) {
+	t.Parallel()
+	server, store := newTestServer(t)
+
+	first := serve(server, http.MethodGet, "/api/events", "", nil)
+	etag := first.Header().Get("ETag")
+	if etag == "" {
+		t.Fatal("response has no ETag")
+	}
+
+	cached := serve(server, http.MethodGet, "/api/events", "", http.Header{"If-None-Match": {etag}})
+	if cached.Code != http.StatusNotModified {
+		t.Fatalf("status with current ETag = %d, want %d", cached.Code, http.StatusNotModified)
+	}
+
+	store.AddChange(models.Change{Type: models.ChangeEventDeleted, PlanningID: "home", EventID: "dentist_home"})
+	stale := serve(server, http.MethodGet, "/api/events", "", http.Header{"If-None-Match": {etag}})
+	if stale.Code != http.StatusOK {
+		t.Fatalf("status after a change = %d, want %d", stale.Code, http.StatusOK)
+	}
+}
+
+func TestGetEventHandlers(t *testing.T) {
+	t.Parallel()
+	server, _ := newTestServer(t)
+
+	tests := []struct {
+		name   string
+		target string
+		status int
+	}{
+		{"by ID", "/api/events/review_work", http.StatusOK},
+		{"unknown ID", "/api/events/missing_work", http.StatusNotFound},
+		{"by planning and UID", "/api/plannings/home/events/dentist", http.StatusOK},
+		{"UID of another planning", "/api/plannings/home/events/review", http.StatusNotFound},
+		{"invalid timezone", "/api/events/review_work?tz=Mars/Olympus", http.StatusBadRequest},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := serve(server, http.MethodGet, tt.target, "", nil)
+			if rec.Code != tt.status {
+				t.Fatalf("GET %s status = %d, want %d: %s", tt.target, rec.Code, tt.status, rec.Body.String())
+			}
+		})
+	}
+}
+
+func TestGetPlanningHandlerCountsEvents(t *testing.T) {
+	t.Parallel()
+	server, _ := newTestServer(t)
+
+	rec := serve(server, http.MethodGet, "/api/plannings/work", "", nil)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status = %d, want %d: %s", rec.Code, http.StatusOK, rec.Body.String())
+	}
+
+	var planning models.PlanningResponse
+	decode(t, rec, &planning)
+	if planning.ID != "work" || planning.EventCount != 2 {
+		t.Fatalf("planning = %s with %d events, want work with 2", planning.ID, planning.EventCount)
+	}
+
+	if rec := serve(server, http.MethodGet, "/api/plannings/missing", "", nil); rec.Code != http.StatusNotFound {
+		t.Fatalf("unknown planning status = %d, want %d", rec.Code, http.StatusNotFound)
+	}
+}
+
+func TestStoreFailureIsInternalServerError(t *testing.T) {
+	t.Parallel()
+	server, store := newTestServer(t)
+	store.Fail(errors.New("connection refused"))
+
+	for _, target := range []string{"/api/events", "/api/plannings", "/api/planning-groups", "/api/webhooks"} {
+		rec := serve(server, http.MethodGet, target, "", admin)
+		if rec.Code != http.StatusInternalServerError {
+			t.Errorf("GET %s status = %d, want %d", target, rec.Code, http.StatusInternalServerError)
+		}
+	}
+}
+
+// TestStoreFailureIsLoggedNotReturned swaps the default logger, so it does not run in parallel
+func TestStoreFailureIsLoggedNotReturned(t *testing.T) {
+	var logged bytes.Buffer
+	previous := slog.Default()
+	slog.SetDefault(slog.New(slog.NewTextHandler(&logged, nil)))
+	t.Cleanup(func() { slog.SetDefault(previous) })
+
+	server, store := newTestServer(t)
+	store.Fail(errors.New(`pq: relation "events" does not exist`))
+
+	req := httptest.NewRequest(http.MethodGet, "/api/events", nil)
+	req = req.WithContext(logging.WithRequestID(req.Context(), "req-42"))
+	rec := httptest.NewRecorder()
+	server.Router().ServeHTTP(rec, req)
+
+	if rec.Code != http.StatusInternalServerError {
+		t.Fatalf("status = %d, want %d", rec.Code, http.StatusInternalServerError)
+	}
+	if contentType := rec.Header().Get("Content-Type"); contentType != models.ProblemContentType {
+		t.Errorf("Content-Type = %q, want %q", contentType, models.ProblemContentType)
+	}
+	var problem models.Problem
+	decode(t, rec, &problem)
+	if problem.Code != models.ProblemInternalError || problem.RequestID != "req-42" {
+		t.Errorf("problem = %+v, want code %q and request ID req-42", problem, models.ProblemInternalError)
+	}
+	if strings.Contains(rec.Body.String(), "relation") {
+		t.Errorf("response reveals the store error: %s", rec.Body.String())
+	}
+	if !strings.Contains(logged.String(), `relation \"events\" does not exist`) {
+		t.Errorf("store error not logged: %s", logged.String())
+	}
+}
+
+func TestEmptyListsAreArrays(t *testing.T) {
+	t.Parallel()
+	server, _ := newTestServer(t)
+
+	for _, target := range []string{"/api/plannings/unknown/events", "/api/webhooks", "/api/planning-groups"} {
+		rec := serve(server, http.MethodGet, target, "", admin)
+		if body := strings.TrimSpace(rec.Body.String()); rec.Code != http.StatusOK || body != "[]" {
+			t.Errorf("GET %s = %d %s, want 200 []", target, rec.Code, body)
+		}
+	}
+}
+
+func TestPlanningGroupLifecycle(t *testing.T) {
+	t.Parallel()
+	server, _ := newTestServer(t)
+
+	rec := serve(server, http.MethodPost, "/api/planning-groups", `{"name":" Team ","sort_order":2}`, admin)
+	if rec.Code != http.StatusCreated {
+		t.Fatalf("create status = %d, want %d: %s", rec.Code, http.StatusCreated, rec.Body.String())
+	}
+	var group models.PlanningGroup
+	decode(t, rec, &group)
+	if group.ID == 0 || group.Name != "Team" {
+		t.Fatalf("created group = %+v, want a new ID and the trimmed name", group)
+	}
+
+	rec = serve(server, http.MethodPut, "/api/plannings/work/settings", `{"group_id":1,"sort_order":1}`, admin)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("settings status = %d, want %d: %s", rec.Code, http.StatusOK, rec.Body.String())
+	}
+
+	var planning models.PlanningResponse
+	decode(t, serve(server, http.MethodGet, "/api/plannings/work", "", nil), &planning)
+	if planning.GroupID == nil || *planning.GroupID != group.ID || planning.GroupName != "Team" {
+		t.Fatalf("planning group = %v %q, want %d Team", planning.GroupID, planning.GroupName, group.ID)
+	}
+
+	if rec := serve(server, http.MethodDelete, "/api/planning-groups/1", "", admin); rec.Code != http.StatusNoContent {
+		t.Fatalf("delete status = %d, want %d", rec.Code, http.StatusNoContent)
+	}
+	if rec := serve(server, http.MethodDelete, "/api/planning-groups/1", "", admin); rec.Code != http.StatusNotFound {
+		t.Fatalf("second delete status = %d, want %d", rec.Code, http.StatusNotFound)
+	}
+
+	decode(t, serve(server, http.MethodGet, "/api/plannings/work", "", nil), &planning)
+	if planning.GroupID != nil {
+		t.Fatalf("planning group after delete = %d, want none", *planning.GroupID)
+	}
+}
+
+func TestCreateWebhookHandler(t *testing.T) {
+	t.Parallel()
+	server, store := newTestServer(t)
+	latest := store.AddChange(models.Change{Type: models.ChangePlanningChanged, PlanningID: "work"})
+
+	rec := serve(server, http.MethodPost, "/api/webhooks", `{"url":"https://example.com/hook","planning_id":"missing"}`, admin)
+	if rec.Code != http.StatusBadRequest {
+		t.Fatalf("unknown planning status = %d, want %d", rec.Code, http.StatusBadRequest)
+	}
+
+	rec = serve(server, http.MethodPost, "/api/webhooks", `{"url":"https://example.com/hook","planning_id":"work"}`, admin)
+	if rec.Code != http.StatusCreated {
+		t.Fatalf("status = %d, want %d: %s", rec.Code, http.StatusCreated, rec.Body.String())
+	}
+	var created models.WebhookResponse
+	decode(t, rec, &created)
+	if created.Secret == "" {
+		t.Fatal("created webhook has no generated secret")
+	}
+
+	hook, err := store.Webhooks().FindByID(created.ID)
+	if err != nil {
+		t.Fatalf("webhook not stored: %v", err)
+	}
+	if hook.LastChangeID != latest {
+		t.Fatalf("webhook cursor = %d, want the latest change %d", hook.LastChangeID, latest)
+	}
+
+	rec = serve(server, http.MethodGet, "/api/webhooks/"+created.ID, "", admin)
+	var fetched models.WebhookResponse
+	decode(t, rec, &fetched)
+	if fetched.Secret != "" {
+		t.Fatal("fetched webhook exposes its secret")
+	}
+}
+
+func TestCreateWebhookHandlerRejectsInternalAddresses(t *testing.T) {
+	t.Parallel()
+	server, _ := newTestServer(t)
+
+	for _, url := range []string{
+		"http://127.0.0.1:8080/hook",
+		"http://localhost/hook",
+		"http://[::1]/hook",
+		"http://[::ffff:127.0.0.1]/hook",
+		"http://10.0.0.12/hook",
+		"http://192.168.1.1/hook",
+		"http://169.254.169.254/latest/meta-data",
+		"http://0.0.0.0/hook",
+	} {
+		rec := serve(server, http.MethodPost, "/api/webhooks", `{"url":"`+url+`"}`, admin)
+		if rec.Code != http.StatusBadRequest {
+			t.Errorf("%s: status = %d, want %d", url, rec.Code, http.StatusBadRequest)
+		}
+	}
+}
+
+func TestLayoutWritesRequireAdminToken(t *testing.T) {
+	t.Parallel()
+	server, _ := newTestServer(t)
+
+	for _, request := range []struct{ method, target, body string }{
+		{http.MethodPost, "/api/planning-groups", `{"name":"Team"}`},
+		{http.MethodPut, "/api/planning-groups/1", `{"name":"Team"}`},
+		{http.MethodDelete, "/api/planning-groups/1", ""},
+		{http.MethodPut, "/api/plannings/work/settings", `{"hidden_by_default":true}`},
+	} {
+		rec := serve(server, request.method, request.target, request.body, http.Header{"X-User-Id": {"alice"}})
+		if rec.Code != http.StatusUnauthorized {
+			t.Errorf("%s %s status = %d, want %d", request.method, request.target, rec.Code, http.StatusUnauthorized)
+		}
+	}
+
+	// Preferences only affect the user they belong to, and need no token
+	rec := serve(server, http.MethodPut, "/api/plannings/work/preferences", `{"hidden":true}`, http.Header{"X-User-Id": {"alice"}})
+	if rec.Code != http.StatusOK {
+		t.Fatalf("preference status = %d, want %d: %s", rec.Code, http.StatusOK, rec.Body.String())
+	}
+
+	var planning models.PlanningResponse
+	decode(t, serve(server, http.MethodGet, "/api/plannings/work", "", nil), &planning)
+	if planning.HiddenByDefault {
+		t.Fatal("an anonymous request changed the settings of the planning")
+	}
+}
+
+func TestWebhookRoutesRequireAdminToken(t *testing.T) {
+	t.Parallel()
+
+	for name, header := range map[string]http.Header{
+		"no token":    nil,
+		"wrong token": {"Authorization": {"Bearer wrong"}},
+		"not bearer":  {"Authorization": {testAdminToken}},
+	} {
+		server, _ := newTestServer(t)
+		rec := serve(server, http.MethodPost, "/api/webhooks", `{"url":"https://example.com/hook"}`, header)
+		if rec.Code != http.StatusUnauthorized {
+			t.Errorf("%s: status = %d, want %d", name, rec.Code, http.StatusUnauthorized)
+		}
+	}
+
+	// Without a configured token, no request gets through
+	server := NewServer(Dependencies{}, Config{})
+	rec := serve(server, http.MethodGet, "/api/webhooks", "", http.Header{"Authorization": {"Bearer "}})
+	if rec.Code != http.StatusUnauthorized {
+		t.Errorf("no configured token: status = %d, want %d", rec.Code, http.StatusUnauthorized)
+	}
+}
+
+func TestGetChangesHandlerFollowsSnapshotWithDelta(t *testing.T) {
+	t.Parallel()
+	server, store := newTestServer(t)
+
+	var snapshot models.DeltaResponse
+	decode(t, serve(server, http.MethodGet, "/api/changes?plannings=work", "", nil), &snapshot)
+	if !snapshot.Full || len(snapshot.Events) != 2 {
+		t.Fatalf("snapshot = full %v with %d events, want a full snapshot of 2 events", snapshot.Full, len(snapshot.Events))
+	}
+
+	store.AddEvent(models.Event{UID: "retro", PlanningID: "work", Summary: "Retro", StartTime: time.Now(), EndTime: time.Now().Add(time.Hour)})
+	store.AddChange(models.Change{Type: models.ChangeEventCreated, PlanningID: "work", EventID: "retro_work"})
+	store.AddChange(models.Change{Type: models.ChangeEventDeleted, PlanningID: "work", EventID: "standup_work"})
+	store.AddChange(models.Change{Type: models.ChangeEventCreated, PlanningID: "home", EventID: "gym_home"})
+
+	var delta models.DeltaResponse
+	decode(t, serve(server, http.MethodGet, "/api/changes?plannings=work&since="+snapshot.Token, "", nil), &delta)
+	if delta.Full || len(delta.Events) != 1 || delta.Events[0].ID != "retro_work" {
+		t.Fatalf("delta events = %+v, want retro_work only", delta.Events)
+	}
+	if len(delta.Deleted) != 1 || delta.Deleted[0] != "standup_work" {
+		t.Fatalf("delta deleted = %v, want [standup_work]", delta.Deleted)
+	}
+}
+
+func TestGetChangesHandlerReportsDeletedPlannings(t *testing.T) {
+	t.Parallel()
+	server, store := newTestServer(t)
+
+	var snapshot models.DeltaResponse
+	decode(t, serve(server, http.MethodGet, "/api/changes", "", nil), &snapshot)
+
+	store.DeletePlanning("home")
+	store.AddChange(models.Change{Type: models.ChangePlanningChanged, PlanningID: "home"})
+	store.AddChange(models.Change{Type: models.ChangePlanningChanged, PlanningID: "work"})
+
+	var delta models.DeltaResponse
+	decode(t, serve(server, http.MethodGet, "/api/changes?since="+snapshot.Token, "", nil), &delta)
+	if len(delta.Plannings) != 1 || delta.Plannings[0].ID != "work" {
+		t.Fatalf("delta plannings = %+v, want work only", delta.Plannings)
+	}
+	if len(delta.DeletedPlannings) != 1 || delta.DeletedPlannings[0] != "home" {
+		t.Fatalf("delta deleted plannings = %v, want [home]", delta.DeletedPlannings)
+	}
+}
+
+func TestGetChangesHandlerExpiresPurgedTokens(t *testing.T) {
+	t.Parallel()
+	server, store := newTestServer(t)
+
+	for i := 0; i < 5; i++ {
+		store.AddChange(models.Change{Type: models.ChangeEventUpdated, PlanningID: "work", EventID: "standup_work"})
+	}
+	// Changes 1 to 3 are purged; purging an older part of the log again keeps the watermark
+	store.PurgeChanges(3)
+	store.PurgeChanges(1)
+
+	tests := []struct {
+		name   string
+		since  uint64
+		status int
+	}{
+		{"purged past", 2, http.StatusGone},
+		{"at the watermark", 3, http.StatusOK},
+		{"after the watermark", 4, http.StatusOK},
+		{"latest", 5, http.StatusOK},
+		{"from another database", 6, http.StatusGone},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := serve(server, http.MethodGet, "/api/changes?since="+encodeSyncToken(tt.since), "", nil)
+			if rec.Code != tt.status {
+				t.Fatalf("status = %d, want %d: %s", rec.Code, tt.status, rec.Body)
+			}
+		})
+	}
+}
+
+func TestGetChangesHandlerSnapshotOfPurgedLog(t *testing.T) {
+	t.Parallel()
+	server, store := newTestServer(t)
+
+	store.AddChange(models.Change{Type: models.ChangeEventUpdated, PlanningID: "work", EventID: "standup_work"})
+	store.AddChange(models.Change{Type: models.ChangeEventUpdated, PlanningID: "work", EventID: "review_work"})
+	store.PurgeChanges(2)
+
+	var snapshot models.DeltaResponse
+	decode(t, serve(server, http.MethodGet, "/api/changes", "", nil), &snapshot)
+	rec := serve(server, http.MethodGet, "/api/changes?since="+snapshot.Token, "", nil)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("delta after the snapshot of an empty log = %d, want 200: %s", rec.Code, rec.Body)
+	}
+}
+
+func TestReadinessHandler(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name    string
+		prepare func(*Server, *memory.Store)
+		status  int
+		want    string
+	}{
+		{"ready", func(*Server, *memory.Store) {}, http.StatusOK, models.ReadinessReady},
+		{"database down", func(_ *Server, store *memory.Store) { store.Fail(errors.New("connection refused")) }, http.StatusServiceUnavailable, models.ReadinessNotReady},
+		{"schema behind", func(_ *Server, store *memory.Store) { store.SetSchemaVersion(0) }, http.StatusServiceUnavailable, models.ReadinessNotReady},
+		{"shutting down", func(server *Server, _ *memory.Store) { server.SetShuttingDown() }, http.StatusServiceUnavailable, models.ReadinessShuttingDown},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			server, store := newTestServer(t)
+			tt.prepare(server, store)
+
+			rec := serve(server, http.MethodGet, "/api/health/ready", "", nil)
+			var readiness models.Readiness
+			decode(t, rec, &readiness)
+			if rec.Code != tt.status || readiness.Status != tt.want {
+				t.Fatalf("readiness = %d %s, want %d %s", rec.Code, readiness.Status, tt.status, tt.want)
+			}
+		})
+	}
+}
+
+func TestReadinessHandlerHidesDatabaseErrors(t *testing.T) {
+	t.Parallel()
+	server, store := newTestServer(t)
+	store.Fail(errors.New("dial tcp db.internal:5432: password authentication failed for user \"calendo\""))
+
+	rec := serve(server, http.MethodGet, "/api/health/ready", "", nil)
+	if body := rec.Body.String(); strings.Contains(body, "db.internal") || strings.Contains(body, "calendo") {
+		t.Fatalf("readiness body leaks the database error: %s", body)
+	}
+	var readiness models.Readiness
+	decode(t, rec, &readiness)
+	if readiness.Database.Error != "database unreachable" {
+		t.Fatalf("database error = %q, want database unreachable", readiness.Database.Error)
+	}
+}
+
+func TestReadinessHandlerReportsStalePlannings(t *testing.T) {
+	t.Parallel()
+	server, store := newTestServer(t)
+	store.RecordSync("work", time.Now().Add(-10*time.Minute), "succeeded")
+	store.RecordSync("home", time.Now().Add(-10*time.Minute), "failed")
+
+	var readiness models.Readiness
+	decode(t, serve(server, http.MethodGet, "/api/health/ready", "", nil), &readiness)
+
+	stale := make(map[string]bool)
+	for _, sync := range readiness.Plannings {
+		stale[sync.PlanningID] = sync.Stale
+	}
+	if stale["work"] || !stale["home"] {
+		t.Fatalf("stale plannings = %v, want home only", stale)
+	}
+}
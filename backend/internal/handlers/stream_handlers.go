@@ -0,0 +1,137 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/do2024-2047/CalenDO/internal/models"
+)
+
+const (
+	// heartbeatInterval keeps idle streams open through proxies
+	heartbeatInterval = 25 * time.Second
+	// replayBatchSize is the number of missed changes read at once when a client resumes
+	replayBatchSize = 500
+	// clientRetry is the reconnection delay suggested to EventSource clients, in milliseconds
+	clientRetry = 5000
+)
+
+// errStreamingUnsupported is reported when the response writer cannot flush messages
+var errStreamingUnsupported = errors.New("streaming unsupported by the response writer")
+
+// StreamHandler godoc
+// @Summary Stream calendar changes
+// @Description Server-Sent Events stream of event created/updated/deleted and planning changed messages.
+// @Description Each message has the change ID as its SSE id, the change type as its event name and a
+// @Description models.ChangeResponse as JSON data. Clients resume after a disconnect by sending the last
+// @Description received ID in the Last-Event-ID header (browsers do this automatically) or last_event_id parameter.
+// @ID streamChanges
+// @Tags stream
+// @Produce text/event-stream
+// @Param plannings query string false "Comma-separated planning IDs (all plannings when omitted)"
+// @Param last_event_id query integer false "Resume after this change ID"
+// @Param Last-Event-ID header integer false "Resume after this change ID"
+// @Success 200 {object} models.ChangeResponse
+// @Failure 400 {object} models.Problem "Bad request (invalid_parameter)"
+// @Failure 500 {object} models.Problem "Streaming unsupported (internal_error)"
+// @Router /stream [get]
+func (s *Server) StreamHandler(w http.ResponseWriter, r *http.Request) {
+	lastID, err := parseLastEventID(r)
+	if err != nil {
+		invalidParameter(w, r, err)
+		return
+	}
+
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		internalError(w, r, errStreamingUnsupported)
+		return
+	}
+
+	// Streams outlive the server's write timeout
+	http.NewResponseController(w).SetWriteDeadline(time.Time{})
+
+	planningIDs := parsePlanningIDs(r.URL.Query().Get("plannings"))
+
+	// Subscribe before replaying so nothing recorded in between is lost
+	sub := s.broker.Subscribe(planningIDs)
+	defer s.broker.Unsubscribe(sub)
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.Header().Set("X-Accel-Buffering", "no")
+	w.WriteHeader(http.StatusOK)
+	fmt.Fprintf(w, "retry: %d\n\n", clientRetry)
+	flusher.Flush()
+
+	if lastID > 0 {
+		for {
+			changes, err := s.changes.WithContext(r.Context()).FindSince(lastID, planningIDs, replayBatchSize)
+			if err != nil {
+				fmt.Fprintf(w, "event: error\ndata: %q\n\n", "failed to replay missed changes")
+				flusher.Flush()
+				return
+			}
+			for _, change := range changes {
+				writeChangeEvent(w, change)
+				lastID = change.ID
+			}
+			flusher.Flush()
+			if len(changes) < replayBatchSize {
+				break
+			}
+		}
+	}
+
+	heartbeat := time.NewTicker(heartbeatInterval)
+	defer heartbeat.Stop()
+
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case <-heartbeat.C:
+			fmt.Fprint(w, ": heartbeat\n\n")
+			flusher.Flush()
+		case change, ok := <-sub.Changes():
+			if !ok {
+				return
+			}
+			// Skip what the replay already sent
+			if change.ID <= lastID {
+				continue
+			}
+			writeChangeEvent(w, change)
+			lastID = change.ID
+			flusher.Flush()
+		}
+	}
+}
+
+// parseLastEventID reads the change ID a client resumes from, 0 when starting fresh
+func parseLastEventID(r *http.Request) (uint64, error) {
+	value := r.Header.Get("Last-Event-ID")
+	if value == "" {
+		value = r.URL.Query().Get("last_event_id")
+	}
+	if value == "" {
+		return 0, nil
+	}
+
+	id, err := strconv.ParseUint(value, 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid last event ID %q", value)
+	}
+	return id, nil
+}
+
+// writeChangeEvent writes a change as a Server-Sent Event
+func writeChangeEvent(w http.ResponseWriter, change *models.Change) {
+	data, _ := json.Marshal(change.ToResponse())
+	fmt.Fprintf(w, "id: %d\nevent: %s\ndata: %s\n\n", change.ID, change.Type, data)
+}
@@ -0,0 +1,309 @@
+package handlers
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/do2024-2047/CalenDO/internal/models"
+	"github.com/do2024-2047/CalenDO/internal/repository"
+	"github.com/google/uuid"
+	"github.com/gorilla/mux"
+)
+
+const (
+	// defaultDeliveryLimit is the number of delivery log entries returned by default
+	defaultDeliveryLimit = 50
+	// maxDeliveryLimit caps the number of delivery log entries a single request may ask for
+	maxDeliveryLimit = 500
+)
+
+// webhookChangeTypes are the change types a webhook may subscribe to
+var webhookChangeTypes = map[string]bool{
+	models.ChangeEventCreated:    true,
+	models.ChangeEventUpdated:    true,
+	models.ChangeEventDeleted:    true,
+	models.ChangePlanningChanged: true,
+}
+
+// GetWebhooksHandler godoc
+// @Summary Get all webhooks
+// @Description Retrieve all registered webhooks. Secrets are never returned.
+// @ID getWebhooks
+// @Tags webhooks
+// @Produce json
+// @Success 200 {array} models.WebhookResponse
+// @Failure 401 {object} models.Problem "Admin token missing or invalid (unauthorized)"
+// @Failure 500 {object} models.Problem "Internal server error (internal_error)"
+// @Security AdminToken
+// @Router /webhooks [get]
+func (s *Server) GetWebhooksHandler(w http.ResponseWriter, r *http.Request) {
+	hooks, err := s.webhooks.WithContext(r.Context()).FindAll()
+	if err != nil {
+		internalError(w, r, err)
+		return
+	}
+
+	responses := make([]models.WebhookResponse, 0, len(hooks))
+	for _, hook := range hooks {
+		responses = append(responses, hook.ToResponse())
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(responses)
+}
+
+// CreateWebhookHandler godoc
+// @Summary Register a webhook
+// @Description Register a webhook for one planning or, without planning_id, for all of them.
+// @Description Each change is POSTed as a models.WebhookPayload. The X-CalenDO-Signature header holds
+// @Description "sha256=" followed by the hex HMAC-SHA256 of "<X-CalenDO-Timestamp>.<body>" keyed with the secret.
+// @Description The secret is only returned in this response; it is generated when not provided.
+// @Description Only changes recorded after registration are delivered. URLs resolving to loopback, private,
+// @Description link-local or unspecified addresses are rejected, and deliveries do not follow redirects.
+// @ID createWebhook
+// @Tags webhooks
+// @Accept json
+// @Produce json
+// @Param request body models.WebhookRequest true "Webhook to register"
+// @Success 201 {object} models.WebhookResponse
+// @Failure 400 {object} models.Problem "Bad request (invalid_parameter or invalid_body)"
+// @Failure 401 {object} models.Problem "Admin token missing or invalid (unauthorized)"
+// @Failure 500 {object} models.Problem "Internal server error (internal_error)"
+// @Security AdminToken
+// @Router /webhooks [post]
+func (s *Server) CreateWebhookHandler(w http.ResponseWriter, r *http.Request) {
+	var request models.WebhookRequest
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		invalidBody(w, r, "Invalid request body: "+err.Error())
+		return
+	}
+
+	if err := s.validateWebhookRequest(r, request); err != nil {
+		invalidBody(w, r, err.Error())
+		return
+	}
+
+	if request.PlanningID != "" {
+		if _, err := s.plannings.WithContext(r.Context()).FindByID(request.PlanningID); err == repository.ErrNotFound {
+			invalidBody(w, r, "Planning not found")
+			return
+		} else if err != nil {
+			internalError(w, r, err)
+			return
+		}
+	}
+
+	secret := request.Secret
+	if secret == "" {
+		var err error
+		if secret, err = generateSecret(); err != nil {
+			internalError(w, r, err)
+			return
+		}
+	}
+
+	// Start from the current end of the change log rather than replaying history
+	latest, err := s.changes.WithContext(r.Context()).LatestID()
+	if err != nil {
+		internalError(w, r, err)
+		return
+	}
+
+	hook := &models.Webhook{
+		ID:           uuid.NewString(),
+		URL:          request.URL,
+		PlanningID:   request.PlanningID,
+		EventTypes:   strings.Join(request.EventTypes, ","),
+		Secret:       secret,
+		Active:       true,
+		LastChangeID: latest,
+	}
+	if err := s.webhooks.WithContext(r.Context()).Create(hook); err != nil {
+		internalError(w, r, err)
+		return
+	}
+
+	response := hook.ToResponse()
+	response.Secret = secret
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(response)
+}
+
+// GetWebhookHandler godoc
+// @Summary Get a specific webhook
+// @Description Get a registered webhook by its ID
+// @ID getWebhook
+// @Tags webhooks
+// @Produce json
+// @Param id path string true "Webhook ID"
+// @Success 200 {object} models.WebhookResponse
+// @Failure 401 {object} models.Problem "Admin token missing or invalid (unauthorized)"
+// @Failure 404 {object} models.Problem "Webhook not found (webhook_not_found)"
+// @Failure 500 {object} models.Problem "Internal server error (internal_error)"
+// @Security AdminToken
+// @Router /webhooks/{id} [get]
+func (s *Server) GetWebhookHandler(w http.ResponseWriter, r *http.Request) {
+	hook, ok := s.findWebhook(w, r, mux.Vars(r)["id"])
+	if !ok {
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(hook.ToResponse())
+}
+
+// DeleteWebhookHandler godoc
+// @Summary Delete a webhook
+// @Description Unregister a webhook and remove its delivery log
+// @ID deleteWebhook
+// @Tags webhooks
+// @Param id path string true "Webhook ID"
+// @Success 204 "No content"
+// @Failure 401 {object} models.Problem "Admin token missing or invalid (unauthorized)"
+// @Failure 404 {object} models.Problem "Webhook not found (webhook_not_found)"
+// @Failure 500 {object} models.Problem "Internal server error (internal_error)"
+// @Security AdminToken
+// @Router /webhooks/{id} [delete]
+func (s *Server) DeleteWebhookHandler(w http.ResponseWriter, r *http.Request) {
+	err := s.webhooks.WithContext(r.Context()).Delete(mux.Vars(r)["id"])
+	if err == repository.ErrNotFound {
+		writeProblem(w, r, http.StatusNotFound, models.ProblemWebhookNotFound, "Webhook not found")
+		return
+	} else if err != nil {
+		internalError(w, r, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// TestWebhookHandler godoc
+// @Summary Send a test payload to a webhook
+// @Description Send a signed payload of type "ping" once, without retries, and return the recorded delivery attempt
+// @ID testWebhook
+// @Tags webhooks
+// @Produce json
+// @Param id path string true "Webhook ID"
+// @Success 200 {object} models.WebhookDelivery
+// @Failure 401 {object} models.Problem "Admin token missing or invalid (unauthorized)"
+// @Failure 404 {object} models.Problem "Webhook not found (webhook_not_found)"
+// @Failure 500 {object} models.Problem "Internal server error (internal_error)"
+// @Security AdminToken
+// @Router /webhooks/{id}/test [post]
+func (s *Server) TestWebhookHandler(w http.ResponseWriter, r *http.Request) {
+	hook, ok := s.findWebhook(w, r, mux.Vars(r)["id"])
+	if !ok {
+		return
+	}
+
+	payload := models.WebhookPayload{
+		DeliveryID: uuid.NewString(),
+		Type:       models.WebhookPingType,
+		PlanningID: hook.PlanningID,
+		OccurredAt: time.Now().UTC(),
+	}
+	delivery := s.dispatcher.Deliver(r.Context(), hook, payload, 1)
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(delivery)
+}
+
+// GetWebhookDeliveriesHandler godoc
+// @Summary Get the delivery log of a webhook
+// @Description Retrieve the most recent delivery attempts of a webhook, newest first
+// @ID getWebhookDeliveries
+// @Tags webhooks
+// @Produce json
+// @Param id path string true "Webhook ID"
+// @Param limit query integer false "Maximum number of attempts to return (default: 50, max: 500)"
+// @Success 200 {array} models.WebhookDelivery
+// @Failure 400 {object} models.Problem "Bad request (invalid_parameter)"
+// @Failure 401 {object} models.Problem "Admin token missing or invalid (unauthorized)"
+// @Failure 404 {object} models.Problem "Webhook not found (webhook_not_found)"
+// @Failure 500 {object} models.Problem "Internal server error (internal_error)"
+// @Security AdminToken
+// @Router /webhooks/{id}/deliveries [get]
+func (s *Server) GetWebhookDeliveriesHandler(w http.ResponseWriter, r *http.Request) {
+	limit := defaultDeliveryLimit
+	if value := r.URL.Query().Get("limit"); value != "" {
+		parsed, err := strconv.Atoi(value)
+		if err != nil || parsed <= 0 {
+			writeProblem(w, r, http.StatusBadRequest, models.ProblemInvalidParameter, fmt.Sprintf("invalid limit %q", value))
+			return
+		}
+		limit = parsed
+		if limit > maxDeliveryLimit {
+			limit = maxDeliveryLimit
+		}
+	}
+
+	hook, ok := s.findWebhook(w, r, mux.Vars(r)["id"])
+	if !ok {
+		return
+	}
+
+	deliveries, err := s.webhooks.WithContext(r.Context()).FindDeliveries(hook.ID, limit)
+	if err != nil {
+		internalError(w, r, err)
+		return
+	}
+	if deliveries == nil {
+		deliveries = []*models.WebhookDelivery{}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(deliveries)
+}
+
+// findWebhook loads a webhook, writing the error response when it cannot be found
+func (s *Server) findWebhook(w http.ResponseWriter, r *http.Request, id string) (*models.Webhook, bool) {
+	hook, err := s.webhooks.WithContext(r.Context()).FindByID(id)
+	if err == repository.ErrNotFound {
+		writeProblem(w, r, http.StatusNotFound, models.ProblemWebhookNotFound, "Webhook not found")
+		return nil, false
+	} else if err != nil {
+		internalError(w, r, err)
+		return nil, false
+	}
+	return hook, true
+}
+
+// validateWebhookRequest checks the URL and change types of a webhook registration
+func (s *Server) validateWebhookRequest(r *http.Request, request models.WebhookRequest) error {
+	target, err := url.Parse(request.URL)
+	if err != nil || (target.Scheme != "http" && target.Scheme != "https") || target.Host == "" {
+		return fmt.Errorf("url must be an absolute http or https URL")
+	}
+	if err := s.dispatcher.CheckURL(r.Context(), request.URL); err != nil {
+		return fmt.Errorf("url is not allowed: %v", err)
+	}
+
+	for _, t := range request.EventTypes {
+		if !webhookChangeTypes[t] {
+			return fmt.Errorf("unknown event type %q", t)
+		}
+	}
+	return nil
+}
+
+// generateSecret returns a random hex-encoded signing secret
+func generateSecret() (string, error) {
+	buf := make([]byte, 32)
+	if _, err := rand.Read(buf); err != nil {
+		return "", fmt.Errorf("failed to generate secret: %v", err)
+	}
+	return hex.EncodeToString(buf), nil
+}
@@ -0,0 +1,242 @@
+package integration
+
+import (
+	"bytes"
+	"flag"
+	"fmt"
+	"log/slog"
+	"net"
+	"os"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	embeddedpostgres "github.com/fergusstrange/embedded-postgres"
+	"gorm.io/gorm"
+
+	"github.com/do2024-2047/CalenDO/internal/database"
+	"github.com/do2024-2047/CalenDO/internal/handlers"
+	"github.com/do2024-2047/CalenDO/internal/repository"
+	"github.com/do2024-2047/CalenDO/internal/repository/memory"
+)
+
+// dsnEnv names the variable pointing the tests at an existing Postgres database instead of
+// an embedded one. The database is wiped by every test.
+const dsnEnv = "TEST_DATABASE_DSN"
+
+var requirePostgres = flag.Bool("postgres", false, "fail instead of skipping the Postgres backend when it is unavailable")
+
+// postgresRequired reports whether an unavailable Postgres fails the tests: with -postgres,
+// and on CI, where a skipped backend would otherwise go unnoticed
+func postgresRequired() bool {
+	return *requirePostgres || os.Getenv("CI") != ""
+}
+
+// postgresTables are emptied before every test, children first
+var postgresTables = []string{
+	"webhook_deliveries", "webhooks", "user_planning_preferences", "planning_settings",
+	"planning_groups", "change_purges", "changes", "events", "plannings",
+}
+
+func openMemory(t *testing.T, fx fixtures) handlers.Dependencies {
+	store := memory.New()
+	for _, planning := range fx.plannings {
+		store.AddPlanning(*planning)
+	}
+	for _, event := range fx.events {
+		store.AddEvent(*event)
+	}
+	for _, change := range fx.changes {
+		store.AddChange(*change)
+	}
+
+	deps := memoryDependencies(store)
+	hook := *fx.webhook
+	if err := deps.Webhooks.Create(&hook); err != nil {
+		t.Fatalf("failed to create the fixture webhook: %v", err)
+	}
+	return deps
+}
+
+func brokenMemory(t *testing.T) handlers.Dependencies {
+	store := memory.New()
+	store.Fail(errBroken)
+	return memoryDependencies(store)
+}
+
+func memoryDependencies(store *memory.Store) handlers.Dependencies {
+	return handlers.Dependencies{
+		Events:    store.Events(),
+		Plannings: store.Plannings(),
+		Changes:   store.Changes(),
+		Webhooks:  store.Webhooks(),
+		Layouts:   store.Layouts(),
+		Health:    store.Health(),
+	}
+}
+
+func openPostgres(t *testing.T, fx fixtures) handlers.Dependencies {
+	db := postgresDB(t)
+
+	if err := db.Exec("TRUNCATE " + strings.Join(postgresTables, ", ") + " RESTART IDENTITY CASCADE").Error; err != nil {
+		t.Fatalf("failed to empty the database: %v", err)
+	}
+	for _, planning := range fx.plannings {
+		mustCreate(t, db, planning)
+	}
+	for _, event := range fx.events {
+		mustCreate(t, db, event)
+	}
+	for _, change := range fx.changes {
+		mustCreate(t, db, change)
+	}
+
+	deps := postgresDependencies(db)
+	hook := *fx.webhook
+	if err := deps.Webhooks.Create(&hook); err != nil {
+		t.Fatalf("failed to create the fixture webhook: %v", err)
+	}
+	return deps
+}
+
+// brokenPostgres returns repositories on a connection pool that is already closed
+func brokenPostgres(t *testing.T) handlers.Dependencies {
+	postgresDB(t)
+
+	db, err := database.Open(postgres.dsn, slog.LevelError)
+	if err != nil {
+		t.Fatalf("failed to open the database: %v", err)
+	}
+	database.Close(db)
+	return postgresDependencies(db)
+}
+
+func postgresDependencies(db *gorm.DB) handlers.Dependencies {
+	return handlers.Dependencies{
+		Events:    repository.NewEventRepository(db),
+		Plannings: repository.NewPlanningRepository(db),
+		Changes:   repository.NewChangeRepository(db),
+		Webhooks:  repository.NewWebhookRepository(db),
+		Layouts:   repository.NewLayoutRepository(db),
+		Health:    repository.NewHealthRepository(db),
+	}
+}
+
+func mustCreate(t *testing.T, db *gorm.DB, value any) {
+	t.Helper()
+	if err := db.Create(value).Error; err != nil {
+		t.Fatalf("failed to load fixture %+v: %v", value, err)
+	}
+}
+
+// postgres is the database shared by the tests of the Postgres backend. It is started
+// on first use: from TEST_DATABASE_DSN when set, as an embedded server otherwise.
+var postgres struct {
+	once     sync.Once
+	dsn      string
+	db       *gorm.DB
+	embedded *embeddedpostgres.EmbeddedPostgres
+	runtime  string
+	// skip is why Postgres is unavailable, empty when it runs
+	skip string
+}
+
+// postgresDB returns the migrated test database. When it is unavailable, the test is
+// skipped, or fails if Postgres is required.
+func postgresDB(t *testing.T) *gorm.DB {
+	t.Helper()
+	if testing.Short() {
+		t.Skip("Postgres backend skipped in short mode")
+	}
+
+	postgres.once.Do(startPostgres)
+	if postgres.skip != "" {
+		if postgresRequired() {
+			t.Fatal(postgres.skip)
+		}
+		t.Skip(postgres.skip)
+	}
+	return postgres.db
+}
+
+func startPostgres() {
+	postgres.dsn = os.Getenv(dsnEnv)
+	if postgres.dsn == "" {
+		if err := startEmbeddedPostgres(); err != nil {
+			postgres.skip = fmt.Sprintf("Postgres unavailable, set %s to use an existing database: %v", dsnEnv, err)
+			return
+		}
+	}
+
+	db, err := database.Open(postgres.dsn, slog.LevelError)
+	if err != nil {
+		postgres.skip = fmt.Sprintf("failed to connect to Postgres: %v", err)
+		return
+	}
+	if err := repository.Migrate(db); err != nil {
+		postgres.skip = fmt.Sprintf("failed to migrate Postgres: %v", err)
+		return
+	}
+	postgres.db = db
+}
+
+// startEmbeddedPostgres downloads (once, into the user cache) and starts a Postgres 16
+// server on a free port, with the C locale so that text sorts like Go strings
+func startEmbeddedPostgres() error {
+	port, err := freePort()
+	if err != nil {
+		return err
+	}
+	runtime, err := os.MkdirTemp("", "calendo-postgres-")
+	if err != nil {
+		return err
+	}
+
+	var output bytes.Buffer
+	config := embeddedpostgres.DefaultConfig().
+		Version(embeddedpostgres.V16).
+		Port(port).
+		Database("calendo_test").
+		Username("calendo").
+		Password("calendo").
+		Locale("C").
+		RuntimePath(runtime).
+		StartTimeout(time.Minute).
+		Logger(&output)
+
+	embedded := embeddedpostgres.NewDatabase(config)
+	if err := embedded.Start(); err != nil {
+		os.RemoveAll(runtime)
+		if output.Len() > 0 {
+			return fmt.Errorf("%w\n%s", err, output.String())
+		}
+		return err
+	}
+
+	postgres.embedded = embedded
+	postgres.runtime = runtime
+	postgres.dsn = fmt.Sprintf("host=localhost port=%d user=calendo password=calendo dbname=calendo_test sslmode=disable", port)
+	return nil
+}
+
+// stopPostgres stops the embedded server, if the tests started one
+func stopPostgres() {
+	if postgres.db != nil {
+		database.Close(postgres.db)
+	}
+	if postgres.embedded != nil {
+		postgres.embedded.Stop()
+		os.RemoveAll(postgres.runtime)
+	}
+}
+
+// freePort returns a TCP port nothing listens on
+func freePort() (uint32, error) {
+	listener, err := net.Listen("tcp", "localhost:0")
+	if err != nil {
+		return 0, err
+	}
+	defer listener.Close()
+	return uint32(listener.Addr().(*net.TCPAddr).Port), nil
+}
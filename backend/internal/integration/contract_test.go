@@ -0,0 +1,137 @@
+package integration
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/getkin/kin-openapi/openapi2"
+	"github.com/getkin/kin-openapi/openapi2conv"
+	"github.com/getkin/kin-openapi/openapi3"
+	"github.com/getkin/kin-openapi/openapi3filter"
+	"github.com/getkin/kin-openapi/routers"
+	"github.com/getkin/kin-openapi/routers/gorillamux"
+
+	"github.com/do2024-2047/CalenDO/docs"
+	"github.com/do2024-2047/CalenDO/internal/handlers"
+	"github.com/do2024-2047/CalenDO/internal/models"
+)
+
+// contract is the embedded specification, converted to OpenAPI 3 to validate requests
+// and responses against. It is loaded on first use.
+var contract struct {
+	once   sync.Once
+	router routers.Router
+	err    error
+}
+
+// contractRouter returns the routes of the specification, failing the test when it is invalid
+func contractRouter(t *testing.T) routers.Router {
+	t.Helper()
+	contract.once.Do(loadContract)
+	if contract.err != nil {
+		t.Fatalf("invalid specification: %v", contract.err)
+	}
+	return contract.router
+}
+
+func loadContract() {
+	var spec openapi2.T
+	if contract.err = json.Unmarshal(docs.SwaggerJSON, &spec); contract.err != nil {
+		return
+	}
+	doc, err := openapi2conv.ToV3(&spec)
+	if err != nil {
+		contract.err = err
+		return
+	}
+	// Match the test requests, which are made to example.com
+	doc.Servers = openapi3.Servers{{URL: "http://example.com" + handlers.APIPrefix}}
+	if contract.err = doc.Validate(context.Background()); contract.err != nil {
+		return
+	}
+	contract.router, contract.err = gorillamux.NewRouter(doc)
+}
+
+func TestSpecificationIsValid(t *testing.T) {
+	contractRouter(t)
+}
+
+// checkContract validates a request and its response against the specification. Problem
+// and JSON bodies are validated against the schema of their status; other media types,
+// like event streams and iCalendar, only have their status checked.
+func checkContract(t *testing.T, req *http.Request, rec *httptest.ResponseRecorder) {
+	t.Helper()
+
+	route, params, err := contractRouter(t).FindRoute(req)
+	if err != nil {
+		t.Fatalf("%s %s is not in the specification: %v", req.Method, req.URL.Path, err)
+	}
+
+	ctx := context.Background()
+	input := &openapi3filter.RequestValidationInput{
+		Request:    req,
+		PathParams: params,
+		Route:      route,
+		Options: &openapi3filter.Options{
+			AuthenticationFunc: openapi3filter.NoopAuthenticationFunc,
+			MultiError:         true,
+		},
+	}
+	// Requests the API rejects may break the specification on purpose
+	if rec.Code < http.StatusBadRequest || rec.Code >= http.StatusInternalServerError {
+		if err := openapi3filter.ValidateRequest(ctx, input); err != nil {
+			t.Errorf("request does not match the specification: %v", err)
+		}
+	}
+
+	response := route.Operation.Responses.Status(rec.Code)
+	if response == nil || response.Value == nil {
+		t.Fatalf("status %d of %s %s is not in the specification", rec.Code, req.Method, route.Path)
+	}
+
+	contentType := rec.Header().Get("Content-Type")
+	switch {
+	case strings.HasPrefix(contentType, models.ProblemContentType):
+		checkSchema(t, response.Value, rec.Body.Bytes())
+	case strings.HasPrefix(contentType, "application/json"):
+		err := openapi3filter.ValidateResponse(ctx, &openapi3filter.ResponseValidationInput{
+			RequestValidationInput: input,
+			Status:                 rec.Code,
+			Header:                 rec.Header(),
+			Body:                   io.NopCloser(bytes.NewReader(rec.Body.Bytes())),
+			Options:                &openapi3filter.Options{IncludeResponseStatus: true, MultiError: true},
+		})
+		if err != nil {
+			t.Errorf("response does not match the specification: %v", err)
+		}
+	}
+}
+
+// checkSchema validates a problem body against the schema of its response, which the
+// specification declares under the media types of the operation's successful responses
+func checkSchema(t *testing.T, response *openapi3.Response, body []byte) {
+	t.Helper()
+
+	// Every media type of a response has the same schema
+	var schema *openapi3.SchemaRef
+	for _, media := range response.Content {
+		schema = media.Schema
+	}
+	if schema == nil {
+		t.Fatalf("response %q has no schema", *response.Description)
+	}
+	var value any
+	if err := json.Unmarshal(body, &value); err != nil {
+		t.Fatalf("failed to decode body %q: %v", body, err)
+	}
+	if err := schema.Value.VisitJSON(value, openapi3.MultiErrors()); err != nil {
+		t.Errorf("problem does not match the specification: %v", err)
+	}
+}
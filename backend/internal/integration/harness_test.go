@@ -0,0 +1,248 @@
+// Package integration runs the HTTP API end to end, on the memory store and on Postgres,
+// and compares its responses with golden files.
+package integration
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gorilla/mux"
+
+	"github.com/do2024-2047/CalenDO/internal/handlers"
+	"github.com/do2024-2047/CalenDO/internal/middleware"
+	"github.com/do2024-2047/CalenDO/internal/models"
+	"github.com/do2024-2047/CalenDO/internal/seed"
+	"github.com/do2024-2047/CalenDO/internal/stream"
+	"github.com/do2024-2047/CalenDO/internal/webhook"
+)
+
+var update = flag.Bool("update", false, "rewrite the golden files from the responses of the memory backend")
+
+// fixtureNow is the time the fixtures are laid out from
+var fixtureNow = time.Date(2026, time.March, 2, 8, 0, 0, 0, time.UTC)
+
+// fixtureWebhookID is the ID of the webhook of the fixtures
+const fixtureWebhookID = "fixture-webhook"
+
+// errBroken is returned by every operation of a broken backend
+var errBroken = errors.New("database is unreachable")
+
+func TestMain(m *testing.M) {
+	flag.Parse()
+	// Postgres hands timestamps back in the local timezone; keep both backends in UTC
+	time.Local = time.UTC
+	// The failures the tests provoke would otherwise be logged by the handlers
+	slog.SetDefault(slog.New(slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{Level: slog.LevelError + 1})))
+
+	code := m.Run()
+	stopPostgres()
+	os.Exit(code)
+}
+
+// fixtures is the data every test starts from
+type fixtures struct {
+	plannings []*models.Planning
+	events    []*models.Event
+	changes   []*models.Change
+	webhook   *models.Webhook
+}
+
+// newFixtures returns the seed data, plus a copy of the team meeting in the work planning
+// (a duplicate and a conflict), a few change log entries and a webhook posting to hookURL
+func newFixtures(hookURL string) fixtures {
+	fx := fixtures{
+		plannings: seed.Plannings(fixtureNow),
+		events:    seed.Events(fixtureNow),
+	}
+
+	copied := &models.Event{
+		UID:          "team-meeting-work",
+		PlanningID:   "work-planning",
+		Summary:      "Team meeting",
+		Location:     "Conference Room A",
+		StartTime:    fixtureNow.Add(24*time.Hour + 2*time.Minute),
+		EndTime:      fixtureNow.Add(25*time.Hour + 2*time.Minute),
+		Created:      fixtureNow,
+		LastModified: fixtureNow,
+	}
+	copied.ID = models.GenerateEventID(copied.UID, copied.PlanningID)
+	fx.events = append(fx.events, copied)
+
+	fx.changes = []*models.Change{
+		{Type: models.ChangeEventCreated, PlanningID: "default-planning", EventID: "sample-event-1_default-planning", Created: fixtureNow},
+		{Type: models.ChangePlanningChanged, PlanningID: "work-planning", Created: fixtureNow},
+		{Type: models.ChangeEventCreated, PlanningID: "work-planning", EventID: copied.ID, Created: fixtureNow},
+		{Type: models.ChangeEventDeleted, PlanningID: "personal-planning", EventID: "cancelled-event_personal-planning", Created: fixtureNow},
+	}
+
+	fx.webhook = &models.Webhook{
+		ID:           fixtureWebhookID,
+		URL:          hookURL,
+		PlanningID:   "work-planning",
+		EventTypes:   models.ChangeEventCreated,
+		Secret:       "fixture-secret",
+		Active:       true,
+		LastChangeID: uint64(len(fx.changes)),
+	}
+	return fx
+}
+
+// backend provides the stores the API runs on
+type backend struct {
+	name string
+	// open returns stores holding fx
+	open func(t *testing.T, fx fixtures) handlers.Dependencies
+	// broken returns stores failing every operation
+	broken func(t *testing.T) handlers.Dependencies
+}
+
+// backends are the backends every test runs against. The memory one comes first: it
+// writes the golden files, which Postgres must then match.
+var backends = []backend{
+	{name: "memory", open: openMemory, broken: brokenMemory},
+	{name: "postgres", open: openPostgres, broken: brokenPostgres},
+}
+
+// newRouter returns the API router on deps, with the request ID and CORS middleware of main
+func newRouter(deps handlers.Dependencies) *mux.Router {
+	deps.Broker = stream.NewBroker(deps.Changes, time.Minute)
+	deps.Dispatcher = webhook.NewDispatcher(deps.Webhooks, deps.Changes, deps.Events, deps.Plannings, deps.Broker, webhook.Config{
+		MaxAttempts: 1,
+		Timeout:     5 * time.Second,
+		// The receiver listens on the loopback interface
+		AllowPrivateNetworks: true,
+	})
+
+	r := handlers.NewServer(deps, handlers.Config{AdminToken: fixtureAdminToken}).Router()
+	r.Use(middleware.RequestID)
+	r.Use(middleware.CORS)
+	return r
+}
+
+// newReceiver returns a server standing in for a webhook endpoint, accepting every delivery
+func newReceiver(t *testing.T) *httptest.Server {
+	t.Helper()
+	receiver := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	t.Cleanup(receiver.Close)
+	return receiver
+}
+
+// volatileLine matches the lines of an iCalendar body that change on every request
+var volatileLine = regexp.MustCompile(`(?m)^(UID|DTSTAMP):.*$`)
+
+// normalize returns a body in its golden form: JSON is indented with the volatile keys
+// blanked, other bodies have their volatile iCalendar lines blanked
+func normalize(t *testing.T, body []byte, volatile []string) []byte {
+	t.Helper()
+
+	var value any
+	if err := json.Unmarshal(body, &value); err != nil {
+		text := strings.ReplaceAll(string(body), "\r\n", "\n")
+		return []byte(volatileLine.ReplaceAllString(text, "$1:<volatile>"))
+	}
+
+	keys := make(map[string]bool, len(volatile))
+	for _, key := range volatile {
+		keys[key] = true
+	}
+	value = scrub(value, keys)
+
+	var normalized bytes.Buffer
+	encoder := json.NewEncoder(&normalized)
+	encoder.SetEscapeHTML(false)
+	encoder.SetIndent("", "  ")
+	if err := encoder.Encode(value); err != nil {
+		t.Fatalf("failed to encode normalized body: %v", err)
+	}
+	return normalized.Bytes()
+}
+
+// scrub replaces the values of the given keys, at any depth, with a placeholder
+func scrub(value any, keys map[string]bool) any {
+	switch v := value.(type) {
+	case map[string]any:
+		for key, field := range v {
+			if keys[key] && field != nil {
+				v[key] = "<volatile>"
+			} else {
+				v[key] = scrub(field, keys)
+			}
+		}
+	case []any:
+		for i, item := range v {
+			v[i] = scrub(item, keys)
+		}
+	}
+	return value
+}
+
+// assertGolden compares a normalized body with testdata/<name>.golden, or rewrites the
+// file when write is set: with -update, on the memory backend under the current prefix.
+func assertGolden(t *testing.T, write bool, name string, got []byte) {
+	t.Helper()
+
+	path := filepath.Join("testdata", name+".golden")
+	if write {
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatalf("failed to create testdata: %v", err)
+		}
+		if err := os.WriteFile(path, got, 0o644); err != nil {
+			t.Fatalf("failed to write golden file: %v", err)
+		}
+		return
+	}
+
+	want, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("failed to read golden file (run go test -update to create it): %v", err)
+	}
+	if !bytes.Equal(got, want) {
+		t.Errorf("response differs from %s:\n%s", path, diff(string(want), string(got)))
+	}
+}
+
+// diff lists the lines that differ between two texts
+func diff(want, got string) string {
+	wantLines := strings.Split(want, "\n")
+	gotLines := strings.Split(got, "\n")
+
+	var out strings.Builder
+	for i := 0; i < len(wantLines) || i < len(gotLines); i++ {
+		var w, g string
+		if i < len(wantLines) {
+			w = wantLines[i]
+		}
+		if i < len(gotLines) {
+			g = gotLines[i]
+		}
+		if w != g {
+			fmt.Fprintf(&out, "line %d:\n  want %s\n  got  %s\n", i+1, w, g)
+		}
+	}
+	return out.String()
+}
+
+// sortedKeys returns the keys of a set in order
+func sortedKeys(set map[string]bool) []string {
+	keys := make([]string, 0, len(set))
+	for key := range set {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
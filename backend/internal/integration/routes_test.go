@@ -0,0 +1,332 @@
+package integration
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gorilla/mux"
+
+	"github.com/do2024-2047/CalenDO/internal/handlers"
+	"github.com/do2024-2047/CalenDO/internal/models"
+	"github.com/do2024-2047/CalenDO/internal/repository/memory"
+)
+
+// routeCase is a request to the API and the response expected for it
+type routeCase struct {
+	name   string
+	method string
+	target string
+	header map[string]string
+	body   string
+	status int
+	// code is the error code of a problem response, internal_error by default for a 500
+	code string
+	// golden compares the body with testdata/<name>.golden
+	golden bool
+	// volatile are the JSON keys whose values change from run to run
+	volatile []string
+	// stream ends the request shortly after it started, for Server-Sent Events
+	stream bool
+}
+
+// syncToken returns the sync token of a change log position, as the delta endpoint encodes it
+func syncToken(changeID string) string {
+	return base64.RawURLEncoding.EncodeToString([]byte("v1." + changeID))
+}
+
+// fixtureAdminToken is the admin token of the API under test
+const fixtureAdminToken = "fixture-admin-token"
+
+// admin holds the header authenticating a request to an administration route
+var admin = map[string]string{"Authorization": "Bearer " + fixtureAdminToken}
+
+// week is the query of a time range covering the fixtures
+const week = "start=2026-03-02&end=2026-03-09"
+
+// routeCases run in order on one server, so that later requests see what earlier ones changed
+var routeCases = []routeCase{
+	{name: "health", method: "GET", target: "/health", status: 200, golden: true, volatile: []string{"time"}},
+	{name: "health-live", method: "GET", target: "/health/live", status: 200, golden: true, volatile: []string{"time"}},
+	{name: "health-ready", method: "GET", target: "/health/ready", status: 200, golden: true, volatile: []string{"time", "latency_ms"}},
+
+	{name: "plannings", method: "GET", target: "/plannings", status: 200, golden: true},
+	{name: "planning-default", method: "GET", target: "/plannings/default", status: 200, golden: true},
+	{name: "planning", method: "GET", target: "/plannings/work-planning", status: 200, golden: true},
+	{name: "planning-not-found", method: "GET", target: "/plannings/missing-planning", status: 404, code: models.ProblemPlanningNotFound},
+
+	{name: "events", method: "GET", target: "/events", status: 200, golden: true},
+	{name: "events-in-timezone", method: "GET", target: "/events", header: map[string]string{"X-Timezone": "Europe/Paris"}, status: 200, golden: true},
+	{name: "events-invalid-timezone", method: "GET", target: "/events?tz=Mars/Olympus", status: 400, code: models.ProblemInvalidParameter},
+	{name: "event", method: "GET", target: "/events/sample-event-1_default-planning", status: 200, golden: true},
+	{name: "event-not-found", method: "GET", target: "/events/missing-event_default-planning", status: 404, code: models.ProblemEventNotFound},
+	{name: "planning-events", method: "GET", target: "/plannings/work-planning/events", status: 200, golden: true},
+	{name: "planning-events-unknown-planning", method: "GET", target: "/plannings/missing-planning/events", status: 200, golden: true},
+	{name: "planning-event", method: "GET", target: "/plannings/personal-planning/events/personal-event-1", status: 200, golden: true},
+	{name: "planning-event-not-found", method: "GET", target: "/plannings/personal-planning/events/work-event-1", status: 404, code: models.ProblemEventNotFound},
+
+	{name: "freebusy", method: "GET", target: "/freebusy?" + week, status: 200, golden: true},
+	{name: "freebusy-ics", method: "GET", target: "/freebusy?format=ics&" + week, status: 200, golden: true},
+	{name: "freebusy-invalid-range", method: "GET", target: "/freebusy?start=2026-03-09&end=2026-03-02", status: 400, code: models.ProblemInvalidParameter},
+	{name: "find-slots", method: "POST", target: "/scheduling/find-slots", status: 200, golden: true, body: `{
+		"plannings": ["default-planning", "work-planning"],
+		"duration_minutes": 60,
+		"working_hours": {"start": "09:00", "end": "17:00", "timezone": "UTC"},
+		"window": {"start": "2026-03-03T00:00:00Z", "end": "2026-03-04T00:00:00Z"},
+		"max_results": 3
+	}`},
+	{name: "find-slots-invalid", method: "POST", target: "/scheduling/find-slots", body: `{"duration_minutes": "an hour"}`, status: 400, code: models.ProblemInvalidBody},
+	{name: "find-slots-preflight", method: "OPTIONS", target: "/scheduling/find-slots", header: map[string]string{"Origin": "http://localhost:3000", "Access-Control-Request-Method": "POST"}, status: 200},
+	{name: "conflicts", method: "GET", target: "/conflicts?" + week, status: 200, golden: true},
+	{name: "conflicts-invalid-scope", method: "GET", target: "/conflicts?scope=galaxy&" + week, status: 400, code: models.ProblemInvalidParameter},
+	{name: "duplicates", method: "GET", target: "/duplicates?" + week, status: 200, golden: true},
+	{name: "duplicates-invalid-threshold", method: "GET", target: "/duplicates?threshold=2&" + week, status: 400, code: models.ProblemInvalidParameter},
+
+	{name: "changes-snapshot", method: "GET", target: "/changes", status: 200, golden: true},
+	{name: "changes-since", method: "GET", target: "/changes?since=" + syncToken("1"), status: 200, golden: true},
+	{name: "changes-invalid-token", method: "GET", target: "/changes?since=not-a-token", status: 400, code: models.ProblemInvalidParameter},
+	{name: "changes-expired-token", method: "GET", target: "/changes?since=" + syncToken("99"), status: 410, code: models.ProblemSyncTokenExpired},
+	{name: "stream-replay", method: "GET", target: "/stream", header: map[string]string{"Last-Event-ID": "2"}, status: 200, golden: true, stream: true},
+	{name: "stream-invalid-last-event-id", method: "GET", target: "/stream?last_event_id=latest", status: 400, code: models.ProblemInvalidParameter},
+
+	{name: "webhooks-without-token", method: "GET", target: "/webhooks", status: 401, code: models.ProblemUnauthorized},
+	{name: "webhook-create-wrong-token", method: "POST", target: "/webhooks", header: map[string]string{"Authorization": "Bearer wrong-token"}, body: `{"url": "https://hooks.example.com/calendo"}`, status: 401, code: models.ProblemUnauthorized},
+	{name: "webhooks", method: "GET", target: "/webhooks", header: admin, status: 200, golden: true, volatile: []string{"url", "created", "updated"}},
+	{name: "webhook", method: "GET", target: "/webhooks/" + fixtureWebhookID, header: admin, status: 200, golden: true, volatile: []string{"url", "created", "updated"}},
+	{name: "webhook-not-found", method: "GET", target: "/webhooks/missing-webhook", header: admin, status: 404, code: models.ProblemWebhookNotFound},
+	{name: "webhook-create", method: "POST", target: "/webhooks", header: admin, body: `{"url": "https://hooks.example.com/calendo", "event_types": ["event.deleted"]}`, status: 201, golden: true, volatile: []string{"id", "secret", "created", "updated"}},
+	{name: "webhook-create-invalid", method: "POST", target: "/webhooks", header: admin, body: `{"url": "ftp://hooks.example.com"}`, status: 400, code: models.ProblemInvalidBody},
+	{name: "webhook-test", method: "POST", target: "/webhooks/" + fixtureWebhookID + "/test", header: admin, status: 200, golden: true, volatile: []string{"delivery_id", "duration_ms", "created"}},
+	{name: "webhook-test-not-found", method: "POST", target: "/webhooks/missing-webhook/test", header: admin, status: 404, code: models.ProblemWebhookNotFound},
+	{name: "webhook-deliveries", method: "GET", target: "/webhooks/" + fixtureWebhookID + "/deliveries", header: admin, status: 200, golden: true, volatile: []string{"delivery_id", "duration_ms", "created"}},
+	{name: "webhook-deliveries-invalid-limit", method: "GET", target: "/webhooks/" + fixtureWebhookID + "/deliveries?limit=0", header: admin, status: 400, code: models.ProblemInvalidParameter},
+	{name: "webhook-delete", method: "DELETE", target: "/webhooks/" + fixtureWebhookID, header: admin, status: 204},
+	{name: "webhook-delete-not-found", method: "DELETE", target: "/webhooks/" + fixtureWebhookID, header: admin, status: 404, code: models.ProblemWebhookNotFound},
+
+	{name: "planning-group-create-anonymous", method: "POST", target: "/planning-groups", body: `{"name": "Projects"}`, status: 401, code: models.ProblemUnauthorized},
+	{name: "planning-groups-empty", method: "GET", target: "/planning-groups", status: 200, golden: true},
+	{name: "planning-group-create", method: "POST", target: "/planning-groups", header: admin, body: `{"name": "Projects", "sort_order": 1}`, status: 201, golden: true, volatile: []string{"created", "updated"}},
+	{name: "planning-group-create-invalid", method: "POST", target: "/planning-groups", header: admin, body: `{"name": ""}`, status: 400, code: models.ProblemInvalidBody},
+	{name: "planning-group-update", method: "PUT", target: "/planning-groups/1", header: admin, body: `{"name": "Clients", "sort_order": 2}`, status: 200, golden: true, volatile: []string{"created", "updated"}},
+	{name: "planning-group-update-not-found", method: "PUT", target: "/planning-groups/2", header: admin, body: `{"name": "Clients"}`, status: 404, code: models.ProblemPlanningGroupNotFound},
+	{name: "planning-settings", method: "PUT", target: "/plannings/work-planning/settings", header: admin, body: `{"group_id": 1, "sort_order": 3, "hidden_by_default": true}`, status: 200, golden: true, volatile: []string{"updated"}},
+	{name: "planning-settings-not-found", method: "PUT", target: "/plannings/missing-planning/settings", header: admin, body: `{"sort_order": 1}`, status: 404, code: models.ProblemPlanningNotFound},
+	{name: "planning-preferences", method: "PUT", target: "/plannings/work-planning/preferences", header: map[string]string{"X-User-ID": "alice"}, body: `{"color": "#8B5CF6", "hidden": false}`, status: 200, golden: true, volatile: []string{"updated"}},
+	{name: "planning-preferences-without-user", method: "PUT", target: "/plannings/work-planning/preferences", body: `{"hidden": true}`, status: 400, code: models.ProblemInvalidParameter},
+	{name: "planning-groups", method: "GET", target: "/planning-groups", status: 200, golden: true, volatile: []string{"created", "updated"}},
+	{name: "plannings-with-layout", method: "GET", target: "/plannings", header: map[string]string{"X-User-ID": "alice"}, status: 200, golden: true},
+	{name: "planning-preferences-delete", method: "DELETE", target: "/plannings/work-planning/preferences", header: map[string]string{"X-User-ID": "alice"}, status: 204},
+	{name: "planning-preferences-delete-not-found", method: "DELETE", target: "/plannings/work-planning/preferences", header: map[string]string{"X-User-ID": "alice"}, status: 404, code: models.ProblemPreferenceNotFound},
+	{name: "planning-group-delete", method: "DELETE", target: "/planning-groups/1", header: admin, status: 204},
+	{name: "planning-group-delete-not-found", method: "DELETE", target: "/planning-groups/1", header: admin, status: 404, code: models.ProblemPlanningGroupNotFound},
+
+	{name: "method-not-allowed", method: "PATCH", target: "/events", status: 405, code: models.ProblemMethodNotAllowed},
+	{name: "unknown-route", method: "GET", target: "/calendars", status: 404, code: models.ProblemRouteNotFound},
+}
+
+// brokenCases run on stores failing every operation
+var brokenCases = []routeCase{
+	{name: "health-ready", method: "GET", target: "/health/ready", status: 503},
+	{name: "plannings", method: "GET", target: "/plannings", status: 500},
+	{name: "planning-default", method: "GET", target: "/plannings/default", status: 500},
+	{name: "planning", method: "GET", target: "/plannings/work-planning", status: 500},
+	{name: "events", method: "GET", target: "/events", status: 500},
+	{name: "event", method: "GET", target: "/events/sample-event-1_default-planning", status: 500},
+	{name: "planning-events", method: "GET", target: "/plannings/work-planning/events", status: 500},
+	{name: "planning-event", method: "GET", target: "/plannings/personal-planning/events/personal-event-1", status: 500},
+	{name: "freebusy", method: "GET", target: "/freebusy?" + week, status: 500},
+	{name: "find-slots", method: "POST", target: "/scheduling/find-slots", body: `{
+		"duration_minutes": 60,
+		"working_hours": {"start": "09:00", "end": "17:00"},
+		"window": {"start": "2026-03-03T00:00:00Z", "end": "2026-03-04T00:00:00Z"}
+	}`, status: 500},
+	{name: "conflicts", method: "GET", target: "/conflicts?" + week, status: 500},
+	{name: "duplicates", method: "GET", target: "/duplicates?" + week, status: 500},
+	{name: "changes-snapshot", method: "GET", target: "/changes", status: 500},
+	{name: "changes-since", method: "GET", target: "/changes?since=" + syncToken("1"), status: 500},
+	{name: "webhooks", method: "GET", target: "/webhooks", header: admin, status: 500},
+	{name: "webhook", method: "GET", target: "/webhooks/" + fixtureWebhookID, header: admin, status: 500},
+	{name: "webhook-create", method: "POST", target: "/webhooks", header: admin, body: `{"url": "https://hooks.example.com/calendo"}`, status: 500},
+	{name: "webhook-test", method: "POST", target: "/webhooks/" + fixtureWebhookID + "/test", header: admin, status: 500},
+	{name: "webhook-deliveries", method: "GET", target: "/webhooks/" + fixtureWebhookID + "/deliveries", header: admin, status: 500},
+	{name: "webhook-delete", method: "DELETE", target: "/webhooks/" + fixtureWebhookID, header: admin, status: 500},
+	{name: "planning-groups", method: "GET", target: "/planning-groups", status: 500},
+	{name: "planning-group-create", method: "POST", target: "/planning-groups", header: admin, body: `{"name": "Projects"}`, status: 500},
+	{name: "planning-group-update", method: "PUT", target: "/planning-groups/1", header: admin, body: `{"name": "Clients"}`, status: 500},
+	{name: "planning-group-delete", method: "DELETE", target: "/planning-groups/1", header: admin, status: 500},
+	{name: "planning-settings", method: "PUT", target: "/plannings/work-planning/settings", header: admin, body: `{"sort_order": 1}`, status: 500},
+	{name: "planning-preferences", method: "PUT", target: "/plannings/work-planning/preferences", header: map[string]string{"X-User-ID": "alice"}, body: `{"hidden": true}`, status: 500},
+	{name: "planning-preferences-delete", method: "DELETE", target: "/plannings/work-planning/preferences", header: map[string]string{"X-User-ID": "alice"}, status: 500},
+}
+
+// prefixes are the path prefixes every case runs under: the current version, whose
+// requests and responses are validated against the specification, and its legacy alias
+var prefixes = []struct{ name, path string }{
+	{name: "v1", path: handlers.APIPrefix},
+	{name: "legacy", path: handlers.LegacyPrefix},
+}
+
+func TestRoutes(t *testing.T) {
+	for _, backend := range backends {
+		t.Run(backend.name, func(t *testing.T) {
+			for _, prefix := range prefixes {
+				t.Run(prefix.name, func(t *testing.T) {
+					receiver := newReceiver(t)
+					router := newRouter(backend.open(t, newFixtures(receiver.URL)))
+					for _, tc := range routeCases {
+						t.Run(tc.name, func(t *testing.T) {
+							check(t, backend.name, prefix.path, router, tc)
+						})
+					}
+				})
+			}
+		})
+	}
+}
+
+func TestRoutesOnFailingStores(t *testing.T) {
+	for _, backend := range backends {
+		t.Run(backend.name, func(t *testing.T) {
+			router := newRouter(backend.broken(t))
+			for _, prefix := range prefixes {
+				t.Run(prefix.name, func(t *testing.T) {
+					for _, tc := range brokenCases {
+						t.Run(tc.name, func(t *testing.T) {
+							check(t, backend.name, prefix.path, router, tc)
+						})
+					}
+				})
+			}
+		})
+	}
+}
+
+// TestRoutesAreCovered fails when a route is added to RegisterRoutes without a test case.
+// Preflight requests are answered by the CORS middleware the same way on every route.
+func TestRoutesAreCovered(t *testing.T) {
+	router := newRouter(memoryDependencies(memory.New()))
+
+	covered := make(map[string]bool)
+	for _, prefix := range prefixes {
+		for _, cases := range [][]routeCase{routeCases, brokenCases} {
+			for _, tc := range cases {
+				var match mux.RouteMatch
+				if !router.Match(httptest.NewRequest(tc.method, prefix.path+tc.target, nil), &match) || match.Route == nil {
+					continue
+				}
+				template, _ := match.Route.GetPathTemplate()
+				covered[tc.method+" "+template] = true
+			}
+		}
+	}
+
+	missing := make(map[string]bool)
+	router.Walk(func(route *mux.Route, _ *mux.Router, _ []*mux.Route) error {
+		template, err := route.GetPathTemplate()
+		if err != nil {
+			return nil
+		}
+		methods, _ := route.GetMethods()
+		for _, method := range methods {
+			if method != http.MethodOptions && !covered[method+" "+template] {
+				missing[method+" "+template] = true
+			}
+		}
+		return nil
+	})
+	if len(missing) > 0 {
+		t.Fatalf("routes without a test case: %v", sortedKeys(missing))
+	}
+}
+
+// newRequest returns the request of a case under a path prefix
+func newRequest(prefix string, tc routeCase) *http.Request {
+	req := httptest.NewRequest(tc.method, prefix+tc.target, strings.NewReader(tc.body))
+	if tc.body != "" {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	for name, value := range tc.header {
+		req.Header.Set(name, value)
+	}
+	return req
+}
+
+// check runs a case under a path prefix through router and compares the response with the
+// expected one
+func check(t *testing.T, backend, prefix string, router *mux.Router, tc routeCase) {
+	t.Helper()
+
+	req := newRequest(prefix, tc)
+	var match mux.RouteMatch
+	matched := router.Match(req, &match) && match.MatchErr == nil
+	if tc.stream {
+		ctx, cancel := context.WithTimeout(req.Context(), 200*time.Millisecond)
+		defer cancel()
+		req = req.WithContext(ctx)
+	}
+
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, req)
+
+	if rec.Code != tc.status {
+		t.Fatalf("%s %s status = %d, want %d: %s", tc.method, req.URL, rec.Code, tc.status, rec.Body.String())
+	}
+	if tc.status >= http.StatusBadRequest && tc.status != http.StatusServiceUnavailable {
+		checkProblem(t, prefix, rec, tc)
+	}
+
+	switch {
+	case !matched || tc.method == http.MethodOptions:
+	case prefix == handlers.APIPrefix:
+		checkContract(t, newRequest(prefix, tc), rec)
+	default:
+		path, _, _ := strings.Cut(tc.target, "?")
+		if link, want := rec.Header().Get("Link"), "<"+handlers.APIPrefix+path+`>; rel="successor-version"`; link != want {
+			t.Errorf("Link = %q, want %q", link, want)
+		}
+	}
+
+	if tc.golden {
+		write := *update && backend == "memory" && prefix == handlers.APIPrefix
+		assertGolden(t, write, tc.name, normalize(t, rec.Body.Bytes(), tc.volatile))
+	}
+}
+
+// checkProblem checks that an error response is a problem with the expected code, which
+// gives its request ID and hides the errors of the stores
+func checkProblem(t *testing.T, prefix string, rec *httptest.ResponseRecorder, tc routeCase) {
+	t.Helper()
+
+	if contentType := rec.Header().Get("Content-Type"); contentType != models.ProblemContentType {
+		t.Errorf("Content-Type = %q, want %q", contentType, models.ProblemContentType)
+	}
+	var problem models.Problem
+	if err := json.Unmarshal(rec.Body.Bytes(), &problem); err != nil {
+		t.Fatalf("failed to decode problem %q: %v", rec.Body.String(), err)
+	}
+
+	code := tc.code
+	if code == "" && tc.status == http.StatusInternalServerError {
+		code = models.ProblemInternalError
+	}
+	if problem.Code != code || problem.Type != models.ProblemTypePrefix+code {
+		t.Errorf("problem code = %q, type = %q, want %q", problem.Code, problem.Type, code)
+	}
+	if problem.Status != tc.status {
+		t.Errorf("problem status = %d, want %d", problem.Status, tc.status)
+	}
+	if path, _, _ := strings.Cut(prefix+tc.target, "?"); problem.Instance != path {
+		t.Errorf("problem instance = %q, want %q", problem.Instance, path)
+	}
+	if id := rec.Header().Get("X-Request-ID"); problem.RequestID != id {
+		t.Errorf("problem request_id = %q, want the X-Request-ID %q", problem.RequestID, id)
+	}
+	if strings.Contains(rec.Body.String(), errBroken.Error()) {
+		t.Errorf("problem reveals the store error: %s", rec.Body.String())
+	}
+}
@@ -0,0 +1,164 @@
+// Package logging sets up structured logging with log/slog from the logging section of
+// the configuration, and carries request IDs through contexts into log records.
+package logging
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/spf13/viper"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// Formats of log records
+const (
+	FormatJSON = "json"
+	FormatText = "text"
+)
+
+// Config is the logging section of the configuration
+type Config struct {
+	// Level is the minimum level logged: debug, info, warn or error (default info)
+	Level string `mapstructure:"level"`
+	// Format is json or text (default json)
+	Format string `mapstructure:"format"`
+	// File also receives the logs when set; standard output always does
+	File string `mapstructure:"file"`
+}
+
+// ConfigFromViper reads the logging section of the configuration
+func ConfigFromViper() Config {
+	return Config{
+		Level:  viper.GetString("logging.level"),
+		Format: viper.GetString("logging.format"),
+		File:   viper.GetString("logging.file"),
+	}
+}
+
+// ParseLevel converts a configured level name to a slog level
+func ParseLevel(name string) (slog.Level, error) {
+	switch strings.ToLower(strings.TrimSpace(name)) {
+	case "debug":
+		return slog.LevelDebug, nil
+	case "", "info":
+		return slog.LevelInfo, nil
+	case "warn", "warning":
+		return slog.LevelWarn, nil
+	case "error":
+		return slog.LevelError, nil
+	}
+	return slog.LevelInfo, fmt.Errorf("invalid logging level %q, expected debug, info, warn or error", name)
+}
+
+// Setup installs the configured logger as the slog default and sends lines written with
+// the standard log package through it. It returns a function closing the log file, if any.
+func Setup(cfg Config) (func(), error) {
+	level, err := ParseLevel(cfg.Level)
+	if err != nil {
+		return func() {}, err
+	}
+
+	var out io.Writer = os.Stdout
+	closeFile := func() {}
+	if cfg.File != "" {
+		file, err := openLogFile(cfg.File)
+		if err != nil {
+			// Containers often have a read-only filesystem; standard output is enough there
+			fmt.Fprintf(os.Stderr, "Warning: Logging to standard output only: %v\n", err)
+		} else {
+			out = io.MultiWriter(os.Stdout, file)
+			closeFile = func() { file.Close() }
+		}
+	}
+
+	opts := &slog.HandlerOptions{Level: level}
+	var handler slog.Handler
+	switch strings.ToLower(cfg.Format) {
+	case "", FormatJSON:
+		handler = slog.NewJSONHandler(out, opts)
+	case FormatText:
+		handler = slog.NewTextHandler(out, opts)
+	default:
+		closeFile()
+		return func() {}, fmt.Errorf("invalid logging format %q, expected json or text", cfg.Format)
+	}
+
+	logger := slog.New(&contextHandler{Handler: handler})
+	slog.SetDefault(logger)
+
+	log.SetFlags(0)
+	log.SetOutput(legacyWriter{logger: logger})
+
+	return closeFile, nil
+}
+
+// openLogFile opens a log file for appending, creating its directory if needed
+func openLogFile(path string) (*os.File, error) {
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return nil, err
+	}
+	return os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o644)
+}
+
+// legacyWriter sends lines written with the standard log package to slog, at the level
+// their "Warning:" or "Error" prefix suggests
+type legacyWriter struct {
+	logger *slog.Logger
+}
+
+func (w legacyWriter) Write(p []byte) (int, error) {
+	message := strings.TrimSpace(string(p))
+	level := slog.LevelInfo
+	if rest, ok := strings.CutPrefix(message, "Warning: "); ok {
+		level, message = slog.LevelWarn, rest
+	} else if strings.HasPrefix(message, "Error") || strings.HasPrefix(message, "Failed") {
+		level = slog.LevelError
+	}
+
+	w.logger.Log(context.Background(), level, message)
+	return len(p), nil
+}
+
+// requestIDKey is the context key of the request ID
+type requestIDKey struct{}
+
+// WithRequestID returns a context carrying a request ID, which every record logged
+// with the context includes
+func WithRequestID(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, requestIDKey{}, id)
+}
+
+// RequestID returns the request ID carried by a context, or an empty string
+func RequestID(ctx context.Context) string {
+	id, _ := ctx.Value(requestIDKey{}).(string)
+	return id
+}
+
+// contextHandler adds the request ID and the trace of the context to each record
+type contextHandler struct {
+	slog.Handler
+}
+
+func (h *contextHandler) Handle(ctx context.Context, record slog.Record) error {
+	if id := RequestID(ctx); id != "" {
+		record.AddAttrs(slog.String("request_id", id))
+	}
+	if span := trace.SpanContextFromContext(ctx); span.IsValid() {
+		record.AddAttrs(slog.String("trace_id", span.TraceID().String()), slog.String("span_id", span.SpanID().String()))
+	}
+	return h.Handler.Handle(ctx, record)
+}
+
+func (h *contextHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return &contextHandler{Handler: h.Handler.WithAttrs(attrs)}
+}
+
+func (h *contextHandler) WithGroup(name string) slog.Handler {
+	return &contextHandler{Handler: h.Handler.WithGroup(name)}
+}
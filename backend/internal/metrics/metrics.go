@@ -0,0 +1,113 @@
+// Package metrics exposes Prometheus metrics about HTTP requests, the database pool
+// and the stored calendar data.
+package metrics
+
+import (
+	"database/sql"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/do2024-2047/CalenDO/internal/repository"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+// namespace prefixes every metric name
+const namespace = "calendo"
+
+var (
+	// registry holds the metrics served by Handler, apart from the global default registry
+	registry = prometheus.NewRegistry()
+
+	requestsTotal = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "http_requests_total",
+		Help:      "HTTP requests by method, route template and status code.",
+	}, []string{"method", "route", "status"})
+
+	requestDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "http_request_duration_seconds",
+		Help:      "HTTP request latency by method and route template.",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"method", "route"})
+)
+
+func init() {
+	registry.MustRegister(
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+		requestsTotal,
+		requestDuration,
+	)
+}
+
+// Register adds the database pool statistics and the event and planning gauges,
+// which are read from the database on every scrape
+func Register(sqlDB *sql.DB, eventRepo repository.EventStore, planningRepo repository.PlanningStore) {
+	registry.MustRegister(
+		collectors.NewDBStatsCollector(sqlDB, "calendo"),
+		&dataCollector{eventRepo: eventRepo, planningRepo: planningRepo},
+	)
+}
+
+// Handler serves the metrics in the Prometheus exposition format
+func Handler() http.Handler {
+	return promhttp.HandlerFor(registry, promhttp.HandlerOpts{})
+}
+
+// ObserveRequest records a served request. route is the mux route template,
+// such as /api/plannings/{id}, so that IDs do not create a series each.
+func ObserveRequest(method, route string, status int, duration time.Duration) {
+	requestsTotal.WithLabelValues(method, route, strconv.Itoa(status)).Inc()
+	requestDuration.WithLabelValues(method, route).Observe(duration.Seconds())
+}
+
+var (
+	eventsDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, "", "events"),
+		"Events stored per planning.",
+		[]string{"planning_id"}, nil,
+	)
+	planningsDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, "", "plannings"),
+		"Plannings stored.",
+		nil, nil,
+	)
+)
+
+// dataCollector counts events and plannings when scraped
+type dataCollector struct {
+	eventRepo    repository.EventStore
+	planningRepo repository.PlanningStore
+}
+
+// Describe implements prometheus.Collector
+func (c *dataCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- eventsDesc
+	ch <- planningsDesc
+}
+
+// Collect implements prometheus.Collector
+func (c *dataCollector) Collect(ch chan<- prometheus.Metric) {
+	counts, err := c.eventRepo.CountByPlanning()
+	if err != nil {
+		slog.Warn("Failed to count events for metrics", "error", err)
+		ch <- prometheus.NewInvalidMetric(eventsDesc, err)
+	} else {
+		for planningID, count := range counts {
+			ch <- prometheus.MustNewConstMetric(eventsDesc, prometheus.GaugeValue, float64(count), planningID)
+		}
+	}
+
+	fp, err := c.planningRepo.Fingerprint()
+	if err != nil {
+		slog.Warn("Failed to count plannings for metrics", "error", err)
+		ch <- prometheus.NewInvalidMetric(planningsDesc, err)
+		return
+	}
+	ch <- prometheus.MustNewConstMetric(planningsDesc, prometheus.GaugeValue, float64(fp.Count))
+}
@@ -0,0 +1,211 @@
+package middleware
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/andybalholm/brotli"
+)
+
+// largeBody is a JSON body above minCompressSize
+var largeBody = `{"events":[` + strings.Repeat(`{"summary":"Team sync"},`, 100) + `{}]}`
+
+// reader returns a reader decoding a body sent with a Content-Encoding
+func reader(t *testing.T, encoding string, body io.Reader) io.Reader {
+	t.Helper()
+	switch encoding {
+	case "br":
+		return brotli.NewReader(body)
+	case "gzip":
+		r, err := gzip.NewReader(body)
+		if err != nil {
+			t.Fatalf("invalid gzip stream: %v", err)
+		}
+		return r
+	}
+	return body
+}
+
+// decompress decodes a whole body sent with a Content-Encoding
+func decompress(t *testing.T, encoding string, body []byte) string {
+	t.Helper()
+	decoded, err := io.ReadAll(reader(t, encoding, bytes.NewReader(body)))
+	if err != nil {
+		t.Fatalf("invalid %s body: %v", encoding, err)
+	}
+	return string(decoded)
+}
+
+func TestNegotiateEncoding(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		header string
+		want   string
+	}{
+		{"", ""},
+		{"identity", ""},
+		{"gzip", "gzip"},
+		{"br", "br"},
+		{"GZIP", "gzip"},
+		{"gzip, br", "br"},
+		{"gzip, deflate, br", "br"},
+		{"br;q=0.8, gzip;q=0.8", "br"},
+		{"br;q=0.5, gzip;q=0.8", "gzip"},
+		{"br; q=0.9, gzip", "gzip"},
+		{"br;q=0, gzip", "gzip"},
+		{"gzip;q=0", ""},
+		{"br;q=0, gzip;q=0", ""},
+		{"*", "br"},
+		{"*;q=0", ""},
+		{"*, br;q=0", "gzip"},
+		{"gzip;q=0, *", "br"},
+		{"gzip, *;q=0", "gzip"},
+		{"br;q=invalid", "br"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.header, func(t *testing.T) {
+			if got := negotiateEncoding(tt.header); got != tt.want {
+				t.Errorf("negotiateEncoding(%q) = %q, want %q", tt.header, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCompress(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name           string
+		method         string
+		acceptEncoding string
+		accept         string
+		contentType    string
+		status         int
+		body           string
+		wantEncoding   string
+	}{
+		{name: "brotli", acceptEncoding: "gzip, br", contentType: "application/json", body: largeBody, wantEncoding: "br"},
+		{name: "gzip", acceptEncoding: "gzip", contentType: "application/json", body: largeBody, wantEncoding: "gzip"},
+		{name: "problem", acceptEncoding: "gzip", contentType: "application/problem+json", status: http.StatusBadRequest, body: largeBody, wantEncoding: "gzip"},
+		{name: "no accepted encoding", acceptEncoding: "identity", contentType: "application/json", body: largeBody},
+		{name: "small body", acceptEncoding: "br", contentType: "application/json", body: `{"status":"ok"}`},
+		{name: "binary content", acceptEncoding: "br", contentType: "image/png", body: largeBody},
+		{name: "event stream content", acceptEncoding: "br", contentType: "text/event-stream", body: largeBody},
+		{name: "event stream request", acceptEncoding: "br", accept: "text/event-stream", contentType: "application/json", body: largeBody},
+		{name: "not modified", acceptEncoding: "br", contentType: "application/json", status: http.StatusNotModified},
+		{name: "head", method: http.MethodHead, acceptEncoding: "br", contentType: "application/json"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			handler := Compress(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Set("Content-Type", tt.contentType)
+				w.Header().Set("Content-Length", strconv.Itoa(len(tt.body)))
+				if tt.status != 0 {
+					w.WriteHeader(tt.status)
+				}
+				// Written in two parts so that the first one is buffered
+				half := len(tt.body) / 2
+				io.WriteString(w, tt.body[:half])
+				io.WriteString(w, tt.body[half:])
+			}))
+
+			method := tt.method
+			if method == "" {
+				method = http.MethodGet
+			}
+			req := httptest.NewRequest(method, "/api/events", nil)
+			req.Header.Set("Accept-Encoding", tt.acceptEncoding)
+			if tt.accept != "" {
+				req.Header.Set("Accept", tt.accept)
+			}
+			rec := httptest.NewRecorder()
+			handler.ServeHTTP(rec, req)
+
+			wantStatus := tt.status
+			if wantStatus == 0 {
+				wantStatus = http.StatusOK
+			}
+			if rec.Code != wantStatus {
+				t.Fatalf("status = %d, want %d", rec.Code, wantStatus)
+			}
+			if got := rec.Header().Get("Content-Encoding"); got != tt.wantEncoding {
+				t.Fatalf("Content-Encoding = %q, want %q", got, tt.wantEncoding)
+			}
+			if got := decompress(t, tt.wantEncoding, rec.Body.Bytes()); got != tt.body {
+				t.Fatalf("body = %q, want %q", got, tt.body)
+			}
+
+			if tt.wantEncoding == "" {
+				if got := rec.Header().Get("Content-Length"); got != strconv.Itoa(len(tt.body)) {
+					t.Errorf("Content-Length = %q on an uncompressed body, want %d", got, len(tt.body))
+				}
+				return
+			}
+			if got := rec.Header().Get("Content-Length"); got != "" {
+				t.Errorf("Content-Length = %q on a compressed body, want none", got)
+			}
+			if got := rec.Header().Values("Vary"); len(got) != 1 || got[0] != "Accept-Encoding" {
+				t.Errorf("Vary = %q, want Accept-Encoding", got)
+			}
+		})
+	}
+}
+
+func TestCompressFlush(t *testing.T) {
+	t.Parallel()
+
+	for _, encoding := range []string{"br", "gzip"} {
+		t.Run(encoding, func(t *testing.T) {
+			t.Parallel()
+
+			// The handler flushes a line smaller than minCompressSize, then waits for the
+			// client to have read it before finishing the response
+			received := make(chan struct{})
+			server := httptest.NewServer(Compress(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Set("Content-Type", "application/json")
+				io.WriteString(w, "first\n")
+				w.(http.Flusher).Flush()
+				<-received
+				io.WriteString(w, "second\n")
+			})))
+			defer server.Close()
+
+			req, _ := http.NewRequest(http.MethodGet, server.URL, nil)
+			req.Header.Set("Accept-Encoding", encoding)
+			// Without DisableCompression, the transport would decode gzip itself. The timeout
+			// fails the test instead of hanging when the first line is not flushed.
+			client := &http.Client{Transport: &http.Transport{DisableCompression: true}, Timeout: 5 * time.Second}
+			resp, err := client.Do(req)
+			if err != nil {
+				close(received)
+				t.Fatalf("request failed: %v", err)
+			}
+			defer resp.Body.Close()
+
+			if got := resp.Header.Get("Content-Encoding"); got != encoding {
+				close(received)
+				t.Fatalf("Content-Encoding = %q, want %q", got, encoding)
+			}
+			lines := bufio.NewReader(reader(t, encoding, resp.Body))
+			first, err := lines.ReadString('\n')
+			close(received)
+			if err != nil || first != "first\n" {
+				t.Fatalf("first flushed line = %q, %v, want first", first, err)
+			}
+			if rest, err := io.ReadAll(lines); err != nil || string(rest) != "second\n" {
+				t.Fatalf("rest of the body = %q, %v, want second", rest, err)
+			}
+		})
+	}
+}
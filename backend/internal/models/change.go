@@ -0,0 +1,62 @@
+package models
+
+import (
+	"time"
+)
+
+// Change types recorded in the change log by the importer
+const (
+	ChangeEventCreated    = "event.created"
+	ChangeEventUpdated    = "event.updated"
+	ChangeEventDeleted    = "event.deleted"
+	ChangePlanningChanged = "planning.changed"
+)
+
+// Change is an entry of the change log written by the importer
+type Change struct {
+	ID         uint64    `json:"id" gorm:"primaryKey;autoIncrement;column:id"`
+	Type       string    `json:"type" gorm:"column:type;not null"`
+	PlanningID string    `json:"planning_id" gorm:"column:planning_id;not null;index"`
+	EventID    string    `json:"event_id,omitempty" validate:"optional" gorm:"column:event_id"`
+	SyncRunID  *uint64   `json:"sync_run_id,omitempty" validate:"optional" gorm:"column:sync_run_id;index"`
+	Created    time.Time `json:"created" gorm:"column:created;autoCreateTime"`
+}
+
+// TableName specifies the table name for the Change model
+func (Change) TableName() string {
+	return "changes"
+}
+
+// ChangePurge records the newest change the importer's purge command removed from the log
+type ChangePurge struct {
+	ID        uint      `gorm:"primaryKey;column:id"`
+	ThroughID uint64    `gorm:"column:through_id;not null"`
+	Purged    time.Time `gorm:"column:purged;autoUpdateTime"`
+}
+
+// TableName specifies the table name for the ChangePurge model
+func (ChangePurge) TableName() string {
+	return "change_purges"
+}
+
+// ChangeResponse represents the response structure for a change log entry
+type ChangeResponse struct {
+	ID         uint64    `json:"id"`
+	Type       string    `json:"type"`
+	PlanningID string    `json:"planning_id"`
+	EventID    string    `json:"event_id,omitempty" validate:"optional"`
+	SyncRunID  *uint64   `json:"sync_run_id,omitempty" validate:"optional"`
+	Created    time.Time `json:"created"`
+}
+
+// ToResponse converts a Change to ChangeResponse
+func (c *Change) ToResponse() ChangeResponse {
+	return ChangeResponse{
+		ID:         c.ID,
+		Type:       c.Type,
+		PlanningID: c.PlanningID,
+		EventID:    c.EventID,
+		SyncRunID:  c.SyncRunID,
+		Created:    c.Created,
+	}
+}
@@ -0,0 +1,22 @@
+package models
+
+import (
+	"time"
+)
+
+// ConflictResponse represents two events that overlap in time
+type ConflictResponse struct {
+	Start        time.Time       `json:"start"`
+	End          time.Time       `json:"end"`
+	SamePlanning bool            `json:"same_planning"`
+	Events       []EventResponse `json:"events"`
+}
+
+// ConflictReportResponse lists the overlapping event pairs found in a time window
+type ConflictReportResponse struct {
+	Start       time.Time          `json:"start"`
+	End         time.Time          `json:"end"`
+	PlanningIDs []string           `json:"planning_ids"`
+	Scope       string             `json:"scope"`
+	Conflicts   []ConflictResponse `json:"conflicts"`
+}
@@ -0,0 +1,19 @@
+package models
+
+// DeltaResponse lists what changed since a sync token, or a full snapshot when no token was given
+type DeltaResponse struct {
+	// Token is passed as since on the next request
+	Token string `json:"token"`
+	// Full is true when the response is a snapshot that replaces the client's copy
+	Full bool `json:"full"`
+	// HasMore is true when more changes are pending; request again with the new token right away
+	HasMore bool `json:"has_more"`
+	// Events holds the current state of events created or updated since the token
+	Events []EventResponse `json:"events"`
+	// Deleted holds the IDs of events removed since the token
+	Deleted []string `json:"deleted"`
+	// Plannings holds the current state of plannings created or changed since the token
+	Plannings []PlanningResponse `json:"plannings"`
+	// DeletedPlannings holds the IDs of plannings removed since the token; their events are gone too
+	DeletedPlannings []string `json:"deleted_plannings"`
+}
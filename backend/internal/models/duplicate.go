@@ -0,0 +1,22 @@
+package models
+
+import (
+	"time"
+)
+
+// DuplicateGroupResponse represents events from different plannings that describe the same occurrence
+type DuplicateGroupResponse struct {
+	// Primary is the ID of the event kept when duplicates are merged
+	Primary string `json:"primary"`
+	// Score is the lowest summary similarity between the primary event and the others, from 0 to 1
+	Score  float64         `json:"score"`
+	Events []EventResponse `json:"events"`
+}
+
+// DuplicateReportResponse lists the suspected duplicates found in a time window
+type DuplicateReportResponse struct {
+	Start       time.Time                `json:"start"`
+	End         time.Time                `json:"end"`
+	PlanningIDs []string                 `json:"planning_ids"`
+	Groups      []DuplicateGroupResponse `json:"groups"`
+}
@@ -2,7 +2,10 @@ package models
 
 import (
 	"fmt"
+	"strings"
 	"time"
+
+	"gorm.io/gorm"
 )
 
 // Event represents a calendar event
@@ -18,11 +21,24 @@ type Event struct {
 	Summary      string    `json:"summary" gorm:"column:summary"`
 	Location     string    `json:"location" gorm:"column:location"`
 	Description  string    `json:"description" gorm:"column:description"`
+	Status       string    `json:"status" gorm:"column:status"`
+	Transparency string    `json:"transparency" gorm:"column:transparency"`
+	// SourceID identifies the calendar source that imported the event, when a planning has several
+	SourceID string `json:"source_id" gorm:"column:source_id;index"`
+	// DeletedAt marks an event removed by the importer; such events are hidden until purged
+	DeletedAt gorm.DeletedAt `json:"-" gorm:"column:deleted_at;index"`
 
 	// Relationships
-	Planning *Planning `json:"planning,omitempty" gorm:"foreignKey:PlanningID;references:ID"`
+	Planning *Planning `json:"planning,omitempty" validate:"optional" gorm:"foreignKey:PlanningID;references:ID"`
 }
 
+// Event status and transparency values, as found in the iCal STATUS and TRANSP properties
+const (
+	EventStatusCancelled    = "CANCELLED"
+	EventTransparencyOpaque = "OPAQUE"
+	EventTransparencyTransp = "TRANSPARENT"
+)
+
 // TableName specifies the table name for the Event model
 func (Event) TableName() string {
 	return "events"
@@ -32,3 +48,10 @@ func (Event) TableName() string {
 func GenerateEventID(uid, planningID string) string {
 	return fmt.Sprintf("%s_%s", uid, planningID)
 }
+
+// BlocksTime reports whether the event should count as busy time.
+// Cancelled events and events marked as transparent never block time.
+func (e *Event) BlocksTime() bool {
+	return !strings.EqualFold(e.Status, EventStatusCancelled) &&
+		!strings.EqualFold(e.Transparency, EventTransparencyTransp)
+}
@@ -15,13 +15,44 @@ type EventResponse struct {
 	StartTime    time.Time         `json:"start_time"`
 	EndTime      time.Time         `json:"end_time"`
 	AllDay       bool              `json:"all_day"`
+	Timezone     string            `json:"timezone"`
+	StartLocal   string            `json:"start_local,omitempty" validate:"optional"`
+	EndLocal     string            `json:"end_local,omitempty" validate:"optional"`
+	StartDate    string            `json:"start_date,omitempty" validate:"optional"`
+	EndDate      string            `json:"end_date,omitempty" validate:"optional"`
+	Status       string            `json:"status,omitempty" validate:"optional"`
+	Transparency string            `json:"transparency,omitempty" validate:"optional"`
+	SourceID     string            `json:"source_id,omitempty" validate:"optional"`
 	Created      time.Time         `json:"created"`
 	LastModified time.Time         `json:"last_modified"`
-	Planning     *PlanningResponse `json:"planning,omitempty"`
+	Planning     *PlanningResponse `json:"planning,omitempty" validate:"optional"`
+	Conflicts    []string          `json:"conflicts,omitempty" validate:"optional"`
+	// DuplicateOf is the ID of the event this one duplicates in another planning
+	DuplicateOf string `json:"duplicate_of,omitempty" validate:"optional"`
+	// Duplicates lists the IDs of the events merged into this one
+	Duplicates []string `json:"duplicates,omitempty" validate:"optional"`
 }
 
-// ToResponse converts an Event to EventResponse
+// ToResponse converts an Event to EventResponse, using the planning's timezone for local fields
 func (e *Event) ToResponse() EventResponse {
+	return e.ToResponseIn(nil)
+}
+
+// ToResponseIn converts an Event to EventResponse with local fields expressed in loc.
+// When loc is nil, the planning's timezone is used, falling back to UTC.
+//
+// Timed events carry their instants with the offset of loc, plus their local
+// wall-clock start and end. All-day events are
+// stored as UTC midnight, so their dates are taken from the UTC calendar day and
+// sent as plain dates, with an exclusive end date.
+func (e *Event) ToResponseIn(loc *time.Location) EventResponse {
+	if loc == nil && e.Planning != nil {
+		loc = LoadLocation(e.Planning.Timezone)
+	}
+	if loc == nil {
+		loc = time.UTC
+	}
+
 	response := EventResponse{
 		ID:           e.ID,
 		UID:          e.UID,
@@ -32,8 +63,27 @@ func (e *Event) ToResponse() EventResponse {
 		StartTime:    e.StartTime,
 		EndTime:      e.EndTime,
 		AllDay:       e.AllDay,
+		Status:       e.Status,
+		Transparency: e.Transparency,
+		SourceID:     e.SourceID,
 		Created:      e.Created,
 		LastModified: e.LastModified,
+		Timezone:     loc.String(),
+	}
+
+	if e.AllDay {
+		startDate := e.StartTime.UTC()
+		endDate := e.EndTime.UTC()
+		if !endDate.After(startDate) {
+			endDate = startDate.AddDate(0, 0, 1)
+		}
+		response.StartDate = startDate.Format(DateFormat)
+		response.EndDate = endDate.Format(DateFormat)
+	} else {
+		response.StartTime = e.StartTime.In(loc)
+		response.EndTime = e.EndTime.In(loc)
+		response.StartLocal = response.StartTime.Format(LocalDateTimeFormat)
+		response.EndLocal = response.EndTime.Format(LocalDateTimeFormat)
 	}
 
 	if e.Planning != nil {
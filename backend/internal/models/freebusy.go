@@ -0,0 +1,19 @@
+package models
+
+import (
+	"time"
+)
+
+// BusyPeriod represents a span of time during which at least one event is scheduled
+type BusyPeriod struct {
+	Start time.Time `json:"start"`
+	End   time.Time `json:"end"`
+}
+
+// FreeBusyResponse represents the aggregated busy time of a set of plannings
+type FreeBusyResponse struct {
+	Start       time.Time    `json:"start"`
+	End         time.Time    `json:"end"`
+	PlanningIDs []string     `json:"planning_ids"`
+	Busy        []BusyPeriod `json:"busy"`
+}
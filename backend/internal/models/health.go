@@ -0,0 +1,66 @@
+package models
+
+import (
+	"time"
+)
+
+// Health check statuses
+const (
+	HealthOK      = "ok"
+	HealthFailing = "failing"
+
+	ReadinessReady        = "ready"
+	ReadinessNotReady     = "not_ready"
+	ReadinessShuttingDown = "shutting_down"
+)
+
+// SchemaVersion records the version of the schema the API migrated the database to
+type SchemaVersion struct {
+	ID       uint      `gorm:"primaryKey;column:id"`
+	Version  int       `gorm:"column:version;not null"`
+	Migrated time.Time `gorm:"column:migrated;autoUpdateTime"`
+}
+
+// TableName specifies the table name for the SchemaVersion model
+func (SchemaVersion) TableName() string {
+	return "schema_version"
+}
+
+// HealthCheck is the outcome of one readiness check
+type HealthCheck struct {
+	Status string `json:"status"`
+	Error  string `json:"error,omitempty" validate:"optional"`
+	// LatencyMs is how long the check took
+	LatencyMs int64 `json:"latency_ms"`
+}
+
+// SchemaCheck compares the schema version of the database with the one the API expects
+type SchemaCheck struct {
+	HealthCheck
+	Version  int `json:"version"`
+	Expected int `json:"expected"`
+}
+
+// PlanningSync reports how fresh the importer's copy of a planning is
+type PlanningSync struct {
+	PlanningID string `json:"planning_id" gorm:"column:planning_id"`
+	Name       string `json:"name" gorm:"column:name"`
+	// LastSync is when the last successful sync of the planning finished
+	LastSync *time.Time `json:"last_sync" gorm:"column:last_sync" extensions:"x-nullable"`
+	// LastStatus is the status of the latest sync run, successful or not
+	LastStatus string `json:"last_status,omitempty" validate:"optional" gorm:"column:last_status"`
+	// AgeSeconds is the time elapsed since LastSync
+	AgeSeconds *int64 `json:"age_seconds" gorm:"-" extensions:"x-nullable"`
+	// Stale is set when the planning was never synced or not for longer than allowed
+	Stale bool `json:"stale" gorm:"-"`
+}
+
+// Readiness is the response of the readiness probe. Stale plannings are reported but do
+// not make the API unready, since every replica would be taken out of rotation alike.
+type Readiness struct {
+	Status    string         `json:"status"`
+	Time      string         `json:"time"`
+	Database  HealthCheck    `json:"database"`
+	Schema    SchemaCheck    `json:"schema"`
+	Plannings []PlanningSync `json:"plannings"`
+}
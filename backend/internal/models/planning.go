@@ -2,6 +2,8 @@ package models
 
 import (
 	"time"
+
+	"gorm.io/gorm"
 )
 
 // Planning represents a calendar planning instance
@@ -13,6 +15,9 @@ type Planning struct {
 	Created     time.Time `json:"created" gorm:"column:created;autoCreateTime"`
 	Updated     time.Time `json:"updated" gorm:"column:updated;autoUpdateTime"`
 	IsDefault   bool      `json:"is_default" gorm:"column:is_default;default:false"`
+	Timezone    string    `json:"timezone" gorm:"column:timezone"`
+	// DeletedAt marks a planning removed by the importer; such plannings are hidden until purged
+	DeletedAt gorm.DeletedAt `json:"-" gorm:"column:deleted_at;index"`
 }
 
 // TableName specifies the table name for the Planning model
@@ -29,7 +34,18 @@ type PlanningResponse struct {
 	Created     time.Time `json:"created"`
 	Updated     time.Time `json:"updated"`
 	IsDefault   bool      `json:"is_default"`
-	EventCount  int       `json:"event_count,omitempty"`
+	Timezone    string    `json:"timezone,omitempty" validate:"optional"`
+	EventCount  int       `json:"event_count,omitempty" validate:"optional"`
+	// GroupID and GroupName identify the group the planning is sorted into, if any
+	GroupID   *uint64 `json:"group_id" extensions:"x-nullable"`
+	GroupName string  `json:"group_name,omitempty" validate:"optional"`
+	// SortOrder orders plannings within their group
+	SortOrder       int  `json:"sort_order"`
+	HiddenByDefault bool `json:"hidden_by_default"`
+	// Hidden is whether the requesting user sees the planning as hidden
+	Hidden bool `json:"hidden"`
+	// DefaultColor is the planning's own colour when the requesting user overrides it
+	DefaultColor string `json:"default_color,omitempty" validate:"optional"`
 }
 
 // ToResponse converts a Planning to PlanningResponse
@@ -42,5 +58,6 @@ func (p *Planning) ToResponse() PlanningResponse {
 		Created:     p.Created,
 		Updated:     p.Updated,
 		IsDefault:   p.IsDefault,
+		Timezone:    p.Timezone,
 	}
 }
@@ -0,0 +1,94 @@
+package models
+
+import (
+	"time"
+)
+
+// PlanningGroup is a folder that plannings can be sorted into
+type PlanningGroup struct {
+	ID        uint64    `json:"id" gorm:"primaryKey;autoIncrement;column:id"`
+	Name      string    `json:"name" gorm:"column:name;not null"`
+	SortOrder int       `json:"sort_order" gorm:"column:sort_order;default:0"`
+	Created   time.Time `json:"created" gorm:"column:created;autoCreateTime"`
+	Updated   time.Time `json:"updated" gorm:"column:updated;autoUpdateTime"`
+}
+
+// TableName specifies the table name for the PlanningGroup model
+func (PlanningGroup) TableName() string {
+	return "planning_groups"
+}
+
+// PlanningGroupRequest is the body used to create or update a planning group
+type PlanningGroupRequest struct {
+	Name      string `json:"name"`
+	SortOrder int    `json:"sort_order" validate:"optional"`
+}
+
+// PlanningSettings holds how a planning is organized for everyone. They are kept apart
+// from the plannings table, which the importer rewrites on every sync.
+type PlanningSettings struct {
+	PlanningID      string    `json:"planning_id" gorm:"primaryKey;column:planning_id"`
+	GroupID         *uint64   `json:"group_id" gorm:"column:group_id;index" extensions:"x-nullable"`
+	SortOrder       int       `json:"sort_order" gorm:"column:sort_order;default:0"`
+	HiddenByDefault bool      `json:"hidden_by_default" gorm:"column:hidden_by_default;default:false"`
+	Updated         time.Time `json:"updated" gorm:"column:updated;autoUpdateTime"`
+}
+
+// TableName specifies the table name for the PlanningSettings model
+func (PlanningSettings) TableName() string {
+	return "planning_settings"
+}
+
+// PlanningSettingsRequest is the body used to replace the settings of a planning
+type PlanningSettingsRequest struct {
+	// GroupID is the group of the planning, or null for none
+	GroupID         *uint64 `json:"group_id" validate:"optional" extensions:"x-nullable"`
+	SortOrder       int     `json:"sort_order" validate:"optional"`
+	HiddenByDefault bool    `json:"hidden_by_default" validate:"optional"`
+}
+
+// UserPlanningPreference overrides the colour or visibility of a planning for one user
+type UserPlanningPreference struct {
+	UserID     string    `json:"user_id" gorm:"primaryKey;column:user_id"`
+	PlanningID string    `json:"planning_id" gorm:"primaryKey;column:planning_id;index"`
+	Color      *string   `json:"color" gorm:"column:color" extensions:"x-nullable"`
+	Hidden     *bool     `json:"hidden" gorm:"column:hidden" extensions:"x-nullable"`
+	Updated    time.Time `json:"updated" gorm:"column:updated;autoUpdateTime"`
+}
+
+// TableName specifies the table name for the UserPlanningPreference model
+func (UserPlanningPreference) TableName() string {
+	return "user_planning_preferences"
+}
+
+// UserPlanningPreferenceRequest is the body used to replace a user's preference for a planning.
+// A null field falls back to the planning's own value.
+type UserPlanningPreferenceRequest struct {
+	Color  *string `json:"color" validate:"optional" extensions:"x-nullable"`
+	Hidden *bool   `json:"hidden" validate:"optional" extensions:"x-nullable"`
+}
+
+// ApplyLayout adds the organization of a planning to its response: the shared settings and
+// group, then the preference of the requesting user. Any of them may be nil.
+func (p *PlanningResponse) ApplyLayout(settings *PlanningSettings, group *PlanningGroup, preference *UserPlanningPreference) {
+	if settings != nil {
+		p.GroupID = settings.GroupID
+		p.SortOrder = settings.SortOrder
+		p.HiddenByDefault = settings.HiddenByDefault
+	}
+	if group != nil {
+		p.GroupName = group.Name
+	}
+
+	p.Hidden = p.HiddenByDefault
+	if preference == nil {
+		return
+	}
+	if preference.Hidden != nil {
+		p.Hidden = *preference.Hidden
+	}
+	if preference.Color != nil && *preference.Color != p.Color {
+		p.DefaultColor = p.Color
+		p.Color = *preference.Color
+	}
+}
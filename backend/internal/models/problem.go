@@ -0,0 +1,39 @@
+package models
+
+// ProblemContentType is the media type of error responses (RFC 7807)
+const ProblemContentType = "application/problem+json"
+
+// ProblemTypePrefix is prefixed to an error code to give the type of its problems
+const ProblemTypePrefix = "urn:calendo:problem:"
+
+// Error codes of problem responses. They are stable: clients may branch on them, while
+// titles and details are meant for people and may change.
+const (
+	ProblemInvalidParameter      = "invalid_parameter"
+	ProblemInvalidBody           = "invalid_body"
+	ProblemUnauthorized          = "unauthorized"
+	ProblemEventNotFound         = "event_not_found"
+	ProblemPlanningNotFound      = "planning_not_found"
+	ProblemPlanningGroupNotFound = "planning_group_not_found"
+	ProblemPreferenceNotFound    = "preference_not_found"
+	ProblemWebhookNotFound       = "webhook_not_found"
+	ProblemRouteNotFound         = "route_not_found"
+	ProblemMethodNotAllowed      = "method_not_allowed"
+	ProblemSyncTokenExpired      = "sync_token_expired"
+	ProblemInternalError         = "internal_error"
+)
+
+// Problem is the body of every error response: an RFC 7807 problem details object,
+// extended with a stable error code and the ID of the request
+type Problem struct {
+	// Type is ProblemTypePrefix followed by Code
+	Type   string `json:"type"`
+	Title  string `json:"title"`
+	Status int    `json:"status"`
+	Detail string `json:"detail,omitempty" validate:"optional"`
+	// Instance is the path of the request that failed
+	Instance string `json:"instance,omitempty" validate:"optional"`
+	Code     string `json:"code"`
+	// RequestID is the X-Request-ID of the request, to quote when reporting the problem
+	RequestID string `json:"request_id,omitempty" validate:"optional"`
+}
@@ -0,0 +1,45 @@
+package models
+
+import (
+	"time"
+)
+
+// WorkingHoursRequest describes the daily hours during which meetings may be scheduled
+type WorkingHoursRequest struct {
+	Start    string   `json:"start" example:"09:00"`
+	End      string   `json:"end" example:"17:00"`
+	Days     []string `json:"days,omitempty" validate:"optional" example:"mon,tue,wed,thu,fri"`
+	Timezone string   `json:"timezone,omitempty" validate:"optional" example:"Europe/Paris"`
+}
+
+// TimeWindowRequest is the period in which to search for slots
+type TimeWindowRequest struct {
+	Start time.Time `json:"start"`
+	End   time.Time `json:"end"`
+}
+
+// FindSlotsRequest is the request body of the meeting slot finder
+type FindSlotsRequest struct {
+	PlanningIDs     []string            `json:"plannings" validate:"optional"`
+	DurationMinutes int                 `json:"duration_minutes" example:"30"`
+	BufferMinutes   int                 `json:"buffer_minutes,omitempty" validate:"optional" example:"10"`
+	StepMinutes     int                 `json:"step_minutes,omitempty" validate:"optional" example:"15"`
+	WorkingHours    WorkingHoursRequest `json:"working_hours"`
+	Window          TimeWindowRequest   `json:"window"`
+	IncludeAllDay   *bool               `json:"include_all_day,omitempty" validate:"optional"`
+	MaxResults      int                 `json:"max_results,omitempty" validate:"optional" example:"10"`
+}
+
+// SlotResponse is a free meeting slot with its ranking score
+type SlotResponse struct {
+	Start time.Time `json:"start"`
+	End   time.Time `json:"end"`
+	Score float64   `json:"score"`
+}
+
+// FindSlotsResponse is the ranked list of free slots
+type FindSlotsResponse struct {
+	PlanningIDs []string       `json:"planning_ids"`
+	Timezone    string         `json:"timezone"`
+	Slots       []SlotResponse `json:"slots"`
+}
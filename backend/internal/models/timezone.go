@@ -0,0 +1,33 @@
+package models
+
+import (
+	"sync"
+	"time"
+)
+
+const (
+	// LocalDateTimeFormat is the wall-clock format used for local times, without offset
+	LocalDateTimeFormat = "2006-01-02T15:04:05"
+	// DateFormat is the format used for plain dates
+	DateFormat = "2006-01-02"
+)
+
+// locations caches loaded timezones, as time.LoadLocation reads the zoneinfo database on every call
+var locations sync.Map
+
+// LoadLocation returns the named timezone, or nil when the name is empty or unknown
+func LoadLocation(name string) *time.Location {
+	if name == "" {
+		return nil
+	}
+	if loc, ok := locations.Load(name); ok {
+		return loc.(*time.Location)
+	}
+
+	loc, err := time.LoadLocation(name)
+	if err != nil {
+		return nil
+	}
+	locations.Store(name, loc)
+	return loc
+}
@@ -0,0 +1,131 @@
+package models
+
+import (
+	"strings"
+	"time"
+)
+
+// WebhookPingType is the type of the payload sent by the test-fire endpoint
+const WebhookPingType = "ping"
+
+// Webhook is a subscription that receives signed change notifications over HTTP
+type Webhook struct {
+	ID         string `json:"id" gorm:"primaryKey;column:id"`
+	URL        string `json:"url" gorm:"column:url;not null"`
+	PlanningID string `json:"planning_id" gorm:"column:planning_id;index"`
+	// EventTypes is a comma-separated list of change types, empty for all of them
+	EventTypes string `json:"event_types" gorm:"column:event_types"`
+	Secret     string `json:"-" gorm:"column:secret;not null"`
+	Active     bool   `json:"active" gorm:"column:active;default:true"`
+	// LastChangeID is the newest change delivered, or given up on after every attempt failed
+	LastChangeID uint64 `json:"last_change_id" gorm:"column:last_change_id;default:0"`
+	// LeaseOwner is the API replica delivering to the webhook until LeaseUntil
+	LeaseOwner string     `json:"-" gorm:"column:lease_owner"`
+	LeaseUntil *time.Time `json:"-" gorm:"column:lease_until"`
+	Created    time.Time  `json:"created" gorm:"column:created;autoCreateTime"`
+	Updated    time.Time  `json:"updated" gorm:"column:updated;autoUpdateTime"`
+}
+
+// TableName specifies the table name for the Webhook model
+func (Webhook) TableName() string {
+	return "webhooks"
+}
+
+// Types returns the change types the webhook subscribes to, empty for all of them
+func (h *Webhook) Types() []string {
+	var types []string
+	for _, t := range strings.Split(h.EventTypes, ",") {
+		if t = strings.TrimSpace(t); t != "" {
+			types = append(types, t)
+		}
+	}
+	return types
+}
+
+// Wants reports whether a change should be delivered to the webhook
+func (h *Webhook) Wants(change *Change) bool {
+	if h.PlanningID != "" && h.PlanningID != change.PlanningID {
+		return false
+	}
+	types := h.Types()
+	if len(types) == 0 {
+		return true
+	}
+	for _, t := range types {
+		if t == change.Type {
+			return true
+		}
+	}
+	return false
+}
+
+// WebhookDelivery records one delivery attempt of a webhook payload
+type WebhookDelivery struct {
+	ID         uint64    `json:"id" gorm:"primaryKey;autoIncrement;column:id"`
+	WebhookID  string    `json:"webhook_id" gorm:"column:webhook_id;not null;index"`
+	DeliveryID string    `json:"delivery_id" gorm:"column:delivery_id;not null;index"`
+	ChangeID   uint64    `json:"change_id" gorm:"column:change_id"`
+	Type       string    `json:"type" gorm:"column:type"`
+	Attempt    int       `json:"attempt" gorm:"column:attempt"`
+	StatusCode int       `json:"status_code" gorm:"column:status_code"`
+	Success    bool      `json:"success" gorm:"column:success"`
+	Error      string    `json:"error,omitempty" validate:"optional" gorm:"column:error"`
+	DurationMs int64     `json:"duration_ms" gorm:"column:duration_ms"`
+	Created    time.Time `json:"created" gorm:"column:created;autoCreateTime"`
+}
+
+// TableName specifies the table name for the WebhookDelivery model
+func (WebhookDelivery) TableName() string {
+	return "webhook_deliveries"
+}
+
+// WebhookRequest is the request body used to register a webhook
+type WebhookRequest struct {
+	URL        string   `json:"url" example:"https://chat.example.com/hooks/calendo"`
+	PlanningID string   `json:"planning_id,omitempty" validate:"optional" example:"work-planning"`
+	EventTypes []string `json:"event_types,omitempty" validate:"optional" example:"event.created,event.deleted"`
+	// Secret used to sign payloads; generated when omitted
+	Secret string `json:"secret,omitempty" validate:"optional"`
+}
+
+// WebhookResponse represents the response structure for a webhook
+type WebhookResponse struct {
+	ID         string    `json:"id"`
+	URL        string    `json:"url"`
+	PlanningID string    `json:"planning_id,omitempty" validate:"optional"`
+	EventTypes []string  `json:"event_types"`
+	Active     bool      `json:"active"`
+	Created    time.Time `json:"created"`
+	Updated    time.Time `json:"updated"`
+	// Secret is only returned when the webhook is created
+	Secret string `json:"secret,omitempty" validate:"optional"`
+}
+
+// ToResponse converts a Webhook to WebhookResponse, without its secret
+func (h *Webhook) ToResponse() WebhookResponse {
+	types := h.Types()
+	if types == nil {
+		types = []string{}
+	}
+	return WebhookResponse{
+		ID:         h.ID,
+		URL:        h.URL,
+		PlanningID: h.PlanningID,
+		EventTypes: types,
+		Active:     h.Active,
+		Created:    h.Created,
+		Updated:    h.Updated,
+	}
+}
+
+// WebhookPayload is the JSON body posted to webhook endpoints
+type WebhookPayload struct {
+	DeliveryID string            `json:"delivery_id"`
+	Type       string            `json:"type"`
+	ChangeID   uint64            `json:"change_id,omitempty" validate:"optional"`
+	PlanningID string            `json:"planning_id,omitempty" validate:"optional"`
+	EventID    string            `json:"event_id,omitempty" validate:"optional"`
+	OccurredAt time.Time         `json:"occurred_at"`
+	Event      *EventResponse    `json:"event,omitempty" validate:"optional"`
+	Planning   *PlanningResponse `json:"planning,omitempty" validate:"optional"`
+}
@@ -0,0 +1,88 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/do2024-2047/CalenDO/internal/database"
+	"github.com/do2024-2047/CalenDO/internal/models"
+	"github.com/jackc/pgx/v5"
+	"gorm.io/gorm"
+)
+
+// ChangeRepository handles database operations for the change log
+type ChangeRepository struct {
+	db *gorm.DB
+}
+
+// NewChangeRepository creates a new change repository
+func NewChangeRepository(db *gorm.DB) *ChangeRepository {
+	return &ChangeRepository{db: db}
+}
+
+// WithContext returns a copy of the repository whose queries carry ctx
+func (r *ChangeRepository) WithContext(ctx context.Context) ChangeStore {
+	return &ChangeRepository{db: r.db.WithContext(ctx)}
+}
+
+// FindSince returns up to limit changes with an ID greater than afterID, oldest first.
+// When planningIDs is empty, changes of every planning are returned.
+func (r *ChangeRepository) FindSince(afterID uint64, planningIDs []string, limit int) ([]*models.Change, error) {
+	query := r.db.Where("id > ?", afterID)
+	if len(planningIDs) > 0 {
+		query = query.Where("planning_id IN ?", planningIDs)
+	}
+
+	var changes []*models.Change
+	result := query.Order("id ASC").Limit(limit).Find(&changes)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+
+	return changes, nil
+}
+
+// LatestID returns the ID of the newest change, or 0 when the log is empty
+func (r *ChangeRepository) LatestID() (uint64, error) {
+	var latest uint64
+	result := r.db.Model(&models.Change{}).Select("COALESCE(MAX(id), 0)").Scan(&latest)
+	if result.Error != nil {
+		return 0, result.Error
+	}
+
+	return latest, nil
+}
+
+// changePurgeID is the ID of the single row of the change_purges table, written by the importer
+const changePurgeID = 1
+
+// PurgedThroughID returns the ID of the newest change purged from the log, 0 if none was
+func (r *ChangeRepository) PurgedThroughID() (uint64, error) {
+	var purge models.ChangePurge
+	result := r.db.Limit(1).Find(&purge, changePurgeID)
+	if result.Error != nil {
+		return 0, result.Error
+	}
+
+	return purge.ThroughID, nil
+}
+
+// Latest returns the newest change, or a zero Change when the log is empty
+func (r *ChangeRepository) Latest() (models.Change, error) {
+	var latest models.Change
+	result := r.db.Order("id DESC").Limit(1).Find(&latest)
+	if result.Error != nil {
+		return models.Change{}, result.Error
+	}
+
+	return latest, nil
+}
+
+// Listen opens a dedicated connection subscribed to a notification channel
+func (r *ChangeRepository) Listen(ctx context.Context, channel string) (*pgx.Conn, error) {
+	return database.Listen(ctx, r.db, channel)
+}
+
+// InitTable initializes the changes and change_purges tables if they don't exist
+func (r *ChangeRepository) InitTable() error {
+	return r.db.AutoMigrate(&models.Change{}, &models.ChangePurge{})
+}
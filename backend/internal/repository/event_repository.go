@@ -1,9 +1,10 @@
 package repository
 
 import (
+	"context"
 	"errors"
+	"time"
 
-	"github.com/do2024-2047/CalenDO/internal/database"
 	"github.com/do2024-2047/CalenDO/internal/models"
 	"gorm.io/gorm"
 )
@@ -16,18 +17,25 @@ var (
 )
 
 // EventRepository handles database operations for calendar events
-type EventRepository struct{}
+type EventRepository struct {
+	db *gorm.DB
+}
 
 // NewEventRepository creates a new event repository
-func NewEventRepository() *EventRepository {
-	return &EventRepository{}
+func NewEventRepository(db *gorm.DB) *EventRepository {
+	return &EventRepository{db: db}
+}
+
+// WithContext returns a copy of the repository whose queries carry ctx
+func (r *EventRepository) WithContext(ctx context.Context) EventStore {
+	return &EventRepository{db: r.db.WithContext(ctx)}
 }
 
 // FindAll returns all events
 func (r *EventRepository) FindAll() ([]*models.Event, error) {
 	var events []*models.Event
 
-	result := database.DB.Preload("Planning").Order("start_time DESC").Find(&events)
+	result := r.db.Preload("Planning").Order("start_time DESC, id ASC").Find(&events)
 	if result.Error != nil {
 		return nil, result.Error
 	}
@@ -42,7 +50,24 @@ func (r *EventRepository) FindByPlanningID(planningID string) ([]*models.Event,
 	}
 
 	var events []*models.Event
-	result := database.DB.Preload("Planning").Where("planning_id = ?", planningID).Order("start_time DESC").Find(&events)
+	result := r.db.Preload("Planning").Where("planning_id = ?", planningID).Order("start_time DESC, id ASC").Find(&events)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+
+	return events, nil
+}
+
+// FindInRange returns the events overlapping the [start, end) window.
+// When planningIDs is empty, events from every planning are returned.
+func (r *EventRepository) FindInRange(planningIDs []string, start, end time.Time) ([]*models.Event, error) {
+	query := r.db.Preload("Planning").Where("start_time < ? AND end_time > ?", end, start)
+	if len(planningIDs) > 0 {
+		query = query.Where("planning_id IN ?", planningIDs)
+	}
+
+	var events []*models.Event
+	result := query.Order("start_time ASC, id ASC").Find(&events)
 	if result.Error != nil {
 		return nil, result.Error
 	}
@@ -57,7 +82,7 @@ func (r *EventRepository) FindByID(id string) (*models.Event, error) {
 	}
 
 	var event models.Event
-	result := database.DB.Preload("Planning").Where("id = ?", id).First(&event)
+	result := r.db.Preload("Planning").Where("id = ?", id).First(&event)
 
 	if result.Error != nil {
 		if errors.Is(result.Error, gorm.ErrRecordNotFound) {
@@ -69,6 +94,21 @@ func (r *EventRepository) FindByID(id string) (*models.Event, error) {
 	return &event, nil
 }
 
+// FindByIDs returns the events with the given IDs; IDs that do not exist are skipped
+func (r *EventRepository) FindByIDs(ids []string) ([]*models.Event, error) {
+	if len(ids) == 0 {
+		return nil, nil
+	}
+
+	var events []*models.Event
+	result := r.db.Preload("Planning").Where("id IN ?", ids).Order("start_time DESC, id ASC").Find(&events)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+
+	return events, nil
+}
+
 // FindByUIDAndPlanningID returns an event by its UID and planning ID
 func (r *EventRepository) FindByUIDAndPlanningID(uid, planningID string) (*models.Event, error) {
 	if uid == "" || planningID == "" {
@@ -77,7 +117,7 @@ func (r *EventRepository) FindByUIDAndPlanningID(uid, planningID string) (*model
 
 	eventID := models.GenerateEventID(uid, planningID)
 	var event models.Event
-	result := database.DB.Preload("Planning").Where("id = ?", eventID).First(&event)
+	result := r.db.Preload("Planning").Where("id = ?", eventID).First(&event)
 
 	if result.Error != nil {
 		if errors.Is(result.Error, gorm.ErrRecordNotFound) {
@@ -89,7 +129,51 @@ func (r *EventRepository) FindByUIDAndPlanningID(uid, planningID string) (*model
 	return &event, nil
 }
 
+// Fingerprint returns the number of events and their latest modification time,
+// including changes to their plannings. When planningIDs is empty, every event is counted.
+func (r *EventRepository) Fingerprint(planningIDs []string) (Fingerprint, error) {
+	query := r.db.Model(&models.Event{}).Select("COUNT(*) AS count, MAX(last_modified) AS last_modified")
+	if len(planningIDs) > 0 {
+		query = query.Where("planning_id IN ?", planningIDs)
+	}
+
+	var row fingerprintRow
+	if err := query.Scan(&row).Error; err != nil {
+		return Fingerprint{}, err
+	}
+
+	// Events embed their planning, so a renamed planning changes the response too
+	plannings := r.db.Model(&models.Planning{}).Select("0 AS count, MAX(updated) AS last_modified")
+	if len(planningIDs) > 0 {
+		plannings = plannings.Where("id IN ?", planningIDs)
+	}
+
+	var planningRow fingerprintRow
+	if err := plannings.Scan(&planningRow).Error; err != nil {
+		return Fingerprint{}, err
+	}
+
+	return row.toFingerprint().Merge(planningRow.toFingerprint()), nil
+}
+
+// CountByPlanning returns the number of events of each planning that has any
+func (r *EventRepository) CountByPlanning() (map[string]int64, error) {
+	var rows []struct {
+		PlanningID string
+		Count      int64
+	}
+	if err := r.db.Model(&models.Event{}).Select("planning_id, COUNT(*) AS count").Group("planning_id").Scan(&rows).Error; err != nil {
+		return nil, err
+	}
+
+	counts := make(map[string]int64, len(rows))
+	for _, row := range rows {
+		counts[row.PlanningID] = row.Count
+	}
+	return counts, nil
+}
+
 // InitTable initializes the events table if it doesn't exist
 func (r *EventRepository) InitTable() error {
-	return database.DB.AutoMigrate(&models.Event{})
+	return r.db.AutoMigrate(&models.Event{})
 }
@@ -0,0 +1,36 @@
+package repository
+
+import (
+	"time"
+)
+
+// Fingerprint summarises the rows behind a query so that clients can tell
+// whether a response changed without downloading it again
+type Fingerprint struct {
+	Count        int64
+	LastModified time.Time
+}
+
+// Merge combines two fingerprints, as for a response built from both sets of rows
+func (f Fingerprint) Merge(other Fingerprint) Fingerprint {
+	merged := Fingerprint{Count: f.Count + other.Count, LastModified: f.LastModified}
+	if other.LastModified.After(merged.LastModified) {
+		merged.LastModified = other.LastModified
+	}
+	return merged
+}
+
+// fingerprintRow is the result of a COUNT/MAX fingerprint query; MAX is NULL on empty tables
+type fingerprintRow struct {
+	Count        int64
+	LastModified *time.Time
+}
+
+// toFingerprint converts a query result to a Fingerprint
+func (row fingerprintRow) toFingerprint() Fingerprint {
+	fp := Fingerprint{Count: row.Count}
+	if row.LastModified != nil {
+		fp.LastModified = row.LastModified.UTC()
+	}
+	return fp
+}
@@ -0,0 +1,98 @@
+package repository
+
+import (
+	"context"
+	"errors"
+
+	"github.com/do2024-2047/CalenDO/internal/models"
+	"gorm.io/gorm"
+)
+
+// SchemaVersion is the version of the schema the InitTable migrations produce.
+// Bump it whenever a migration changes the schema in a way older replicas cannot read.
+const SchemaVersion = 1
+
+// schemaVersionID is the ID of the single row of the schema_version table
+const schemaVersionID = 1
+
+// syncRunsTable is written by the importer, and missing until its first run
+const syncRunsTable = "sync_runs"
+
+// HealthRepository handles the database queries of the health checks
+type HealthRepository struct {
+	db *gorm.DB
+}
+
+// NewHealthRepository creates a new health repository
+func NewHealthRepository(db *gorm.DB) *HealthRepository {
+	return &HealthRepository{db: db}
+}
+
+// WithContext returns a copy of the repository whose queries carry ctx
+func (r *HealthRepository) WithContext(ctx context.Context) HealthStore {
+	return &HealthRepository{db: r.db.WithContext(ctx)}
+}
+
+// Ping checks that the database accepts connections
+func (r *HealthRepository) Ping() error {
+	sqlDB, err := r.db.DB()
+	if err != nil {
+		return err
+	}
+	return sqlDB.PingContext(r.db.Statement.Context)
+}
+
+// FindSchemaVersion returns the schema version recorded in the database, 0 if none is
+func (r *HealthRepository) FindSchemaVersion() (int, error) {
+	var version models.SchemaVersion
+	err := r.db.First(&version, schemaVersionID).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, err
+	}
+	return version.Version, nil
+}
+
+// FindPlanningSyncs returns, for each planning, when it was last synced successfully
+// and the status of its latest sync run
+func (r *HealthRepository) FindPlanningSyncs() ([]models.PlanningSync, error) {
+	db := r.db
+
+	syncs := []models.PlanningSync{}
+	if !db.Migrator().HasTable(syncRunsTable) {
+		err := db.Model(&models.Planning{}).
+			Select("id AS planning_id, name").
+			Order("name").
+			Scan(&syncs).Error
+		return syncs, err
+	}
+
+	err := db.Raw(`
+		SELECT p.id AS planning_id, p.name,
+			(SELECT MAX(r.finished) FROM sync_runs r WHERE r.planning_id = p.id AND r.status = 'succeeded') AS last_sync,
+			(SELECT r.status FROM sync_runs r WHERE r.planning_id = p.id ORDER BY r.started DESC, r.id DESC LIMIT 1) AS last_status
+		FROM plannings p
+		WHERE p.deleted_at IS NULL
+		ORDER BY p.name`).Scan(&syncs).Error
+	return syncs, err
+}
+
+// InitTable initializes the schema_version table and records SchemaVersion, unless a
+// newer replica already migrated the database further
+func (r *HealthRepository) InitTable() error {
+	db := r.db
+	if err := db.AutoMigrate(&models.SchemaVersion{}); err != nil {
+		return err
+	}
+
+	current, err := r.FindSchemaVersion()
+	if err != nil {
+		return err
+	}
+	if current >= SchemaVersion {
+		return nil
+	}
+	return db.Save(&models.SchemaVersion{ID: schemaVersionID, Version: SchemaVersion}).Error
+}
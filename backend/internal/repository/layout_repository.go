@@ -0,0 +1,151 @@
+package repository
+
+import (
+	"context"
+	"errors"
+
+	"github.com/do2024-2047/CalenDO/internal/models"
+	"gorm.io/gorm"
+)
+
+// LayoutRepository handles database operations for planning groups, planning settings
+// and per-user planning preferences
+type LayoutRepository struct {
+	db *gorm.DB
+}
+
+// NewLayoutRepository creates a new layout repository
+func NewLayoutRepository(db *gorm.DB) *LayoutRepository {
+	return &LayoutRepository{db: db}
+}
+
+// WithContext returns a copy of the repository whose queries carry ctx
+func (r *LayoutRepository) WithContext(ctx context.Context) LayoutStore {
+	return &LayoutRepository{db: r.db.WithContext(ctx)}
+}
+
+// FindGroups returns all planning groups in display order
+func (r *LayoutRepository) FindGroups() ([]*models.PlanningGroup, error) {
+	var groups []*models.PlanningGroup
+
+	result := r.db.Order("sort_order ASC, name ASC").Find(&groups)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+
+	return groups, nil
+}
+
+// FindGroupByID returns a planning group by its ID
+func (r *LayoutRepository) FindGroupByID(id uint64) (*models.PlanningGroup, error) {
+	var group models.PlanningGroup
+	result := r.db.Where("id = ?", id).First(&group)
+
+	if result.Error != nil {
+		if errors.Is(result.Error, gorm.ErrRecordNotFound) {
+			return nil, ErrNotFound
+		}
+		return nil, result.Error
+	}
+
+	return &group, nil
+}
+
+// SaveGroup creates or updates a planning group
+func (r *LayoutRepository) SaveGroup(group *models.PlanningGroup) error {
+	return r.db.Save(group).Error
+}
+
+// DeleteGroup removes a planning group; its plannings become ungrouped
+func (r *LayoutRepository) DeleteGroup(id uint64) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		result := tx.Where("id = ?", id).Delete(&models.PlanningGroup{})
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return ErrNotFound
+		}
+		return tx.Model(&models.PlanningSettings{}).Where("group_id = ?", id).Update("group_id", nil).Error
+	})
+}
+
+// FindSettings returns the settings of every planning that has some, by planning ID
+func (r *LayoutRepository) FindSettings() (map[string]*models.PlanningSettings, error) {
+	var settings []*models.PlanningSettings
+	if err := r.db.Find(&settings).Error; err != nil {
+		return nil, err
+	}
+
+	byPlanning := make(map[string]*models.PlanningSettings, len(settings))
+	for _, s := range settings {
+		byPlanning[s.PlanningID] = s
+	}
+	return byPlanning, nil
+}
+
+// SaveSettings creates or replaces the settings of a planning
+func (r *LayoutRepository) SaveSettings(settings *models.PlanningSettings) error {
+	return r.db.Save(settings).Error
+}
+
+// FindPreferences returns the preferences of a user, by planning ID
+func (r *LayoutRepository) FindPreferences(userID string) (map[string]*models.UserPlanningPreference, error) {
+	byPlanning := make(map[string]*models.UserPlanningPreference)
+	if userID == "" {
+		return byPlanning, nil
+	}
+
+	var preferences []*models.UserPlanningPreference
+	if err := r.db.Where("user_id = ?", userID).Find(&preferences).Error; err != nil {
+		return nil, err
+	}
+
+	for _, p := range preferences {
+		byPlanning[p.PlanningID] = p
+	}
+	return byPlanning, nil
+}
+
+// SavePreference creates or replaces a user's preference for a planning
+func (r *LayoutRepository) SavePreference(preference *models.UserPlanningPreference) error {
+	return r.db.Save(preference).Error
+}
+
+// DeletePreference removes a user's preference for a planning
+func (r *LayoutRepository) DeletePreference(userID, planningID string) error {
+	result := r.db.Where("user_id = ? AND planning_id = ?", userID, planningID).Delete(&models.UserPlanningPreference{})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+// Fingerprint returns the number of groups, settings and preferences of a user
+// with their latest update time
+func (r *LayoutRepository) Fingerprint(userID string) (Fingerprint, error) {
+	var fp Fingerprint
+
+	queries := []*gorm.DB{
+		r.db.Model(&models.PlanningGroup{}),
+		r.db.Model(&models.PlanningSettings{}),
+		r.db.Model(&models.UserPlanningPreference{}).Where("user_id = ?", userID),
+	}
+	for _, query := range queries {
+		var row fingerprintRow
+		if err := query.Select("COUNT(*) AS count, MAX(updated) AS last_modified").Scan(&row).Error; err != nil {
+			return Fingerprint{}, err
+		}
+		fp = fp.Merge(row.toFingerprint())
+	}
+
+	return fp, nil
+}
+
+// InitTable initializes the planning group, settings and preference tables if they don't exist
+func (r *LayoutRepository) InitTable() error {
+	return r.db.AutoMigrate(&models.PlanningGroup{}, &models.PlanningSettings{}, &models.UserPlanningPreference{})
+}
@@ -0,0 +1,87 @@
+package memory
+
+import (
+	"context"
+	"errors"
+
+	"github.com/do2024-2047/CalenDO/internal/models"
+	"github.com/do2024-2047/CalenDO/internal/repository"
+	"github.com/jackc/pgx/v5"
+)
+
+// ErrNoNotifications is returned by ChangeStore.Listen: the change broker then relies on polling
+var ErrNoNotifications = errors.New("the memory store sends no notifications")
+
+// ChangeStore implements repository.ChangeStore
+type ChangeStore struct {
+	store *Store
+	ctx   context.Context
+}
+
+// WithContext returns a copy of the store whose operations fail once ctx is done
+func (r *ChangeStore) WithContext(ctx context.Context) repository.ChangeStore {
+	return &ChangeStore{store: r.store, ctx: ctx}
+}
+
+// FindSince returns up to limit changes with an ID greater than afterID, oldest first.
+// When planningIDs is empty, changes of every planning are returned.
+func (r *ChangeStore) FindSince(afterID uint64, planningIDs []string, limit int) ([]*models.Change, error) {
+	s := r.store
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err := s.check(r.ctx); err != nil {
+		return nil, err
+	}
+
+	inPlannings := idSet(planningIDs)
+	var changes []*models.Change
+	for _, change := range s.changes {
+		if len(changes) == limit {
+			break
+		}
+		if change.ID <= afterID || (len(inPlannings) > 0 && !inPlannings[change.PlanningID]) {
+			continue
+		}
+		found := *change
+		changes = append(changes, &found)
+	}
+	return changes, nil
+}
+
+// LatestID returns the ID of the newest change, or 0 when the log is empty
+func (r *ChangeStore) LatestID() (uint64, error) {
+	latest, err := r.Latest()
+	return latest.ID, err
+}
+
+// PurgedThroughID returns the ID of the newest change purged from the log, 0 if none was
+func (r *ChangeStore) PurgedThroughID() (uint64, error) {
+	s := r.store
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err := s.check(r.ctx); err != nil {
+		return 0, err
+	}
+
+	return s.purgedThroughID, nil
+}
+
+// Latest returns the newest change, or a zero Change when the log is empty
+func (r *ChangeStore) Latest() (models.Change, error) {
+	s := r.store
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err := s.check(r.ctx); err != nil {
+		return models.Change{}, err
+	}
+
+	if len(s.changes) == 0 {
+		return models.Change{}, nil
+	}
+	return *s.changes[len(s.changes)-1], nil
+}
+
+// Listen fails with ErrNoNotifications
+func (r *ChangeStore) Listen(ctx context.Context, channel string) (*pgx.Conn, error) {
+	return nil, ErrNoNotifications
+}
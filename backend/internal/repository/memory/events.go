@@ -0,0 +1,260 @@
+package memory
+
+import (
+	"context"
+	"sort"
+	"time"
+
+	"github.com/do2024-2047/CalenDO/internal/models"
+	"github.com/do2024-2047/CalenDO/internal/repository"
+)
+
+// EventStore implements repository.EventStore
+type EventStore struct {
+	store *Store
+	ctx   context.Context
+}
+
+// WithContext returns a copy of the store whose operations fail once ctx is done
+func (r *EventStore) WithContext(ctx context.Context) repository.EventStore {
+	return &EventStore{store: r.store, ctx: ctx}
+}
+
+// FindAll returns all events
+func (r *EventStore) FindAll() ([]*models.Event, error) {
+	return r.find(func(*models.Event) bool { return true }, false)
+}
+
+// FindByPlanningID returns all events for a specific planning
+func (r *EventStore) FindByPlanningID(planningID string) ([]*models.Event, error) {
+	if planningID == "" {
+		return nil, repository.ErrInvalidID
+	}
+	return r.find(func(e *models.Event) bool { return e.PlanningID == planningID }, false)
+}
+
+// FindInRange returns the events overlapping the [start, end) window.
+// When planningIDs is empty, events from every planning are returned.
+func (r *EventStore) FindInRange(planningIDs []string, start, end time.Time) ([]*models.Event, error) {
+	inPlannings := idSet(planningIDs)
+	return r.find(func(e *models.Event) bool {
+		return e.StartTime.Before(end) && e.EndTime.After(start) && (len(inPlannings) == 0 || inPlannings[e.PlanningID])
+	}, true)
+}
+
+// FindByID returns an event by its composite ID
+func (r *EventStore) FindByID(id string) (*models.Event, error) {
+	if id == "" {
+		return nil, repository.ErrInvalidID
+	}
+
+	events, err := r.find(func(e *models.Event) bool { return e.ID == id }, false)
+	if err != nil {
+		return nil, err
+	}
+	if len(events) == 0 {
+		return nil, repository.ErrNotFound
+	}
+	return events[0], nil
+}
+
+// FindByIDs returns the events with the given IDs; IDs that do not exist are skipped
+func (r *EventStore) FindByIDs(ids []string) ([]*models.Event, error) {
+	if len(ids) == 0 {
+		return nil, nil
+	}
+	wanted := idSet(ids)
+	return r.find(func(e *models.Event) bool { return wanted[e.ID] }, false)
+}
+
+// FindByUIDAndPlanningID returns an event by its UID and planning ID
+func (r *EventStore) FindByUIDAndPlanningID(uid, planningID string) (*models.Event, error) {
+	if uid == "" || planningID == "" {
+		return nil, repository.ErrInvalidID
+	}
+	return r.FindByID(models.GenerateEventID(uid, planningID))
+}
+
+// Fingerprint returns the number of events and their latest modification time,
+// including changes to their plannings. When planningIDs is empty, every event is counted.
+func (r *EventStore) Fingerprint(planningIDs []string) (repository.Fingerprint, error) {
+	s := r.store
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err := s.check(r.ctx); err != nil {
+		return repository.Fingerprint{}, err
+	}
+
+	inPlannings := idSet(planningIDs)
+	var count int
+	var modified, updated []time.Time
+	for _, event := range s.events {
+		if event.DeletedAt.Valid || (len(inPlannings) > 0 && !inPlannings[event.PlanningID]) {
+			continue
+		}
+		count++
+		modified = append(modified, event.LastModified)
+	}
+	for _, planning := range s.plannings {
+		if planning.DeletedAt.Valid || (len(inPlannings) > 0 && !inPlannings[planning.ID]) {
+			continue
+		}
+		updated = append(updated, planning.Updated)
+	}
+
+	return fingerprint(count, modified).Merge(fingerprint(0, updated)), nil
+}
+
+// CountByPlanning returns the number of events of each planning that has any
+func (r *EventStore) CountByPlanning() (map[string]int64, error) {
+	events, err := r.FindAll()
+	if err != nil {
+		return nil, err
+	}
+
+	counts := make(map[string]int64)
+	for _, event := range events {
+		counts[event.PlanningID]++
+	}
+	return counts, nil
+}
+
+// find returns copies of the events matching keep, with their planning, sorted by start time
+func (r *EventStore) find(keep func(*models.Event) bool, ascending bool) ([]*models.Event, error) {
+	s := r.store
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err := s.check(r.ctx); err != nil {
+		return nil, err
+	}
+
+	var events []*models.Event
+	for _, event := range s.events {
+		if event.DeletedAt.Valid || !keep(event) {
+			continue
+		}
+		found := *event
+		if planning, ok := s.plannings[event.PlanningID]; ok {
+			copied := *planning
+			found.Planning = &copied
+		}
+		events = append(events, &found)
+	}
+	sortEvents(events, ascending)
+	return events, nil
+}
+
+// PlanningStore implements repository.PlanningStore
+type PlanningStore struct {
+	store *Store
+	ctx   context.Context
+}
+
+// WithContext returns a copy of the store whose operations fail once ctx is done
+func (r *PlanningStore) WithContext(ctx context.Context) repository.PlanningStore {
+	return &PlanningStore{store: r.store, ctx: ctx}
+}
+
+// FindAll returns all plannings
+func (r *PlanningStore) FindAll() ([]*models.Planning, error) {
+	return r.find(func(*models.Planning) bool { return true })
+}
+
+// FindByID returns a planning by its ID
+func (r *PlanningStore) FindByID(id string) (*models.Planning, error) {
+	if id == "" {
+		return nil, repository.ErrInvalidID
+	}
+	return r.first(func(p *models.Planning) bool { return p.ID == id })
+}
+
+// FindByIDs returns the plannings with the given IDs; IDs that do not exist are skipped
+func (r *PlanningStore) FindByIDs(ids []string) ([]*models.Planning, error) {
+	if len(ids) == 0 {
+		return nil, nil
+	}
+	wanted := idSet(ids)
+	return r.find(func(p *models.Planning) bool { return wanted[p.ID] })
+}
+
+// FindByIDWithEventCount returns a planning by its ID with event count
+func (r *PlanningStore) FindByIDWithEventCount(id string) (*models.Planning, int64, error) {
+	planning, err := r.FindByID(id)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	events := &EventStore{store: r.store, ctx: r.ctx}
+	counts, err := events.CountByPlanning()
+	if err != nil {
+		return planning, 0, err
+	}
+	return planning, counts[id], nil
+}
+
+// GetDefault returns the default planning
+func (r *PlanningStore) GetDefault() (*models.Planning, error) {
+	return r.first(func(p *models.Planning) bool { return p.IsDefault })
+}
+
+// Fingerprint returns the number of plannings and their latest update time
+func (r *PlanningStore) Fingerprint() (repository.Fingerprint, error) {
+	plannings, err := r.FindAll()
+	if err != nil {
+		return repository.Fingerprint{}, err
+	}
+
+	updated := make([]time.Time, 0, len(plannings))
+	for _, planning := range plannings {
+		updated = append(updated, planning.Updated)
+	}
+	return fingerprint(len(plannings), updated), nil
+}
+
+// first returns the first planning matching keep, or repository.ErrNotFound
+func (r *PlanningStore) first(keep func(*models.Planning) bool) (*models.Planning, error) {
+	plannings, err := r.find(keep)
+	if err != nil {
+		return nil, err
+	}
+	if len(plannings) == 0 {
+		return nil, repository.ErrNotFound
+	}
+	return plannings[0], nil
+}
+
+// find returns copies of the plannings matching keep, latest created first
+func (r *PlanningStore) find(keep func(*models.Planning) bool) ([]*models.Planning, error) {
+	s := r.store
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err := s.check(r.ctx); err != nil {
+		return nil, err
+	}
+
+	var plannings []*models.Planning
+	for _, planning := range s.plannings {
+		if planning.DeletedAt.Valid || !keep(planning) {
+			continue
+		}
+		found := *planning
+		plannings = append(plannings, &found)
+	}
+	sort.Slice(plannings, func(i, j int) bool {
+		a, b := plannings[i], plannings[j]
+		if !a.Created.Equal(b.Created) {
+			return a.Created.After(b.Created)
+		}
+		return a.ID < b.ID
+	})
+	return plannings, nil
+}
+
+// idSet returns the set of the given IDs
+func idSet(ids []string) map[string]bool {
+	set := make(map[string]bool, len(ids))
+	for _, id := range ids {
+		set[id] = true
+	}
+	return set
+}
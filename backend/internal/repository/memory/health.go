@@ -0,0 +1,65 @@
+package memory
+
+import (
+	"context"
+	"sort"
+
+	"github.com/do2024-2047/CalenDO/internal/models"
+	"github.com/do2024-2047/CalenDO/internal/repository"
+)
+
+// HealthStore implements repository.HealthStore
+type HealthStore struct {
+	store *Store
+	ctx   context.Context
+}
+
+// WithContext returns a copy of the store whose operations fail once ctx is done
+func (r *HealthStore) WithContext(ctx context.Context) repository.HealthStore {
+	return &HealthStore{store: r.store, ctx: ctx}
+}
+
+// Ping fails like the other operations of a failing store
+func (r *HealthStore) Ping() error {
+	s := r.store
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.check(r.ctx)
+}
+
+// FindSchemaVersion returns the schema version of the store
+func (r *HealthStore) FindSchemaVersion() (int, error) {
+	s := r.store
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err := s.check(r.ctx); err != nil {
+		return 0, err
+	}
+	return s.schemaVersion, nil
+}
+
+// FindPlanningSyncs returns, for each planning, when it was last synced successfully
+// and the status of its latest sync run, ordered by planning name
+func (r *HealthStore) FindPlanningSyncs() ([]models.PlanningSync, error) {
+	plannings, err := (&PlanningStore{store: r.store, ctx: r.ctx}).FindAll()
+	if err != nil {
+		return nil, err
+	}
+
+	s := r.store
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	syncs := make([]models.PlanningSync, 0, len(plannings))
+	for _, planning := range plannings {
+		outcome := s.syncs[planning.ID]
+		syncs = append(syncs, models.PlanningSync{
+			PlanningID: planning.ID,
+			Name:       planning.Name,
+			LastSync:   outcome.lastSync,
+			LastStatus: outcome.lastStatus,
+		})
+	}
+	sort.SliceStable(syncs, func(i, j int) bool { return syncs[i].Name < syncs[j].Name })
+	return syncs, nil
+}
@@ -0,0 +1,224 @@
+package memory
+
+import (
+	"context"
+	"sort"
+	"time"
+
+	"github.com/do2024-2047/CalenDO/internal/models"
+	"github.com/do2024-2047/CalenDO/internal/repository"
+)
+
+// LayoutStore implements repository.LayoutStore
+type LayoutStore struct {
+	store *Store
+	ctx   context.Context
+}
+
+// WithContext returns a copy of the store whose operations fail once ctx is done
+func (r *LayoutStore) WithContext(ctx context.Context) repository.LayoutStore {
+	return &LayoutStore{store: r.store, ctx: ctx}
+}
+
+// FindGroups returns all planning groups in display order
+func (r *LayoutStore) FindGroups() ([]*models.PlanningGroup, error) {
+	s := r.store
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err := s.check(r.ctx); err != nil {
+		return nil, err
+	}
+
+	var groups []*models.PlanningGroup
+	for _, group := range s.groups {
+		found := *group
+		groups = append(groups, &found)
+	}
+	sort.Slice(groups, func(i, j int) bool {
+		a, b := groups[i], groups[j]
+		if a.SortOrder != b.SortOrder {
+			return a.SortOrder < b.SortOrder
+		}
+		return a.Name < b.Name
+	})
+	return groups, nil
+}
+
+// FindGroupByID returns a planning group by its ID
+func (r *LayoutStore) FindGroupByID(id uint64) (*models.PlanningGroup, error) {
+	s := r.store
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err := s.check(r.ctx); err != nil {
+		return nil, err
+	}
+
+	group, ok := s.groups[id]
+	if !ok {
+		return nil, repository.ErrNotFound
+	}
+	found := *group
+	return &found, nil
+}
+
+// SaveGroup creates or updates a planning group
+func (r *LayoutStore) SaveGroup(group *models.PlanningGroup) error {
+	s := r.store
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err := s.check(r.ctx); err != nil {
+		return err
+	}
+
+	now := time.Now()
+	if group.ID == 0 {
+		s.nextGroupID++
+		group.ID = s.nextGroupID
+	} else if group.ID > s.nextGroupID {
+		s.nextGroupID = group.ID
+	}
+	if group.Created.IsZero() {
+		group.Created = now
+	}
+	group.Updated = now
+	stored := *group
+	s.groups[group.ID] = &stored
+	return nil
+}
+
+// DeleteGroup removes a planning group; its plannings become ungrouped
+func (r *LayoutStore) DeleteGroup(id uint64) error {
+	s := r.store
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err := s.check(r.ctx); err != nil {
+		return err
+	}
+
+	if _, ok := s.groups[id]; !ok {
+		return repository.ErrNotFound
+	}
+	delete(s.groups, id)
+
+	for _, settings := range s.settings {
+		if settings.GroupID != nil && *settings.GroupID == id {
+			settings.GroupID = nil
+		}
+	}
+	return nil
+}
+
+// FindSettings returns the settings of every planning that has some, by planning ID
+func (r *LayoutStore) FindSettings() (map[string]*models.PlanningSettings, error) {
+	s := r.store
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err := s.check(r.ctx); err != nil {
+		return nil, err
+	}
+
+	byPlanning := make(map[string]*models.PlanningSettings, len(s.settings))
+	for planningID, settings := range s.settings {
+		found := *settings
+		byPlanning[planningID] = &found
+	}
+	return byPlanning, nil
+}
+
+// SaveSettings creates or replaces the settings of a planning
+func (r *LayoutStore) SaveSettings(settings *models.PlanningSettings) error {
+	s := r.store
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err := s.check(r.ctx); err != nil {
+		return err
+	}
+
+	settings.Updated = time.Now()
+	stored := *settings
+	s.settings[settings.PlanningID] = &stored
+	return nil
+}
+
+// FindPreferences returns the preferences of a user, by planning ID
+func (r *LayoutStore) FindPreferences(userID string) (map[string]*models.UserPlanningPreference, error) {
+	s := r.store
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err := s.check(r.ctx); err != nil {
+		return nil, err
+	}
+
+	byPlanning := make(map[string]*models.UserPlanningPreference)
+	if userID == "" {
+		return byPlanning, nil
+	}
+	for key, preference := range s.preferences {
+		if key.userID == userID {
+			found := *preference
+			byPlanning[key.planningID] = &found
+		}
+	}
+	return byPlanning, nil
+}
+
+// SavePreference creates or replaces a user's preference for a planning
+func (r *LayoutStore) SavePreference(preference *models.UserPlanningPreference) error {
+	s := r.store
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err := s.check(r.ctx); err != nil {
+		return err
+	}
+
+	preference.Updated = time.Now()
+	stored := *preference
+	s.preferences[preferenceKey{preference.UserID, preference.PlanningID}] = &stored
+	return nil
+}
+
+// DeletePreference removes a user's preference for a planning
+func (r *LayoutStore) DeletePreference(userID, planningID string) error {
+	s := r.store
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err := s.check(r.ctx); err != nil {
+		return err
+	}
+
+	key := preferenceKey{userID, planningID}
+	if _, ok := s.preferences[key]; !ok {
+		return repository.ErrNotFound
+	}
+	delete(s.preferences, key)
+	return nil
+}
+
+// Fingerprint returns the number of groups, settings and preferences of a user
+// with their latest update time
+func (r *LayoutStore) Fingerprint(userID string) (repository.Fingerprint, error) {
+	s := r.store
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err := s.check(r.ctx); err != nil {
+		return repository.Fingerprint{}, err
+	}
+
+	var count int
+	var updated []time.Time
+	for _, group := range s.groups {
+		count++
+		updated = append(updated, group.Updated)
+	}
+	for _, settings := range s.settings {
+		count++
+		updated = append(updated, settings.Updated)
+	}
+	for key, preference := range s.preferences {
+		if key.userID == userID {
+			count++
+			updated = append(updated, preference.Updated)
+		}
+	}
+	return fingerprint(count, updated), nil
+}
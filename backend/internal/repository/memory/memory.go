@@ -0,0 +1,242 @@
+// Package memory implements the repository stores in memory, so that the HTTP layer
+// can be tested without Postgres.
+package memory
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/do2024-2047/CalenDO/internal/models"
+	"github.com/do2024-2047/CalenDO/internal/repository"
+	"gorm.io/gorm"
+)
+
+// Store holds the data behind every store of this package. The stores it returns
+// share it, like the Postgres repositories share a database.
+type Store struct {
+	mu sync.Mutex
+
+	plannings   map[string]*models.Planning
+	events      map[string]*models.Event
+	changes     []*models.Change
+	webhooks    map[string]*models.Webhook
+	deliveries  []*models.WebhookDelivery
+	groups      map[uint64]*models.PlanningGroup
+	settings    map[string]*models.PlanningSettings
+	preferences map[preferenceKey]*models.UserPlanningPreference
+	syncs       map[string]planningSync
+
+	schemaVersion int
+	nextChangeID  uint64
+	// purgedThroughID is the newest change removed by PurgeChanges
+	purgedThroughID uint64
+	nextGroupID     uint64
+	nextDeliveryID  uint64
+
+	// err, when set, fails every operation
+	err error
+}
+
+// preferenceKey identifies the preference of a user for a planning
+type preferenceKey struct {
+	userID     string
+	planningID string
+}
+
+// planningSync is the outcome of the importer's syncs of a planning
+type planningSync struct {
+	lastSync   *time.Time
+	lastStatus string
+}
+
+// New creates an empty store, migrated to the current schema version
+func New() *Store {
+	return &Store{
+		plannings:     make(map[string]*models.Planning),
+		events:        make(map[string]*models.Event),
+		webhooks:      make(map[string]*models.Webhook),
+		groups:        make(map[uint64]*models.PlanningGroup),
+		settings:      make(map[string]*models.PlanningSettings),
+		preferences:   make(map[preferenceKey]*models.UserPlanningPreference),
+		syncs:         make(map[string]planningSync),
+		schemaVersion: repository.SchemaVersion,
+	}
+}
+
+// The stores of this package implement the repository interfaces
+var (
+	_ repository.EventStore    = (*EventStore)(nil)
+	_ repository.PlanningStore = (*PlanningStore)(nil)
+	_ repository.ChangeStore   = (*ChangeStore)(nil)
+	_ repository.WebhookStore  = (*WebhookStore)(nil)
+	_ repository.LayoutStore   = (*LayoutStore)(nil)
+	_ repository.HealthStore   = (*HealthStore)(nil)
+)
+
+// Events returns the event store
+func (s *Store) Events() repository.EventStore {
+	return &EventStore{store: s}
+}
+
+// Plannings returns the planning store
+func (s *Store) Plannings() repository.PlanningStore {
+	return &PlanningStore{store: s}
+}
+
+// Changes returns the change log store
+func (s *Store) Changes() repository.ChangeStore {
+	return &ChangeStore{store: s}
+}
+
+// Webhooks returns the webhook store
+func (s *Store) Webhooks() repository.WebhookStore {
+	return &WebhookStore{store: s}
+}
+
+// Layouts returns the planning group, settings and preference store
+func (s *Store) Layouts() repository.LayoutStore {
+	return &LayoutStore{store: s}
+}
+
+// Health returns the store answering the readiness checks
+func (s *Store) Health() repository.HealthStore {
+	return &HealthStore{store: s}
+}
+
+// Fail makes every following operation return err, as a broken database would.
+// A nil err heals the store.
+func (s *Store) Fail(err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.err = err
+}
+
+// AddPlanning stores a planning, setting its creation and update times when missing
+func (s *Store) AddPlanning(planning models.Planning) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now()
+	if planning.Created.IsZero() {
+		planning.Created = now
+	}
+	if planning.Updated.IsZero() {
+		planning.Updated = now
+	}
+	s.plannings[planning.ID] = &planning
+}
+
+// DeletePlanning removes a planning the way the importer does, leaving it hidden until purged
+func (s *Store) DeletePlanning(id string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if planning, ok := s.plannings[id]; ok {
+		planning.DeletedAt = gorm.DeletedAt{Time: time.Now(), Valid: true}
+	}
+}
+
+// AddEvent stores an event, deriving its ID from its UID and planning when missing
+func (s *Store) AddEvent(event models.Event) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if event.ID == "" {
+		event.ID = models.GenerateEventID(event.UID, event.PlanningID)
+	}
+	if event.LastModified.IsZero() {
+		event.LastModified = time.Now()
+	}
+	event.Planning = nil
+	s.events[event.ID] = &event
+}
+
+// AddChange appends an entry to the change log and returns its ID
+func (s *Store) AddChange(change models.Change) uint64 {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.nextChangeID++
+	change.ID = s.nextChangeID
+	if change.Created.IsZero() {
+		change.Created = time.Now()
+	}
+	s.changes = append(s.changes, &change)
+	return change.ID
+}
+
+// PurgeChanges removes the change log entries up to throughID, as the importer's purge
+// command does with the entries older than the retention period
+func (s *Store) PurgeChanges(throughID uint64) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	kept := s.changes[:0]
+	for _, change := range s.changes {
+		if change.ID > throughID {
+			kept = append(kept, change)
+		}
+	}
+	s.changes = kept
+	if throughID > s.purgedThroughID {
+		s.purgedThroughID = throughID
+	}
+}
+
+// SetSchemaVersion records the schema version of the store
+func (s *Store) SetSchemaVersion(version int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.schemaVersion = version
+}
+
+// RecordSync records a sync run of a planning by the importer. Only successful runs
+// move the time of the last sync.
+func (s *Store) RecordSync(planningID string, finished time.Time, status string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	outcome := s.syncs[planningID]
+	outcome.lastStatus = status
+	if status == "succeeded" && (outcome.lastSync == nil || finished.After(*outcome.lastSync)) {
+		outcome.lastSync = &finished
+	}
+	s.syncs[planningID] = outcome
+}
+
+// check returns the error an operation run under ctx fails with, if any
+func (s *Store) check(ctx context.Context) error {
+	if ctx != nil {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+	}
+	return s.err
+}
+
+// fingerprint returns the count and latest modification time of a set of rows
+func fingerprint(count int, times []time.Time) repository.Fingerprint {
+	fp := repository.Fingerprint{Count: int64(count)}
+	for _, t := range times {
+		if t.After(fp.LastModified) {
+			fp.LastModified = t
+		}
+	}
+	if !fp.LastModified.IsZero() {
+		fp.LastModified = fp.LastModified.UTC()
+	}
+	return fp
+}
+
+// sortEvents orders events by start time, latest first unless ascending, then by ID
+func sortEvents(events []*models.Event, ascending bool) {
+	sort.Slice(events, func(i, j int) bool {
+		a, b := events[i], events[j]
+		if !a.StartTime.Equal(b.StartTime) {
+			return a.StartTime.Before(b.StartTime) == ascending
+		}
+		return a.ID < b.ID
+	})
+}
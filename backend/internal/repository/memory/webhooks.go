@@ -0,0 +1,206 @@
+package memory
+
+import (
+	"context"
+	"sort"
+	"time"
+
+	"github.com/do2024-2047/CalenDO/internal/models"
+	"github.com/do2024-2047/CalenDO/internal/repository"
+)
+
+// WebhookStore implements repository.WebhookStore
+type WebhookStore struct {
+	store *Store
+	ctx   context.Context
+}
+
+// WithContext returns a copy of the store whose operations fail once ctx is done
+func (r *WebhookStore) WithContext(ctx context.Context) repository.WebhookStore {
+	return &WebhookStore{store: r.store, ctx: ctx}
+}
+
+// FindAll returns all webhooks
+func (r *WebhookStore) FindAll() ([]*models.Webhook, error) {
+	return r.find(func(*models.Webhook) bool { return true })
+}
+
+// FindActive returns the webhooks that should receive deliveries
+func (r *WebhookStore) FindActive() ([]*models.Webhook, error) {
+	return r.find(func(h *models.Webhook) bool { return h.Active })
+}
+
+// FindByID returns a webhook by its ID
+func (r *WebhookStore) FindByID(id string) (*models.Webhook, error) {
+	if id == "" {
+		return nil, repository.ErrInvalidID
+	}
+
+	hooks, err := r.find(func(h *models.Webhook) bool { return h.ID == id })
+	if err != nil {
+		return nil, err
+	}
+	if len(hooks) == 0 {
+		return nil, repository.ErrNotFound
+	}
+	return hooks[0], nil
+}
+
+// Create stores a new webhook
+func (r *WebhookStore) Create(webhook *models.Webhook) error {
+	s := r.store
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err := s.check(r.ctx); err != nil {
+		return err
+	}
+
+	now := time.Now()
+	webhook.Created = now
+	webhook.Updated = now
+	stored := *webhook
+	s.webhooks[webhook.ID] = &stored
+	return nil
+}
+
+// Delete removes a webhook and its delivery log
+func (r *WebhookStore) Delete(id string) error {
+	s := r.store
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err := s.check(r.ctx); err != nil {
+		return err
+	}
+
+	if _, ok := s.webhooks[id]; !ok {
+		return repository.ErrNotFound
+	}
+	delete(s.webhooks, id)
+
+	kept := s.deliveries[:0]
+	for _, delivery := range s.deliveries {
+		if delivery.WebhookID != id {
+			kept = append(kept, delivery)
+		}
+	}
+	s.deliveries = kept
+	return nil
+}
+
+// AdvanceCursor moves the webhook's delivery cursor from one change ID to another.
+// It returns false when the cursor was moved first.
+func (r *WebhookStore) AdvanceCursor(id string, from, to uint64) (bool, error) {
+	s := r.store
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err := s.check(r.ctx); err != nil {
+		return false, err
+	}
+
+	hook, ok := s.webhooks[id]
+	if !ok || hook.LastChangeID != from {
+		return false, nil
+	}
+	hook.LastChangeID = to
+	hook.Updated = time.Now()
+	return true, nil
+}
+
+// AcquireLease makes owner the only process delivering to a webhook for ttl. It returns
+// false while another owner holds the lease.
+func (r *WebhookStore) AcquireLease(id, owner string, ttl time.Duration) (bool, error) {
+	s := r.store
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err := s.check(r.ctx); err != nil {
+		return false, err
+	}
+
+	hook, ok := s.webhooks[id]
+	now := time.Now()
+	if !ok || (hook.LeaseOwner != owner && hook.LeaseUntil != nil && hook.LeaseUntil.After(now)) {
+		return false, nil
+	}
+	until := now.Add(ttl)
+	hook.LeaseOwner = owner
+	hook.LeaseUntil = &until
+	return true, nil
+}
+
+// ReleaseLease ends the lease of owner on a webhook, if it still holds it
+func (r *WebhookStore) ReleaseLease(id, owner string) error {
+	s := r.store
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err := s.check(r.ctx); err != nil {
+		return err
+	}
+
+	if hook, ok := s.webhooks[id]; ok && hook.LeaseOwner == owner {
+		hook.LeaseOwner = ""
+		hook.LeaseUntil = nil
+	}
+	return nil
+}
+
+// RecordDelivery stores a delivery attempt in the delivery log
+func (r *WebhookStore) RecordDelivery(delivery *models.WebhookDelivery) error {
+	s := r.store
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err := s.check(r.ctx); err != nil {
+		return err
+	}
+
+	s.nextDeliveryID++
+	delivery.ID = s.nextDeliveryID
+	delivery.Created = time.Now()
+	stored := *delivery
+	s.deliveries = append(s.deliveries, &stored)
+	return nil
+}
+
+// FindDeliveries returns the most recent delivery attempts of a webhook
+func (r *WebhookStore) FindDeliveries(webhookID string, limit int) ([]*models.WebhookDelivery, error) {
+	s := r.store
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err := s.check(r.ctx); err != nil {
+		return nil, err
+	}
+
+	var deliveries []*models.WebhookDelivery
+	for i := len(s.deliveries) - 1; i >= 0 && len(deliveries) < limit; i-- {
+		if s.deliveries[i].WebhookID == webhookID {
+			found := *s.deliveries[i]
+			deliveries = append(deliveries, &found)
+		}
+	}
+	return deliveries, nil
+}
+
+// find returns copies of the webhooks matching keep, latest created first
+func (r *WebhookStore) find(keep func(*models.Webhook) bool) ([]*models.Webhook, error) {
+	s := r.store
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err := s.check(r.ctx); err != nil {
+		return nil, err
+	}
+
+	var hooks []*models.Webhook
+	for _, hook := range s.webhooks {
+		if keep(hook) {
+			found := *hook
+			hooks = append(hooks, &found)
+		}
+	}
+	sort.Slice(hooks, func(i, j int) bool {
+		a, b := hooks[i], hooks[j]
+		if !a.Created.Equal(b.Created) {
+			return a.Created.After(b.Created)
+		}
+		return a.ID < b.ID
+	})
+	return hooks, nil
+}
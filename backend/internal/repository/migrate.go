@@ -0,0 +1,29 @@
+package repository
+
+import (
+	"fmt"
+
+	"gorm.io/gorm"
+)
+
+// Migrate creates or updates the tables of every repository, then records SchemaVersion
+func Migrate(db *gorm.DB) error {
+	migrations := []struct {
+		name string
+		run  func() error
+	}{
+		{"planning table", NewPlanningRepository(db).InitTable},
+		{"event table", NewEventRepository(db).InitTable},
+		{"change table", NewChangeRepository(db).InitTable},
+		{"webhook tables", NewWebhookRepository(db).InitTable},
+		{"planning layout tables", NewLayoutRepository(db).InitTable},
+		{"schema version", NewHealthRepository(db).InitTable},
+	}
+
+	for _, migration := range migrations {
+		if err := migration.run(); err != nil {
+			return fmt.Errorf("failed to initialize %s: %v", migration.name, err)
+		}
+	}
+	return nil
+}
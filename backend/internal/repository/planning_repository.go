@@ -1,26 +1,33 @@
 package repository
 
 import (
+	"context"
 	"errors"
 
-	"github.com/do2024-2047/CalenDO/internal/database"
 	"github.com/do2024-2047/CalenDO/internal/models"
 	"gorm.io/gorm"
 )
 
 // PlanningRepository handles database operations for plannings
-type PlanningRepository struct{}
+type PlanningRepository struct {
+	db *gorm.DB
+}
 
 // NewPlanningRepository creates a new planning repository
-func NewPlanningRepository() *PlanningRepository {
-	return &PlanningRepository{}
+func NewPlanningRepository(db *gorm.DB) *PlanningRepository {
+	return &PlanningRepository{db: db}
+}
+
+// WithContext returns a copy of the repository whose queries carry ctx
+func (r *PlanningRepository) WithContext(ctx context.Context) PlanningStore {
+	return &PlanningRepository{db: r.db.WithContext(ctx)}
 }
 
 // FindAll returns all plannings
 func (r *PlanningRepository) FindAll() ([]*models.Planning, error) {
 	var plannings []*models.Planning
 
-	result := database.DB.Order("created DESC").Find(&plannings)
+	result := r.db.Order("created DESC, id ASC").Find(&plannings)
 	if result.Error != nil {
 		return nil, result.Error
 	}
@@ -35,7 +42,7 @@ func (r *PlanningRepository) FindByID(id string) (*models.Planning, error) {
 	}
 
 	var planning models.Planning
-	result := database.DB.Where("id = ?", id).First(&planning)
+	result := r.db.Where("id = ?", id).First(&planning)
 
 	if result.Error != nil {
 		if errors.Is(result.Error, gorm.ErrRecordNotFound) {
@@ -47,6 +54,21 @@ func (r *PlanningRepository) FindByID(id string) (*models.Planning, error) {
 	return &planning, nil
 }
 
+// FindByIDs returns the plannings with the given IDs; IDs that do not exist are skipped
+func (r *PlanningRepository) FindByIDs(ids []string) ([]*models.Planning, error) {
+	if len(ids) == 0 {
+		return nil, nil
+	}
+
+	var plannings []*models.Planning
+	result := r.db.Where("id IN ?", ids).Order("created DESC, id ASC").Find(&plannings)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+
+	return plannings, nil
+}
+
 // FindByIDWithEventCount returns a planning by its ID with event count
 func (r *PlanningRepository) FindByIDWithEventCount(id string) (*models.Planning, int64, error) {
 	planning, err := r.FindByID(id)
@@ -55,7 +77,7 @@ func (r *PlanningRepository) FindByIDWithEventCount(id string) (*models.Planning
 	}
 
 	var eventCount int64
-	countResult := database.DB.Model(&models.Event{}).Where("planning_id = ?", id).Count(&eventCount)
+	countResult := r.db.Model(&models.Event{}).Where("planning_id = ?", id).Count(&eventCount)
 	if countResult.Error != nil {
 		return planning, 0, countResult.Error
 	}
@@ -66,7 +88,7 @@ func (r *PlanningRepository) FindByIDWithEventCount(id string) (*models.Planning
 // GetDefault returns the default planning
 func (r *PlanningRepository) GetDefault() (*models.Planning, error) {
 	var planning models.Planning
-	result := database.DB.Where("is_default = ?", true).First(&planning)
+	result := r.db.Where("is_default = ?", true).First(&planning)
 
 	if result.Error != nil {
 		if errors.Is(result.Error, gorm.ErrRecordNotFound) {
@@ -78,7 +100,18 @@ func (r *PlanningRepository) GetDefault() (*models.Planning, error) {
 	return &planning, nil
 }
 
+// Fingerprint returns the number of plannings and their latest update time
+func (r *PlanningRepository) Fingerprint() (Fingerprint, error) {
+	var row fingerprintRow
+	result := r.db.Model(&models.Planning{}).Select("COUNT(*) AS count, MAX(updated) AS last_modified").Scan(&row)
+	if result.Error != nil {
+		return Fingerprint{}, result.Error
+	}
+
+	return row.toFingerprint(), nil
+}
+
 // InitTable initializes the plannings table if it doesn't exist
 func (r *PlanningRepository) InitTable() error {
-	return database.DB.AutoMigrate(&models.Planning{})
+	return r.db.AutoMigrate(&models.Planning{})
 }
@@ -0,0 +1,101 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/do2024-2047/CalenDO/internal/models"
+	"github.com/jackc/pgx/v5"
+)
+
+// The stores below are what the HTTP layer, the change broker and the webhook dispatcher
+// need from the database. The Postgres repositories of this package implement them, and
+// the memory package offers an implementation for tests. WithContext returns a store whose
+// operations carry ctx, to be cancelled with a request and traced and logged with it.
+
+// EventStore reads calendar events
+type EventStore interface {
+	WithContext(ctx context.Context) EventStore
+	FindAll() ([]*models.Event, error)
+	FindByPlanningID(planningID string) ([]*models.Event, error)
+	FindInRange(planningIDs []string, start, end time.Time) ([]*models.Event, error)
+	FindByID(id string) (*models.Event, error)
+	FindByIDs(ids []string) ([]*models.Event, error)
+	FindByUIDAndPlanningID(uid, planningID string) (*models.Event, error)
+	Fingerprint(planningIDs []string) (Fingerprint, error)
+	CountByPlanning() (map[string]int64, error)
+}
+
+// PlanningStore reads plannings
+type PlanningStore interface {
+	WithContext(ctx context.Context) PlanningStore
+	FindAll() ([]*models.Planning, error)
+	FindByID(id string) (*models.Planning, error)
+	FindByIDs(ids []string) ([]*models.Planning, error)
+	FindByIDWithEventCount(id string) (*models.Planning, int64, error)
+	GetDefault() (*models.Planning, error)
+	Fingerprint() (Fingerprint, error)
+}
+
+// ChangeStore reads the change log written by the importer
+type ChangeStore interface {
+	WithContext(ctx context.Context) ChangeStore
+	FindSince(afterID uint64, planningIDs []string, limit int) ([]*models.Change, error)
+	LatestID() (uint64, error)
+	// PurgedThroughID returns the ID of the newest change purged from the log, 0 if none was
+	PurgedThroughID() (uint64, error)
+	Latest() (models.Change, error)
+	// Listen opens a connection subscribed to the notifications of a channel
+	Listen(ctx context.Context, channel string) (*pgx.Conn, error)
+}
+
+// WebhookStore manages webhooks and their delivery log
+type WebhookStore interface {
+	WithContext(ctx context.Context) WebhookStore
+	FindAll() ([]*models.Webhook, error)
+	FindActive() ([]*models.Webhook, error)
+	FindByID(id string) (*models.Webhook, error)
+	Create(webhook *models.Webhook) error
+	Delete(id string) error
+	AdvanceCursor(id string, from, to uint64) (bool, error)
+	// AcquireLease makes owner the only process delivering to a webhook for ttl. It returns
+	// false while another owner holds the lease; the owner renews it by acquiring it again.
+	AcquireLease(id, owner string, ttl time.Duration) (bool, error)
+	// ReleaseLease ends the lease of owner on a webhook, if it still holds it
+	ReleaseLease(id, owner string) error
+	RecordDelivery(delivery *models.WebhookDelivery) error
+	FindDeliveries(webhookID string, limit int) ([]*models.WebhookDelivery, error)
+}
+
+// LayoutStore manages planning groups, planning settings and user preferences
+type LayoutStore interface {
+	WithContext(ctx context.Context) LayoutStore
+	FindGroups() ([]*models.PlanningGroup, error)
+	FindGroupByID(id uint64) (*models.PlanningGroup, error)
+	SaveGroup(group *models.PlanningGroup) error
+	DeleteGroup(id uint64) error
+	FindSettings() (map[string]*models.PlanningSettings, error)
+	SaveSettings(settings *models.PlanningSettings) error
+	FindPreferences(userID string) (map[string]*models.UserPlanningPreference, error)
+	SavePreference(preference *models.UserPlanningPreference) error
+	DeletePreference(userID, planningID string) error
+	Fingerprint(userID string) (Fingerprint, error)
+}
+
+// HealthStore answers the readiness checks
+type HealthStore interface {
+	WithContext(ctx context.Context) HealthStore
+	Ping() error
+	FindSchemaVersion() (int, error)
+	FindPlanningSyncs() ([]models.PlanningSync, error)
+}
+
+// The Postgres repositories implement the stores
+var (
+	_ EventStore    = (*EventRepository)(nil)
+	_ PlanningStore = (*PlanningRepository)(nil)
+	_ ChangeStore   = (*ChangeRepository)(nil)
+	_ WebhookStore  = (*WebhookRepository)(nil)
+	_ LayoutStore   = (*LayoutRepository)(nil)
+	_ HealthStore   = (*HealthRepository)(nil)
+)
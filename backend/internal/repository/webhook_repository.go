@@ -0,0 +1,146 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/do2024-2047/CalenDO/internal/models"
+	"gorm.io/gorm"
+)
+
+// WebhookRepository handles database operations for webhooks and their delivery log
+type WebhookRepository struct {
+	db *gorm.DB
+}
+
+// NewWebhookRepository creates a new webhook repository
+func NewWebhookRepository(db *gorm.DB) *WebhookRepository {
+	return &WebhookRepository{db: db}
+}
+
+// WithContext returns a copy of the repository whose queries carry ctx
+func (r *WebhookRepository) WithContext(ctx context.Context) WebhookStore {
+	return &WebhookRepository{db: r.db.WithContext(ctx)}
+}
+
+// FindAll returns all webhooks
+func (r *WebhookRepository) FindAll() ([]*models.Webhook, error) {
+	var webhooks []*models.Webhook
+
+	result := r.db.Order("created DESC, id ASC").Find(&webhooks)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+
+	return webhooks, nil
+}
+
+// FindActive returns the webhooks that should receive deliveries
+func (r *WebhookRepository) FindActive() ([]*models.Webhook, error) {
+	var webhooks []*models.Webhook
+
+	result := r.db.Where("active = ?", true).Find(&webhooks)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+
+	return webhooks, nil
+}
+
+// FindByID returns a webhook by its ID
+func (r *WebhookRepository) FindByID(id string) (*models.Webhook, error) {
+	if id == "" {
+		return nil, ErrInvalidID
+	}
+
+	var webhook models.Webhook
+	result := r.db.Where("id = ?", id).First(&webhook)
+
+	if result.Error != nil {
+		if errors.Is(result.Error, gorm.ErrRecordNotFound) {
+			return nil, ErrNotFound
+		}
+		return nil, result.Error
+	}
+
+	return &webhook, nil
+}
+
+// Create stores a new webhook
+func (r *WebhookRepository) Create(webhook *models.Webhook) error {
+	return r.db.Create(webhook).Error
+}
+
+// Delete removes a webhook and its delivery log
+func (r *WebhookRepository) Delete(id string) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		result := tx.Where("id = ?", id).Delete(&models.Webhook{})
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return ErrNotFound
+		}
+		return tx.Where("webhook_id = ?", id).Delete(&models.WebhookDelivery{}).Error
+	})
+}
+
+// AdvanceCursor moves the webhook's delivery cursor from one change ID to another.
+// It returns false when another process moved the cursor first.
+func (r *WebhookRepository) AdvanceCursor(id string, from, to uint64) (bool, error) {
+	result := r.db.Model(&models.Webhook{}).
+		Where("id = ? AND last_change_id = ?", id, from).
+		Update("last_change_id", to)
+	if result.Error != nil {
+		return false, result.Error
+	}
+
+	return result.RowsAffected == 1, nil
+}
+
+// AcquireLease makes owner the only process delivering to a webhook for ttl, so that each
+// change is delivered once even with several API replicas running. Lease times are those
+// of the database, which replicas share, rather than of each replica's clock.
+func (r *WebhookRepository) AcquireLease(id, owner string, ttl time.Duration) (bool, error) {
+	result := r.db.Model(&models.Webhook{}).
+		Where("id = ? AND (lease_owner = ? OR lease_until IS NULL OR lease_until < NOW())", id, owner).
+		UpdateColumns(map[string]interface{}{
+			"lease_owner": owner,
+			"lease_until": gorm.Expr("NOW() + make_interval(secs => ?)", ttl.Seconds()),
+		})
+	if result.Error != nil {
+		return false, result.Error
+	}
+
+	return result.RowsAffected == 1, nil
+}
+
+// ReleaseLease ends the lease of owner on a webhook, if it still holds it
+func (r *WebhookRepository) ReleaseLease(id, owner string) error {
+	return r.db.Model(&models.Webhook{}).
+		Where("id = ? AND lease_owner = ?", id, owner).
+		UpdateColumns(map[string]interface{}{"lease_owner": "", "lease_until": nil}).Error
+}
+
+// RecordDelivery stores a delivery attempt in the delivery log
+func (r *WebhookRepository) RecordDelivery(delivery *models.WebhookDelivery) error {
+	return r.db.Create(delivery).Error
+}
+
+// FindDeliveries returns the most recent delivery attempts of a webhook
+func (r *WebhookRepository) FindDeliveries(webhookID string, limit int) ([]*models.WebhookDelivery, error) {
+	var deliveries []*models.WebhookDelivery
+
+	result := r.db.Where("webhook_id = ?", webhookID).Order("id DESC").Limit(limit).Find(&deliveries)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+
+	return deliveries, nil
+}
+
+// InitTable initializes the webhook tables if they don't exist
+func (r *WebhookRepository) InitTable() error {
+	return r.db.AutoMigrate(&models.Webhook{}, &models.WebhookDelivery{})
+}
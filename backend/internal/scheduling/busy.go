@@ -0,0 +1,121 @@
+// Package scheduling contains the time arithmetic shared by the free/busy
+// and scheduling endpoints.
+package scheduling
+
+import (
+	"sort"
+	"time"
+
+	"github.com/do2024-2047/CalenDO/internal/models"
+)
+
+// Interval is a half-open [Start, End) span of time
+type Interval struct {
+	Start time.Time
+	End   time.Time
+}
+
+// Duration returns the length of the interval
+func (i Interval) Duration() time.Duration {
+	return i.End.Sub(i.Start)
+}
+
+// Overlaps reports whether two intervals share any instant
+func (i Interval) Overlaps(other Interval) bool {
+	return i.Start.Before(other.End) && other.Start.Before(i.End)
+}
+
+// Clip restricts the interval to the given window. The second return value is
+// false when nothing of the interval is left inside the window.
+func (i Interval) Clip(window Interval) (Interval, bool) {
+	if i.Start.Before(window.Start) {
+		i.Start = window.Start
+	}
+	if i.End.After(window.End) {
+		i.End = window.End
+	}
+	return i, i.Start.Before(i.End)
+}
+
+// EventInterval returns the span of time an event occupies.
+//
+// All-day events are stored as UTC midnight dates. They are mapped onto whole
+// days in loc so that an all-day event blocks the local day it was meant for,
+// and an all-day event without a usable end covers exactly one day.
+func EventInterval(event *models.Event, loc *time.Location) Interval {
+	if !event.AllDay {
+		return Interval{Start: event.StartTime, End: event.EndTime}
+	}
+
+	startDate := event.StartTime.UTC()
+	endDate := event.EndTime.UTC()
+	days := int(endDate.Sub(startDate).Hours()+23) / 24
+	if days < 1 {
+		days = 1
+	}
+
+	start := time.Date(startDate.Year(), startDate.Month(), startDate.Day(), 0, 0, 0, 0, loc)
+	return Interval{Start: start, End: start.AddDate(0, 0, days)}
+}
+
+// Merge sorts the intervals and merges the ones that overlap or touch
+func Merge(intervals []Interval) []Interval {
+	if len(intervals) == 0 {
+		return []Interval{}
+	}
+
+	sorted := make([]Interval, len(intervals))
+	copy(sorted, intervals)
+	sort.Slice(sorted, func(a, b int) bool {
+		return sorted[a].Start.Before(sorted[b].Start)
+	})
+
+	merged := []Interval{sorted[0]}
+	for _, interval := range sorted[1:] {
+		last := &merged[len(merged)-1]
+		if interval.Start.After(last.End) {
+			merged = append(merged, interval)
+			continue
+		}
+		if interval.End.After(last.End) {
+			last.End = interval.End
+		}
+	}
+
+	return merged
+}
+
+// BusyOptions controls which events count as busy time
+type BusyOptions struct {
+	// IncludeAllDay makes all-day events block their whole day
+	IncludeAllDay bool
+	// Location is used to place all-day events on local days
+	Location *time.Location
+}
+
+// BusyIntervals returns the merged busy intervals of the events within window.
+// Cancelled and transparent events are skipped.
+func BusyIntervals(events []*models.Event, window Interval, opts BusyOptions) []Interval {
+	loc := opts.Location
+	if loc == nil {
+		loc = time.UTC
+	}
+
+	var intervals []Interval
+	for _, event := range events {
+		if !event.BlocksTime() {
+			continue
+		}
+		if event.AllDay && !opts.IncludeAllDay {
+			continue
+		}
+
+		interval, ok := EventInterval(event, loc).Clip(window)
+		if !ok {
+			continue
+		}
+		intervals = append(intervals, interval)
+	}
+
+	return Merge(intervals)
+}
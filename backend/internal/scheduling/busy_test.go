@@ -0,0 +1,254 @@
+package scheduling
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/do2024-2047/CalenDO/internal/models"
+)
+
+// day is the Monday the intervals of the tests are laid out on
+var day = time.Date(2026, time.March, 2, 0, 0, 0, 0, time.UTC)
+
+// at returns the time hours after midnight of day, in UTC
+func at(hours float64) time.Time {
+	return day.Add(time.Duration(hours * float64(time.Hour)))
+}
+
+// span returns the interval between two times of day
+func span(start, end float64) Interval {
+	return Interval{Start: at(start), End: at(end)}
+}
+
+// location loads a timezone, failing the test when it is unknown
+func location(t *testing.T, name string) *time.Location {
+	t.Helper()
+	loc, err := time.LoadLocation(name)
+	if err != nil {
+		t.Fatalf("failed to load %s: %v", name, err)
+	}
+	return loc
+}
+
+// format lists intervals as start-end pairs, in the location of their start
+func format(intervals []Interval) string {
+	var parts []string
+	for _, interval := range intervals {
+		parts = append(parts, fmt.Sprintf("%s-%s", interval.Start.Format(time.RFC3339), interval.End.Format(time.RFC3339)))
+	}
+	return strings.Join(parts, " ")
+}
+
+// allDay returns an all-day event stored like the importer does, as UTC midnight dates
+func allDay(start, end time.Time) *models.Event {
+	return &models.Event{UID: "all-day", AllDay: true, StartTime: start, EndTime: end}
+}
+
+func TestEventInterval(t *testing.T) {
+	t.Parallel()
+	paris := location(t, "Europe/Paris")
+	newYork := location(t, "America/New_York")
+
+	tests := []struct {
+		name      string
+		event     *models.Event
+		loc       *time.Location
+		wantStart string
+		wantEnd   string
+	}{
+		{
+			name:      "timed event",
+			event:     &models.Event{StartTime: at(9), EndTime: at(10.5)},
+			loc:       paris,
+			wantStart: "2026-03-02T09:00:00Z",
+			wantEnd:   "2026-03-02T10:30:00Z",
+		},
+		{
+			name:      "one day",
+			event:     allDay(day, day.AddDate(0, 0, 1)),
+			loc:       paris,
+			wantStart: "2026-03-02T00:00:00+01:00",
+			wantEnd:   "2026-03-03T00:00:00+01:00",
+		},
+		{
+			name:      "one day west of UTC stays on its date",
+			event:     allDay(day, day.AddDate(0, 0, 1)),
+			loc:       newYork,
+			wantStart: "2026-03-02T00:00:00-05:00",
+			wantEnd:   "2026-03-03T00:00:00-05:00",
+		},
+		{
+			name:      "three days",
+			event:     allDay(day, day.AddDate(0, 0, 3)),
+			loc:       paris,
+			wantStart: "2026-03-02T00:00:00+01:00",
+			wantEnd:   "2026-03-05T00:00:00+01:00",
+		},
+		{
+			name:      "end equal to start covers one day",
+			event:     allDay(day, day),
+			loc:       paris,
+			wantStart: "2026-03-02T00:00:00+01:00",
+			wantEnd:   "2026-03-03T00:00:00+01:00",
+		},
+		{
+			name:      "end before start covers one day",
+			event:     allDay(day, day.AddDate(0, 0, -1)),
+			loc:       paris,
+			wantStart: "2026-03-02T00:00:00+01:00",
+			wantEnd:   "2026-03-03T00:00:00+01:00",
+		},
+		{
+			name:      "partial end day rounds up",
+			event:     allDay(day, day.AddDate(0, 0, 1).Add(time.Hour)),
+			loc:       paris,
+			wantStart: "2026-03-02T00:00:00+01:00",
+			wantEnd:   "2026-03-04T00:00:00+01:00",
+		},
+		{
+			name:      "start stored off midnight keeps its UTC date",
+			event:     allDay(day.Add(23*time.Hour), day.AddDate(0, 0, 1).Add(23*time.Hour)),
+			loc:       newYork,
+			wantStart: "2026-03-02T00:00:00-05:00",
+			wantEnd:   "2026-03-03T00:00:00-05:00",
+		},
+		{
+			name:      "day of a daylight saving change",
+			event:     allDay(time.Date(2026, time.March, 29, 0, 0, 0, 0, time.UTC), time.Date(2026, time.March, 30, 0, 0, 0, 0, time.UTC)),
+			loc:       paris,
+			wantStart: "2026-03-29T00:00:00+01:00",
+			wantEnd:   "2026-03-30T00:00:00+02:00",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			interval := EventInterval(tt.event, tt.loc)
+			if got := interval.Start.Format(time.RFC3339); got != tt.wantStart {
+				t.Errorf("start = %s, want %s", got, tt.wantStart)
+			}
+			if got := interval.End.Format(time.RFC3339); got != tt.wantEnd {
+				t.Errorf("end = %s, want %s", got, tt.wantEnd)
+			}
+		})
+	}
+}
+
+func TestMerge(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name      string
+		intervals []Interval
+		want      []Interval
+	}{
+		{"none", nil, []Interval{}},
+		{"one", []Interval{span(9, 10)}, []Interval{span(9, 10)}},
+		{"disjoint", []Interval{span(9, 10), span(11, 12)}, []Interval{span(9, 10), span(11, 12)}},
+		{"unsorted", []Interval{span(11, 12), span(9, 10)}, []Interval{span(9, 10), span(11, 12)}},
+		{"overlapping", []Interval{span(9, 11), span(10, 12)}, []Interval{span(9, 12)}},
+		{"touching", []Interval{span(9, 10), span(10, 11)}, []Interval{span(9, 11)}},
+		{"contained", []Interval{span(9, 12), span(10, 11)}, []Interval{span(9, 12)}},
+		{"same start", []Interval{span(9, 10), span(9, 11)}, []Interval{span(9, 11)}},
+		{"chain", []Interval{span(13, 14), span(9, 10.5), span(10, 13)}, []Interval{span(9, 14)}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := Merge(tt.intervals)
+			if got == nil {
+				t.Fatal("Merge returned nil, want an empty slice")
+			}
+			if format(got) != format(tt.want) {
+				t.Errorf("Merge = %s, want %s", format(got), format(tt.want))
+			}
+		})
+	}
+}
+
+func TestMergeLeavesItsInputUnchanged(t *testing.T) {
+	t.Parallel()
+
+	intervals := []Interval{span(11, 12), span(9, 11.5)}
+	Merge(intervals)
+	if want := []Interval{span(11, 12), span(9, 11.5)}; format(intervals) != format(want) {
+		t.Errorf("input = %s after Merge, want %s", format(intervals), format(want))
+	}
+}
+
+func TestBusyIntervals(t *testing.T) {
+	t.Parallel()
+	paris := location(t, "Europe/Paris")
+	window := span(8, 18)
+
+	meeting := func(start, end float64) *models.Event {
+		return &models.Event{StartTime: at(start), EndTime: at(end)}
+	}
+	with := func(event *models.Event, edit func(*models.Event)) *models.Event {
+		edit(event)
+		return event
+	}
+
+	tests := []struct {
+		name   string
+		events []*models.Event
+		opts   BusyOptions
+		want   []Interval
+	}{
+		{
+			name:   "merges overlapping events",
+			events: []*models.Event{meeting(9, 10), meeting(9.5, 11), meeting(14, 15)},
+			want:   []Interval{span(9, 11), span(14, 15)},
+		},
+		{
+			name:   "clips to the window",
+			events: []*models.Event{meeting(7, 9), meeting(17, 20)},
+			want:   []Interval{span(8, 9), span(17, 18)},
+		},
+		{
+			name:   "drops events outside the window",
+			events: []*models.Event{meeting(6, 8), meeting(18, 19)},
+			want:   []Interval{},
+		},
+		{
+			name: "skips cancelled and transparent events",
+			events: []*models.Event{
+				with(meeting(9, 10), func(e *models.Event) { e.Status = "cancelled" }),
+				with(meeting(11, 12), func(e *models.Event) { e.Transparency = models.EventTransparencyTransp }),
+				with(meeting(13, 14), func(e *models.Event) { e.Transparency = models.EventTransparencyOpaque }),
+			},
+			want: []Interval{span(13, 14)},
+		},
+		{
+			name:   "skips all-day events by default",
+			events: []*models.Event{allDay(day, day.AddDate(0, 0, 1)), meeting(9, 10)},
+			want:   []Interval{span(9, 10)},
+		},
+		{
+			name:   "all-day events block the window in UTC without a location",
+			events: []*models.Event{allDay(day, day.AddDate(0, 0, 1)), meeting(9, 10)},
+			opts:   BusyOptions{IncludeAllDay: true},
+			want:   []Interval{span(8, 18)},
+		},
+		{
+			name:   "all-day events of the previous local day",
+			events: []*models.Event{allDay(day.AddDate(0, 0, -1), day)},
+			opts:   BusyOptions{IncludeAllDay: true, Location: paris},
+			want:   []Interval{},
+		},
+		{
+			name:   "all-day events in a location",
+			events: []*models.Event{allDay(day, day.AddDate(0, 0, 1))},
+			opts:   BusyOptions{IncludeAllDay: true, Location: paris},
+			want:   []Interval{span(8, 18)},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := BusyIntervals(tt.events, window, tt.opts)
+			if format(got) != format(tt.want) {
+				t.Errorf("BusyIntervals = %s, want %s", format(got), format(tt.want))
+			}
+		})
+	}
+}
@@ -0,0 +1,117 @@
+package scheduling
+
+import (
+	"sort"
+	"time"
+
+	"github.com/do2024-2047/CalenDO/internal/models"
+)
+
+// Conflict scopes select which overlapping pairs are reported
+const (
+	ScopeAll   = "all"
+	ScopeSame  = "same"
+	ScopeCross = "cross"
+)
+
+// Overlap is a pair of events that share some time
+type Overlap struct {
+	First  *models.Event
+	Second *models.Event
+	// Interval is the time the two events have in common
+	Interval Interval
+}
+
+// OverlapOptions controls which events and pairs are considered
+type OverlapOptions struct {
+	// Scope is one of ScopeAll, ScopeSame (same planning only) or ScopeCross
+	// (different plannings only). An empty scope means ScopeAll.
+	Scope string
+	// IncludeAllDay makes all-day events conflict with everything on their day
+	IncludeAllDay bool
+	// Location is used to place all-day events on local days
+	Location *time.Location
+}
+
+// ValidScope reports whether scope is a known conflict scope
+func ValidScope(scope string) bool {
+	switch scope {
+	case "", ScopeAll, ScopeSame, ScopeCross:
+		return true
+	}
+	return false
+}
+
+// FindOverlaps returns every pair of events whose times overlap, ordered by the
+// start of the overlap. Cancelled and transparent events never conflict.
+func FindOverlaps(events []*models.Event, opts OverlapOptions) []Overlap {
+	loc := opts.Location
+	if loc == nil {
+		loc = time.UTC
+	}
+
+	type entry struct {
+		event    *models.Event
+		interval Interval
+	}
+
+	var entries []entry
+	seen := make(map[string]bool)
+	for _, event := range events {
+		if seen[event.ID] || !event.BlocksTime() || (event.AllDay && !opts.IncludeAllDay) {
+			continue
+		}
+		seen[event.ID] = true
+
+		interval := EventInterval(event, loc)
+		if !interval.Start.Before(interval.End) {
+			continue
+		}
+		entries = append(entries, entry{event: event, interval: interval})
+	}
+
+	sort.Slice(entries, func(a, b int) bool {
+		return entries[a].interval.Start.Before(entries[b].interval.Start)
+	})
+
+	overlaps := []Overlap{}
+	var active []entry
+	for _, current := range entries {
+		// Drop the events that ended before this one starts
+		kept := active[:0]
+		for _, other := range active {
+			if other.interval.End.After(current.interval.Start) {
+				kept = append(kept, other)
+			}
+		}
+		active = kept
+
+		for _, other := range active {
+			samePlanning := other.event.PlanningID == current.event.PlanningID
+			if (opts.Scope == ScopeSame && !samePlanning) || (opts.Scope == ScopeCross && samePlanning) {
+				continue
+			}
+
+			common, _ := current.interval.Clip(other.interval)
+			overlaps = append(overlaps, Overlap{First: other.event, Second: current.event, Interval: common})
+		}
+
+		active = append(active, current)
+	}
+
+	sort.SliceStable(overlaps, func(a, b int) bool {
+		return overlaps[a].Interval.Start.Before(overlaps[b].Interval.Start)
+	})
+
+	return overlaps
+}
+
+// ConflictIndex maps each event ID to the IDs of the events it overlaps with
+func ConflictIndex(overlaps []Overlap) map[string][]string {
+	index := make(map[string][]string)
+	for _, overlap := range overlaps {
+		index[overlap.First.ID] = append(index[overlap.First.ID], overlap.Second.ID)
+		index[overlap.Second.ID] = append(index[overlap.Second.ID], overlap.First.ID)
+	}
+	return index
+}
@@ -0,0 +1,154 @@
+package scheduling
+
+import (
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/do2024-2047/CalenDO/internal/models"
+)
+
+// timed returns an event of a planning between two times of day
+func timed(id, planningID string, start, end float64) *models.Event {
+	return &models.Event{ID: id, PlanningID: planningID, StartTime: at(start), EndTime: at(end)}
+}
+
+// pair describes an overlap as its two event IDs, in alphabetical order, and its common interval
+func pair(overlap Overlap) string {
+	ids := []string{overlap.First.ID, overlap.Second.ID}
+	sort.Strings(ids)
+	return ids[0] + "+" + ids[1] + "@" + overlap.Interval.Start.Format("15:04") + "-" + overlap.Interval.End.Format("15:04")
+}
+
+func TestFindOverlaps(t *testing.T) {
+	t.Parallel()
+	paris := location(t, "Europe/Paris")
+
+	cancelled := timed("c", "work", 9, 11)
+	cancelled.Status = models.EventStatusCancelled
+	transparent := timed("t", "work", 9, 11)
+	transparent.Transparency = models.EventTransparencyTransp
+	holiday := allDay(day, day.AddDate(0, 0, 1))
+	holiday.ID, holiday.PlanningID = "h", "home"
+
+	tests := []struct {
+		name   string
+		events []*models.Event
+		opts   OverlapOptions
+		want   []string
+	}{
+		{
+			name:   "partial overlap",
+			events: []*models.Event{timed("a", "work", 9, 10), timed("b", "work", 9.5, 11)},
+			want:   []string{"a+b@09:30-10:00"},
+		},
+		{
+			name:   "touching events do not overlap",
+			events: []*models.Event{timed("a", "work", 9, 10), timed("b", "work", 10, 11)},
+			want:   nil,
+		},
+		{
+			name:   "contained event",
+			events: []*models.Event{timed("a", "work", 9, 12), timed("b", "work", 10, 11)},
+			want:   []string{"a+b@10:00-11:00"},
+		},
+		{
+			name:   "identical times",
+			events: []*models.Event{timed("a", "work", 9, 10), timed("b", "work", 9, 10), timed("c", "home", 9, 10)},
+			want:   []string{"a+b@09:00-10:00", "a+c@09:00-10:00", "b+c@09:00-10:00"},
+		},
+		{
+			name:   "unsorted input",
+			events: []*models.Event{timed("b", "work", 10, 11), timed("a", "work", 9, 10.5)},
+			want:   []string{"a+b@10:00-10:30"},
+		},
+		{
+			name:   "long event stays active after shorter ones end",
+			events: []*models.Event{timed("a", "work", 9, 17), timed("b", "work", 9, 10), timed("c", "work", 10, 11), timed("d", "work", 16.5, 18)},
+			want:   []string{"a+b@09:00-10:00", "a+c@10:00-11:00", "a+d@16:30-17:00"},
+		},
+		{
+			name:   "chain of overlaps",
+			events: []*models.Event{timed("a", "work", 9, 12), timed("b", "work", 10, 11), timed("c", "work", 11.5, 13)},
+			want:   []string{"a+b@10:00-11:00", "a+c@11:30-12:00"},
+		},
+		{
+			name:   "zero-length event inside another",
+			events: []*models.Event{timed("a", "work", 9, 12), timed("b", "work", 10, 10)},
+			want:   nil,
+		},
+		{
+			name:   "event ending before it starts",
+			events: []*models.Event{timed("a", "work", 9, 12), timed("b", "work", 11, 10)},
+			want:   nil,
+		},
+		{
+			name:   "event listed twice",
+			events: []*models.Event{timed("a", "work", 9, 10), timed("a", "work", 9, 10), timed("b", "home", 9.5, 10.5)},
+			want:   []string{"a+b@09:30-10:00"},
+		},
+		{
+			name:   "cancelled and transparent events",
+			events: []*models.Event{timed("a", "work", 9, 10), cancelled, transparent},
+			want:   nil,
+		},
+		{
+			name:   "same planning only",
+			events: []*models.Event{timed("a", "work", 9, 11), timed("b", "work", 10, 12), timed("c", "home", 10, 12)},
+			opts:   OverlapOptions{Scope: ScopeSame},
+			want:   []string{"a+b@10:00-11:00"},
+		},
+		{
+			name:   "different plannings only",
+			events: []*models.Event{timed("a", "work", 9, 11), timed("b", "work", 10, 12), timed("c", "home", 10, 12)},
+			opts:   OverlapOptions{Scope: ScopeCross},
+			want:   []string{"a+c@10:00-11:00", "b+c@10:00-12:00"},
+		},
+		{
+			name:   "all-day events skipped by default",
+			events: []*models.Event{holiday, timed("a", "work", 9, 10)},
+			want:   nil,
+		},
+		{
+			name:   "all-day events on their local day",
+			events: []*models.Event{holiday, timed("a", "work", 9, 10), timed("b", "work", 23.5, 24)},
+			opts:   OverlapOptions{IncludeAllDay: true, Location: paris},
+			want:   []string{"a+h@09:00-10:00"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got []string
+			for _, overlap := range FindOverlaps(tt.events, tt.opts) {
+				got = append(got, pair(overlap))
+			}
+			sort.Strings(got)
+			if strings.Join(got, " ") != strings.Join(tt.want, " ") {
+				t.Errorf("overlaps = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFindOverlapsOrderedByOverlapStart(t *testing.T) {
+	t.Parallel()
+
+	overlaps := FindOverlaps([]*models.Event{
+		timed("a", "work", 9, 17),
+		timed("b", "work", 15, 16),
+		timed("c", "work", 8, 10),
+	}, OverlapOptions{})
+
+	var got []string
+	for _, overlap := range overlaps {
+		got = append(got, pair(overlap))
+	}
+	if want := "a+c@09:00-10:00 a+b@15:00-16:00"; strings.Join(got, " ") != want {
+		t.Errorf("overlaps = %v, want %s", got, want)
+	}
+
+	index := ConflictIndex(overlaps)
+	if strings.Join(index["a"], ",") != "c,b" || strings.Join(index["b"], ",") != "a" || strings.Join(index["c"], ",") != "a" {
+		t.Errorf("conflict index = %v, want a: c,b, b: a and c: a", index)
+	}
+}
@@ -0,0 +1,230 @@
+package scheduling
+
+import (
+	"container/heap"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+)
+
+// WorkingHours describes the part of each day during which meetings may be placed
+type WorkingHours struct {
+	// DayStart and DayEnd are offsets from local midnight
+	DayStart time.Duration
+	DayEnd   time.Duration
+	Days     map[time.Weekday]bool
+	Location *time.Location
+}
+
+// SlotQuery describes a search for free meeting slots
+type SlotQuery struct {
+	Window       Interval
+	Duration     time.Duration
+	Buffer       time.Duration
+	Step         time.Duration
+	WorkingHours WorkingHours
+	MaxResults   int
+}
+
+// Slot is a candidate meeting time with its ranking score
+type Slot struct {
+	Interval
+	Score float64
+}
+
+var weekdayNames = map[string]time.Weekday{
+	"sun": time.Sunday,
+	"mon": time.Monday,
+	"tue": time.Tuesday,
+	"wed": time.Wednesday,
+	"thu": time.Thursday,
+	"fri": time.Friday,
+	"sat": time.Saturday,
+}
+
+// ParseClock parses an HH:MM wall-clock time into an offset from midnight.
+// "24:00" is accepted as the end of the day.
+func ParseClock(value string) (time.Duration, error) {
+	var hours, minutes int
+	if _, err := fmt.Sscanf(value, "%d:%d", &hours, &minutes); err != nil {
+		return 0, fmt.Errorf("invalid time of day %q, expected HH:MM", value)
+	}
+	if hours < 0 || minutes < 0 || minutes > 59 || hours > 24 || (hours == 24 && minutes != 0) {
+		return 0, fmt.Errorf("invalid time of day %q, expected HH:MM", value)
+	}
+	return time.Duration(hours)*time.Hour + time.Duration(minutes)*time.Minute, nil
+}
+
+// ParseWeekdays parses three-letter day names (mon, tue, ...).
+// An empty list means Monday to Friday.
+func ParseWeekdays(values []string) (map[time.Weekday]bool, error) {
+	days := make(map[time.Weekday]bool)
+	if len(values) == 0 {
+		for day := time.Monday; day <= time.Friday; day++ {
+			days[day] = true
+		}
+		return days, nil
+	}
+
+	for _, value := range values {
+		key := strings.ToLower(strings.TrimSpace(value))
+		if len(key) > 3 {
+			key = key[:3]
+		}
+		day, ok := weekdayNames[key]
+		if !ok {
+			return nil, fmt.Errorf("invalid weekday %q", value)
+		}
+		days[day] = true
+	}
+	return days, nil
+}
+
+// workingIntervals returns the working-hour spans that fall inside the window
+func workingIntervals(window Interval, hours WorkingHours) []Interval {
+	loc := hours.Location
+	if loc == nil {
+		loc = time.UTC
+	}
+
+	var intervals []Interval
+	first := window.Start.In(loc)
+	day := time.Date(first.Year(), first.Month(), first.Day(), 0, 0, 0, 0, loc)
+	for ; day.Before(window.End); day = day.AddDate(0, 0, 1) {
+		if !hours.Days[day.Weekday()] {
+			continue
+		}
+
+		// Build the boundaries from wall-clock components so DST days keep
+		// their nominal working hours
+		start := time.Date(day.Year(), day.Month(), day.Day(),
+			int(hours.DayStart/time.Hour), int(hours.DayStart%time.Hour/time.Minute), 0, 0, loc)
+		end := time.Date(day.Year(), day.Month(), day.Day(),
+			int(hours.DayEnd/time.Hour), int(hours.DayEnd%time.Hour/time.Minute), 0, 0, loc)
+
+		if interval, ok := (Interval{Start: start, End: end}).Clip(window); ok {
+			intervals = append(intervals, interval)
+		}
+	}
+
+	return intervals
+}
+
+// subtract removes the busy intervals (sorted and merged) from the free interval
+func subtract(free Interval, busy []Interval) []Interval {
+	var gaps []Interval
+	cursor := free.Start
+	for _, b := range busy {
+		if !b.End.After(cursor) {
+			continue
+		}
+		if !b.Start.Before(free.End) {
+			break
+		}
+		if b.Start.After(cursor) {
+			gaps = append(gaps, Interval{Start: cursor, End: b.Start})
+		}
+		cursor = b.End
+	}
+	if cursor.Before(free.End) {
+		gaps = append(gaps, Interval{Start: cursor, End: free.End})
+	}
+	return gaps
+}
+
+// alignUp rounds t up to the next multiple of step counted from local midnight
+func alignUp(t time.Time, step time.Duration) time.Time {
+	midnight := time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, t.Location())
+	offset := t.Sub(midnight)
+	if rem := offset % step; rem != 0 {
+		return t.Add(step - rem)
+	}
+	return t
+}
+
+// better reports whether slot a ranks before slot b: higher score first, then earlier start
+func better(a, b Slot) bool {
+	if a.Score != b.Score {
+		return a.Score > b.Score
+	}
+	return a.Start.Before(b.Start)
+}
+
+// worstFirst is a heap of slots with the worst-ranked slot at its root, holding the best
+// slots found so far
+type worstFirst []Slot
+
+func (h worstFirst) Len() int           { return len(h) }
+func (h worstFirst) Less(a, b int) bool { return better(h[b], h[a]) }
+func (h worstFirst) Swap(a, b int)      { h[a], h[b] = h[b], h[a] }
+func (h *worstFirst) Push(x any)        { *h = append(*h, x.(Slot)) }
+func (h *worstFirst) Pop() any {
+	old := *h
+	slot := old[len(old)-1]
+	*h = old[:len(old)-1]
+	return slot
+}
+
+// FindSlots returns the free slots of the requested duration, best first.
+//
+// Busy intervals are widened by the buffer before free time is computed.
+// Slots are ranked so that earlier slots come first, and slots that would leave
+// a gap too short for another meeting of the same length are pushed down.
+// With MaxResults set, only the best slots are kept while the window is scanned,
+// so a wide window with a small step does not hold every candidate in memory.
+func FindSlots(busy []Interval, query SlotQuery) []Slot {
+	if query.Duration <= 0 {
+		return []Slot{}
+	}
+	step := query.Step
+	if step <= 0 {
+		step = 15 * time.Minute
+	}
+
+	padded := make([]Interval, 0, len(busy))
+	for _, b := range busy {
+		padded = append(padded, Interval{Start: b.Start.Add(-query.Buffer), End: b.End.Add(query.Buffer)})
+	}
+	padded = Merge(padded)
+
+	loc := query.WorkingHours.Location
+	if loc == nil {
+		loc = time.UTC
+	}
+	windowLength := query.Window.Duration().Seconds()
+
+	slots := worstFirst{}
+	for _, working := range workingIntervals(query.Window, query.WorkingHours) {
+		for _, gap := range subtract(working, padded) {
+			for start := alignUp(gap.Start.In(loc), step); !start.Add(query.Duration).After(gap.End); start = start.Add(step) {
+				end := start.Add(query.Duration)
+
+				score := 1.0
+				if before := start.Sub(gap.Start); before > 0 && before < query.Duration {
+					score -= 0.25
+				}
+				if after := gap.End.Sub(end); after > 0 && after < query.Duration {
+					score -= 0.25
+				}
+				if windowLength > 0 {
+					score -= 0.5 * start.Sub(query.Window.Start).Seconds() / windowLength
+				}
+
+				slot := Slot{Interval: Interval{Start: start, End: end}, Score: score}
+				switch {
+				case query.MaxResults <= 0:
+					slots = append(slots, slot)
+				case len(slots) < query.MaxResults:
+					heap.Push(&slots, slot)
+				case better(slot, slots[0]):
+					slots[0] = slot
+					heap.Fix(&slots, 0)
+				}
+			}
+		}
+	}
+
+	sort.Slice(slots, func(a, b int) bool { return better(slots[a], slots[b]) })
+	return slots
+}
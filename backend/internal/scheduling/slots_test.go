@@ -0,0 +1,174 @@
+package scheduling
+
+import (
+	"sort"
+	"strings"
+	"testing"
+	"time"
+)
+
+// starts lists the starts of slots in chronological order, as HH:MM in the location of each slot
+func starts(slots []Slot) string {
+	var times []time.Time
+	for _, slot := range slots {
+		times = append(times, slot.Start)
+	}
+	sort.Slice(times, func(a, b int) bool { return times[a].Before(times[b]) })
+
+	var parts []string
+	for _, t := range times {
+		parts = append(parts, t.Format("15:04"))
+	}
+	return strings.Join(parts, " ")
+}
+
+// workingDay returns working hours from start to end, every day of the week
+func workingDay(start, end float64, loc *time.Location) WorkingHours {
+	days := make(map[time.Weekday]bool)
+	for weekday := time.Sunday; weekday <= time.Saturday; weekday++ {
+		days[weekday] = true
+	}
+	return WorkingHours{
+		DayStart: time.Duration(start * float64(time.Hour)),
+		DayEnd:   time.Duration(end * float64(time.Hour)),
+		Days:     days,
+		Location: loc,
+	}
+}
+
+func TestFindSlotsStepAndBuffer(t *testing.T) {
+	t.Parallel()
+	kolkata := location(t, "Asia/Kolkata")
+
+	tests := []struct {
+		name  string
+		busy  []Interval
+		query SlotQuery
+		want  string
+	}{
+		{
+			name:  "default step of 15 minutes",
+			query: SlotQuery{Window: span(0, 24), Duration: time.Hour, WorkingHours: workingDay(9, 10.5, nil)},
+			want:  "09:00 09:15 09:30",
+		},
+		{
+			name:  "negative step falls back to 15 minutes",
+			query: SlotQuery{Window: span(0, 24), Duration: time.Hour, Step: -time.Minute, WorkingHours: workingDay(9, 10.5, nil)},
+			want:  "09:00 09:15 09:30",
+		},
+		{
+			name:  "custom step",
+			query: SlotQuery{Window: span(0, 24), Duration: time.Hour, Step: 30 * time.Minute, WorkingHours: workingDay(9, 11, nil)},
+			want:  "09:00 09:30 10:00",
+		},
+		{
+			name:  "slot ending at the end of working hours",
+			query: SlotQuery{Window: span(0, 24), Duration: time.Hour, Step: time.Hour, WorkingHours: workingDay(9, 10, nil)},
+			want:  "09:00",
+		},
+		{
+			name:  "free time shorter than the duration",
+			query: SlotQuery{Window: span(0, 24), Duration: time.Hour, WorkingHours: workingDay(9, 9.75, nil)},
+			want:  "",
+		},
+		{
+			name:  "starts aligned on the step after a busy interval",
+			busy:  []Interval{span(9, 9+10.0/60)},
+			query: SlotQuery{Window: span(0, 24), Duration: 30 * time.Minute, Step: 30 * time.Minute, WorkingHours: workingDay(9, 11, nil)},
+			want:  "09:30 10:00 10:30",
+		},
+		{
+			name:  "starts aligned on the step after the window start",
+			query: SlotQuery{Window: span(9+20.0/60, 24), Duration: 30 * time.Minute, Step: 30 * time.Minute, WorkingHours: workingDay(9, 11, nil)},
+			want:  "09:30 10:00 10:30",
+		},
+		{
+			name: "steps counted from local midnight",
+			// 03:45 UTC is 09:15 in Kolkata, five and a half hours ahead
+			query: SlotQuery{Window: span(3.75, 24), Duration: 30 * time.Minute, Step: time.Hour, WorkingHours: workingDay(9, 11, kolkata)},
+			want:  "10:00",
+		},
+		{
+			name:  "busy interval without buffer",
+			busy:  []Interval{span(10, 11)},
+			query: SlotQuery{Window: span(0, 24), Duration: time.Hour, Step: time.Hour, WorkingHours: workingDay(9, 12, nil)},
+			want:  "09:00 11:00",
+		},
+		{
+			name:  "buffer on both sides of a busy interval",
+			busy:  []Interval{span(10, 11)},
+			query: SlotQuery{Window: span(0, 24), Duration: 30 * time.Minute, Buffer: 15 * time.Minute, WorkingHours: workingDay(9, 12, nil)},
+			want:  "09:00 09:15 11:15 11:30",
+		},
+		{
+			name:  "buffers joining two busy intervals",
+			busy:  []Interval{span(10, 10.5), span(11, 11.5)},
+			query: SlotQuery{Window: span(0, 24), Duration: 30 * time.Minute, Buffer: 15 * time.Minute, WorkingHours: workingDay(9, 12, nil)},
+			want:  "09:00 09:15",
+		},
+		{
+			name:  "buffer of a busy interval outside working hours",
+			busy:  []Interval{span(8, 9)},
+			query: SlotQuery{Window: span(0, 24), Duration: 30 * time.Minute, Buffer: 15 * time.Minute, Step: 15 * time.Minute, WorkingHours: workingDay(9, 10, nil)},
+			want:  "09:15 09:30",
+		},
+		{
+			name:  "no buffer at the edges of working hours",
+			query: SlotQuery{Window: span(0, 24), Duration: time.Hour, Buffer: 30 * time.Minute, Step: time.Hour, WorkingHours: workingDay(9, 11, nil)},
+			want:  "09:00 10:00",
+		},
+		{
+			name:  "zero duration",
+			query: SlotQuery{Window: span(0, 24), WorkingHours: workingDay(9, 11, nil)},
+			want:  "",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := starts(FindSlots(tt.busy, tt.query)); got != tt.want {
+				t.Errorf("slot starts = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFindSlotsRanking(t *testing.T) {
+	t.Parallel()
+
+	// The 09:30 slot would leave half an hour on each side, too short for another meeting
+	busy := []Interval{span(11, 12)}
+	query := SlotQuery{Window: span(0, 24), Duration: time.Hour, Step: 30 * time.Minute, WorkingHours: workingDay(9, 12, nil), MaxResults: 2}
+
+	slots := FindSlots(busy, query)
+	if len(slots) != 2 {
+		t.Fatalf("got %d slots, want MaxResults = 2", len(slots))
+	}
+	if got := slots[0].Start.Format("15:04") + " " + slots[1].Start.Format("15:04"); got != "09:00 10:00" {
+		t.Errorf("best slots = %s, want 09:00 10:00", got)
+	}
+	if slots[0].Score <= slots[1].Score {
+		t.Errorf("scores = %v, %v, want the earlier slot first", slots[0].Score, slots[1].Score)
+	}
+}
+
+func TestFindSlotsWideWindow(t *testing.T) {
+	t.Parallel()
+
+	// A year with a one-minute step and working hours around the clock is about 527,000
+	// candidate slots; only the best MaxResults are kept while they are scanned
+	query := SlotQuery{Window: span(0, 366*24), Duration: time.Hour, Step: time.Minute, WorkingHours: workingDay(0, 24, nil), MaxResults: 100}
+	busy := []Interval{span(2, 3), span(30, 31)}
+
+	slots := FindSlots(busy, query)
+	if len(slots) != query.MaxResults {
+		t.Fatalf("got %d slots, want MaxResults = %d", len(slots), query.MaxResults)
+	}
+
+	query.MaxResults = 0
+	all := FindSlots(busy, query)
+	for i, slot := range slots {
+		if !slot.Start.Equal(all[i].Start) || slot.Score != all[i].Score {
+			t.Fatalf("slot %d = %v, want %v as in the ranking of every candidate", i, slot, all[i])
+		}
+	}
+}
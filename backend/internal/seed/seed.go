@@ -0,0 +1,114 @@
+// Package seed holds the sample plannings and events loaded by cmd/seed. The API tests
+// use them as fixtures.
+package seed
+
+import (
+	"time"
+
+	"github.com/do2024-2047/CalenDO/internal/models"
+)
+
+// Plannings returns the sample plannings, created one second apart from now
+func Plannings(now time.Time) []*models.Planning {
+	plannings := []*models.Planning{
+		{
+			ID:          "default-planning",
+			Name:        "My Calendar",
+			Description: "Default calendar planning",
+			Color:       "#3B82F6",
+			IsDefault:   true,
+		},
+		{
+			ID:          "work-planning",
+			Name:        "Work Schedule",
+			Description: "Work-related events and meetings",
+			Color:       "#EF4444",
+			IsDefault:   false,
+		},
+		{
+			ID:          "personal-planning",
+			Name:        "Personal",
+			Description: "Personal events and activities",
+			Color:       "#10B981",
+			IsDefault:   false,
+		},
+	}
+
+	for i, planning := range plannings {
+		planning.Created = now.Add(time.Duration(i) * time.Second)
+		planning.Updated = planning.Created
+	}
+	return plannings
+}
+
+// Events returns the sample events of the plannings, in the days following now
+func Events(now time.Time) []*models.Event {
+	events := []*models.Event{
+		// Default planning events
+		{
+			UID:         "sample-event-1",
+			PlanningID:  "default-planning",
+			Summary:     "Team Meeting",
+			Description: "Weekly team sync meeting",
+			Location:    "Conference Room A",
+			StartTime:   now.Add(24 * time.Hour),
+			EndTime:     now.Add(24*time.Hour + time.Hour),
+		},
+		{
+			UID:         "sample-event-2",
+			PlanningID:  "default-planning",
+			Summary:     "Doctor Appointment",
+			Description: "Annual checkup",
+			Location:    "Medical Center",
+			StartTime:   now.Add(48 * time.Hour),
+			EndTime:     now.Add(48*time.Hour + 30*time.Minute),
+		},
+
+		// Work planning events
+		{
+			UID:         "work-event-1",
+			PlanningID:  "work-planning",
+			Summary:     "Project Review",
+			Description: "Quarterly project review meeting",
+			Location:    "Boardroom",
+			StartTime:   now.Add(72 * time.Hour),
+			EndTime:     now.Add(72*time.Hour + 2*time.Hour),
+		},
+		{
+			UID:         "work-event-2",
+			PlanningID:  "work-planning",
+			Summary:     "Client Presentation",
+			Description: "Presenting project results to client",
+			Location:    "Client Office",
+			StartTime:   now.Add(96 * time.Hour),
+			EndTime:     now.Add(96*time.Hour + 90*time.Minute),
+		},
+
+		// Personal planning events
+		{
+			UID:         "personal-event-1",
+			PlanningID:  "personal-planning",
+			Summary:     "Gym Session",
+			Description: "Weekly workout routine",
+			Location:    "Local Gym",
+			StartTime:   now.Add(12 * time.Hour),
+			EndTime:     now.Add(12*time.Hour + 90*time.Minute),
+		},
+		{
+			UID:         "personal-event-2",
+			PlanningID:  "personal-planning",
+			Summary:     "Dinner with Friends",
+			Description: "Monthly dinner gathering",
+			Location:    "Italian Restaurant",
+			StartTime:   now.Add(120 * time.Hour),
+			EndTime:     now.Add(120*time.Hour + 3*time.Hour),
+		},
+	}
+
+	for _, event := range events {
+		event.ID = models.GenerateEventID(event.UID, event.PlanningID)
+		event.Created = now
+		event.LastModified = now
+	}
+	return events
+}
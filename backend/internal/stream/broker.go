@@ -0,0 +1,215 @@
+// Package stream fans out change log entries written by the importer to
+// long-lived API clients.
+package stream
+
+import (
+	"context"
+	"log/slog"
+	"sync"
+	"time"
+
+	"github.com/do2024-2047/CalenDO/internal/models"
+	"github.com/do2024-2047/CalenDO/internal/repository"
+)
+
+const (
+	// NotifyChannel is the Postgres channel on which the importer announces new changes
+	NotifyChannel = "calendo_changes"
+	// subscriptionBuffer is how many changes a subscriber may lag behind before it is dropped
+	subscriptionBuffer = 256
+	// batchSize is the number of changes read from the log at once
+	batchSize = 500
+)
+
+// Subscription receives the changes of a set of plannings
+type Subscription struct {
+	changes     chan *models.Change
+	planningIDs map[string]bool
+}
+
+// Changes returns the channel on which changes are delivered.
+// It is closed when the subscriber falls too far behind or the broker stops.
+func (s *Subscription) Changes() <-chan *models.Change {
+	return s.changes
+}
+
+// matches reports whether the change concerns one of the subscribed plannings
+func (s *Subscription) matches(change *models.Change) bool {
+	return len(s.planningIDs) == 0 || s.planningIDs[change.PlanningID]
+}
+
+// Broker reads new entries of the change log and delivers them to subscribers.
+// It wakes up on Postgres notifications from the importer and also polls at a
+// fixed interval, so a missed notification only delays delivery.
+type Broker struct {
+	changes      repository.ChangeStore
+	pollInterval time.Duration
+
+	mu          sync.Mutex
+	subscribers map[*Subscription]struct{}
+	// lastID is the newest change delivered, once positioned is set
+	lastID     uint64
+	positioned bool
+	stopped    bool
+}
+
+// NewBroker creates a new broker reading from the given change store
+func NewBroker(changes repository.ChangeStore, pollInterval time.Duration) *Broker {
+	return &Broker{
+		changes:      changes,
+		pollInterval: pollInterval,
+		subscribers:  make(map[*Subscription]struct{}),
+	}
+}
+
+// Subscribe registers a subscriber for the given plannings (all plannings when empty)
+func (b *Broker) Subscribe(planningIDs []string) *Subscription {
+	sub := &Subscription{
+		changes:     make(chan *models.Change, subscriptionBuffer),
+		planningIDs: make(map[string]bool),
+	}
+	for _, id := range planningIDs {
+		sub.planningIDs[id] = true
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.stopped {
+		close(sub.changes)
+		return sub
+	}
+	b.subscribers[sub] = struct{}{}
+	return sub
+}
+
+// Unsubscribe removes a subscriber and closes its channel
+func (b *Broker) Unsubscribe(sub *Subscription) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.remove(sub)
+}
+
+// remove drops a subscriber; the caller must hold b.mu
+func (b *Broker) remove(sub *Subscription) {
+	if _, ok := b.subscribers[sub]; ok {
+		delete(b.subscribers, sub)
+		close(sub.changes)
+	}
+}
+
+// Run delivers changes until the context is cancelled, then closes every subscription
+func (b *Broker) Run(ctx context.Context) {
+	b.position()
+
+	notify := make(chan struct{}, 1)
+	go b.listen(ctx, notify)
+
+	ticker := time.NewTicker(b.pollInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			b.stop()
+			return
+		case <-notify:
+		case <-ticker.C:
+		}
+		b.dispatch()
+	}
+}
+
+// listen waits for notifications from the importer, reconnecting on failure
+func (b *Broker) listen(ctx context.Context, notify chan<- struct{}) {
+	for ctx.Err() == nil {
+		conn, err := b.changes.Listen(ctx, NotifyChannel)
+		if err != nil {
+			slog.Warn("Change listener unavailable, relying on polling", "error", err)
+			select {
+			case <-ctx.Done():
+				return
+			case <-time.After(b.pollInterval):
+			}
+			continue
+		}
+
+		for {
+			if _, err := conn.WaitForNotification(ctx); err != nil {
+				if ctx.Err() == nil {
+					slog.Warn("Change listener disconnected", "error", err)
+				}
+				break
+			}
+			select {
+			case notify <- struct{}{}:
+			default:
+			}
+		}
+		conn.Close(context.Background())
+	}
+}
+
+// position starts delivery after the newest change of the log. Until the log can be read,
+// the broker stays unpositioned and retries on every wake-up: starting from 0 would replay
+// the whole log to every subscriber.
+func (b *Broker) position() {
+	latest, err := b.changes.LatestID()
+	if err != nil {
+		slog.Warn("Failed to read latest change ID, retrying", "error", err)
+		return
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.lastID = latest
+	b.positioned = true
+}
+
+// dispatch reads the changes recorded since the last dispatch and delivers them
+func (b *Broker) dispatch() {
+	if !b.positioned {
+		b.position()
+		return
+	}
+	for {
+		changes, err := b.changes.FindSince(b.lastID, nil, batchSize)
+		if err != nil {
+			slog.Warn("Failed to read change log", "error", err)
+			return
+		}
+		if len(changes) == 0 {
+			return
+		}
+
+		b.mu.Lock()
+		for _, change := range changes {
+			for sub := range b.subscribers {
+				if !sub.matches(change) {
+					continue
+				}
+				select {
+				case sub.changes <- change:
+				default:
+					// The subscriber is too slow; dropping it lets the client
+					// reconnect and catch up from its last event ID
+					b.remove(sub)
+				}
+			}
+		}
+		b.mu.Unlock()
+
+		b.lastID = changes[len(changes)-1].ID
+		if len(changes) < batchSize {
+			return
+		}
+	}
+}
+
+// stop closes every subscription and refuses new ones
+func (b *Broker) stop() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.stopped = true
+	for sub := range b.subscribers {
+		b.remove(sub)
+	}
+}
@@ -0,0 +1,179 @@
+package stream
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/do2024-2047/CalenDO/internal/models"
+	"github.com/do2024-2047/CalenDO/internal/repository"
+	"github.com/do2024-2047/CalenDO/internal/repository/memory"
+)
+
+// pollInterval is the poll interval of the brokers of the tests
+const pollInterval = 5 * time.Millisecond
+
+// startBroker runs a broker on changes until the end of the test
+func startBroker(t *testing.T, changes repository.ChangeStore) *Broker {
+	t.Helper()
+	broker := NewBroker(changes, pollInterval)
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		broker.Run(ctx)
+		close(done)
+	}()
+	t.Cleanup(func() {
+		cancel()
+		<-done
+	})
+	return broker
+}
+
+// receive returns the next change of a subscription, failing the test when none comes
+func receive(t *testing.T, sub *Subscription) *models.Change {
+	t.Helper()
+	select {
+	case change, ok := <-sub.Changes():
+		if !ok {
+			t.Fatal("subscription closed, want a change")
+		}
+		return change
+	case <-time.After(time.Second):
+		t.Fatal("no change delivered within a second")
+	}
+	return nil
+}
+
+// positioned waits until the broker has read the latest change ID
+func positioned(t *testing.T, broker *Broker) {
+	t.Helper()
+	deadline := time.Now().Add(time.Second)
+	for {
+		broker.mu.Lock()
+		ok := broker.positioned
+		broker.mu.Unlock()
+		if ok {
+			return
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("broker not positioned within a second")
+		}
+		time.Sleep(pollInterval)
+	}
+}
+
+// failingLatest fails LatestID while failing is set
+type failingLatest struct {
+	repository.ChangeStore
+	failing atomic.Bool
+	calls   atomic.Int32
+}
+
+func (f *failingLatest) LatestID() (uint64, error) {
+	f.calls.Add(1)
+	if f.failing.Load() {
+		return 0, errors.New("connection refused")
+	}
+	return f.ChangeStore.LatestID()
+}
+
+func TestBrokerFansOutChangesByPlanning(t *testing.T) {
+	t.Parallel()
+	store := memory.New()
+	broker := startBroker(t, store.Changes())
+	positioned(t, broker)
+
+	all := broker.Subscribe(nil)
+	work := broker.Subscribe([]string{"work"})
+	store.AddChange(models.Change{Type: models.ChangeEventCreated, PlanningID: "home", EventID: "dentist_home"})
+	store.AddChange(models.Change{Type: models.ChangeEventCreated, PlanningID: "work", EventID: "standup_work"})
+
+	if first, second := receive(t, all), receive(t, all); first.EventID != "dentist_home" || second.EventID != "standup_work" {
+		t.Fatalf("all plannings received %s then %s, want dentist_home then standup_work", first.EventID, second.EventID)
+	}
+	if change := receive(t, work); change.EventID != "standup_work" {
+		t.Fatalf("work received %s, want standup_work", change.EventID)
+	}
+	select {
+	case change := <-work.Changes():
+		t.Fatalf("work received %v, want nothing more", change)
+	case <-time.After(10 * pollInterval):
+	}
+}
+
+func TestBrokerDropsSlowSubscribers(t *testing.T) {
+	t.Parallel()
+	store := memory.New()
+	broker := startBroker(t, store.Changes())
+	positioned(t, broker)
+
+	slow := broker.Subscribe(nil)
+	fast := broker.Subscribe(nil)
+	received := make(chan int)
+	go func() {
+		count := 0
+		for range fast.Changes() {
+			count++
+			received <- count
+		}
+	}()
+	// waitFor waits until the subscriber keeping up has received count changes
+	waitFor := func(count int) {
+		t.Helper()
+		for {
+			select {
+			case got := <-received:
+				if got == count {
+					return
+				}
+			case <-time.After(time.Second):
+				t.Fatalf("the subscriber keeping up did not receive %d changes", count)
+			}
+		}
+	}
+
+	// Fill the buffer of the slow subscriber, then overflow it
+	for i := 0; i < subscriptionBuffer; i++ {
+		store.AddChange(models.Change{Type: models.ChangeEventUpdated, PlanningID: "work", EventID: "standup_work"})
+	}
+	waitFor(subscriptionBuffer)
+	store.AddChange(models.Change{Type: models.ChangeEventUpdated, PlanningID: "work", EventID: "standup_work"})
+	waitFor(subscriptionBuffer + 1)
+
+	// The slow subscriber keeps what fit in its buffer, then its channel is closed
+	count := 0
+	for range slow.Changes() {
+		count++
+	}
+	if count != subscriptionBuffer {
+		t.Fatalf("slow subscriber received %d changes before being dropped, want %d", count, subscriptionBuffer)
+	}
+}
+
+func TestBrokerWaitsForTheLatestChangeID(t *testing.T) {
+	t.Parallel()
+	store := memory.New()
+	for _, id := range []string{"standup_work", "review_work", "dentist_home"} {
+		store.AddChange(models.Change{Type: models.ChangeEventCreated, PlanningID: "work", EventID: id})
+	}
+
+	changes := &failingLatest{ChangeStore: store.Changes()}
+	changes.failing.Store(true)
+	broker := startBroker(t, changes)
+	sub := broker.Subscribe(nil)
+
+	// Let the broker fail at startup and on a few wake-ups, then heal the store
+	for changes.calls.Load() < 3 {
+		time.Sleep(pollInterval)
+	}
+	changes.failing.Store(false)
+	positioned(t, broker)
+	store.AddChange(models.Change{Type: models.ChangeEventCreated, PlanningID: "work", EventID: "retro_work"})
+
+	if change := receive(t, sub); change.EventID != "retro_work" {
+		t.Fatalf("first change delivered = %s, want retro_work and no replay of the log", change.EventID)
+	}
+}
@@ -0,0 +1,115 @@
+// Package tracing sets up OpenTelemetry tracing from the tracing section of the
+// configuration, and records a span for each SQL statement run within a trace.
+package tracing
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/spf13/viper"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/exporters/stdout/stdouttrace"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.37.0"
+)
+
+// Exporters of spans
+const (
+	ExporterNone   = "none"
+	ExporterOTLP   = "otlp"
+	ExporterStdout = "stdout"
+)
+
+// DefaultServiceName names the API in traces when tracing.service_name is not set
+const DefaultServiceName = "calendo-api"
+
+// Config is the tracing section of the configuration
+type Config struct {
+	// Exporter is otlp, stdout or none (default none)
+	Exporter string `mapstructure:"exporter"`
+	// Endpoint is the OTLP/HTTP collector URL, such as http://localhost:4318. When empty,
+	// the OTEL_EXPORTER_OTLP_ENDPOINT environment variable or the exporter default applies.
+	Endpoint string `mapstructure:"endpoint"`
+	// ServiceName is the service.name resource attribute of every span
+	ServiceName string `mapstructure:"service_name"`
+	// SampleRatio is the share of new traces recorded, from 0 to 1 (default 1). Traces
+	// a caller sampled are recorded; those it did not are sampled like new ones.
+	SampleRatio float64 `mapstructure:"sample_ratio"`
+}
+
+// ConfigFromViper reads the tracing section of the configuration
+func ConfigFromViper() Config {
+	cfg := Config{
+		Exporter:    viper.GetString("tracing.exporter"),
+		Endpoint:    viper.GetString("tracing.endpoint"),
+		ServiceName: viper.GetString("tracing.service_name"),
+		SampleRatio: 1,
+	}
+	if viper.IsSet("tracing.sample_ratio") {
+		cfg.SampleRatio = viper.GetFloat64("tracing.sample_ratio")
+	}
+	if cfg.ServiceName == "" {
+		cfg.ServiceName = DefaultServiceName
+	}
+	return cfg
+}
+
+// Setup installs the W3C trace context propagator and, unless the exporter is none, a
+// tracer provider sending spans to the configured exporter. It returns a function
+// flushing the pending spans and stopping the exporter.
+func Setup(ctx context.Context, cfg Config) (func(context.Context) error, error) {
+	// Propagate incoming trace context even when this service records nothing
+	otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator(
+		propagation.TraceContext{},
+		propagation.Baggage{},
+	))
+
+	if cfg.SampleRatio < 0 || cfg.SampleRatio > 1 {
+		return nil, fmt.Errorf("invalid tracing sample_ratio %v, expected a value from 0 to 1", cfg.SampleRatio)
+	}
+
+	var exporter sdktrace.SpanExporter
+	var err error
+	switch strings.ToLower(cfg.Exporter) {
+	case "", ExporterNone:
+		return func(context.Context) error { return nil }, nil
+	case ExporterOTLP:
+		var opts []otlptracehttp.Option
+		if cfg.Endpoint != "" {
+			opts = append(opts, otlptracehttp.WithEndpointURL(cfg.Endpoint))
+		}
+		exporter, err = otlptracehttp.New(ctx, opts...)
+	case ExporterStdout:
+		// Standard output carries the logs; keep spans apart from them
+		exporter, err = stdouttrace.New(stdouttrace.WithWriter(os.Stderr), stdouttrace.WithPrettyPrint())
+	default:
+		return nil, fmt.Errorf("invalid tracing exporter %q, expected otlp, stdout or none", cfg.Exporter)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to create %s trace exporter: %v", cfg.Exporter, err)
+	}
+
+	res, err := resource.Merge(resource.Default(), resource.NewWithAttributes(
+		semconv.SchemaURL,
+		semconv.ServiceName(cfg.ServiceName),
+	))
+	if err != nil {
+		return nil, fmt.Errorf("failed to describe trace resource: %v", err)
+	}
+
+	// The frontend starts traces without sampling them, leaving the decision to the ratio
+	ratio := sdktrace.TraceIDRatioBased(cfg.SampleRatio)
+	provider := sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(exporter),
+		sdktrace.WithResource(res),
+		sdktrace.WithSampler(sdktrace.ParentBased(ratio, sdktrace.WithRemoteParentNotSampled(ratio))),
+	)
+	otel.SetTracerProvider(provider)
+
+	return provider.Shutdown, nil
+}
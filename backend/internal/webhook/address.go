@@ -0,0 +1,84 @@
+package webhook
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/netip"
+	"net/url"
+	"syscall"
+	"time"
+)
+
+// ErrForbiddenAddress is returned for webhook URLs pointing to an address webhooks may not
+// reach: the API would otherwise POST on behalf of whoever registers the webhook to
+// services only reachable from its own network, such as cloud metadata endpoints.
+var ErrForbiddenAddress = errors.New("loopback, private, link-local and unspecified addresses are not allowed")
+
+// forbidden reports whether webhooks may not be delivered to an address
+func forbidden(addr netip.Addr) bool {
+	addr = addr.Unmap()
+	return addr.IsLoopback() || addr.IsPrivate() || addr.IsUnspecified() ||
+		addr.IsLinkLocalUnicast() || addr.IsLinkLocalMulticast() || addr.IsInterfaceLocalMulticast()
+}
+
+// CheckURL checks that the host of a webhook URL does not resolve to a forbidden address.
+// A host that does not resolve is accepted: deliveries check the address again when they
+// connect, since DNS may answer differently by then.
+func (d *Dispatcher) CheckURL(ctx context.Context, rawURL string) error {
+	if d.config.AllowPrivateNetworks {
+		return nil
+	}
+
+	target, err := url.Parse(rawURL)
+	if err != nil {
+		return err
+	}
+	addrs, err := net.DefaultResolver.LookupNetIP(ctx, "ip", target.Hostname())
+	if err != nil {
+		return nil
+	}
+	for _, addr := range addrs {
+		if forbidden(addr) {
+			return fmt.Errorf("%w: %s resolves to %s", ErrForbiddenAddress, target.Hostname(), addr.Unmap())
+		}
+	}
+	return nil
+}
+
+// newClient returns the HTTP client of deliveries. It refuses to connect to forbidden
+// addresses, whatever the URL resolves to when the delivery is made, and does not follow
+// redirects, which a receiver could use to point the request elsewhere.
+func newClient(config Config) *http.Client {
+	dialer := &net.Dialer{Timeout: config.Timeout, KeepAlive: 30 * time.Second}
+	if !config.AllowPrivateNetworks {
+		dialer.Control = func(network, address string, _ syscall.RawConn) error {
+			addrPort, err := netip.ParseAddrPort(address)
+			if err != nil {
+				return err
+			}
+			if forbidden(addrPort.Addr()) {
+				return fmt.Errorf("%w: %s", ErrForbiddenAddress, addrPort.Addr().Unmap())
+			}
+			return nil
+		}
+	}
+
+	return &http.Client{
+		Timeout: config.Timeout,
+		// No proxy: the dialer would check the address of the proxy instead of the receiver's
+		Transport: &http.Transport{
+			DialContext:           dialer.DialContext,
+			ForceAttemptHTTP2:     true,
+			MaxIdleConns:          100,
+			IdleConnTimeout:       90 * time.Second,
+			TLSHandshakeTimeout:   10 * time.Second,
+			ExpectContinueTimeout: time.Second,
+		},
+		CheckRedirect: func(*http.Request, []*http.Request) error {
+			return http.ErrUseLastResponse
+		},
+	}
+}
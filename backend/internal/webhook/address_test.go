@@ -0,0 +1,92 @@
+package webhook
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/netip"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/do2024-2047/CalenDO/internal/models"
+	"github.com/do2024-2047/CalenDO/internal/repository/memory"
+	"github.com/do2024-2047/CalenDO/internal/stream"
+)
+
+func TestForbidden(t *testing.T) {
+	tests := []struct {
+		addr string
+		want bool
+	}{
+		{"127.0.0.1", true},
+		{"127.3.2.1", true},
+		{"::1", true},
+		{"::ffff:127.0.0.1", true},
+		{"10.1.2.3", true},
+		{"172.16.0.1", true},
+		{"192.168.0.10", true},
+		{"fd00::1", true},
+		{"169.254.169.254", true},
+		{"fe80::1", true},
+		{"0.0.0.0", true},
+		{"::", true},
+		{"93.184.215.14", false},
+		{"172.32.0.1", false},
+		{"2606:2800:21f:cb07:6820:80da:af6b:8b2c", false},
+	}
+	for _, tt := range tests {
+		if got := forbidden(netip.MustParseAddr(tt.addr)); got != tt.want {
+			t.Errorf("forbidden(%s) = %v, want %v", tt.addr, got, tt.want)
+		}
+	}
+}
+
+// newTestDispatcher returns a dispatcher on an empty memory store
+func newTestDispatcher(config Config) *Dispatcher {
+	store := memory.New()
+	broker := stream.NewBroker(store.Changes(), time.Minute)
+	return NewDispatcher(store.Webhooks(), store.Changes(), store.Events(), store.Plannings(), broker, config)
+}
+
+func TestDeliverRefusesForbiddenAddresses(t *testing.T) {
+	var received bool
+	receiver := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		received = true
+	}))
+	defer receiver.Close()
+
+	// The URL passed registration, but the receiver listens on the loopback interface
+	hook := &models.Webhook{ID: "hook", URL: receiver.URL, Secret: "secret", Active: true}
+	delivery := newTestDispatcher(Config{}).Deliver(context.Background(), hook, models.WebhookPayload{DeliveryID: "d1"}, 1)
+	if delivery.Success || !strings.Contains(delivery.Error, ErrForbiddenAddress.Error()) {
+		t.Fatalf("delivery = success %v, error %q, want refused", delivery.Success, delivery.Error)
+	}
+	if received {
+		t.Fatal("the receiver got the payload")
+	}
+
+	if err := newTestDispatcher(Config{}).CheckURL(context.Background(), receiver.URL); !errors.Is(err, ErrForbiddenAddress) {
+		t.Fatalf("CheckURL(%s) = %v, want %v", receiver.URL, err, ErrForbiddenAddress)
+	}
+}
+
+func TestDeliverDoesNotFollowRedirects(t *testing.T) {
+	var redirected bool
+	target := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		redirected = true
+	}))
+	defer target.Close()
+	receiver := httptest.NewServer(http.RedirectHandler(target.URL, http.StatusTemporaryRedirect))
+	defer receiver.Close()
+
+	hook := &models.Webhook{ID: "hook", URL: receiver.URL, Secret: "secret", Active: true}
+	delivery := newTestDispatcher(Config{AllowPrivateNetworks: true}).Deliver(context.Background(), hook, models.WebhookPayload{DeliveryID: "d1"}, 1)
+	if delivery.Success || delivery.StatusCode != http.StatusTemporaryRedirect {
+		t.Fatalf("delivery = success %v, status %d, want a failed %d", delivery.Success, delivery.StatusCode, http.StatusTemporaryRedirect)
+	}
+	if redirected {
+		t.Fatal("the redirect was followed")
+	}
+}
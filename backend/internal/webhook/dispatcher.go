@@ -0,0 +1,417 @@
+// Package webhook delivers change log entries to registered webhook endpoints
+// as signed JSON payloads.
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/do2024-2047/CalenDO/internal/models"
+	"github.com/do2024-2047/CalenDO/internal/repository"
+	"github.com/do2024-2047/CalenDO/internal/stream"
+	"github.com/google/uuid"
+)
+
+// Headers sent with every delivery
+const (
+	HeaderEvent     = "X-CalenDO-Event"
+	HeaderDelivery  = "X-CalenDO-Delivery"
+	HeaderTimestamp = "X-CalenDO-Timestamp"
+	HeaderSignature = "X-CalenDO-Signature"
+)
+
+// batchSize is the number of changes read for a webhook at once
+const batchSize = 100
+
+// leaseMargin is added to the longest a delivery may take to give the lease of a webhook
+const leaseMargin = 30 * time.Second
+
+// Config holds the delivery settings of a dispatcher
+type Config struct {
+	// MaxAttempts is the number of times a payload is sent before giving up
+	MaxAttempts int
+	// InitialBackoff is the delay before the first retry; it doubles after each attempt
+	InitialBackoff time.Duration
+	// Timeout bounds a single HTTP request
+	Timeout time.Duration
+	// PollInterval is how often pending changes are checked without a wake-up from the broker
+	PollInterval time.Duration
+	// AllowPrivateNetworks lets webhooks reach loopback, private, link-local and
+	// unspecified addresses, for development and tests
+	AllowPrivateNetworks bool
+}
+
+// Dispatcher sends new changes to the active webhooks
+type Dispatcher struct {
+	webhooks  repository.WebhookStore
+	changes   repository.ChangeStore
+	events    repository.EventStore
+	plannings repository.PlanningStore
+	broker    *stream.Broker
+	client    *http.Client
+	config    Config
+
+	// owner identifies this dispatcher in the webhook leases it holds
+	owner string
+	// leaseTTL outlasts the delivery of a change with every retry
+	leaseTTL time.Duration
+
+	// sending is the context of the deliveries started by Run. It outlives the context of
+	// Run, so that a stopping dispatcher finishes the deliveries in flight, and is only
+	// cancelled by abort once Drain runs out of time.
+	sending context.Context
+	abort   context.CancelFunc
+	// stopped is closed when Run returns
+	stopped chan struct{}
+
+	// busy holds the IDs of webhooks with a batch in flight
+	busy sync.Map
+	wg   sync.WaitGroup
+}
+
+// NewDispatcher creates a new dispatcher
+func NewDispatcher(
+	webhooks repository.WebhookStore,
+	changes repository.ChangeStore,
+	events repository.EventStore,
+	plannings repository.PlanningStore,
+	broker *stream.Broker,
+	config Config,
+) *Dispatcher {
+	if config.MaxAttempts <= 0 {
+		config.MaxAttempts = 5
+	}
+	if config.InitialBackoff <= 0 {
+		config.InitialBackoff = 2 * time.Second
+	}
+	if config.Timeout <= 0 {
+		config.Timeout = 10 * time.Second
+	}
+	if config.PollInterval <= 0 {
+		config.PollInterval = 30 * time.Second
+	}
+
+	leaseTTL := time.Duration(config.MaxAttempts)*config.Timeout + leaseMargin
+	for attempt, backoff := 1, config.InitialBackoff; attempt < config.MaxAttempts; attempt++ {
+		leaseTTL += backoff
+		backoff *= 2
+	}
+
+	sending, abort := context.WithCancel(context.Background())
+	return &Dispatcher{
+		webhooks:  webhooks,
+		changes:   changes,
+		events:    events,
+		plannings: plannings,
+		broker:    broker,
+		client:    newClient(config),
+		config:    config,
+		owner:     uuid.NewString(),
+		leaseTTL:  leaseTTL,
+		sending:   sending,
+		abort:     abort,
+		stopped:   make(chan struct{}),
+	}
+}
+
+// Run delivers changes until the context is cancelled. No delivery starts afterwards, but
+// those in flight go on until they end or Drain aborts them.
+// The broker only serves as a wake-up signal: the change log is the source of truth,
+// so a dropped subscription or missed notification only delays delivery.
+func (d *Dispatcher) Run(ctx context.Context) {
+	defer close(d.stopped)
+
+	ticker := time.NewTicker(d.config.PollInterval)
+	defer ticker.Stop()
+
+	sub := d.broker.Subscribe(nil)
+	defer func() { d.broker.Unsubscribe(sub) }()
+
+	for {
+		d.dispatch(ctx)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		case _, ok := <-sub.Changes():
+			if !ok {
+				if ctx.Err() != nil {
+					return
+				}
+				sub = d.broker.Subscribe(nil)
+				continue
+			}
+			// Drain the backlog so a burst of changes triggers a single dispatch
+			for drained := false; !drained; {
+				select {
+				case _, ok := <-sub.Changes():
+					drained = !ok
+				default:
+					drained = true
+				}
+			}
+		}
+	}
+}
+
+// Drain waits for Run to return, once its context is cancelled, and for the deliveries in
+// flight, aborting them when ctx ends. Aborted deliveries are made again after a restart.
+func (d *Dispatcher) Drain(ctx context.Context) {
+	done := make(chan struct{})
+	go func() {
+		<-d.stopped
+		d.wg.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+	case <-ctx.Done():
+		d.abort()
+		<-done
+	}
+}
+
+// dispatch starts a delivery batch for every active webhook that is not already busy
+func (d *Dispatcher) dispatch(ctx context.Context) {
+	hooks, err := d.webhooks.FindActive()
+	if err != nil {
+		slog.Warn("Failed to load webhooks", "error", err)
+		return
+	}
+
+	for _, hook := range hooks {
+		if _, running := d.busy.LoadOrStore(hook.ID, struct{}{}); running {
+			continue
+		}
+		d.wg.Add(1)
+		go func(hook *models.Webhook) {
+			defer d.wg.Done()
+			defer d.busy.Delete(hook.ID)
+			d.deliverPending(ctx, hook)
+		}(hook)
+	}
+}
+
+// deliverPending delivers the changes recorded since the webhook's cursor in order. It holds
+// the webhook's lease meanwhile, so that other replicas leave the webhook alone, and moves
+// the cursor past each change once it is delivered or given up on: changes whose delivery
+// is interrupted are delivered again later.
+func (d *Dispatcher) deliverPending(ctx context.Context, hook *models.Webhook) {
+	if !d.renewLease(hook) {
+		return
+	}
+	defer func() {
+		if err := d.webhooks.ReleaseLease(hook.ID, d.owner); err != nil {
+			slog.Warn("Failed to release webhook lease", "webhook_id", hook.ID, "error", err)
+		}
+	}()
+
+	// Another replica may have moved the cursor since the webhook was loaded
+	current, err := d.webhooks.FindByID(hook.ID)
+	if err != nil {
+		slog.Warn("Failed to reload webhook", "webhook_id", hook.ID, "error", err)
+		return
+	}
+	hook.LastChangeID = current.LastChangeID
+
+	var planningIDs []string
+	if hook.PlanningID != "" {
+		planningIDs = []string{hook.PlanningID}
+	}
+
+	for ctx.Err() == nil {
+		changes, err := d.changes.FindSince(hook.LastChangeID, planningIDs, batchSize)
+		if err != nil {
+			slog.Warn("Failed to read change log for webhook", "webhook_id", hook.ID, "error", err)
+			return
+		}
+		if len(changes) == 0 {
+			return
+		}
+
+		// Changes the webhook does not want move the cursor with the next delivered one
+		for _, change := range changes {
+			if !hook.Wants(change) {
+				continue
+			}
+			if ctx.Err() != nil || !d.renewLease(hook) || !d.deliverWithRetry(ctx, hook, d.buildPayload(change)) {
+				return
+			}
+			if !d.advanceCursor(hook, change.ID) {
+				return
+			}
+		}
+		if !d.advanceCursor(hook, changes[len(changes)-1].ID) {
+			return
+		}
+
+		if len(changes) < batchSize {
+			return
+		}
+	}
+}
+
+// renewLease acquires or extends the lease of a webhook for the time a delivery may take.
+// It returns false when another replica holds it.
+func (d *Dispatcher) renewLease(hook *models.Webhook) bool {
+	held, err := d.webhooks.AcquireLease(hook.ID, d.owner, d.leaseTTL)
+	if err != nil {
+		slog.Warn("Failed to lease webhook", "webhook_id", hook.ID, "error", err)
+		return false
+	}
+	return held
+}
+
+// advanceCursor moves the cursor of a webhook to a handled change. It returns false when the
+// cursor was moved by another process, which then delivers the following changes.
+func (d *Dispatcher) advanceCursor(hook *models.Webhook, to uint64) bool {
+	if to == hook.LastChangeID {
+		return true
+	}
+	advanced, err := d.webhooks.AdvanceCursor(hook.ID, hook.LastChangeID, to)
+	if err != nil {
+		slog.Warn("Failed to advance webhook cursor", "webhook_id", hook.ID, "error", err)
+		return false
+	}
+	if advanced {
+		hook.LastChangeID = to
+	}
+	return advanced
+}
+
+// buildPayload creates the payload of a change, including the current state of its event or planning
+func (d *Dispatcher) buildPayload(change *models.Change) models.WebhookPayload {
+	payload := models.WebhookPayload{
+		DeliveryID: uuid.NewString(),
+		Type:       change.Type,
+		ChangeID:   change.ID,
+		PlanningID: change.PlanningID,
+		EventID:    change.EventID,
+		OccurredAt: change.Created,
+	}
+
+	switch change.Type {
+	case models.ChangeEventCreated, models.ChangeEventUpdated:
+		if event, err := d.events.FindByID(change.EventID); err == nil {
+			response := event.ToResponse()
+			payload.Event = &response
+		}
+	case models.ChangePlanningChanged:
+		if planning, err := d.plannings.FindByID(change.PlanningID); err == nil {
+			response := planning.ToResponse()
+			payload.Planning = &response
+		}
+	}
+
+	return payload
+}
+
+// deliverWithRetry sends a payload until it succeeds or the attempts run out, and returns
+// true then. It returns false when the context ends first: the attempt in flight is
+// finished, but no retry is made.
+func (d *Dispatcher) deliverWithRetry(ctx context.Context, hook *models.Webhook, payload models.WebhookPayload) bool {
+	backoff := d.config.InitialBackoff
+	for attempt := 1; attempt <= d.config.MaxAttempts; attempt++ {
+		if d.Deliver(d.sending, hook, payload, attempt).Success {
+			return true
+		}
+		if ctx.Err() != nil {
+			return false
+		}
+		if attempt == d.config.MaxAttempts {
+			slog.Warn("Giving up on webhook delivery",
+				"delivery_id", payload.DeliveryID, "webhook_id", hook.ID, "attempts", attempt)
+			return true
+		}
+
+		select {
+		case <-ctx.Done():
+			return false
+		case <-time.After(backoff):
+		}
+		backoff *= 2
+	}
+	return true
+}
+
+// Deliver sends a payload once and records the attempt in the delivery log
+func (d *Dispatcher) Deliver(ctx context.Context, hook *models.Webhook, payload models.WebhookPayload, attempt int) *models.WebhookDelivery {
+	delivery := &models.WebhookDelivery{
+		WebhookID:  hook.ID,
+		DeliveryID: payload.DeliveryID,
+		ChangeID:   payload.ChangeID,
+		Type:       payload.Type,
+		Attempt:    attempt,
+	}
+
+	started := time.Now()
+	status, err := d.send(ctx, hook, payload, started)
+	delivery.DurationMs = time.Since(started).Milliseconds()
+	delivery.StatusCode = status
+	if err != nil {
+		delivery.Error = err.Error()
+	} else {
+		delivery.Success = true
+	}
+
+	if err := d.webhooks.RecordDelivery(delivery); err != nil {
+		slog.Warn("Failed to record webhook delivery", "webhook_id", hook.ID, "error", err)
+	}
+	return delivery
+}
+
+// send posts a signed payload and returns the response status code
+func (d *Dispatcher) send(ctx context.Context, hook *models.Webhook, payload models.WebhookPayload, now time.Time) (int, error) {
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return 0, fmt.Errorf("failed to encode payload: %w", err)
+	}
+
+	timestamp := strconv.FormatInt(now.Unix(), 10)
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, hook.URL, bytes.NewReader(body))
+	if err != nil {
+		return 0, fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "CalenDO-Webhook/1.0")
+	req.Header.Set(HeaderEvent, payload.Type)
+	req.Header.Set(HeaderDelivery, payload.DeliveryID)
+	req.Header.Set(HeaderTimestamp, timestamp)
+	req.Header.Set(HeaderSignature, "sha256="+Sign(hook.Secret, timestamp, body))
+
+	resp, err := d.client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, io.LimitReader(resp.Body, 64<<10))
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return resp.StatusCode, fmt.Errorf("unexpected status %s", resp.Status)
+	}
+	return resp.StatusCode, nil
+}
+
+// Sign computes the hex-encoded HMAC-SHA256 of "<timestamp>.<body>" with the webhook secret.
+// Receivers recompute it to authenticate the payload and reject stale timestamps to prevent replays.
+func Sign(secret, timestamp string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(timestamp))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return hex.EncodeToString(mac.Sum(nil))
+}
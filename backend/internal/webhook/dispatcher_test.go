@@ -0,0 +1,224 @@
+package webhook
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/do2024-2047/CalenDO/internal/models"
+	"github.com/do2024-2047/CalenDO/internal/repository/memory"
+	"github.com/do2024-2047/CalenDO/internal/stream"
+)
+
+// receiver records the change IDs of the payloads it gets, answering with respond
+type receiver struct {
+	mu       sync.Mutex
+	received []uint64
+	respond  func(changeID uint64) int
+}
+
+func (rc *receiver) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	var payload models.WebhookPayload
+	json.NewDecoder(r.Body).Decode(&payload)
+
+	rc.mu.Lock()
+	rc.received = append(rc.received, payload.ChangeID)
+	rc.mu.Unlock()
+	w.WriteHeader(rc.respond(payload.ChangeID))
+}
+
+func (rc *receiver) changeIDs() []uint64 {
+	rc.mu.Lock()
+	defer rc.mu.Unlock()
+	return append([]uint64(nil), rc.received...)
+}
+
+// newDeliveryTest returns a dispatcher and a webhook posting to handler, with three
+// changes pending for it
+func newDeliveryTest(t *testing.T, handler http.Handler, config Config) (*Dispatcher, *memory.Store, *models.Webhook) {
+	t.Helper()
+
+	server := httptest.NewServer(handler)
+	t.Cleanup(server.Close)
+
+	store := memory.New()
+	hook := &models.Webhook{ID: "hook", URL: server.URL, Secret: "secret", Active: true}
+	if err := store.Webhooks().Create(hook); err != nil {
+		t.Fatalf("failed to create webhook: %v", err)
+	}
+	for i := 0; i < 3; i++ {
+		store.AddChange(models.Change{Type: models.ChangeEventUpdated, PlanningID: "work", EventID: "standup_work"})
+	}
+
+	config.AllowPrivateNetworks = true
+	broker := stream.NewBroker(store.Changes(), time.Minute)
+	d := NewDispatcher(store.Webhooks(), store.Changes(), store.Events(), store.Plannings(), broker, config)
+	return d, store, hook
+}
+
+// cursor returns the stored cursor of the webhook
+func cursor(t *testing.T, store *memory.Store) uint64 {
+	t.Helper()
+	hook, err := store.Webhooks().FindByID("hook")
+	if err != nil {
+		t.Fatalf("failed to load webhook: %v", err)
+	}
+	return hook.LastChangeID
+}
+
+func TestDeliverPendingMovesCursorAfterEachChange(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	// Change 2 first fails, and the dispatcher stops before retrying it
+	var failed bool
+	rc := &receiver{respond: func(changeID uint64) int {
+		if changeID == 2 && !failed {
+			failed = true
+			cancel()
+			return http.StatusServiceUnavailable
+		}
+		return http.StatusNoContent
+	}}
+	d, store, hook := newDeliveryTest(t, rc, Config{MaxAttempts: 3, InitialBackoff: time.Hour})
+
+	d.deliverPending(ctx, hook)
+	if got := cursor(t, store); got != 1 {
+		t.Fatalf("cursor = %d after an interrupted delivery of change 2, want 1", got)
+	}
+
+	// The interrupted change is delivered again, then the rest
+	d.deliverPending(context.Background(), hook)
+	if got := cursor(t, store); got != 3 {
+		t.Fatalf("cursor = %d, want 3", got)
+	}
+	want := []uint64{1, 2, 2, 3}
+	if got := rc.changeIDs(); !slices.Equal(got, want) {
+		t.Fatalf("received changes %v, want %v", got, want)
+	}
+}
+
+func TestDeliverPendingGivesUpAndMovesOn(t *testing.T) {
+	rc := &receiver{respond: func(changeID uint64) int {
+		if changeID == 2 {
+			return http.StatusInternalServerError
+		}
+		return http.StatusNoContent
+	}}
+	d, store, hook := newDeliveryTest(t, rc, Config{MaxAttempts: 2, InitialBackoff: time.Millisecond})
+
+	d.deliverPending(context.Background(), hook)
+	if got := cursor(t, store); got != 3 {
+		t.Fatalf("cursor = %d, want 3", got)
+	}
+	if got := rc.changeIDs(); len(got) != 4 {
+		t.Fatalf("received changes %v, want 1, 2 twice and 3", got)
+	}
+}
+
+func TestDeliverPendingLeavesLeasedWebhooks(t *testing.T) {
+	rc := &receiver{respond: func(uint64) int { return http.StatusNoContent }}
+	d, store, hook := newDeliveryTest(t, rc, Config{})
+
+	// Another replica is delivering to the webhook
+	if held, err := store.Webhooks().AcquireLease("hook", "other-replica", time.Minute); err != nil || !held {
+		t.Fatalf("AcquireLease = %v, %v", held, err)
+	}
+	d.deliverPending(context.Background(), hook)
+	if got := rc.changeIDs(); len(got) != 0 {
+		t.Fatalf("received changes %v while another replica holds the lease", got)
+	}
+
+	// Once released, the lease is free for the taking
+	if err := store.Webhooks().ReleaseLease("hook", "other-replica"); err != nil {
+		t.Fatalf("ReleaseLease: %v", err)
+	}
+	d.deliverPending(context.Background(), hook)
+	if got := cursor(t, store); got != 3 {
+		t.Fatalf("cursor = %d, want 3", got)
+	}
+}
+
+func TestDeliverPendingReloadsCursor(t *testing.T) {
+	rc := &receiver{respond: func(uint64) int { return http.StatusNoContent }}
+	d, store, hook := newDeliveryTest(t, rc, Config{})
+
+	// Another replica delivered the first two changes since the webhook was loaded
+	if moved, err := store.Webhooks().AdvanceCursor("hook", 0, 2); err != nil || !moved {
+		t.Fatalf("AdvanceCursor = %v, %v", moved, err)
+	}
+	d.deliverPending(context.Background(), hook)
+	if got := rc.changeIDs(); len(got) != 1 || got[0] != 3 {
+		t.Fatalf("received changes %v, want [3]", got)
+	}
+}
+
+// blockingReceiver answers every delivery once release is closed, after signalling received
+type blockingReceiver struct {
+	received chan struct{}
+	release  chan struct{}
+}
+
+func (rc *blockingReceiver) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	rc.received <- struct{}{}
+	select {
+	case <-rc.release:
+		w.WriteHeader(http.StatusNoContent)
+	case <-r.Context().Done():
+	}
+}
+
+// startStopping runs d, stops it once the receiver got a delivery and drains it until drain ends
+func startStopping(t *testing.T, d *Dispatcher, rc *blockingReceiver, drain context.Context) {
+	t.Helper()
+
+	ctx, stop := context.WithCancel(context.Background())
+	go d.Run(ctx)
+	select {
+	case <-rc.received:
+	case <-time.After(5 * time.Second):
+		t.Fatal("no delivery was made")
+	}
+	stop()
+	d.Drain(drain)
+}
+
+func TestDrainFinishesDeliveriesInFlight(t *testing.T) {
+	rc := &blockingReceiver{received: make(chan struct{}, 3), release: make(chan struct{})}
+	d, store, _ := newDeliveryTest(t, rc, Config{PollInterval: time.Hour})
+
+	time.AfterFunc(50*time.Millisecond, func() { close(rc.release) })
+	startStopping(t, d, rc, context.Background())
+
+	deliveries, err := store.Webhooks().FindDeliveries("hook", 10)
+	if err != nil {
+		t.Fatalf("FindDeliveries: %v", err)
+	}
+	if len(deliveries) != 1 || !deliveries[0].Success {
+		t.Fatalf("deliveries = %+v, want the delivery in flight to succeed", deliveries)
+	}
+	// No delivery starts once the dispatcher is stopped
+	if got := cursor(t, store); got != 1 {
+		t.Fatalf("cursor = %d, want 1", got)
+	}
+}
+
+func TestDrainAbortsDeliveriesAfterTimeout(t *testing.T) {
+	rc := &blockingReceiver{received: make(chan struct{}, 3), release: make(chan struct{})}
+	defer close(rc.release)
+	d, store, _ := newDeliveryTest(t, rc, Config{PollInterval: time.Hour, Timeout: time.Minute})
+
+	drain, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	startStopping(t, d, rc, drain)
+
+	// The aborted delivery is made again after a restart
+	if got := cursor(t, store); got != 0 {
+		t.Fatalf("cursor = %d, want 0", got)
+	}
+}
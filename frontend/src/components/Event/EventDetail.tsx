@@ -1,7 +1,7 @@
 import React, { useState } from 'react';
 import { X, MapPin, Calendar, Clock } from 'lucide-react';
 import { Event } from '../../types';
-import { formatDate, formatTime } from '../../utils/dateUtils';
+import { formatDate, formatTime, localEnd, localStart } from '../../utils/dateUtils';
 import { formatTextForDisplay } from '../../utils/textUtils';
 import { getEventColors } from '../../utils/colorUtils';
 import { useCalendar } from '../../contexts/CalendarContext';
@@ -14,9 +14,8 @@ interface EventDetailProps {
 const EventDetail: React.FC<EventDetailProps> = ({ event, onClose }) => {
   const { plannings } = useCalendar();
   const [isClosing, setIsClosing] = useState(false);
-  const startDate = new Date(event.start_time);
-  const dateLabel = formatDate(startDate, event.all_day ? 'UTC' : undefined);
-  const timeLabel = event.all_day ? 'All day' : `${formatTime(event.start_time)} - ${formatTime(event.end_time)}`;
+  const dateLabel = formatDate(new Date(localStart(event)));
+  const timeLabel = event.all_day ? 'All day' : `${formatTime(localStart(event))} - ${formatTime(localEnd(event))}`;
   
   // Get planning information
   const eventPlanning = plannings.find(p => p.id === event.planning_id) || event.planning;
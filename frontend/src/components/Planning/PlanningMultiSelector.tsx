@@ -1,6 +1,7 @@
-import { useState, useRef, useEffect } from 'react';
+import { Fragment, useState, useRef, useEffect } from 'react';
 import { useCalendar } from '../../contexts/CalendarContext';
 import { formatTextForDisplay } from '../../utils/textUtils';
+import { UserIdSettings } from './UserIdSettings';
 
 export const PlanningMultiSelector = () => {
   const { 
@@ -120,50 +121,61 @@ export const PlanningMultiSelector = () => {
           
           {/* Planning options */}
           <div className="py-1">
-            {plannings.map((planning) => {
+            {plannings.map((planning, index) => {
               const isSelected = selectedPlannings.some(p => p.id === planning.id);
+              // Plannings arrive ordered by group, so a header starts each new group
+              const startsGroup = planning.group_name &&
+                (index === 0 || plannings[index - 1].group_id !== planning.group_id);
               return (
-                <div
-                  key={planning.id}
-                  onClick={() => togglePlanningSelection(planning)}
-                  className={`flex items-start ${isMobile ? 'px-2 py-2' : 'px-3 py-3'} hover:bg-gray-50 cursor-pointer border-b border-gray-100 last:border-b-0`}
-                >
-                  <input
-                    type="checkbox"
-                    checked={isSelected}
-                    onChange={() => {}} // Handled by onClick
-                    className={`mr-2 h-4 w-4 text-purple-600 focus:ring-purple-500 border-gray-300 rounded mt-0.5`}
-                  />
-                  <div 
-                    className={`${isMobile ? 'w-2.5 h-2.5' : 'w-3 h-3'} rounded-full border border-gray-300 mr-2 mt-0.5 flex-shrink-0`}
-                    style={{ backgroundColor: planning.color }}
-                  />
-                  <div className="flex-1 min-w-0">
-                    <div className="flex items-center justify-between mb-1">
-                      <div className={`${isMobile ? 'text-xs' : 'text-sm'} font-medium text-gray-900 truncate`}>
-                        {planning.name}
-                        {planning.is_default && (
-                          <span className={`ml-2 ${isMobile ? 'text-xs' : 'text-xs'} bg-green-100 text-green-800 px-1 py-0.5 rounded`}>
-                            Default
-                          </span>
+                <Fragment key={planning.id}>
+                  {startsGroup && (
+                    <div className="px-3 pt-2 pb-1 text-xs font-semibold uppercase tracking-wide text-gray-500 bg-gray-50">
+                      {planning.group_name}
+                    </div>
+                  )}
+                  <div
+                    onClick={() => togglePlanningSelection(planning)}
+                    className={`flex items-start ${isMobile ? 'px-2 py-2' : 'px-3 py-3'} hover:bg-gray-50 cursor-pointer border-b border-gray-100 last:border-b-0`}
+                  >
+                    <input
+                      type="checkbox"
+                      checked={isSelected}
+                      onChange={() => {}} // Handled by onClick
+                      className={`mr-2 h-4 w-4 text-purple-600 focus:ring-purple-500 border-gray-300 rounded mt-0.5`}
+                    />
+                    <div 
+                      className={`${isMobile ? 'w-2.5 h-2.5' : 'w-3 h-3'} rounded-full border border-gray-300 mr-2 mt-0.5 flex-shrink-0`}
+                      style={{ backgroundColor: planning.color }}
+                    />
+                    <div className="flex-1 min-w-0">
+                      <div className="flex items-center justify-between mb-1">
+                        <div className={`${isMobile ? 'text-xs' : 'text-sm'} font-medium text-gray-900 truncate`}>
+                          {planning.name}
+                          {planning.is_default && (
+                            <span className={`ml-2 ${isMobile ? 'text-xs' : 'text-xs'} bg-green-100 text-green-800 px-1 py-0.5 rounded`}>
+                              Default
+                            </span>
+                          )}
+                        </div>
+                        {planning.event_count !== undefined && (
+                          <div className={`${isMobile ? 'text-xs' : 'text-xs'} text-gray-400 ml-2 flex-shrink-0`}>
+                            {planning.event_count} events
+                          </div>
                         )}
                       </div>
-                      {planning.event_count !== undefined && (
-                        <div className={`${isMobile ? 'text-xs' : 'text-xs'} text-gray-400 ml-2 flex-shrink-0`}>
-                          {planning.event_count} events
+                      {planning.description && !isMobile && (
+                        <div className="text-xs text-gray-500 leading-relaxed whitespace-pre-line">
+                          {formatTextForDisplay(planning.description)}
                         </div>
                       )}
                     </div>
-                    {planning.description && !isMobile && (
-                      <div className="text-xs text-gray-500 leading-relaxed whitespace-pre-line">
-                        {formatTextForDisplay(planning.description)}
-                      </div>
-                    )}
                   </div>
-                </div>
+                </Fragment>
               );
             })}
           </div>
+
+          <UserIdSettings />
         </div>
       )}
     </div>
@@ -0,0 +1,87 @@
+import { useState } from 'react';
+import { useCalendar } from '../../contexts/CalendarContext';
+
+// Longest user ID the API accepts in the X-User-ID header
+const MAX_USER_ID_LENGTH = 128;
+
+// Shows the ID planning preferences are saved under, and lets the user enter the ID of
+// another device so that both share their colours and visibility
+export const UserIdSettings = () => {
+  const { userId, changeUserId } = useCalendar();
+  const [isEditing, setIsEditing] = useState(false);
+  const [draft, setDraft] = useState('');
+
+  const trimmed = draft.trim();
+  const isValid = trimmed.length > 0 && trimmed.length <= MAX_USER_ID_LENGTH;
+
+  const startEditing = () => {
+    setDraft(userId);
+    setIsEditing(true);
+  };
+
+  const save = async () => {
+    if (!isValid) {
+      return;
+    }
+    setIsEditing(false);
+    if (trimmed !== userId) {
+      await changeUserId(trimmed);
+    }
+  };
+
+  return (
+    <div className="p-2 border-t border-gray-200 bg-gray-50">
+      <div className="text-xs font-semibold uppercase tracking-wide text-gray-500 mb-1">
+        Sync ID
+      </div>
+      {isEditing ? (
+        <form
+          className="flex gap-1"
+          onSubmit={event => {
+            event.preventDefault();
+            save();
+          }}
+        >
+          <input
+            type="text"
+            value={draft}
+            onChange={event => setDraft(event.target.value)}
+            maxLength={MAX_USER_ID_LENGTH}
+            autoFocus
+            className="flex-1 min-w-0 border border-gray-300 rounded px-2 py-1 text-xs text-gray-700 focus:outline-none focus:ring-2 focus:ring-purple-300"
+          />
+          <button
+            type="submit"
+            disabled={!isValid}
+            className="px-2 py-1 text-xs text-white bg-purple-600 rounded hover:bg-purple-700 disabled:opacity-50"
+          >
+            Save
+          </button>
+          <button
+            type="button"
+            onClick={() => setIsEditing(false)}
+            className="px-2 py-1 text-xs text-gray-600 hover:bg-gray-100 rounded"
+          >
+            Cancel
+          </button>
+        </form>
+      ) : (
+        <div className="flex items-center gap-2">
+          <code className="flex-1 min-w-0 truncate text-xs text-gray-700 select-all" title={userId}>
+            {userId || 'Unavailable'}
+          </code>
+          <button
+            type="button"
+            onClick={startEditing}
+            className="px-2 py-1 text-xs text-purple-600 hover:bg-purple-50 rounded"
+          >
+            Change
+          </button>
+        </div>
+      )}
+      <p className="mt-1 text-xs text-gray-400">
+        Enter the ID of another device to share its planning colours and visibility. Keep it private: anyone with it can change them.
+      </p>
+    </div>
+  );
+};
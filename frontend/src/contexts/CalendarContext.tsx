@@ -1,6 +1,8 @@
-import React, { createContext, useContext, useState, ReactNode } from 'react';
+import React, { createContext, useContext, useEffect, useRef, useState, ReactNode } from 'react';
 import { Event, Planning, CalendarViewType, SearchFilters } from '../types';
 import { useEvents, usePlannings } from '../hooks/useApiData';
+import { useChangeStream } from '../hooks/useChangeStream';
+import { cachedApi, getUserId, setUserId } from '../services/cachedApi';
 
 interface CalendarContextType {
   events: Event[];
@@ -25,6 +27,8 @@ interface CalendarContextType {
   clearPlanningSelection: () => void;
   refreshEvents: () => Promise<void>;
   refreshPlannings: () => Promise<void>;
+  userId: string;
+  changeUserId: (userId: string) => Promise<void>;
 }
 
 const CalendarContext = createContext<CalendarContextType | undefined>(undefined);
@@ -45,6 +49,10 @@ const getInitialView = (): CalendarViewType => {
   return 'month'; // Default fallback
 };
 
+// Plannings shown for a selection; an empty selection shows every planning
+const isShown = (selection: Planning[], planning: Planning): boolean =>
+  selection.length === 0 || selection.some(p => p.id === planning.id);
+
 // Helper function to get initial selected plannings from localStorage
 const getInitialSelectedPlannings = (): Planning[] => {
   try {
@@ -91,38 +99,82 @@ export const CalendarProvider = ({ children }: { children: ReactNode }) => {
   // Combine error states
   const error = eventsError?.message || planningsError?.message || null;
 
-  const togglePlanningSelection = (planning: Planning) => {
-    setSelectedPlannings(prev => {
-      const isSelected = prev.some(p => p.id === planning.id);
-      const newSelection = isSelected 
-        ? prev.filter(p => p.id !== planning.id)
-        : [...prev, planning];
-      
-      // Save to localStorage
-      try {
-        localStorage.setItem(SELECTED_PLANNINGS_STORAGE_KEY, JSON.stringify(newSelection));
-      } catch (error) {
-        console.warn('Failed to save selected plannings to localStorage:', error);
-      }
-      
-      // Update currentPlanning for backward compatibility
-      if (newSelection.length === 0) {
-        setCurrentPlanning(null);
-      } else if (newSelection.length === 1) {
-        setCurrentPlanning(newSelection[0]);
-      } else {
-        // Multiple selections - keep the first one as current for backward compatibility
-        setCurrentPlanning(newSelection[0]);
+  // Save which plannings are hidden on the server, so the selection follows the user to other devices.
+  // Loaded plannings are not refreshed after each save, so remember what was saved since.
+  const savedHidden = useRef(new Map<string, boolean>());
+  const saveHiddenPlannings = React.useCallback((selection: Planning[]) => {
+    (plannings || []).forEach(planning => {
+      const hidden = !isShown(selection, planning);
+      if (hidden !== (savedHidden.current.get(planning.id) ?? planning.hidden ?? false)) {
+        savedHidden.current.set(planning.id, hidden);
+        cachedApi.setPlanningPreference(planning.id, { hidden }).catch(error => {
+          console.warn('Failed to save planning visibility:', error);
+        });
       }
-      
-      return newSelection;
     });
+  }, [plannings]);
+
+  // Start from the visibility saved on the server once plannings have loaded. Servers without
+  // planning preferences leave `hidden` unset, and the selection saved in localStorage is kept.
+  const serverSelectionApplied = useRef(false);
+  useEffect(() => {
+    if (serverSelectionApplied.current || !plannings || plannings.length === 0) {
+      return;
+    }
+    if (plannings.every(planning => planning.hidden === undefined)) {
+      return;
+    }
+    serverSelectionApplied.current = true;
+
+    const visible = plannings.filter(planning => !planning.hidden);
+    if (visible.length === plannings.length) {
+      // Nothing hidden yet: move a selection saved by an older version to the server
+      const saved = getInitialSelectedPlannings();
+      if (saved.length > 0) {
+        saveHiddenPlannings(saved);
+      }
+      return;
+    }
+
+    setSelectedPlannings(visible);
+    setCurrentPlanning(visible.length > 0 ? visible[0] : null);
+    try {
+      localStorage.setItem(SELECTED_PLANNINGS_STORAGE_KEY, JSON.stringify(visible));
+    } catch (error) {
+      console.warn('Failed to save selected plannings to localStorage:', error);
+    }
+  }, [plannings, saveHiddenPlannings]);
+
+  const togglePlanningSelection = (planning: Planning) => {
+    const isSelected = selectedPlannings.some(p => p.id === planning.id);
+    const newSelection = isSelected 
+      ? selectedPlannings.filter(p => p.id !== planning.id)
+      : [...selectedPlannings, planning];
+
+    setSelectedPlannings(newSelection);
+    saveHiddenPlannings(newSelection);
+
+    // Save to localStorage
+    try {
+      localStorage.setItem(SELECTED_PLANNINGS_STORAGE_KEY, JSON.stringify(newSelection));
+    } catch (error) {
+      console.warn('Failed to save selected plannings to localStorage:', error);
+    }
+
+    // Update currentPlanning for backward compatibility
+    if (newSelection.length === 0) {
+      setCurrentPlanning(null);
+    } else {
+      // Multiple selections - keep the first one as current for backward compatibility
+      setCurrentPlanning(newSelection[0]);
+    }
   };
 
   const selectAllPlannings = () => {
     const allPlannings = plannings || [];
     setSelectedPlannings(allPlannings);
     setCurrentPlanning(allPlannings.length > 0 ? allPlannings[0] : null);
+    saveHiddenPlannings(allPlannings);
     
     // Save to localStorage
     try {
@@ -135,6 +187,7 @@ export const CalendarProvider = ({ children }: { children: ReactNode }) => {
   const clearPlanningSelection = () => {
     setSelectedPlannings([]);
     setCurrentPlanning(null);
+    saveHiddenPlannings([]);
     
     // Save to localStorage
     try {
@@ -147,12 +200,13 @@ export const CalendarProvider = ({ children }: { children: ReactNode }) => {
   // Enhanced setSelectedPlannings function that persists to localStorage
   const handleSetSelectedPlannings = React.useCallback((plannings: Planning[]) => {
     setSelectedPlannings(plannings);
+    saveHiddenPlannings(plannings);
     try {
       localStorage.setItem(SELECTED_PLANNINGS_STORAGE_KEY, JSON.stringify(plannings));
     } catch (error) {
       console.warn('Failed to save selected plannings to localStorage:', error);
     }
-  }, []);
+  }, [saveHiddenPlannings]);
 
   // Keep backward compatibility with currentPlanning
   const handleSetCurrentPlanning = (planning: Planning | null) => {
@@ -164,6 +218,24 @@ export const CalendarProvider = ({ children }: { children: ReactNode }) => {
     }
   };
 
+  // Switch to the planning preferences saved for another user ID, such as the one of another
+  // device. The selection of the previous user is dropped rather than saved for the new one.
+  const [userId, setUserIdState] = useState(getUserId);
+  const changeUserId = React.useCallback(async (newUserId: string) => {
+    setUserId(newUserId);
+    setUserIdState(getUserId());
+    savedHidden.current.clear();
+    serverSelectionApplied.current = false;
+    setSelectedPlannings([]);
+    setCurrentPlanning(null);
+    try {
+      localStorage.setItem(SELECTED_PLANNINGS_STORAGE_KEY, JSON.stringify([]));
+    } catch (error) {
+      console.warn('Failed to save selected plannings to localStorage:', error);
+    }
+    await refreshPlanningsData();
+  }, [refreshPlanningsData]);
+
   // Wrap refresh functions
   const refreshEvents = React.useCallback(async () => {
     await refreshEventsData();
@@ -173,6 +245,12 @@ export const CalendarProvider = ({ children }: { children: ReactNode }) => {
     await refreshPlanningsData();
   }, [refreshPlanningsData]);
 
+  // Refresh as soon as the server reports that an import changed something
+  useChangeStream({
+    onEventsChanged: refreshEvents,
+    onPlanningsChanged: refreshPlannings
+  });
+
   // Enhanced setView function that persists to localStorage
   const handleSetView = React.useCallback((newView: CalendarViewType) => {
     setView(newView);
@@ -214,7 +292,9 @@ export const CalendarProvider = ({ children }: { children: ReactNode }) => {
     selectAllPlannings,
     clearPlanningSelection,
     refreshEvents,
-    refreshPlannings
+    refreshPlannings,
+    userId,
+    changeUserId
   };
 
   return (
@@ -5,7 +5,7 @@ import { useCallback } from 'react';
 
 // Hook for events data
 export const useEvents = () => {
-  const fetchEvents = useCallback(() => cachedApi.getEvents(), []);
+  const fetchEvents = useCallback(() => cachedApi.syncEvents(), []);
   
   return useCachedData<Event[]>(
     fetchEvents,
@@ -83,7 +83,7 @@ export const useDefaultPlanning = () => {
 export const useApiHealth = () => {
   const fetchHealth = useCallback(() => cachedApi.checkHealth(), []);
   
-  return useCachedData<{ status: string }>(
+  return useCachedData<Record<string, string>>(
     fetchHealth,
     'health',
     {
@@ -0,0 +1,60 @@
+import { useEffect, useRef } from 'react';
+import { API_PREFIX } from '../services/generated/client';
+import { useOnlineStatus } from './useOnlineStatus';
+
+// The event stream is not JSON, so the generated client leaves it out
+const STREAM_URL = `${API_PREFIX}/stream`;
+
+// Delay before refreshing, so a sync that touches many events triggers a single refresh
+const REFRESH_DEBOUNCE = 1000;
+
+const EVENT_CHANGE_TYPES = ['event.created', 'event.updated', 'event.deleted'];
+const PLANNING_CHANGE_TYPES = ['planning.changed'];
+
+interface UseChangeStreamOptions {
+  onEventsChanged: () => void;
+  onPlanningsChanged: () => void;
+}
+
+// Subscribe to the server's change stream and refresh data when an import changes it.
+// EventSource reconnects on its own and resumes from the last received change.
+export const useChangeStream = ({ onEventsChanged, onPlanningsChanged }: UseChangeStreamOptions) => {
+  const isOnline = useOnlineStatus();
+  const callbacksRef = useRef({ onEventsChanged, onPlanningsChanged });
+  callbacksRef.current = { onEventsChanged, onPlanningsChanged };
+
+  useEffect(() => {
+    if (!isOnline || typeof EventSource === 'undefined') {
+      return;
+    }
+
+    const source = new EventSource(STREAM_URL);
+    const timers: { events?: number; plannings?: number } = {};
+
+    const schedule = (kind: 'events' | 'plannings') => {
+      window.clearTimeout(timers[kind]);
+      timers[kind] = window.setTimeout(() => {
+        if (kind === 'events') {
+          callbacksRef.current.onEventsChanged();
+        } else {
+          callbacksRef.current.onPlanningsChanged();
+        }
+      }, REFRESH_DEBOUNCE);
+    };
+
+    const handleEventChange = () => schedule('events');
+    const handlePlanningChange = () => {
+      schedule('plannings');
+      schedule('events');
+    };
+
+    EVENT_CHANGE_TYPES.forEach(type => source.addEventListener(type, handleEventChange));
+    PLANNING_CHANGE_TYPES.forEach(type => source.addEventListener(type, handlePlanningChange));
+
+    return () => {
+      window.clearTimeout(timers.events);
+      window.clearTimeout(timers.plannings);
+      source.close();
+    };
+  }, [isOnline]);
+};
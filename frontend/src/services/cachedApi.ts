@@ -1,7 +1,6 @@
-import { Event, Planning } from '../types';
+import { DeltaResponse, Event, Planning, PlanningPreference } from '../types';
 import { apiCache } from './cache';
-
-const API_BASE_URL = '/api';
+import { ApiError, Client, createClient } from './generated/client';
 
 // Cache durations (in milliseconds)
 const CACHE_DURATIONS = {
@@ -11,6 +10,112 @@ const CACHE_DURATIONS = {
   HEALTH: 5 * 60 * 1000, // 5 minutes
 };
 
+// Cache key of the token returned by the delta sync endpoint
+const SYNC_TOKEN_KEY = 'events_sync_token';
+
+// Planning preferences (visibility, colour) are stored server-side for this ID, so copying
+// it to another device brings them along
+const USER_ID_STORAGE_KEY = 'calendo-user-id';
+
+export const getUserId = (): string => {
+  try {
+    let userId = localStorage.getItem(USER_ID_STORAGE_KEY);
+    if (!userId) {
+      userId = crypto.randomUUID();
+      localStorage.setItem(USER_ID_STORAGE_KEY, userId);
+    }
+    return userId;
+  } catch (error) {
+    console.warn('Failed to load user ID from localStorage:', error);
+    return '';
+  }
+};
+
+export const setUserId = (userId: string): void => {
+  try {
+    localStorage.setItem(USER_ID_STORAGE_KEY, userId.trim());
+    // Cached plannings carry the preferences of the previous user
+    apiCache.delete('plannings');
+  } catch (error) {
+    console.warn('Failed to save user ID to localStorage:', error);
+  }
+};
+
+// Random lowercase hex string of the given number of bytes
+const randomHex = (bytes: number): string =>
+  Array.from(crypto.getRandomValues(new Uint8Array(bytes)), byte => byte.toString(16).padStart(2, '0')).join('');
+
+// Request options starting a W3C trace, so that the spans the backend records for the
+// request are found under one trace ID. The trace is not marked as sampled: the backend's
+// sample ratio decides whether it is recorded.
+const withTrace = (init: RequestInit = {}): RequestInit => ({
+  ...init,
+  headers: { ...init.headers, traceparent: `00-${randomHex(16)}-${randomHex(8)}-00` },
+});
+
+// Timezone of the browser, in which the API is asked to express the local times of events
+const browserTimezone = (): string | undefined => {
+  try {
+    return Intl.DateTimeFormat().resolvedOptions().timeZone;
+  } catch {
+    return undefined;
+  }
+};
+
+// Request options asking for local times and dates in the browser's timezone, so that
+// events can be placed on days without converting their times
+const withTimezone = (init: RequestInit = {}): RequestInit => {
+  const timezone = browserTimezone();
+  if (!timezone) {
+    return init;
+  }
+  return { ...init, headers: { ...init.headers, 'X-Timezone': timezone } };
+};
+
+// Request options identifying the user, so that plannings come with their preferences
+const withUser = (init: RequestInit = {}): RequestInit => {
+  const userId = getUserId();
+  if (!userId) {
+    return withTrace(init);
+  }
+  return withTrace({ ...init, headers: { ...init.headers, 'X-User-ID': userId } });
+};
+
+// Client of the API, generated from its specification so that requests and responses cannot drift
+const client = createClient((path, init) => fetch(path, withUser(withTimezone(init))));
+
+// The same client through the Discord Activity proxy, which serves the API under /.proxy
+const proxyClient = createClient((path, init) => fetch(`/.proxy${path}`, withUser(withTimezone(init))));
+
+// Check if this is a CSP error or network error that might be Discord Activity related
+const isCSPError = (error: unknown): boolean =>
+  error instanceof TypeError &&
+  (error.message.includes('Failed to fetch') ||
+   error.message.includes('NetworkError') ||
+   error.message.includes('blocked by CORS') ||
+   error.message.includes('Content Security Policy'));
+
+// Apply a delta to a list of events, keeping the newest events first like /api/v1/events
+const applyDelta = (events: Event[], delta: DeltaResponse): Event[] => {
+  const byId = new Map(events.map(event => [event.id, event]));
+  delta.deleted.forEach(id => byId.delete(id));
+  delta.events.forEach(event => byId.set(event.id, event));
+
+  // Events of a deleted planning go with it
+  const deletedPlannings = new Set(delta.deleted_plannings);
+  byId.forEach((event, id) => {
+    if (deletedPlannings.has(event.planning_id)) {
+      byId.delete(id);
+    }
+  });
+
+  // Events embed their planning, so refresh it when the planning changed
+  const plannings = new Map(delta.plannings.map(planning => [planning.id, planning]));
+  return Array.from(byId.values())
+    .map(event => plannings.has(event.planning_id) ? { ...event, planning: plannings.get(event.planning_id) } : event)
+    .sort((a, b) => b.start_time.localeCompare(a.start_time));
+};
+
 interface CachedApiOptions {
   forceRefresh?: boolean;
   fallbackToCache?: boolean;
@@ -18,7 +123,7 @@ interface CachedApiOptions {
 
 class CachedApiService {
   private async fetchWithCache<T>(
-    url: string,
+    load: (client: Client) => Promise<T>,
     cacheKey: string,
     cacheDuration: number,
     options: CachedApiOptions = {}
@@ -30,59 +135,39 @@ class CachedApiService {
       const cachedData = apiCache.get<T>(cacheKey);
       if (cachedData) {
         // Return cached data and try to refresh in background
-        this.refreshInBackground(url, cacheKey, cacheDuration);
+        this.refreshInBackground(load, cacheKey, cacheDuration);
         return cachedData;
       }
     }
 
     // Try to fetch from network with Discord Activity CSP fallback
     try {
-      const response = await fetch(url);
-      if (!response.ok) {
-        throw new Error(`HTTP error! Status: ${response.status}`);
-      }
-      
-      const data = await response.json();
-      const processedData = data || (Array.isArray([]) ? [] : data);
-      
+      const data = await load(client);
+
       // Cache the successful response
-      apiCache.set(cacheKey, processedData, cacheDuration);
-      
-      return processedData;
+      apiCache.set(cacheKey, data, cacheDuration);
+
+      return data;
     } catch (error) {
-      console.error(`Network request failed for ${url}:`, error);
-      
-      // Check if this is a CSP error or network error that might be Discord Activity related
-      const isCSPError = error instanceof TypeError && 
-        (error.message.includes('Failed to fetch') || 
-         error.message.includes('NetworkError') || 
-         error.message.includes('blocked by CORS') ||
-         error.message.includes('Content Security Policy'));
-      
-      if (isCSPError && url.includes('/api/')) {
+      console.error(`Network request failed for ${cacheKey}:`, error);
+
+      if (isCSPError(error)) {
         // Try with Discord Activity proxy prefix
-        const proxyUrl = url.replace('/api/', '/.proxy/api/');
-        console.log(`Retrying with Discord Activity proxy: ${proxyUrl}`);
-        
+        console.log(`Retrying ${cacheKey} with Discord Activity proxy`);
+
         try {
-          const proxyResponse = await fetch(proxyUrl);
-          if (!proxyResponse.ok) {
-            throw new Error(`HTTP error! Status: ${proxyResponse.status}`);
-          }
-          
-          const proxyData = await proxyResponse.json();
-          const processedProxyData = proxyData || (Array.isArray([]) ? [] : proxyData);
-          
+          const proxyData = await load(proxyClient);
+
           // Cache the successful response
-          apiCache.set(cacheKey, processedProxyData, cacheDuration);
-          
-          return processedProxyData;
+          apiCache.set(cacheKey, proxyData, cacheDuration);
+
+          return proxyData;
         } catch (proxyError) {
-          console.error(`Proxy request also failed for ${proxyUrl}:`, proxyError);
+          console.error(`Proxy request also failed for ${cacheKey}:`, proxyError);
           // Continue to fallback logic below
         }
       }
-      
+
       // Fallback to cache if network fails
       if (fallbackToCache) {
         const cachedData = apiCache.get<T>(cacheKey);
@@ -91,68 +176,109 @@ class CachedApiService {
           return cachedData;
         }
       }
-      
+
       // If no cache available, throw the error
       throw error;
     }
   }
 
-  private async refreshInBackground(
-    url: string,
+  private async refreshInBackground<T>(
+    load: (client: Client) => Promise<T>,
     cacheKey: string,
     cacheDuration: number
   ): Promise<void> {
     try {
-      const response = await fetch(url);
-      if (response.ok) {
-        const data = await response.json();
-        const processedData = data || (Array.isArray([]) ? [] : data);
-        apiCache.set(cacheKey, processedData, cacheDuration);
-        return;
-      }
+      apiCache.set(cacheKey, await load(client), cacheDuration);
     } catch (error) {
-      // Check if this is a CSP error that might be Discord Activity related
-      const isCSPError = error instanceof TypeError && 
-        (error.message.includes('Failed to fetch') || 
-         error.message.includes('NetworkError') || 
-         error.message.includes('blocked by CORS') ||
-         error.message.includes('Content Security Policy'));
-      
-      if (isCSPError && url.includes('/api/')) {
+      if (isCSPError(error)) {
         // Try with Discord Activity proxy prefix
-        const proxyUrl = url.replace('/api/', '/.proxy/api/');
-        console.debug(`Background refresh retrying with Discord Activity proxy: ${proxyUrl}`);
-        
+        console.debug(`Background refresh of ${cacheKey} retrying with Discord Activity proxy`);
+
         try {
-          const proxyResponse = await fetch(proxyUrl);
-          if (proxyResponse.ok) {
-            const proxyData = await proxyResponse.json();
-            const processedProxyData = proxyData || (Array.isArray([]) ? [] : proxyData);
-            apiCache.set(cacheKey, processedProxyData, cacheDuration);
-            return;
-          }
+          apiCache.set(cacheKey, await load(proxyClient), cacheDuration);
+          return;
         } catch (proxyError) {
-          console.debug(`Background proxy refresh also failed for ${proxyUrl}:`, proxyError);
+          console.debug(`Background proxy refresh also failed for ${cacheKey}:`, proxyError);
         }
       }
-      
+
       // Silently fail background refresh
       console.debug(`Background refresh failed for ${cacheKey}:`, error);
     }
   }
 
   async getEvents(options?: CachedApiOptions): Promise<Event[]> {
-    return this.fetchWithCache<Event[]>(
-      `${API_BASE_URL}/events`,
+    return this.fetchWithCache(
+      api => api.getEvents(),
       'events',
       CACHE_DURATIONS.EVENTS,
       options
     );
   }
 
+  // Bring the cached events up to date with the changes made since the last sync,
+  // downloading a full snapshot only when there is no sync token or it has expired
+  async syncEvents(): Promise<Event[]> {
+    let token = apiCache.get<string>(SYNC_TOKEN_KEY);
+    let events = token ? apiCache.get<Event[]>('events') : null;
+    // Deltas only cover changed events, so start over when the cached ones are in another timezone
+    if (!events || events.some(event => event.timezone !== browserTimezone())) {
+      token = null;
+      events = null;
+    }
+    const deletedPlannings = new Set<string>();
+
+    try {
+      for (;;) {
+        let delta: DeltaResponse;
+        try {
+          delta = await client.getChanges({ since: token ?? undefined });
+        } catch (error) {
+          // The server no longer has the changes since our token; start over
+          if (error instanceof ApiError && error.status === 410 && token) {
+            token = null;
+            events = null;
+            continue;
+          }
+          throw error;
+        }
+
+        events = applyDelta(delta.full ? [] : events || [], delta);
+        delta.deleted_plannings.forEach(id => deletedPlannings.add(id));
+        token = delta.token;
+        if (!delta.has_more) {
+          break;
+        }
+      }
+    } catch (error) {
+      // Fall back to a plain download, which also handles the Discord Activity proxy
+      console.debug('Delta sync failed, downloading all events:', error);
+      apiCache.delete(SYNC_TOKEN_KEY);
+      return this.getEvents({ forceRefresh: true });
+    }
+
+    const synced = events || [];
+    apiCache.set('events', synced, CACHE_DURATIONS.EVENTS);
+    apiCache.set(SYNC_TOKEN_KEY, token, CACHE_DURATIONS.EVENTS);
+    this.forgetPlannings(deletedPlannings);
+    return synced;
+  }
+
+  // Drop deleted plannings from the cache, so that they are not listed until the next download
+  private forgetPlannings(ids: Set<string>): void {
+    if (ids.size === 0) {
+      return;
+    }
+    ids.forEach(id => apiCache.delete(`planning_${id}`));
+    const plannings = apiCache.get<Planning[]>('plannings');
+    if (plannings) {
+      apiCache.set('plannings', plannings.filter(planning => !ids.has(planning.id)), CACHE_DURATIONS.PLANNINGS);
+    }
+  }
+
   async getEventByUid(uid: string, options?: CachedApiOptions): Promise<Event> {
-    return this.fetchWithCache<Event>(
-      `${API_BASE_URL}/events/${uid}`,
+    return this.fetchWithCache(
+      api => api.getEvent(uid),
       `event_${uid}`,
       CACHE_DURATIONS.EVENTS,
       options
@@ -160,8 +286,8 @@ class CachedApiService {
   }
 
   async getPlannings(options?: CachedApiOptions): Promise<Planning[]> {
-    return this.fetchWithCache<Planning[]>(
-      `${API_BASE_URL}/plannings`,
+    return this.fetchWithCache(
+      api => api.getPlannings(),
       'plannings',
       CACHE_DURATIONS.PLANNINGS,
       options
@@ -169,8 +295,8 @@ class CachedApiService {
   }
 
   async getPlanningById(id: string, options?: CachedApiOptions): Promise<Planning> {
-    return this.fetchWithCache<Planning>(
-      `${API_BASE_URL}/plannings/${id}`,
+    return this.fetchWithCache(
+      api => api.getPlanning(id),
       `planning_${id}`,
       CACHE_DURATIONS.PLANNING_DETAIL,
       options
@@ -178,17 +304,39 @@ class CachedApiService {
   }
 
   async getDefaultPlanning(options?: CachedApiOptions): Promise<Planning> {
-    return this.fetchWithCache<Planning>(
-      `${API_BASE_URL}/plannings/default`,
+    return this.fetchWithCache(
+      api => api.getDefaultPlanning(),
       'default_planning',
       CACHE_DURATIONS.PLANNING_DETAIL,
       options
     );
   }
 
-  async checkHealth(options?: CachedApiOptions): Promise<{ status: string }> {
-    return this.fetchWithCache<{ status: string }>(
-      `${API_BASE_URL}/health`,
+  // Save the user's colour or visibility override for a planning, and apply it to the cached plannings
+  async setPlanningPreference(id: string, preference: PlanningPreference): Promise<void> {
+    await client.setPlanningPreference(id, preference, { 'X-User-ID': getUserId() });
+
+    const cached = apiCache.get<Planning[]>('plannings');
+    if (cached) {
+      const updated = cached.map(planning => {
+        if (planning.id !== id) {
+          return planning;
+        }
+        const ownColor = planning.default_color ?? planning.color;
+        return {
+          ...planning,
+          hidden: preference.hidden ?? planning.hidden_by_default ?? false,
+          color: preference.color ?? ownColor,
+          default_color: preference.color ? ownColor : undefined
+        };
+      });
+      apiCache.set('plannings', updated, CACHE_DURATIONS.PLANNINGS);
+    }
+  }
+
+  async checkHealth(options?: CachedApiOptions): Promise<Record<string, string>> {
+    return this.fetchWithCache(
+      api => api.getHealth(),
       'health',
       CACHE_DURATIONS.HEALTH,
       options
@@ -250,7 +398,7 @@ export const api = {
     return cachedApi.getDefaultPlanning();
   },
 
-  async checkHealth(): Promise<{ status: string }> {
+  async checkHealth(): Promise<Record<string, string>> {
     return cachedApi.checkHealth();
   }
 };
@@ -0,0 +1,587 @@
+// Code generated by backend/cmd/tsclient from backend/docs/swagger.json. DO NOT EDIT.
+// Regenerate with make client in backend/ after changing the API.
+
+export const API_PREFIX = '/api/v1';
+
+export interface BusyPeriod {
+  end: string;
+  start: string;
+}
+
+export interface ChangeResponse {
+  created: string;
+  event_id?: string;
+  id: number;
+  planning_id: string;
+  sync_run_id?: number;
+  type: string;
+}
+
+export interface ConflictReportResponse {
+  conflicts: ConflictResponse[];
+  end: string;
+  planning_ids: string[];
+  scope: string;
+  start: string;
+}
+
+export interface ConflictResponse {
+  end: string;
+  events: EventResponse[];
+  same_planning: boolean;
+  start: string;
+}
+
+export interface DeltaResponse {
+  /** Deleted holds the IDs of events removed since the token */
+  deleted: string[];
+  /** DeletedPlannings holds the IDs of plannings removed since the token; their events are gone too */
+  deleted_plannings: string[];
+  /** Events holds the current state of events created or updated since the token */
+  events: EventResponse[];
+  /** Full is true when the response is a snapshot that replaces the client's copy */
+  full: boolean;
+  /** HasMore is true when more changes are pending; request again with the new token right away */
+  has_more: boolean;
+  /** Plannings holds the current state of plannings created or changed since the token */
+  plannings: PlanningResponse[];
+  /** Token is passed as since on the next request */
+  token: string;
+}
+
+export interface DuplicateGroupResponse {
+  events: EventResponse[];
+  /** Primary is the ID of the event kept when duplicates are merged */
+  primary: string;
+  /** Score is the lowest summary similarity between the primary event and the others, from 0 to 1 */
+  score: number;
+}
+
+export interface DuplicateReportResponse {
+  end: string;
+  groups: DuplicateGroupResponse[];
+  planning_ids: string[];
+  start: string;
+}
+
+export interface EventResponse {
+  all_day: boolean;
+  conflicts?: string[];
+  created: string;
+  description: string;
+  /** DuplicateOf is the ID of the event this one duplicates in another planning */
+  duplicate_of?: string;
+  /** Duplicates lists the IDs of the events merged into this one */
+  duplicates?: string[];
+  end_date?: string;
+  end_local?: string;
+  end_time: string;
+  id: string;
+  last_modified: string;
+  location: string;
+  planning?: PlanningResponse;
+  planning_id: string;
+  source_id?: string;
+  start_date?: string;
+  start_local?: string;
+  start_time: string;
+  status?: string;
+  summary: string;
+  timezone: string;
+  transparency?: string;
+  uid: string;
+}
+
+export interface FindSlotsRequest {
+  buffer_minutes?: number;
+  duration_minutes: number;
+  include_all_day?: boolean;
+  max_results?: number;
+  plannings?: string[];
+  step_minutes?: number;
+  window: TimeWindowRequest;
+  working_hours: WorkingHoursRequest;
+}
+
+export interface FindSlotsResponse {
+  planning_ids: string[];
+  slots: SlotResponse[];
+  timezone: string;
+}
+
+export interface FreeBusyResponse {
+  busy: BusyPeriod[];
+  end: string;
+  planning_ids: string[];
+  start: string;
+}
+
+export interface HealthCheck {
+  error?: string;
+  /** LatencyMs is how long the check took */
+  latency_ms: number;
+  status: string;
+}
+
+export interface PlanningGroup {
+  created: string;
+  id: number;
+  name: string;
+  sort_order: number;
+  updated: string;
+}
+
+export interface PlanningGroupRequest {
+  name: string;
+  sort_order?: number;
+}
+
+export interface PlanningResponse {
+  color: string;
+  created: string;
+  /** DefaultColor is the planning's own colour when the requesting user overrides it */
+  default_color?: string;
+  description: string;
+  event_count?: number;
+  /** GroupID and GroupName identify the group the planning is sorted into, if any */
+  group_id: number | null;
+  group_name?: string;
+  /** Hidden is whether the requesting user sees the planning as hidden */
+  hidden: boolean;
+  hidden_by_default: boolean;
+  id: string;
+  is_default: boolean;
+  name: string;
+  /** SortOrder orders plannings within their group */
+  sort_order: number;
+  timezone?: string;
+  updated: string;
+}
+
+export interface PlanningSettings {
+  group_id: number | null;
+  hidden_by_default: boolean;
+  planning_id: string;
+  sort_order: number;
+  updated: string;
+}
+
+export interface PlanningSettingsRequest {
+  /** GroupID is the group of the planning, or null for none */
+  group_id?: number | null;
+  hidden_by_default?: boolean;
+  sort_order?: number;
+}
+
+export interface PlanningSync {
+  /** AgeSeconds is the time elapsed since LastSync */
+  age_seconds: number | null;
+  /** LastStatus is the status of the latest sync run, successful or not */
+  last_status?: string;
+  /** LastSync is when the last successful sync of the planning finished */
+  last_sync: string | null;
+  name: string;
+  planning_id: string;
+  /** Stale is set when the planning was never synced or not for longer than allowed */
+  stale: boolean;
+}
+
+export interface Problem {
+  code: string;
+  detail?: string;
+  /** Instance is the path of the request that failed */
+  instance?: string;
+  /** RequestID is the X-Request-ID of the request, to quote when reporting the problem */
+  request_id?: string;
+  status: number;
+  title: string;
+  /** Type is ProblemTypePrefix followed by Code */
+  type: string;
+}
+
+export interface Readiness {
+  database: HealthCheck;
+  plannings: PlanningSync[];
+  schema: SchemaCheck;
+  status: string;
+  time: string;
+}
+
+export interface SchemaCheck {
+  error?: string;
+  expected: number;
+  /** LatencyMs is how long the check took */
+  latency_ms: number;
+  status: string;
+  version: number;
+}
+
+export interface SlotResponse {
+  end: string;
+  score: number;
+  start: string;
+}
+
+export interface TimeWindowRequest {
+  end: string;
+  start: string;
+}
+
+export interface UserPlanningPreference {
+  color: string | null;
+  hidden: boolean | null;
+  planning_id: string;
+  updated: string;
+  user_id: string;
+}
+
+export interface UserPlanningPreferenceRequest {
+  color?: string | null;
+  hidden?: boolean | null;
+}
+
+export interface WebhookDelivery {
+  attempt: number;
+  change_id: number;
+  created: string;
+  delivery_id: string;
+  duration_ms: number;
+  error?: string;
+  id: number;
+  status_code: number;
+  success: boolean;
+  type: string;
+  webhook_id: string;
+}
+
+export interface WebhookRequest {
+  event_types?: string[];
+  planning_id?: string;
+  /** Secret used to sign payloads; generated when omitted */
+  secret?: string;
+  url: string;
+}
+
+export interface WebhookResponse {
+  active: boolean;
+  created: string;
+  event_types: string[];
+  id: string;
+  planning_id?: string;
+  /** Secret is only returned when the webhook is created */
+  secret?: string;
+  updated: string;
+  url: string;
+}
+
+export interface WorkingHoursRequest {
+  days?: string[];
+  end: string;
+  start: string;
+  timezone?: string;
+}
+
+/** ApiError is thrown for responses outside 2xx, with the problem details the API sent */
+export class ApiError extends Error {
+  readonly status: number;
+  readonly problem: Problem | null;
+
+  constructor(status: number, problem: Problem | null) {
+    super(problem?.detail ?? problem?.title ?? `HTTP error! Status: ${status}`);
+    this.name = 'ApiError';
+    this.status = status;
+    this.problem = problem;
+  }
+}
+
+/** Fetcher sends a request to a path starting with API_PREFIX, like fetch */
+export type Fetcher = (path: string, init: RequestInit) => Promise<Response>;
+
+type Values = Record<string, string | number | boolean | undefined>;
+
+interface RequestOptions {
+  query?: Values;
+  headers?: Values;
+  body?: unknown;
+}
+
+const readProblem = async (response: Response): Promise<Problem | null> => {
+  if (!response.headers.get('Content-Type')?.startsWith('application/problem+json')) {
+    return null;
+  }
+  try {
+    return (await response.json()) as Problem;
+  } catch {
+    return null;
+  }
+};
+
+const request = async <T>(fetcher: Fetcher, method: string, path: string, options: RequestOptions = {}): Promise<T> => {
+  const query = new URLSearchParams();
+  Object.entries(options.query ?? {}).forEach(([name, value]) => {
+    if (value !== undefined) {
+      query.set(name, String(value));
+    }
+  });
+  const headers: Record<string, string> = {};
+  Object.entries(options.headers ?? {}).forEach(([name, value]) => {
+    if (value !== undefined) {
+      headers[name] = String(value);
+    }
+  });
+  if (options.body !== undefined) {
+    headers['Content-Type'] = 'application/json';
+  }
+
+  const search = query.toString();
+  const response = await fetcher(`${API_PREFIX}${path}${search ? `?${search}` : ''}`, {
+    method,
+    headers,
+    body: options.body === undefined ? undefined : JSON.stringify(options.body),
+  });
+  if (!response.ok) {
+    throw new ApiError(response.status, await readProblem(response));
+  }
+  if (response.status === 204) {
+    return undefined as T;
+  }
+  return (await response.json()) as T;
+};
+
+/** Query and header parameters of getChanges */
+export interface GetChangesParams {
+  /** Sync token from a previous response (full snapshot when omitted) */
+  since?: string;
+  /** Comma-separated planning IDs (all plannings when omitted) */
+  plannings?: string;
+  /** IANA timezone for local times (default: X-Timezone header, then the planning's timezone, then UTC) */
+  tz?: string;
+  /** Preferred display timezone */
+  'X-Timezone'?: string;
+}
+
+/** Query and header parameters of getConflicts */
+export interface GetConflictsParams {
+  /** Comma-separated planning IDs (all plannings when omitted) */
+  plannings?: string;
+  /** Window start, RFC 3339 or YYYY-MM-DD (default: today) */
+  start?: string;
+  /** Window end, RFC 3339 or YYYY-MM-DD (default: start + 7 days) */
+  end?: string;
+  /** Which pairs to report: all, same or cross (default: all) */
+  scope?: string;
+  /** IANA timezone used for dates, all-day events and local times (default: X-Timezone header, then UTC) */
+  tz?: string;
+  /** Preferred display timezone */
+  'X-Timezone'?: string;
+  /** Whether all-day events can conflict (default: false) */
+  include_all_day?: boolean;
+}
+
+/** Query and header parameters of getDuplicates */
+export interface GetDuplicatesParams {
+  /** Comma-separated planning IDs (all plannings when omitted) */
+  plannings?: string;
+  /** Window start, RFC 3339 or YYYY-MM-DD (default: today) */
+  start?: string;
+  /** Window end, RFC 3339 or YYYY-MM-DD (default: start + 7 days) */
+  end?: string;
+  /** Minimum summary similarity between 0 and 1 (default: configured, 0.8) */
+  threshold?: number;
+  /** Largest difference between start times and between end times, e.g. 10m (default: configured, 5m) */
+  tolerance?: string;
+  /** IANA timezone used for dates and local times (default: X-Timezone header, then UTC) */
+  tz?: string;
+  /** Preferred display timezone */
+  'X-Timezone'?: string;
+}
+
+/** Query and header parameters of getEvents */
+export interface GetEventsParams {
+  /** IANA timezone for local times (default: X-Timezone header, then the planning's timezone, then UTC) */
+  tz?: string;
+  /** Preferred display timezone */
+  'X-Timezone'?: string;
+  /** List the IDs of overlapping events in each event's conflicts field */
+  include_conflicts?: boolean;
+  /** Conflicts to report: all, same or cross planning (default: all) */
+  scope?: string;
+  /** Whether all-day events can conflict (default: false) */
+  include_all_day?: boolean;
+  /** Duplicates across plannings: show, flag (set duplicate_of) or merge (keep the primary event only) (default: duplicates.policy) */
+  duplicates?: string;
+}
+
+/** Query and header parameters of getEvent */
+export interface GetEventParams {
+  /** IANA timezone for local times (default: X-Timezone header, then the planning's timezone, then UTC) */
+  tz?: string;
+  /** Preferred display timezone */
+  'X-Timezone'?: string;
+}
+
+/** Query and header parameters of getFreeBusy */
+export interface GetFreeBusyParams {
+  /** Comma-separated planning IDs (all plannings when omitted) */
+  plannings?: string;
+  /** Window start, RFC 3339 or YYYY-MM-DD (default: today) */
+  start?: string;
+  /** Window end, RFC 3339 or YYYY-MM-DD (default: start + 7 days) */
+  end?: string;
+  /** IANA timezone used for dates and all-day events (default: X-Timezone header, then UTC) */
+  tz?: string;
+  /** Preferred display timezone */
+  'X-Timezone'?: string;
+  /** Whether all-day events count as busy (default: true) */
+  include_all_day?: boolean;
+  /** Response format: json or ics (default: json, or ics when Accept is text/calendar) */
+  format?: string;
+}
+
+/** Query and header parameters of getPlannings */
+export interface GetPlanningsParams {
+  /** User whose planning preferences apply */
+  'X-User-ID'?: string;
+}
+
+/** Query and header parameters of getDefaultPlanning */
+export interface GetDefaultPlanningParams {
+  /** User whose planning preferences apply */
+  'X-User-ID'?: string;
+}
+
+/** Query and header parameters of getPlanning */
+export interface GetPlanningParams {
+  /** User whose planning preferences apply */
+  'X-User-ID'?: string;
+}
+
+/** Query and header parameters of getPlanningEvents */
+export interface GetPlanningEventsParams {
+  /** IANA timezone for local times (default: X-Timezone header, then the planning's timezone, then UTC) */
+  tz?: string;
+  /** Preferred display timezone */
+  'X-Timezone'?: string;
+  /** List the IDs of overlapping events in each event's conflicts field */
+  include_conflicts?: boolean;
+  /** Comma-separated planning IDs whose events are also checked for conflicts */
+  conflicts_with?: string;
+  /** Whether all-day events can conflict (default: false) */
+  include_all_day?: boolean;
+}
+
+/** Query and header parameters of setPlanningPreference */
+export interface SetPlanningPreferenceParams {
+  /** User the preference belongs to */
+  'X-User-ID': string;
+}
+
+/** Query and header parameters of deletePlanningPreference */
+export interface DeletePlanningPreferenceParams {
+  /** User the preference belongs to */
+  'X-User-ID': string;
+}
+
+/** Query and header parameters of getPlanningEventByUid */
+export interface GetPlanningEventByUidParams {
+  /** IANA timezone for local times (default: X-Timezone header, then the planning's timezone, then UTC) */
+  tz?: string;
+  /** Preferred display timezone */
+  'X-Timezone'?: string;
+}
+
+/** Query and header parameters of getWebhookDeliveries */
+export interface GetWebhookDeliveriesParams {
+  /** Maximum number of attempts to return (default: 50, max: 500) */
+  limit?: number;
+}
+
+/** createClient returns the operations of the API, sending their requests through fetcher */
+export const createClient = (fetcher: Fetcher) => ({
+  /** Get changes since a sync token (GET /changes) */
+  getChanges: (params: GetChangesParams = {}): Promise<DeltaResponse> =>
+    request<DeltaResponse>(fetcher, 'GET', `/changes`, { query: { since: params.since, plannings: params.plannings, tz: params.tz }, headers: { 'X-Timezone': params['X-Timezone'] } }),
+  /** Get overlapping events (GET /conflicts) */
+  getConflicts: (params: GetConflictsParams = {}): Promise<ConflictReportResponse> =>
+    request<ConflictReportResponse>(fetcher, 'GET', `/conflicts`, { query: { plannings: params.plannings, start: params.start, end: params.end, scope: params.scope, tz: params.tz, include_all_day: params.include_all_day }, headers: { 'X-Timezone': params['X-Timezone'] } }),
+  /** Get suspected duplicate events (GET /duplicates) */
+  getDuplicates: (params: GetDuplicatesParams = {}): Promise<DuplicateReportResponse> =>
+    request<DuplicateReportResponse>(fetcher, 'GET', `/duplicates`, { query: { plannings: params.plannings, start: params.start, end: params.end, threshold: params.threshold, tolerance: params.tolerance, tz: params.tz }, headers: { 'X-Timezone': params['X-Timezone'] } }),
+  /** Get all events (GET /events) */
+  getEvents: (params: GetEventsParams = {}): Promise<EventResponse[]> =>
+    request<EventResponse[]>(fetcher, 'GET', `/events`, { query: { tz: params.tz, include_conflicts: params.include_conflicts, scope: params.scope, include_all_day: params.include_all_day, duplicates: params.duplicates }, headers: { 'X-Timezone': params['X-Timezone'] } }),
+  /** Get a specific event (GET /events/{id}) */
+  getEvent: (id: string, params: GetEventParams = {}): Promise<EventResponse> =>
+    request<EventResponse>(fetcher, 'GET', `/events/${encodeURIComponent(String(id))}`, { query: { tz: params.tz }, headers: { 'X-Timezone': params['X-Timezone'] } }),
+  /** Get free/busy information (GET /freebusy) */
+  getFreeBusy: (params: GetFreeBusyParams = {}): Promise<FreeBusyResponse> =>
+    request<FreeBusyResponse>(fetcher, 'GET', `/freebusy`, { query: { plannings: params.plannings, start: params.start, end: params.end, tz: params.tz, include_all_day: params.include_all_day, format: params.format }, headers: { 'X-Timezone': params['X-Timezone'] } }),
+  /** Get API health status (GET /health) */
+  getHealth: (): Promise<Record<string, string>> =>
+    request<Record<string, string>>(fetcher, 'GET', `/health`),
+  /** Liveness probe (GET /health/live) */
+  getLiveness: (): Promise<Record<string, string>> =>
+    request<Record<string, string>>(fetcher, 'GET', `/health/live`),
+  /** Readiness probe (GET /health/ready) */
+  getReadiness: (): Promise<Readiness> =>
+    request<Readiness>(fetcher, 'GET', `/health/ready`),
+  /** Get all planning groups (GET /planning-groups) */
+  getPlanningGroups: (): Promise<PlanningGroup[]> =>
+    request<PlanningGroup[]>(fetcher, 'GET', `/planning-groups`),
+  /** Create a planning group (POST /planning-groups) */
+  createPlanningGroup: (body: PlanningGroupRequest): Promise<PlanningGroup> =>
+    request<PlanningGroup>(fetcher, 'POST', `/planning-groups`, { body }),
+  /** Update a planning group (PUT /planning-groups/{id}) */
+  updatePlanningGroup: (id: number, body: PlanningGroupRequest): Promise<PlanningGroup> =>
+    request<PlanningGroup>(fetcher, 'PUT', `/planning-groups/${encodeURIComponent(String(id))}`, { body }),
+  /** Delete a planning group (DELETE /planning-groups/{id}) */
+  deletePlanningGroup: (id: number): Promise<void> =>
+    request<void>(fetcher, 'DELETE', `/planning-groups/${encodeURIComponent(String(id))}`),
+  /** Get all plannings (GET /plannings) */
+  getPlannings: (params: GetPlanningsParams = {}): Promise<PlanningResponse[]> =>
+    request<PlanningResponse[]>(fetcher, 'GET', `/plannings`, { headers: { 'X-User-ID': params['X-User-ID'] } }),
+  /** Get the default planning (GET /plannings/default) */
+  getDefaultPlanning: (params: GetDefaultPlanningParams = {}): Promise<PlanningResponse> =>
+    request<PlanningResponse>(fetcher, 'GET', `/plannings/default`, { headers: { 'X-User-ID': params['X-User-ID'] } }),
+  /** Get a specific planning (GET /plannings/{id}) */
+  getPlanning: (id: string, params: GetPlanningParams = {}): Promise<PlanningResponse> =>
+    request<PlanningResponse>(fetcher, 'GET', `/plannings/${encodeURIComponent(String(id))}`, { headers: { 'X-User-ID': params['X-User-ID'] } }),
+  /** Get events for a specific planning (GET /plannings/{id}/events) */
+  getPlanningEvents: (id: string, params: GetPlanningEventsParams = {}): Promise<EventResponse[]> =>
+    request<EventResponse[]>(fetcher, 'GET', `/plannings/${encodeURIComponent(String(id))}/events`, { query: { tz: params.tz, include_conflicts: params.include_conflicts, conflicts_with: params.conflicts_with, include_all_day: params.include_all_day }, headers: { 'X-Timezone': params['X-Timezone'] } }),
+  /** Update a user's preference for a planning (PUT /plannings/{id}/preferences) */
+  setPlanningPreference: (id: string, body: UserPlanningPreferenceRequest, params: SetPlanningPreferenceParams): Promise<UserPlanningPreference> =>
+    request<UserPlanningPreference>(fetcher, 'PUT', `/plannings/${encodeURIComponent(String(id))}/preferences`, { headers: { 'X-User-ID': params['X-User-ID'] }, body }),
+  /** Reset a user's preference for a planning (DELETE /plannings/{id}/preferences) */
+  deletePlanningPreference: (id: string, params: DeletePlanningPreferenceParams): Promise<void> =>
+    request<void>(fetcher, 'DELETE', `/plannings/${encodeURIComponent(String(id))}/preferences`, { headers: { 'X-User-ID': params['X-User-ID'] } }),
+  /** Update the settings of a planning (PUT /plannings/{id}/settings) */
+  setPlanningSettings: (id: string, body: PlanningSettingsRequest): Promise<PlanningSettings> =>
+    request<PlanningSettings>(fetcher, 'PUT', `/plannings/${encodeURIComponent(String(id))}/settings`, { body }),
+  /** Get a specific event from a planning (GET /plannings/{planningId}/events/{uid}) */
+  getPlanningEventByUid: (planningId: string, uid: string, params: GetPlanningEventByUidParams = {}): Promise<EventResponse> =>
+    request<EventResponse>(fetcher, 'GET', `/plannings/${encodeURIComponent(String(planningId))}/events/${encodeURIComponent(String(uid))}`, { query: { tz: params.tz }, headers: { 'X-Timezone': params['X-Timezone'] } }),
+  /** Find free meeting slots (POST /scheduling/find-slots) */
+  findSlots: (body: FindSlotsRequest): Promise<FindSlotsResponse> =>
+    request<FindSlotsResponse>(fetcher, 'POST', `/scheduling/find-slots`, { body }),
+  /** Get all webhooks (GET /webhooks) */
+  getWebhooks: (): Promise<WebhookResponse[]> =>
+    request<WebhookResponse[]>(fetcher, 'GET', `/webhooks`),
+  /** Register a webhook (POST /webhooks) */
+  createWebhook: (body: WebhookRequest): Promise<WebhookResponse> =>
+    request<WebhookResponse>(fetcher, 'POST', `/webhooks`, { body }),
+  /** Get a specific webhook (GET /webhooks/{id}) */
+  getWebhook: (id: string): Promise<WebhookResponse> =>
+    request<WebhookResponse>(fetcher, 'GET', `/webhooks/${encodeURIComponent(String(id))}`),
+  /** Delete a webhook (DELETE /webhooks/{id}) */
+  deleteWebhook: (id: string): Promise<void> =>
+    request<void>(fetcher, 'DELETE', `/webhooks/${encodeURIComponent(String(id))}`),
+  /** Get the delivery log of a webhook (GET /webhooks/{id}/deliveries) */
+  getWebhookDeliveries: (id: string, params: GetWebhookDeliveriesParams = {}): Promise<WebhookDelivery[]> =>
+    request<WebhookDelivery[]>(fetcher, 'GET', `/webhooks/${encodeURIComponent(String(id))}/deliveries`, { query: { limit: params.limit } }),
+  /** Send a test payload to a webhook (POST /webhooks/{id}/test) */
+  testWebhook: (id: string): Promise<WebhookDelivery> =>
+    request<WebhookDelivery>(fetcher, 'POST', `/webhooks/${encodeURIComponent(String(id))}/test`),
+});
+
+export type Client = ReturnType<typeof createClient>;
@@ -1,27 +1,20 @@
-export interface Planning {
-  id: string;
-  name: string;
-  description: string;
-  color: string;
-  created: string;
-  updated: string;
-  is_default: boolean;
-  event_count?: number;
-}
+import type {
+  DeltaResponse,
+  EventResponse,
+  PlanningResponse,
+  UserPlanningPreferenceRequest,
+} from '../services/generated/client';
 
-export interface Event {
-  uid: string;
-  planning_id: string;
-  summary: string;
-  description: string;
-  location: string;
-  start_time: string;
-  end_time: string;
-  all_day: boolean;
-  created: string;
-  last_modified: string;
-  planning?: Planning;
-}
+// The shapes the API exchanges are generated from its specification; see services/generated/client.ts
+export type Planning = PlanningResponse;
+
+// Per-user override of a planning's colour or visibility; null falls back to the planning
+export type PlanningPreference = UserPlanningPreferenceRequest;
+
+export type Event = EventResponse;
+
+// Response of GET /api/v1/changes: a full snapshot, or what changed since a sync token
+export type { DeltaResponse };
 
 export interface EventInput {
   planning_id: string;
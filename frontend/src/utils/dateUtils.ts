@@ -1,12 +1,11 @@
 import { Event } from '../types';
 
-export const formatDate = (date: Date, timeZone?: string): string => {
+export const formatDate = (date: Date): string => {
   return new Intl.DateTimeFormat('en-US', {
     weekday: 'long',
     year: 'numeric',
     month: 'long',
-    day: 'numeric',
-    ...(timeZone ? { timeZone } : {})
+    day: 'numeric'
   }).format(date);
 };
 
@@ -182,24 +181,32 @@ export const getWeekendVisibility = (weekDays: Date[], events: Event[]): { showS
   return { showSunday, showSaturday };
 };
 
-// Determine whether an event occurs on the given day (handles multi-day and all-day events)
-export const eventOccursOnDay = (event: Event, day: Date): boolean => {
-  const eventStart = new Date(event.start_time);
-  const eventEnd = new Date(event.end_time);
+// Format a local date as a plain YYYY-MM-DD date
+export const toPlainDate = (date: Date): string => {
+  const month = String(date.getMonth() + 1).padStart(2, '0');
+  const day = String(date.getDate()).padStart(2, '0');
+  return `${date.getFullYear()}-${month}-${day}`;
+};
 
-  if (event.all_day) {
-    // Compare using UTC dates and treat end as exclusive
-    const dayUtc = Date.UTC(day.getUTCFullYear(), day.getUTCMonth(), day.getUTCDate());
-    const startUtc = Date.UTC(eventStart.getUTCFullYear(), eventStart.getUTCMonth(), eventStart.getUTCDate());
-    const endUtc = Date.UTC(eventEnd.getUTCFullYear(), eventEnd.getUTCMonth(), eventEnd.getUTCDate());
-    return dayUtc >= startUtc && dayUtc < endUtc;
-  }
+// Local wall-clock start and end of an event (YYYY-MM-DDTHH:mm:ss), as sent by the API in the
+// browser's timezone; all-day events run from midnight of their first date to midnight of their
+// exclusive end date. Wall-clock strings of one format compare in time order.
+export const localStart = (event: Event): string =>
+  event.all_day ? `${event.start_date}T00:00:00` : event.start_local!;
+
+export const localEnd = (event: Event): string =>
+  event.all_day ? `${event.end_date}T00:00:00` : event.end_local!;
 
-  // For timed events, check for any overlap with the day's local range
-  const dayStartLocal = new Date(day.getFullYear(), day.getMonth(), day.getDate(), 0, 0, 0, 0);
-  const dayEndLocal = new Date(dayStartLocal.getTime() + 24 * 60 * 60 * 1000);
+// Local wall-clock bounds of a day: its midnight and the next one
+const dayBounds = (day: Date): { dayStart: string; dayEnd: string } => ({
+  dayStart: `${toPlainDate(day)}T00:00:00`,
+  dayEnd: `${toPlainDate(addDays(day, 1))}T00:00:00`
+});
 
-  return eventStart < dayEndLocal && eventEnd > dayStartLocal;
+// Determine whether an event occurs on the given day (handles multi-day and all-day events)
+export const eventOccursOnDay = (event: Event, day: Date): boolean => {
+  const { dayStart, dayEnd } = dayBounds(day);
+  return localStart(event) < dayEnd && localEnd(event) > dayStart;
 };
 
 export interface EventDaySegment {
@@ -213,37 +220,25 @@ export const getEventDaySegment = (event: Event, day: Date): EventDaySegment | n
     return null;
   }
 
-  if (event.all_day) {
-    return {
-      mode: 'all-day',
-      start_time: event.start_time,
-      end_time: event.end_time
-    };
-  }
-
-  const eventStart = new Date(event.start_time);
-  const eventEnd = new Date(event.end_time);
-  const dayStart = new Date(day.getFullYear(), day.getMonth(), day.getDate(), 0, 0, 0, 0);
-  const dayEnd = new Date(dayStart.getTime() + 24 * 60 * 60 * 1000);
+  const eventStart = localStart(event);
+  const eventEnd = localEnd(event);
+  const { dayStart, dayEnd } = dayBounds(day);
 
   const startsBeforeDay = eventStart < dayStart;
   const endsAfterDay = eventEnd > dayEnd;
 
-  if (startsBeforeDay && endsAfterDay) {
+  if (event.all_day || (startsBeforeDay && endsAfterDay)) {
     return {
       mode: 'all-day',
-      start_time: event.start_time,
-      end_time: event.end_time
+      start_time: eventStart,
+      end_time: eventEnd
     };
   }
 
-  const startTime = startsBeforeDay ? dayStart : eventStart;
-  const endTime = endsAfterDay ? dayEnd : eventEnd;
-
   return {
     mode: 'timed',
-    start_time: startTime.toISOString(),
-    end_time: endTime.toISOString()
+    start_time: startsBeforeDay ? dayStart : eventStart,
+    end_time: endsAfterDay ? dayEnd : eventEnd
   };
 };
 
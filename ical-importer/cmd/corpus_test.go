@@ -0,0 +1,146 @@
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+var updateCorpus = flag.Bool("update", false, "rewrite the golden event lists of testdata/corpus")
+
+// corpusPlanningID is the planning the corpus events are parsed into
+const corpusPlanningID = "corpus"
+
+// corpusEvent is the golden form of an imported event. Times keep their location, so that
+// a feed parsed in the wrong timezone shows up even when the instant is right.
+type corpusEvent struct {
+	UID          string   `json:"uid"`
+	Summary      string   `json:"summary"`
+	Start        string   `json:"start"`
+	End          string   `json:"end"`
+	AllDay       bool     `json:"all_day,omitempty"`
+	Location     string   `json:"location,omitempty"`
+	Description  string   `json:"description,omitempty"`
+	Status       string   `json:"status,omitempty"`
+	Transparency string   `json:"transparency,omitempty"`
+	Categories   []string `json:"categories,omitempty"`
+	// Created and LastModified are only set when the feed has them; otherwise they are
+	// the time of the import
+	Created      string `json:"created,omitempty"`
+	LastModified string `json:"last_modified,omitempty"`
+}
+
+// corpusTime formats a time with its location
+func corpusTime(t time.Time) string {
+	return t.Format(time.RFC3339) + " " + t.Location().String()
+}
+
+// importCorpusFile parses and expands the events of a feed like the import pipeline does,
+// and returns their golden form
+func importCorpusFile(t *testing.T, path string) []corpusEvent {
+	t.Helper()
+
+	cal, err := parseICalFromFile(path)
+	if err != nil {
+		t.Fatalf("parseICalFromFile returned error: %v", err)
+	}
+
+	// Events without CREATED or LAST-MODIFIED get the time of the import, later than any
+	// time of the corpus
+	imported := time.Now()
+	parsed, overrides := parseEvents(context.Background(), cal, corpusPlanningID)
+
+	events := []corpusEvent{}
+	for _, event := range expandEvents(context.Background(), parsed, overrides, 100) {
+		golden := corpusEvent{
+			UID:          event.UID,
+			Summary:      event.Summary,
+			Start:        corpusTime(event.StartTime),
+			End:          corpusTime(event.EndTime),
+			AllDay:       event.AllDay,
+			Location:     event.Location,
+			Description:  event.Description,
+			Status:       event.Status,
+			Transparency: event.Transparency,
+			Categories:   event.Categories,
+		}
+		if event.Created.Before(imported) {
+			golden.Created = corpusTime(event.Created)
+		}
+		if event.LastModified.Before(imported) {
+			golden.LastModified = corpusTime(event.LastModified)
+		}
+		events = append(events, golden)
+	}
+	return events
+}
+
+// TestImportCorpus imports the feeds of testdata/corpus, saved from real providers and
+// edge cases, and compares the events with the golden lists next to them. Run
+// go test ./cmd -run TestImportCorpus -update after an intended change, and review the diff.
+func TestImportCorpus(t *testing.T) {
+	feeds, err := filepath.Glob(filepath.Join("testdata", "corpus", "*.ics"))
+	if err != nil {
+		t.Fatalf("failed to list the corpus: %v", err)
+	}
+	if len(feeds) == 0 {
+		t.Fatal("the corpus is empty")
+	}
+
+	for _, feed := range feeds {
+		name := strings.TrimSuffix(filepath.Base(feed), ".ics")
+		t.Run(name, func(t *testing.T) {
+			var got bytes.Buffer
+			encoder := json.NewEncoder(&got)
+			encoder.SetEscapeHTML(false)
+			encoder.SetIndent("", "  ")
+			if err := encoder.Encode(importCorpusFile(t, feed)); err != nil {
+				t.Fatalf("failed to encode events: %v", err)
+			}
+
+			golden := strings.TrimSuffix(feed, ".ics") + ".golden.json"
+			if *updateCorpus {
+				if err := os.WriteFile(golden, got.Bytes(), 0o644); err != nil {
+					t.Fatalf("failed to write %s: %v", golden, err)
+				}
+				return
+			}
+
+			want, err := os.ReadFile(golden)
+			if err != nil {
+				t.Fatalf("failed to read %s (run with -update to create it): %v", golden, err)
+			}
+			if !bytes.Equal(got.Bytes(), want) {
+				t.Errorf("events of %s differ from %s:\n%s", feed, golden, lineDiff(string(want), string(got.Bytes())))
+			}
+		})
+	}
+}
+
+// lineDiff lists the lines that differ between two texts
+func lineDiff(want, got string) string {
+	wantLines := strings.Split(want, "\n")
+	gotLines := strings.Split(got, "\n")
+
+	var out strings.Builder
+	for i := 0; i < len(wantLines) || i < len(gotLines); i++ {
+		var w, g string
+		if i < len(wantLines) {
+			w = wantLines[i]
+		}
+		if i < len(gotLines) {
+			g = gotLines[i]
+		}
+		if w != g {
+			fmt.Fprintf(&out, "line %d:\n  want %s\n  got  %s\n", i+1, w, g)
+		}
+	}
+	return out.String()
+}
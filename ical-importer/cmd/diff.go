@@ -0,0 +1,145 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/do2024-2047/CalenDO/ical-importer/internal/importer"
+)
+
+// Dry run output formats
+const (
+	outputTable = "table"
+	outputJSON  = "json"
+	outputText  = "text"
+)
+
+// diffTimeLayout is how event start times are shown in table and text output
+const diffTimeLayout = "2006-01-02 15:04 MST"
+
+// validateOutputFormat checks the value of --output
+func validateOutputFormat(format string) error {
+	switch format {
+	case outputTable, outputJSON, outputText:
+		return nil
+	}
+	return fmt.Errorf("unknown format %q, expected table, json or text", format)
+}
+
+// printDryRunPlans writes the plans collected during a dry run to stdout
+func printDryRunPlans() {
+	if !dryRun {
+		return
+	}
+	if err := writeSyncPlans(os.Stdout, dryRunPlans, outputFormat); err != nil {
+		slog.Warn("Failed to write dry run output", "error", err)
+	}
+}
+
+// writeSyncPlans writes sync plans in the given format
+func writeSyncPlans(w io.Writer, plans []*importer.SyncPlan, format string) error {
+	switch format {
+	case outputJSON:
+		if plans == nil {
+			plans = []*importer.SyncPlan{}
+		}
+		encoder := json.NewEncoder(w)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(plans)
+	case outputTable:
+		return writePlanTable(w, plans)
+	default:
+		return writePlanText(w, plans)
+	}
+}
+
+// writePlanTable writes one row per event change, with one line per changed field
+func writePlanTable(w io.Writer, plans []*importer.SyncPlan) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "PLANNING\tACTION\tSTART\tSUMMARY\tUID\tCHANGES")
+
+	for _, plan := range plans {
+		rows := []struct {
+			action string
+			events []importer.EventDiff
+		}{
+			{"create", plan.Create},
+			{"update", plan.Update},
+			{"delete", plan.Delete},
+		}
+		for _, row := range rows {
+			for _, event := range row.events {
+				changes := make([]string, 0, len(event.Changes))
+				for _, change := range event.Changes {
+					changes = append(changes, change.Field)
+				}
+				fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%s\t%s\n",
+					plan.PlanningID, row.action, formatDiffTime(event.StartTime),
+					event.Summary, event.UID, strings.Join(changes, ","))
+				for _, change := range event.Changes {
+					fmt.Fprintf(tw, "\t\t\t\t\t%s: %q -> %q\n", change.Field, change.Old, change.New)
+				}
+			}
+		}
+	}
+
+	if err := tw.Flush(); err != nil {
+		return err
+	}
+
+	for _, plan := range plans {
+		fmt.Fprintf(w, "%s: %d to create, %d to update, %d to delete, %d unchanged\n",
+			plan.PlanningID, len(plan.Create), len(plan.Update), len(plan.Delete), plan.Unchanged)
+		if plan.Aborted != "" {
+			fmt.Fprintf(w, "%s: sync would be aborted: %s\n", plan.PlanningID, plan.Aborted)
+		}
+	}
+	return nil
+}
+
+// writePlanText writes a unified-diff-like listing: + for created events, - for deleted
+// events and ~ for updated events followed by the old and new value of each changed field
+func writePlanText(w io.Writer, plans []*importer.SyncPlan) error {
+	for _, plan := range plans {
+		state := "existing"
+		if plan.NewPlanning {
+			state = "new"
+		}
+		fmt.Fprintf(w, "--- planning %s (%s, %s)\n", plan.PlanningID, plan.PlanningName, state)
+		fmt.Fprintf(w, "+++ %s\n", plan.Source)
+		fmt.Fprintf(w, "@@ %d to create, %d to update, %d to delete, %d unchanged @@\n",
+			len(plan.Create), len(plan.Update), len(plan.Delete), plan.Unchanged)
+		if plan.Aborted != "" {
+			fmt.Fprintf(w, "!! sync would be aborted: %s\n", plan.Aborted)
+		}
+
+		for _, event := range plan.Create {
+			fmt.Fprintf(w, "+ %s  %s  [%s]\n", formatDiffTime(event.StartTime), event.Summary, event.UID)
+		}
+		for _, event := range plan.Update {
+			fmt.Fprintf(w, "~ %s  %s  [%s]\n", formatDiffTime(event.StartTime), event.Summary, event.UID)
+			for _, change := range event.Changes {
+				fmt.Fprintf(w, "-   %s: %s\n", change.Field, change.Old)
+				fmt.Fprintf(w, "+   %s: %s\n", change.Field, change.New)
+			}
+		}
+		for _, event := range plan.Delete {
+			fmt.Fprintf(w, "- %s  %s  [%s]\n", formatDiffTime(event.StartTime), event.Summary, event.UID)
+		}
+	}
+	return nil
+}
+
+// formatDiffTime formats an event start time for table and text output
+func formatDiffTime(t time.Time) string {
+	if t.IsZero() {
+		return "-"
+	}
+	return t.Format(diffTimeLayout)
+}
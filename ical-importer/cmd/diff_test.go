@@ -0,0 +1,75 @@
+package cmd
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/do2024-2047/CalenDO/ical-importer/internal/importer"
+	"github.com/do2024-2047/CalenDO/ical-importer/internal/models"
+)
+
+func testSyncPlan() *importer.SyncPlan {
+	start := time.Date(2026, time.May, 4, 9, 0, 0, 0, time.UTC)
+	return &importer.SyncPlan{
+		PlanningID:   "ical-1234",
+		PlanningName: "Timetable",
+		Source:       "https://example.com/calendar.ics",
+		Create:       []importer.EventDiff{{ID: "new_ical-1234", UID: "new", Summary: "Exam", StartTime: start}},
+		Update: []importer.EventDiff{{
+			ID: "lab_ical-1234", UID: "lab", Summary: "Lab session", StartTime: start,
+			Changes: []models.FieldChange{{Field: "summary", Old: "Lab", New: "Lab session"}},
+		}},
+		Delete:    []importer.EventDiff{{ID: "old_ical-1234", UID: "old", Summary: "Cancelled", StartTime: start}},
+		Unchanged: 3,
+	}
+}
+
+func TestWriteSyncPlansText(t *testing.T) {
+	var buf bytes.Buffer
+	if err := writeSyncPlans(&buf, []*importer.SyncPlan{testSyncPlan()}, outputText); err != nil {
+		t.Fatalf("writeSyncPlans returned error: %v", err)
+	}
+
+	output := buf.String()
+	for _, want := range []string{
+		"--- planning ical-1234 (Timetable, existing)",
+		"@@ 1 to create, 1 to update, 1 to delete, 3 unchanged @@",
+		"+ 2026-05-04 09:00 UTC  Exam  [new]",
+		"~ 2026-05-04 09:00 UTC  Lab session  [lab]",
+		"-   summary: Lab\n+   summary: Lab session",
+		"- 2026-05-04 09:00 UTC  Cancelled  [old]",
+	} {
+		if !strings.Contains(output, want) {
+			t.Fatalf("text output does not contain %q:\n%s", want, output)
+		}
+	}
+}
+
+func TestWriteSyncPlansJSON(t *testing.T) {
+	var buf bytes.Buffer
+	if err := writeSyncPlans(&buf, []*importer.SyncPlan{testSyncPlan()}, outputJSON); err != nil {
+		t.Fatalf("writeSyncPlans returned error: %v", err)
+	}
+
+	var plans []importer.SyncPlan
+	if err := json.Unmarshal(buf.Bytes(), &plans); err != nil {
+		t.Fatalf("output is not valid JSON: %v", err)
+	}
+	if len(plans) != 1 || len(plans[0].Update) != 1 || plans[0].Update[0].Changes[0].New != "Lab session" {
+		t.Fatalf("decoded plans = %+v, want the written plan", plans)
+	}
+}
+
+func TestValidateOutputFormat(t *testing.T) {
+	for _, format := range []string{outputTable, outputJSON, outputText} {
+		if err := validateOutputFormat(format); err != nil {
+			t.Fatalf("validateOutputFormat(%q) returned error: %v", format, err)
+		}
+	}
+	if err := validateOutputFormat("yaml"); err == nil {
+		t.Fatal("validateOutputFormat(\"yaml\") returned no error")
+	}
+}
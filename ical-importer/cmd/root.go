@@ -1,25 +1,37 @@
 package cmd
 
 import (
+	"context"
 	"crypto/sha256"
 	"encoding/hex"
 	"fmt"
-	"log"
+	"io"
+	"log/slog"
 	"net/http"
 	"net/url"
 	"os"
+	"os/signal"
 	"path/filepath"
+	"sort"
 	"strings"
+	"syscall"
 	"time"
 
 	"github.com/do2024-2047/CalenDO/ical-importer/internal/database"
 	"github.com/do2024-2047/CalenDO/ical-importer/internal/importer"
+	"github.com/do2024-2047/CalenDO/ical-importer/internal/logging"
+	"github.com/do2024-2047/CalenDO/ical-importer/internal/metrics"
 	"github.com/do2024-2047/CalenDO/ical-importer/internal/models"
+	"github.com/do2024-2047/CalenDO/ical-importer/internal/rules"
+	"github.com/do2024-2047/CalenDO/ical-importer/internal/tracing"
 	"github.com/emersion/go-ical"
 	"github.com/google/uuid"
 	"github.com/spf13/cobra"
 	"github.com/spf13/viper"
 	"github.com/teambition/rrule-go"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
 	"gopkg.in/yaml.v3"
 )
 
@@ -29,8 +41,33 @@ var (
 	customName string
 	customID   string
 	syncDelete bool // New flag to control deletion behavior
+
+	// Dry run output
+	outputFormat string
+	dryRunPlans  []*importer.SyncPlan
+
+	// Mass-deletion guard flags
+	force          bool
+	maxDeleteRatio float64
+
+	// Restore, runs and purge command flags
+	restorePlanning string
+	restoreRun      uint64
+	runsPlanning    string
+	runsLimit       int
+	purgeOlderThan  time.Duration
+
+	// shutdownTracing flushes the spans of the syncs before exiting
+	shutdownTracing = func(context.Context) error { return nil }
 )
 
+// defaultRetention is how long deleted rows, the change log and sync runs are kept when
+// retention.period is not configured
+const defaultRetention = 30 * 24 * time.Hour
+
+// icalUTCFormat is the iCalendar format of a UTC date-time
+const icalUTCFormat = "20060102T150405Z"
+
 // rootCmd represents the base command when called without any subcommands
 var rootCmd = &cobra.Command{
 	Use:   "ical-importer",
@@ -118,6 +155,41 @@ Configuration options:
 	Run:  runSync,
 }
 
+// runsCmd represents the runs command
+var runsCmd = &cobra.Command{
+	Use:   "runs",
+	Short: "List recent sync runs",
+	Long: `List the most recent sync runs with the number of events each one created,
+updated and deleted. Use a run ID with the restore command to undo it.`,
+	Run: runRuns,
+}
+
+// restoreCmd represents the restore command
+var restoreCmd = &cobra.Command{
+	Use:   "restore",
+	Short: "Restore a planning to its state before a sync run",
+	Long: `Undo a sync run and every later run of the same planning, bringing its events
+back to how they were before the run. Deleted events are kept (soft-deleted) until
+they are purged, so they can be restored within the retention period.
+
+The restore is recorded as a run of its own, so it can be undone in turn.
+
+Examples:
+  ical-importer runs --planning ical-3f2a9c1b7d4e
+  ical-importer restore --planning ical-3f2a9c1b7d4e --before-run 42`,
+	Run: runRestore,
+}
+
+// purgeCmd represents the purge command
+var purgeCmd = &cobra.Command{
+	Use:   "purge",
+	Short: "Permanently remove expired deleted data",
+	Long: `Permanently remove soft-deleted events and plannings, change log entries and
+sync runs older than the retention period (retention.period in the config file,
+30 days by default). Runs older than the retention period can no longer be restored.`,
+	Run: runPurge,
+}
+
 // SyncConfig represents the sync configuration file structure
 type SyncConfig struct {
 	Calendars []CalendarSource `yaml:"calendars"`
@@ -129,6 +201,55 @@ type CalendarSource struct {
 	Enabled  bool   `yaml:"enabled"`
 	CustomID string `yaml:"custom_id,omitempty"` // Optional custom planning ID
 	Color    string `yaml:"color,omitempty"`     // Optional custom color
+
+	// Optional share of the planning's events a sync may delete (default 0.5)
+	MaxDeleteRatio *float64 `yaml:"max_delete_ratio,omitempty"`
+	// Optional permission for a sync to leave the planning empty
+	AllowEmpty bool `yaml:"allow_empty,omitempty"`
+	// Optional rules filtering and transforming events before they are synced
+	Rules []rules.Rule `yaml:"rules,omitempty"`
+	// Optional planning name when several sources share a custom_id (defaults to the source name)
+	PlanningName string `yaml:"planning_name,omitempty"`
+	// Optional skipping of events another source of the same planning already imported
+	Dedupe bool `yaml:"dedupe,omitempty"`
+}
+
+// planningID returns the ID of the planning the source feeds
+func (c CalendarSource) planningID() string {
+	if c.CustomID != "" {
+		return c.CustomID
+	}
+	return generatePlanningID(c.URL)
+}
+
+// planningName returns the name the source gives a planning it shares with other sources
+func (c CalendarSource) planningName() string {
+	if c.PlanningName != "" {
+		return c.PlanningName
+	}
+	return c.Name
+}
+
+// groupSourcesByPlanning returns the enabled sources of each planning, in configuration order
+func groupSourcesByPlanning(sources []CalendarSource) map[string][]CalendarSource {
+	groups := make(map[string][]CalendarSource)
+	for _, source := range sources {
+		if source.Enabled {
+			groups[source.planningID()] = append(groups[source.planningID()], source)
+		}
+	}
+	return groups
+}
+
+// DeletionPolicy returns the mass-deletion guard settings of the source
+func (c CalendarSource) DeletionPolicy() importer.DeletionPolicy {
+	policy := importer.DefaultDeletionPolicy()
+	if c.MaxDeleteRatio != nil {
+		policy.MaxDeleteRatio = *c.MaxDeleteRatio
+	}
+	policy.AllowEmpty = c.AllowEmpty
+	policy.Force = force
+	return policy
 }
 
 func init() {
@@ -137,20 +258,40 @@ func init() {
 	// Global flags
 	rootCmd.PersistentFlags().StringVar(&configFile, "config", "", "config file (default is ./config.yaml)")
 	rootCmd.PersistentFlags().BoolVar(&dryRun, "dry-run", false, "show what would be imported without making changes")
+	rootCmd.PersistentFlags().StringVar(&outputFormat, "output", "text", "dry run output format: table, json or text")
 
 	// Import command specific flags
 	importCmd.Flags().StringVar(&customName, "name", "", "custom name for the planning/calendar")
 	importCmd.Flags().StringVar(&customID, "id", "", "custom ID for the planning/calendar")
 	importCmd.Flags().BoolVar(&syncDelete, "sync-delete", true, "delete events that are no longer in the iCal feed")
+	importCmd.Flags().BoolVar(&force, "force", false, "delete events even when the sync would remove most of the planning")
+	importCmd.Flags().Float64Var(&maxDeleteRatio, "max-delete-ratio", importer.DefaultMaxDeleteRatio, "largest share of a planning's events a sync may delete")
 
 	// Sync command specific flags
 	syncCmd.Flags().BoolVar(&syncDelete, "sync-delete", true, "delete events that are no longer in the iCal feed")
+	syncCmd.Flags().BoolVar(&force, "force", false, "delete events even when the sync would remove most of a planning")
+
+	// Runs command specific flags
+	runsCmd.Flags().StringVar(&runsPlanning, "planning", "", "only list runs of this planning")
+	runsCmd.Flags().IntVar(&runsLimit, "limit", 20, "maximum number of runs to list")
+
+	// Restore command specific flags
+	restoreCmd.Flags().StringVar(&restorePlanning, "planning", "", "ID of the planning to restore")
+	restoreCmd.Flags().Uint64Var(&restoreRun, "before-run", 0, "restore the state before this sync run")
+	restoreCmd.MarkFlagRequired("planning")
+	restoreCmd.MarkFlagRequired("before-run")
+
+	// Purge command specific flags
+	purgeCmd.Flags().DurationVar(&purgeOlderThan, "older-than", 0, "override the configured retention period (e.g. 720h)")
 
 	// Add subcommands
 	rootCmd.AddCommand(importCmd)
 	rootCmd.AddCommand(initCmd)
 	rootCmd.AddCommand(statsCmd)
 	rootCmd.AddCommand(syncCmd)
+	rootCmd.AddCommand(runsCmd)
+	rootCmd.AddCommand(restoreCmd)
+	rootCmd.AddCommand(purgeCmd)
 }
 
 // Execute adds all child commands to the root command and sets flags appropriately
@@ -158,6 +299,12 @@ func Execute() error {
 	return rootCmd.Execute()
 }
 
+// fatal logs an error and exits with status 1
+func fatal(msg string, args ...any) {
+	slog.Error(msg, args...)
+	os.Exit(1)
+}
+
 // initConfig reads in config file and ENV variables if set
 func initConfig() {
 	if configFile != "" {
@@ -180,16 +327,36 @@ func initConfig() {
 	viper.BindEnv("database.port", "DATABASE_PORT")
 	viper.BindEnv("database.driver", "DATABASE_DRIVER")
 	viper.BindEnv("database.sslmode", "DATABASE_SSLMODE")
+	viper.BindEnv("metrics.textfile", "METRICS_TEXTFILE")
+	viper.BindEnv("metrics.pushgateway", "METRICS_PUSHGATEWAY")
+	viper.BindEnv("logging.level", "LOG_LEVEL")
+	viper.BindEnv("logging.format", "LOG_FORMAT")
+	viper.BindEnv("tracing.exporter", "TRACING_EXPORTER")
+	viper.BindEnv("tracing.endpoint", "TRACING_ENDPOINT")
 
 	// If a config file is found, read it in
-	if err := viper.ReadInConfig(); err == nil {
-		log.Printf("Using config file: %s", viper.ConfigFileUsed())
-	} else {
-		log.Printf("Warning: Could not read config file: %v", err)
+	configErr := viper.ReadInConfig()
+	if configErr != nil {
 		// Set default database configuration
 		setDefaultConfig()
 	}
 
+	// Log as configured from here on
+	if _, err := logging.Setup(logging.ConfigFromViper()); err != nil {
+		fatal("Failed to initialize logging", "error", err)
+	}
+	if configErr == nil {
+		slog.Info("Using config file", "file", viper.ConfigFileUsed())
+	} else {
+		slog.Warn("Could not read config file", "error", configErr)
+	}
+
+	shutdown, err := tracing.Setup(context.Background(), tracing.ConfigFromViper())
+	if err != nil {
+		fatal("Failed to initialize tracing", "error", err)
+	}
+	shutdownTracing = shutdown
+
 	// Override config with environment variables after reading the file
 	if dbPassword := os.Getenv("DATABASE_PASSWORD"); dbPassword != "" {
 		viper.Set("database.password", dbPassword)
@@ -225,12 +392,17 @@ func setDefaultConfig() {
 	viper.SetDefault("database.max_open_conns", 25)
 	viper.SetDefault("database.max_idle_conns", 5)
 	viper.SetDefault("database.conn_max_lifetime", "5m")
+	viper.SetDefault("retention.period", defaultRetention)
 }
 
 func runImport(cmd *cobra.Command, args []string) {
+	if err := validateOutputFormat(outputFormat); err != nil {
+		fatal("Invalid --output", "error", err)
+	}
+
 	// Initialize database
 	if err := database.Initialize(); err != nil {
-		log.Fatalf("Failed to initialize database: %v", err)
+		fatal("Failed to initialize database", "error", err)
 	}
 	defer database.Close()
 
@@ -238,33 +410,117 @@ func runImport(cmd *cobra.Command, args []string) {
 
 	// Validate that if multiple sources are provided, no custom name/ID is used
 	if len(args) > 1 && (customName != "" || customID != "") {
-		log.Fatalf("Custom name and ID can only be used with a single source")
+		fatal("Custom name and ID can only be used with a single source")
 	}
 
-	for _, source := range args {
-		log.Printf("Processing source: %s", source)
+	ctx, stop := interruptContext()
+	defer stop()
+
+	for i, source := range args {
+		if ctx.Err() != nil {
+			slog.Warn("Interrupted, skipped the remaining sources", "skipped", len(args)-i)
+			break
+		}
+
+		slog.Info("Processing source", "source", source)
 
 		// Parse and import the iCal source
-		if err := processICalSourceWithCustomization(importerService, source, customName, customID, ""); err != nil {
-			log.Printf("Failed to process source %s: %v", source, err)
+		opts := sourceOptions{
+			Name:   customName,
+			ID:     customID,
+			Policy: importer.DeletionPolicy{MaxDeleteRatio: maxDeleteRatio, Force: force},
+		}
+		if err := processICalSourceWithCustomization(ctx, importerService, source, opts); err != nil {
+			slog.Error("Failed to process source", "source", source, "error", err)
 			continue
 		}
 
-		log.Printf("Successfully processed source: %s", source)
+		slog.Info("Processed source", "source", source)
 	}
 
-	log.Println("Import completed!")
+	slog.Info("Import completed")
+	printDryRunPlans()
+	flushMetrics()
+	flushTraces()
+}
+
+// sourceOptions customizes how a source is imported
+type sourceOptions struct {
+	Name   string // Custom planning name
+	ID     string // Custom planning ID
+	Color  string // Custom planning color
+	Policy importer.DeletionPolicy
+	Rules  *rules.Set
+	// MergedFrom names every source of the planning when several sources feed it
+	MergedFrom []string
+	// Dedupe drops events another source of the planning already imported
+	Dedupe bool
 }
 
-func processICalSourceWithCustomization(importerService *importer.Importer, source, customName, customID, customColor string) error {
+// processICalSourceWithCustomization imports one source. Cancelling ctx interrupts the
+// fetch and stops the import before anything is written; once writing has started, the
+// sync is carried out to the end so that it is never left halfway.
+func processICalSourceWithCustomization(ctx context.Context, importerService *importer.Importer, source string, opts sourceOptions) (err error) {
+	// Determine the final planning ID
+	var finalPlanningID string
+	if opts.ID != "" {
+		finalPlanningID = opts.ID
+	} else {
+		finalPlanningID = generatePlanningID(source)
+	}
+
+	// Each source only owns, and may only delete, the events it imported
+	sourceID := generateSourceID(source)
+
+	// Every record logged during the sync names the planning and source
+	ctx = logging.With(ctx, "planning_id", finalPlanningID, "source_id", sourceID)
+
+	// Record the outcome of the sync once everything, the run included, is finished
+	started := time.Now()
+	var run *models.SyncRun
+	var fetched int64
+	eventCount := 0
+	defer func() {
+		if dryRun {
+			return
+		}
+		result := metrics.Sync{
+			PlanningID: finalPlanningID,
+			SourceID:   sourceID,
+			Duration:   time.Since(started),
+			FetchBytes: fetched,
+			Parsed:     eventCount,
+			Err:        err,
+		}
+		if run != nil {
+			result.Created, result.Updated, result.Deleted = run.Created, run.Updated, run.Deleted
+		}
+		metrics.ObserveSync(result)
+	}()
+
+	// Trace the stages of the sync, with their SQL statements
+	ctx, span := tracing.Tracer().Start(ctx, "sync source", trace.WithAttributes(
+		attribute.String("calendo.planning_id", finalPlanningID),
+		attribute.String("calendo.source_id", sourceID),
+	))
+	defer func() {
+		if err != nil {
+			span.RecordError(err)
+			span.SetStatus(codes.Error, err.Error())
+		}
+		span.End()
+	}()
+	importerService = importerService.WithContext(context.WithoutCancel(ctx))
+
 	// Parse the source to determine if it's a URL or file path
 	var cal *ical.Calendar
-	var err error
 	var planningName string
 
+	_, fetchSpan := tracing.Tracer().Start(ctx, "fetch")
 	if strings.HasPrefix(source, "http://") || strings.HasPrefix(source, "https://") {
-		cal, err = fetchICalFromURL(source)
+		cal, fetched, err = fetchICalFromURL(ctx, source)
 		if err != nil {
+			fetchSpan.End()
 			return fmt.Errorf("failed to fetch iCal from URL: %w", err)
 		}
 		planningName = extractNameFromURL(source)
@@ -272,6 +528,7 @@ func processICalSourceWithCustomization(importerService *importer.Importer, sour
 		filePath := strings.TrimPrefix(source, "file://")
 		cal, err = parseICalFromFile(filePath)
 		if err != nil {
+			fetchSpan.End()
 			return fmt.Errorf("failed to parse iCal file: %w", err)
 		}
 		planningName = extractNameFromFile(filePath)
@@ -279,16 +536,19 @@ func processICalSourceWithCustomization(importerService *importer.Importer, sour
 		// Assume it's a local file path
 		cal, err = parseICalFromFile(source)
 		if err != nil {
+			fetchSpan.End()
 			return fmt.Errorf("failed to parse iCal file: %w", err)
 		}
 		planningName = extractNameFromFile(source)
 	}
+	fetchSpan.SetAttributes(attribute.Int64("calendo.fetch_bytes", fetched))
+	fetchSpan.End()
 
 	// Determine the final planning name
 	var finalPlanningName string
-	if customName != "" {
+	if opts.Name != "" {
 		// Use custom name if provided
-		finalPlanningName = customName
+		finalPlanningName = opts.Name
 	} else {
 		// Try to get name from calendar's X-WR-CALNAME property, fallback to extracted name
 		finalPlanningName = planningName
@@ -297,18 +557,10 @@ func processICalSourceWithCustomization(importerService *importer.Importer, sour
 		}
 	}
 
-	// Determine the final planning ID
-	var finalPlanningID string
-	if customID != "" {
-		finalPlanningID = customID
-	} else {
-		finalPlanningID = generatePlanningID(source)
-	}
-
 	// Determine the final color
 	var finalColor string
-	if customColor != "" {
-		finalColor = customColor
+	if opts.Color != "" {
+		finalColor = opts.Color
 	} else {
 		finalColor = generateRandomColor()
 	}
@@ -320,105 +572,164 @@ func processICalSourceWithCustomization(importerService *importer.Importer, sour
 		Description: generateCalendarDescription(cal, source),
 		Color:       finalColor,
 		IsDefault:   false,
+		Timezone:    extractCalendarTimezone(ctx, cal),
 	}
 
-	if dryRun {
-		log.Printf("[DRY RUN] Would create planning: %s (%s)", planning.Name, planning.ID)
-	} else {
-		if err := importerService.CreateOrUpdatePlanning(planning); err != nil {
-			return fmt.Errorf("failed to create planning: %w", err)
+	if len(opts.MergedFrom) > 1 {
+		// Keep the planning stable whichever of its sources syncs last
+		planning.Description = fmt.Sprintf("Merged from %d sources: %s", len(opts.MergedFrom), strings.Join(opts.MergedFrom, ", "))
+		if existing, err := importerService.GetPlanningByID(planning.ID); err == nil && existing.Timezone != "" {
+			planning.Timezone = existing.Timezone
 		}
-		log.Printf("Created/Updated planning: %s (%s)", planning.Name, planning.ID)
 	}
 
-	// Process events
-	var allNewEvents []*models.Event
-	eventCount := 0
-	for _, child := range cal.Children {
-		if child.Name == "VEVENT" {
-			baseEvent, err := parseEvent(child, planning.ID)
-			if err != nil {
-				log.Printf("Warning: Failed to parse event: %v", err)
-				continue
-			}
+	// Parse events
+	_, parseSpan := tracing.Tracer().Start(ctx, "parse")
+	parsed, overrides := parseEvents(ctx, cal, planning.ID)
+	parseSpan.SetAttributes(attribute.Int("calendo.events", len(parsed)))
+	parseSpan.End()
+
+	// Expand recurring events
+	_, expandSpan := tracing.Tracer().Start(ctx, "expand")
+	allNewEvents := expandEvents(ctx, parsed, overrides, 100) // Max 100 occurrences per event
+	eventCount = len(allNewEvents)
+	expandSpan.SetAttributes(attribute.Int("calendo.occurrences", eventCount))
+	expandSpan.End()
+
+	// Apply the source's rules before anything is compared or written
+	if opts.Rules != nil {
+		allNewEvents = opts.Rules.Apply(allNewEvents)
+		if dropped := eventCount - len(allNewEvents); dropped > 0 {
+			slog.InfoContext(ctx, "Rules dropped events", "dropped", dropped, "events", eventCount)
+		}
+		eventCount = len(allNewEvents)
+	}
 
-			// Expand recurring events
-			events, err := expandRecurringEvent(baseEvent, child, 100) // Max 100 occurrences
-			if err != nil {
-				log.Printf("Warning: Failed to expand recurring event %s: %v", baseEvent.Summary, err)
-				continue
-			}
+	for _, event := range allNewEvents {
+		event.SourceID = sourceID
+	}
 
-			// Collect all events for sync
-			allNewEvents = append(allNewEvents, events...)
-			eventCount += len(events)
+	if opts.Dedupe {
+		deduped, err := importerService.DropCrossSourceDuplicates(planning.ID, sourceID, allNewEvents)
+		if err != nil {
+			return fmt.Errorf("failed to check duplicates: %w", err)
 		}
+		if dropped := len(allNewEvents) - len(deduped); dropped > 0 {
+			slog.InfoContext(ctx, "Skipped events already imported into the planning by another source", "skipped", dropped)
+		}
+		allNewEvents = deduped
+		eventCount = len(allNewEvents)
+	}
+
+	// Everything from here on compares with or writes to the database, so stop now when
+	// asked to exit, and never after
+	if err := ctx.Err(); err != nil {
+		return fmt.Errorf("sync interrupted: %w", err)
 	}
+	syncCtx, syncSpan := tracing.Tracer().Start(ctx, "sync", trace.WithAttributes(attribute.Int("calendo.events", len(allNewEvents))))
+	defer syncSpan.End()
+	importerService = importerService.WithContext(context.WithoutCancel(syncCtx))
 
 	if dryRun {
-		log.Printf("[DRY RUN] Would sync %d events for planning: %s", eventCount, planning.Name)
+		slog.InfoContext(ctx, "[DRY RUN] Would create planning", "planning_name", planning.Name)
+	} else {
+		// Record the run so that its changes can be undone with the restore command. Its
+		// outcome is the error this function returns, and its counts go to the metrics.
+		if begun, beginErr := importerService.BeginRun(planning.ID, source); beginErr != nil {
+			slog.WarnContext(ctx, "Failed to start sync run", "error", beginErr)
+		} else {
+			// From here on, records also name the run
+			syncCtx = logging.With(syncCtx, "run_id", begun.ID)
+			ctx = logging.With(ctx, "run_id", begun.ID)
+			importerService = importerService.WithContext(context.WithoutCancel(syncCtx))
+			slog.InfoContext(ctx, "Started sync run")
+			run = begun
+			defer func() { run = importerService.FinishRun(err) }()
+		}
 
-		// In dry run mode, show what would be deleted (only if sync-delete is enabled)
+		// Check the deletions before touching the planning, so an aborted sync changes nothing
 		if syncDelete {
-			existingEvents, err := importerService.GetEventsByPlanningID(planning.ID)
-			if err == nil {
-				newEventUIDs := make(map[string]bool)
-				for _, event := range allNewEvents {
-					newEventUIDs[event.UID] = true
-				}
-
-				deletionCount := 0
-				for _, existingEvent := range existingEvents {
-					if !newEventUIDs[existingEvent.UID] {
-						log.Printf("[DRY RUN] Would delete event no longer in iCal: %s (%s)", existingEvent.Summary, existingEvent.UID)
-						deletionCount++
-					}
-				}
-				if deletionCount > 0 {
-					log.Printf("[DRY RUN] Would delete %d events no longer in iCal", deletionCount)
-				}
+			if err := importerService.CheckSync(planning.ID, sourceID, allNewEvents, opts.Policy); err != nil {
+				return fmt.Errorf("sync aborted: %w", err)
 			}
-		} else {
-			log.Printf("[DRY RUN] Sync-delete disabled - would only add/update events")
 		}
+
+		if err := importerService.CreateOrUpdatePlanning(planning); err != nil {
+			return fmt.Errorf("failed to create planning: %w", err)
+		}
+		slog.InfoContext(ctx, "Created or updated planning", "planning_name", planning.Name)
+	}
+
+	if dryRun {
+		plan, err := importerService.PlanSync(planning, source, sourceID, allNewEvents, syncDelete, opts.Policy)
+		if err != nil {
+			return fmt.Errorf("failed to plan sync: %w", err)
+		}
+		slog.InfoContext(ctx, "[DRY RUN] Would sync events", "events", eventCount,
+			"create", len(plan.Create), "update", len(plan.Update), "delete", len(plan.Delete), "unchanged", plan.Unchanged)
+		if plan.Aborted != "" {
+			slog.WarnContext(ctx, "[DRY RUN] Sync would be aborted", "reason", plan.Aborted)
+		}
+		if !syncDelete {
+			slog.InfoContext(ctx, "[DRY RUN] Sync-delete disabled, would only add and update events")
+		}
+		dryRunPlans = append(dryRunPlans, plan)
 	} else {
 		// Sync events based on sync-delete flag
 		if syncDelete {
 			// Sync events (add/update new ones, delete removed ones)
-			if err := importerService.SyncEventsForPlanning(planning.ID, allNewEvents); err != nil {
+			if err := importerService.SyncEventsForPlanning(planning.ID, sourceID, allNewEvents, opts.Policy); err != nil {
 				return fmt.Errorf("failed to sync events: %w", err)
 			}
-			log.Printf("Synced %d events for planning: %s", eventCount, planning.Name)
+			slog.InfoContext(ctx, "Synced events", "events", eventCount)
 		} else {
 			// Legacy mode: only add/update events, don't delete
 			for _, event := range allNewEvents {
 				if err := importerService.CreateOrUpdateEvent(event); err != nil {
-					log.Printf("Warning: Failed to import event %s: %v", event.Summary, err)
+					slog.WarnContext(ctx, "Failed to import event", "event_uid", event.UID, "error", err)
 				}
 			}
-			log.Printf("Imported %d events for planning: %s", eventCount, planning.Name)
+			slog.InfoContext(ctx, "Imported events", "events", eventCount)
 		}
 	}
 
 	// Generate and log calendar description
-	desc := generateCalendarDescription(cal, source)
-	log.Printf("Calendar description:\n%s", desc)
+	slog.DebugContext(ctx, "Calendar description", "description", generateCalendarDescription(cal, source))
 
 	return nil
 }
 
-func fetchICalFromURL(url string) (*ical.Calendar, error) {
-	resp, err := http.Get(url)
+// fetchICalFromURL downloads and decodes an iCal feed, returning the number of bytes read
+func fetchICalFromURL(ctx context.Context, url string) (*ical.Calendar, int64, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
 	if err != nil {
-		return nil, err
+		return nil, 0, err
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return nil, 0, err
 	}
 	defer resp.Body.Close()
 
 	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("HTTP %d: %s", resp.StatusCode, resp.Status)
+		return nil, 0, fmt.Errorf("HTTP %d: %s", resp.StatusCode, resp.Status)
 	}
 
-	return ical.NewDecoder(resp.Body).Decode()
+	body := &countingReader{r: resp.Body}
+	cal, err := ical.NewDecoder(body).Decode()
+	return cal, body.n, err
+}
+
+// countingReader counts the bytes read through it
+type countingReader struct {
+	r io.Reader
+	n int64
+}
+
+func (c *countingReader) Read(p []byte) (int, error) {
+	n, err := c.r.Read(p)
+	c.n += int64(n)
+	return n, err
 }
 
 func parseICalFromFile(filePath string) (*ical.Calendar, error) {
@@ -437,6 +748,102 @@ func parseICalFromFile(filePath string) (*ical.Calendar, error) {
 	return cal, nil
 }
 
+// parsedEvent is an event of a feed with the VEVENT it was parsed from
+type parsedEvent struct {
+	event     *models.Event
+	component *ical.Component
+}
+
+// recurrenceOverride is a VEVENT replacing one occurrence of a recurring event
+type recurrenceOverride struct {
+	event *models.Event
+	// recurrenceID is the original start of the occurrence it replaces
+	recurrenceID time.Time
+}
+
+// parseEvents parses the VEVENTs of a feed. Those replacing a single occurrence of a
+// recurring event (with a RECURRENCE-ID) are returned apart, by UID, to be applied once
+// the series is expanded.
+func parseEvents(ctx context.Context, cal *ical.Calendar, planningID string) ([]parsedEvent, map[string][]recurrenceOverride) {
+	var parsed []parsedEvent
+	overrides := make(map[string][]recurrenceOverride)
+	for _, child := range cal.Children {
+		if child.Name != ical.CompEvent {
+			continue
+		}
+		event, err := parseEvent(child, planningID)
+		if err != nil {
+			slog.WarnContext(ctx, "Failed to parse event", "error", err)
+			continue
+		}
+
+		recurrenceID := child.Props.Get(ical.PropRecurrenceID)
+		if recurrenceID == nil {
+			parsed = append(parsed, parsedEvent{event: event, component: child})
+			continue
+		}
+		original, err := parseDateTimeProperty(recurrenceID)
+		if err != nil {
+			slog.WarnContext(ctx, "Failed to parse recurrence ID", "event_uid", event.UID, "error", err)
+			continue
+		}
+		overrides[event.UID] = append(overrides[event.UID], recurrenceOverride{event: event, recurrenceID: original})
+	}
+	return parsed, overrides
+}
+
+// expandEvents expands the recurring events of a feed, then replaces the occurrences
+// that have an override. An override whose occurrence is not found, because the series
+// is not in the feed or does not reach it, is kept as an event of its own.
+func expandEvents(ctx context.Context, parsed []parsedEvent, overrides map[string][]recurrenceOverride, maxOccurrences int) []*models.Event {
+	var events []*models.Event
+	expanded := make(map[string]bool)
+	for _, p := range parsed {
+		occurrences, err := expandRecurringEvent(ctx, p.event, p.component, maxOccurrences)
+		if err != nil {
+			slog.WarnContext(ctx, "Failed to expand recurring event", "event_uid", p.event.UID, "error", err)
+			continue
+		}
+		expanded[p.event.UID] = true
+		events = append(events, applyOverrides(occurrences, overrides[p.event.UID])...)
+	}
+
+	// Overrides left without their series
+	uids := make([]string, 0, len(overrides))
+	for uid := range overrides {
+		if !expanded[uid] {
+			uids = append(uids, uid)
+		}
+	}
+	sort.Strings(uids)
+	for _, uid := range uids {
+		events = append(events, applyOverrides(nil, overrides[uid])...)
+	}
+	return events
+}
+
+// applyOverrides replaces the occurrences of a series that have an override. The override
+// takes the UID of the occurrence, so that the event keeps its ID across syncs; one
+// matching no occurrence is added with its recurrence ID appended to its UID.
+func applyOverrides(occurrences []*models.Event, overrides []recurrenceOverride) []*models.Event {
+	for _, override := range overrides {
+		replaced := false
+		for i, occurrence := range occurrences {
+			if occurrence.StartTime.Equal(override.recurrenceID) {
+				override.event.UID = occurrence.UID
+				occurrences[i] = override.event
+				replaced = true
+				break
+			}
+		}
+		if !replaced {
+			override.event.UID = fmt.Sprintf("%s-%s", override.event.UID, override.recurrenceID.UTC().Format(icalUTCFormat))
+			occurrences = append(occurrences, override.event)
+		}
+	}
+	return occurrences
+}
+
 func parseEvent(component *ical.Component, planningID string) (*models.Event, error) {
 	event := &models.Event{
 		PlanningID: planningID,
@@ -461,6 +868,26 @@ func parseEvent(component *ical.Component, planningID string) (*models.Event, er
 		event.Location = location.Value
 	}
 
+	if status := component.Props.Get("STATUS"); status != nil {
+		event.Status = strings.ToUpper(status.Value)
+	}
+
+	if transp := component.Props.Get("TRANSP"); transp != nil {
+		event.Transparency = strings.ToUpper(transp.Value)
+	}
+
+	for _, prop := range component.Props.Values("CATEGORIES") {
+		categories, err := prop.TextList()
+		if err != nil {
+			continue
+		}
+		for _, category := range categories {
+			if category = strings.TrimSpace(category); category != "" {
+				event.Categories = append(event.Categories, category)
+			}
+		}
+	}
+
 	allDay := false
 
 	// Parse dates
@@ -480,6 +907,15 @@ func parseEvent(component *ical.Component, planningID string) (*models.Event, er
 			return nil, fmt.Errorf("failed to parse end time: %w", err)
 		}
 		event.EndTime = endTime
+	} else if duration := component.Props.Get(ical.PropDuration); duration != nil {
+		length, err := duration.Duration()
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse duration: %w", err)
+		}
+		event.EndTime = event.StartTime.Add(length)
+	} else if allDay {
+		// A date without an end lasts the whole day
+		event.EndTime = event.StartTime.AddDate(0, 0, 1)
 	} else {
 		// If no end time, assume 1 hour duration
 		event.EndTime = event.StartTime.Add(time.Hour)
@@ -507,12 +943,46 @@ func parseEvent(component *ical.Component, planningID string) (*models.Event, er
 	return event, nil
 }
 
+// parseDateTimeProperty parses a DATE or DATE-TIME property. Dates are at midnight UTC,
+// local date-times are in their TZID, an IANA or a Windows timezone name.
 func parseDateTimeProperty(prop *ical.Prop) (time.Time, error) {
 	if prop == nil {
 		return time.Time{}, fmt.Errorf("unable to parse nil date property")
 	}
 
-	return prop.DateTime(time.UTC)
+	tzid := prop.Params.Get(ical.PropTimezoneID)
+	if tzid == "" || isDateOnlyProperty(prop) {
+		return prop.DateTime(time.UTC)
+	}
+
+	loc, err := loadTimezone(tzid)
+	if err != nil {
+		return time.Time{}, err
+	}
+	// go-ical would load the TZID itself, and fail on Windows names
+	local := *prop
+	local.Params = make(ical.Params, len(prop.Params))
+	for name, values := range prop.Params {
+		if name != ical.PropTimezoneID {
+			local.Params[name] = values
+		}
+	}
+	return local.DateTime(loc)
+}
+
+// parseDateTimeList parses the comma-separated values of an EXDATE or RDATE property
+func parseDateTimeList(prop *ical.Prop) ([]time.Time, error) {
+	var times []time.Time
+	for _, value := range strings.Split(prop.Value, ",") {
+		single := *prop
+		single.Value = strings.TrimSpace(value)
+		t, err := parseDateTimeProperty(&single)
+		if err != nil {
+			return nil, err
+		}
+		times = append(times, t)
+	}
+	return times, nil
 }
 
 func isDateOnlyProperty(prop *ical.Prop) bool {
@@ -523,6 +993,30 @@ func isDateOnlyProperty(prop *ical.Prop) bool {
 	return strings.EqualFold(prop.Params.Get("VALUE"), "DATE")
 }
 
+// extractCalendarTimezone returns the calendar's default timezone from X-WR-TIMEZONE,
+// falling back to the first VTIMEZONE when it names a known IANA zone
+func extractCalendarTimezone(ctx context.Context, cal *ical.Calendar) string {
+	if prop := cal.Props.Get("X-WR-TIMEZONE"); prop != nil && prop.Value != "" {
+		if loc, err := loadTimezone(prop.Value); err == nil {
+			return loc.String()
+		}
+		slog.WarnContext(ctx, "Ignoring unknown calendar timezone", "timezone", prop.Value)
+	}
+
+	for _, child := range cal.Children {
+		if child.Name != ical.CompTimezone {
+			continue
+		}
+		if tzid := child.Props.Get(ical.PropTimezoneID); tzid != nil && tzid.Value != "" {
+			if loc, err := loadTimezone(tzid.Value); err == nil {
+				return loc.String()
+			}
+		}
+	}
+
+	return ""
+}
+
 func extractNameFromURL(urlStr string) string {
 	u, err := url.Parse(urlStr)
 	if err != nil {
@@ -556,6 +1050,13 @@ func generatePlanningID(source string) string {
 	return fmt.Sprintf("ical-%s", hashStr[:12])
 }
 
+// generateSourceID derives the ID recorded on the events of a source, so that a source
+// sharing its planning with others only deletes its own events
+func generateSourceID(source string) string {
+	hash := sha256.Sum256([]byte(source))
+	return fmt.Sprintf("src-%s", hex.EncodeToString(hash[:])[:12])
+}
+
 func generateRandomColor() string {
 	colors := []string{
 		"#3B82F6", // Blue
@@ -576,23 +1077,23 @@ func generateRandomColor() string {
 func runInit(cmd *cobra.Command, args []string) {
 	// Initialize database
 	if err := database.Initialize(); err != nil {
-		log.Fatalf("Failed to initialize database: %v", err)
+		fatal("Failed to initialize database", "error", err)
 	}
 	defer database.Close()
 
 	importerService := importer.NewImporter(database.DB)
 
 	if err := importerService.InitializeTables(); err != nil {
-		log.Fatalf("Failed to initialize tables: %v", err)
+		fatal("Failed to initialize tables", "error", err)
 	}
 
-	log.Println("Database tables initialized successfully!")
+	slog.Info("Database tables initialized")
 }
 
 func runStats(cmd *cobra.Command, args []string) {
 	// Initialize database
 	if err := database.Initialize(); err != nil {
-		log.Fatalf("Failed to initialize database: %v", err)
+		fatal("Failed to initialize database", "error", err)
 	}
 	defer database.Close()
 
@@ -601,68 +1102,250 @@ func runStats(cmd *cobra.Command, args []string) {
 
 	stats, err := importerService.GetStats()
 	if err != nil {
-		log.Fatalf("Failed to get statistics: %v", err)
+		fatal("Failed to get statistics", "error", err)
+	}
+
+	slog.Info("Database statistics",
+		"plannings", stats["plannings"], "events", stats["events"], "deleted_events", stats["deleted_events"])
+}
+
+// runRuns lists recent sync runs
+func runRuns(cmd *cobra.Command, args []string) {
+	// Initialize database
+	if err := database.Initialize(); err != nil {
+		fatal("Failed to initialize database", "error", err)
+	}
+	defer database.Close()
+
+	importerService := importer.NewImporter(database.DB)
+
+	runs, err := importerService.ListRuns(runsPlanning, runsLimit)
+	if err != nil {
+		fatal("Failed to list sync runs", "error", err)
+	}
+	if len(runs) == 0 {
+		slog.Info("No sync runs recorded")
+		return
+	}
+
+	for _, run := range runs {
+		slog.Info("Sync run", "run_id", run.ID, "started", run.Started.Format(time.RFC3339), "status", run.Status,
+			"planning_id", run.PlanningID, "created", run.Created, "updated", run.Updated, "deleted", run.Deleted, "error", run.Error)
+	}
+}
+
+// runRestore restores a planning to its state before a sync run
+func runRestore(cmd *cobra.Command, args []string) {
+	// Initialize database
+	if err := database.Initialize(); err != nil {
+		fatal("Failed to initialize database", "error", err)
+	}
+	defer database.Close()
+
+	importerService := importer.NewImporter(database.DB)
+
+	if dryRun {
+		runs, err := importerService.ListRuns(restorePlanning, -1)
+		if err != nil {
+			fatal("Failed to list sync runs", "error", err)
+		}
+		for _, run := range runs {
+			if run.ID >= restoreRun {
+				slog.Info("[DRY RUN] Would undo sync run", "run_id", run.ID, "started", run.Started.Format(time.RFC3339),
+					"created", run.Created, "updated", run.Updated, "deleted", run.Deleted)
+			}
+		}
+		return
+	}
+
+	count, err := importerService.RestoreBeforeRun(restorePlanning, restoreRun)
+	if err != nil {
+		fatal("Failed to restore planning", "planning_id", restorePlanning, "error", err)
 	}
 
-	log.Printf("Database statistics:")
-	log.Printf("  Plannings: %d", stats["plannings"])
-	log.Printf("  Events: %d", stats["events"])
+	slog.Info("Restored planning to its state before the run", "planning_id", restorePlanning, "run_id", restoreRun, "changes", count)
+}
+
+// runPurge permanently removes deleted data older than the retention period
+func runPurge(cmd *cobra.Command, args []string) {
+	retention := purgeOlderThan
+	if retention <= 0 {
+		retention = viper.GetDuration("retention.period")
+	}
+	if retention <= 0 {
+		retention = defaultRetention
+	}
+	cutoff := time.Now().Add(-retention)
+
+	// Initialize database
+	if err := database.Initialize(); err != nil {
+		fatal("Failed to initialize database", "error", err)
+	}
+	defer database.Close()
+
+	if dryRun {
+		slog.Info("[DRY RUN] Would purge deleted data", "older_than", cutoff.Format(time.RFC3339))
+		return
+	}
+
+	importerService := importer.NewImporter(database.DB)
+
+	removed, err := importerService.Purge(cutoff)
+	if err != nil {
+		fatal("Failed to purge", "error", err)
+	}
+
+	slog.Info("Purged deleted data", "older_than", cutoff.Format(time.RFC3339),
+		"events", removed["events"], "plannings", removed["plannings"], "changes", removed["changes"], "sync_runs", removed["sync_runs"])
 }
 
 // runSync synchronizes calendars from the given configuration file
 func runSync(cmd *cobra.Command, args []string) {
 	configFile := args[0]
 
+	if err := validateOutputFormat(outputFormat); err != nil {
+		fatal("Invalid --output", "error", err)
+	}
+
 	// Read sync configuration
 	file, err := os.Open(configFile)
 	if err != nil {
-		log.Fatalf("Failed to open sync config file: %v", err)
+		fatal("Failed to open sync config file", "error", err)
 	}
 	defer file.Close()
 
 	var config SyncConfig
 	decoder := yaml.NewDecoder(file)
 	if err := decoder.Decode(&config); err != nil {
-		log.Fatalf("Failed to parse sync config: %v", err)
+		fatal("Failed to parse sync config", "error", err)
 	}
 
 	if len(config.Calendars) == 0 {
-		log.Println("No calendars configured for sync")
+		slog.Info("No calendars configured for sync")
 		return
 	}
 
 	// Initialize database
 	if err := database.Initialize(); err != nil {
-		log.Fatalf("Failed to initialize database: %v", err)
+		fatal("Failed to initialize database", "error", err)
 	}
 	defer database.Close()
 
 	importerService := importer.NewImporter(database.DB)
 
-	log.Printf("Starting sync of %d calendar sources...", len(config.Calendars))
+	slog.Info("Starting sync", "sources", len(config.Calendars))
+
+	ctx, stop := interruptContext()
+	defer stop()
+
+	groups := groupSourcesByPlanning(config.Calendars)
 
 	successCount := 0
 	errorCount := 0
 
-	for _, cal := range config.Calendars {
+	for i, cal := range config.Calendars {
+		if ctx.Err() != nil {
+			slog.Warn("Interrupted, skipped the remaining calendar sources", "skipped", len(config.Calendars)-i)
+			break
+		}
+
 		if !cal.Enabled {
-			log.Printf("Skipping disabled calendar: %s", cal.Name)
+			slog.Info("Skipping disabled calendar", "calendar", cal.Name)
+			continue
+		}
+
+		slog.Info("Syncing calendar", "calendar", cal.Name, "planning_id", cal.planningID(), "source_id", generateSourceID(cal.URL))
+
+		ruleSet, err := rules.Compile(cal.Rules)
+		if err != nil {
+			slog.Error("Invalid rules", "calendar", cal.Name, "error", err)
+			errorCount++
 			continue
 		}
 
-		log.Printf("Syncing calendar: %s (%s)", cal.Name, cal.URL)
+		opts := sourceOptions{
+			Name:   cal.Name,
+			ID:     cal.CustomID,
+			Color:  cal.Color,
+			Policy: cal.DeletionPolicy(),
+			Rules:  ruleSet,
+			Dedupe: cal.Dedupe,
+		}
+		if group := groups[cal.planningID()]; len(group) > 1 {
+			// Sources sharing a planning give it the name and color of the first of them
+			opts.Name = group[0].planningName()
+			opts.Color = group[0].Color
+			for _, member := range group {
+				opts.MergedFrom = append(opts.MergedFrom, member.Name)
+			}
+		}
 
-		if err := processICalSourceWithCustomization(importerService, cal.URL, cal.Name, cal.CustomID, cal.Color); err != nil {
-			log.Printf("Failed to sync calendar %s: %v", cal.Name, err)
+		if err := processICalSourceWithCustomization(ctx, importerService, cal.URL, opts); err != nil {
+			slog.Error("Failed to sync calendar", "calendar", cal.Name, "planning_id", cal.planningID(), "source_id", generateSourceID(cal.URL), "error", err)
 			errorCount++
 			continue
 		}
 
-		log.Printf("Successfully synced calendar: %s", cal.Name)
+		slog.Info("Synced calendar", "calendar", cal.Name)
 		successCount++
 	}
 
-	log.Printf("Sync completed! Success: %d, Errors: %d", successCount, errorCount)
+	slog.Info("Sync completed", "succeeded", successCount, "failed", errorCount)
+	printDryRunPlans()
+	flushMetrics()
+	flushTraces()
+}
+
+// interruptContext returns a context cancelled on SIGINT or SIGTERM, so that the current
+// source is finished or abandoned cleanly instead of the process dying mid-sync. A second
+// signal exits immediately.
+func interruptContext() (context.Context, func()) {
+	ctx, cancel := context.WithCancel(context.Background())
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, os.Interrupt, syscall.SIGTERM)
+
+	go func() {
+		select {
+		case sig := <-signals:
+			slog.Warn("Stopping after the current source", "signal", sig.String())
+			signal.Stop(signals)
+			cancel()
+		case <-ctx.Done():
+		}
+	}()
+
+	return ctx, func() {
+		signal.Stop(signals)
+		cancel()
+	}
+}
+
+// flushMetrics sends the sync metrics to the textfile or Pushgateway configured under metrics
+func flushMetrics() {
+	sink := metrics.Sink{
+		Textfile:    viper.GetString("metrics.textfile"),
+		Pushgateway: viper.GetString("metrics.pushgateway"),
+		Job:         viper.GetString("metrics.job"),
+	}
+	if dryRun || !sink.Enabled() {
+		return
+	}
+
+	if err := metrics.Flush(sink); err != nil {
+		slog.Warn("Failed to write metrics", "error", err)
+		return
+	}
+	slog.Info("Metrics written")
+}
+
+// flushTraces sends the spans still buffered to the configured exporter
+func flushTraces() {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	if err := shutdownTracing(ctx); err != nil {
+		slog.Warn("Failed to flush traces", "error", err)
+	}
 }
 
 // generateCalendarDescription creates a descriptive text about the calendar based on its properties
@@ -732,8 +1415,10 @@ func generateCalendarDescription(cal *ical.Calendar, source string) string {
 	return fmt.Sprintf("Imported from: %s", source)
 }
 
-// expandRecurringEvent expands a recurring event into individual events based on RRULE
-func expandRecurringEvent(baseEvent *models.Event, component *ical.Component, maxOccurrences int) ([]*models.Event, error) {
+// expandRecurringEvent expands a recurring event into individual events based on RRULE,
+// RDATE and EXDATE. Occurrences are computed in the timezone of DTSTART, so that they keep
+// their local time across DST transitions.
+func expandRecurringEvent(ctx context.Context, baseEvent *models.Event, component *ical.Component, maxOccurrences int) ([]*models.Event, error) {
 	// Check if event has RRULE property
 	rruleProp := component.Props.Get("RRULE")
 	if rruleProp == nil {
@@ -741,64 +1426,45 @@ func expandRecurringEvent(baseEvent *models.Event, component *ical.Component, ma
 		return []*models.Event{baseEvent}, nil
 	}
 
-	// Build an rrule.Set including DTSTART, RRULE, EXDATE, and RDATE so DTSTART (and its timezone)
-	// is preserved when expanding occurrences.
-	var sb strings.Builder
-	// Include DTSTART if available (prefer the raw DTSTART prop so TZID/DATE semantics are kept)
-	if dtstartProp := component.Props.Get("DTSTART"); dtstartProp != nil {
-		sb.WriteString(fmt.Sprintf("DTSTART:%s\n", dtstartProp.Value))
-	} else {
-		// Fallback to baseEvent.StartTime in UTC format
-		sb.WriteString(fmt.Sprintf("DTSTART:%s\n", baseEvent.StartTime.UTC().Format("20060102T150405Z")))
+	start := baseEvent.StartTime
+	// A floating UNTIL is in the timezone of DTSTART
+	option, err := rrule.StrToROptionInLocation(rruleProp.Value, start.Location())
+	if err != nil {
+		slog.WarnContext(ctx, "Failed to parse RRULE", "event_uid", baseEvent.UID, "rrule", rruleProp.Value, "error", err)
+		return []*models.Event{baseEvent}, nil
 	}
-
-	// Add RRULE(s)
-	for _, p := range component.Props.Values("RRULE") {
-		sb.WriteString(fmt.Sprintf("RRULE:%s\n", p.Value))
+	option.Dtstart = start
+	rule, err := rrule.NewRRule(*option)
+	if err != nil {
+		slog.WarnContext(ctx, "Failed to parse RRULE", "event_uid", baseEvent.UID, "rrule", rruleProp.Value, "error", err)
+		return []*models.Event{baseEvent}, nil
 	}
 
-	// Add EXDATE(s) and RDATE(s) if present
-	for _, p := range component.Props.Values("EXDATE") {
-		sb.WriteString(fmt.Sprintf("EXDATE:%s\n", p.Value))
-	}
+	set := &rrule.Set{}
+	set.RRule(rule)
 	for _, p := range component.Props.Values("RDATE") {
-		sb.WriteString(fmt.Sprintf("RDATE:%s\n", p.Value))
-	}
-
-	// Parse into an rrule Set which understands EXDATE/RDATE
-	set, err := rrule.StrToRRuleSet(sb.String())
-	if err != nil {
-		// If building the set fails, fallback to single-rule parsing to avoid dropping the event
-		log.Printf("Warning: Failed to build RRuleSet from component: %v; falling back: %v", err, rruleProp.Value)
-		rule, err2 := rrule.StrToRRule(rruleProp.Value)
-		if err2 != nil {
-			log.Printf("Warning: Failed to parse RRULE '%s': %v", rruleProp.Value, err2)
-			return []*models.Event{baseEvent}, nil
+		dates, err := parseDateTimeList(&p)
+		if err != nil {
+			slog.WarnContext(ctx, "Ignoring invalid RDATE", "event_uid", baseEvent.UID, "rdate", p.Value, "error", err)
+			continue
 		}
-
-		duration := baseEvent.EndTime.Sub(baseEvent.StartTime)
-		occurrences := rule.Between(baseEvent.StartTime, baseEvent.StartTime.AddDate(2, 0, 0), true)
-		if len(occurrences) > maxOccurrences {
-			occurrences = occurrences[:maxOccurrences]
+		for _, date := range dates {
+			set.RDate(date)
 		}
-
-		var events []*models.Event
-		for i, occurrence := range occurrences {
-			eventCopy := *baseEvent
-			eventCopy.StartTime = occurrence
-			eventCopy.EndTime = occurrence.Add(duration)
-			if i > 0 {
-				eventCopy.UID = fmt.Sprintf("%s-recurrence-%d", baseEvent.UID, i)
-			}
-			events = append(events, &eventCopy)
+	}
+	for _, p := range component.Props.Values("EXDATE") {
+		dates, err := parseDateTimeList(&p)
+		if err != nil {
+			slog.WarnContext(ctx, "Ignoring invalid EXDATE", "event_uid", baseEvent.UID, "exdate", p.Value, "error", err)
+			continue
+		}
+		for _, date := range dates {
+			set.ExDate(date)
 		}
-		return events, nil
 	}
 
 	// Expand for 2 years from DTSTART
-	windowStart := baseEvent.StartTime
-	windowEnd := baseEvent.StartTime.AddDate(2, 0, 0)
-	occurrences := set.Between(windowStart, windowEnd, true)
+	occurrences := set.Between(start, start.AddDate(2, 0, 0), true)
 	if len(occurrences) > maxOccurrences {
 		occurrences = occurrences[:maxOccurrences]
 	}
@@ -1,6 +1,10 @@
 package cmd
 
 import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
 	"testing"
 	"time"
 
@@ -71,3 +75,66 @@ func TestParseEventMarksAllDayForDateOnlyProperties(t *testing.T) {
 		t.Fatalf("end time = %v, want %v", event.EndTime, expectedEnd)
 	}
 }
+
+func TestExtractCalendarTimezone(t *testing.T) {
+	cal := ical.NewCalendar()
+	if tz := extractCalendarTimezone(context.Background(), cal); tz != "" {
+		t.Fatalf("timezone = %q, want empty", tz)
+	}
+
+	vtimezone := ical.NewComponent(ical.CompTimezone)
+	tzid := ical.NewProp(ical.PropTimezoneID)
+	tzid.Value = "Europe/Paris"
+	vtimezone.Props.Set(tzid)
+	cal.Children = append(cal.Children, vtimezone)
+	if tz := extractCalendarTimezone(context.Background(), cal); tz != "Europe/Paris" {
+		t.Fatalf("timezone = %q, want %q", tz, "Europe/Paris")
+	}
+
+	wrTimezone := ical.NewProp("X-WR-TIMEZONE")
+	wrTimezone.Value = "America/New_York"
+	cal.Props.Set(wrTimezone)
+	if tz := extractCalendarTimezone(context.Background(), cal); tz != "America/New_York" {
+		t.Fatalf("timezone = %q, want %q", tz, "America/New_York")
+	}
+}
+
+func TestGroupSourcesByPlanning(t *testing.T) {
+	sources := []CalendarSource{
+		{Name: "Group A", URL: "https://example.com/a.ics", Enabled: true, CustomID: "timetable", PlanningName: "Timetable"},
+		{Name: "Group B", URL: "https://example.com/b.ics", Enabled: true, CustomID: "timetable"},
+		{Name: "Disabled", URL: "https://example.com/c.ics", Enabled: false, CustomID: "timetable"},
+		{Name: "Personal", URL: "https://example.com/personal.ics", Enabled: true},
+	}
+
+	groups := groupSourcesByPlanning(sources)
+
+	shared := groups["timetable"]
+	if len(shared) != 2 || shared[0].Name != "Group A" || shared[1].Name != "Group B" {
+		t.Fatalf("timetable sources = %+v, want Group A and Group B", shared)
+	}
+	if shared[0].planningName() != "Timetable" {
+		t.Fatalf("planning name = %q, want %q", shared[0].planningName(), "Timetable")
+	}
+	if personal := groups[generatePlanningID("https://example.com/personal.ics")]; len(personal) != 1 {
+		t.Fatalf("personal sources = %+v, want one source", personal)
+	}
+	if generateSourceID(sources[0].URL) == generateSourceID(sources[1].URL) {
+		t.Fatal("sources sharing a planning got the same source ID")
+	}
+}
+
+func TestFetchICalFromURLStopsWhenInterrupted(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-r.Context().Done()
+	}))
+	defer server.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	time.AfterFunc(50*time.Millisecond, cancel)
+
+	_, _, err := fetchICalFromURL(ctx, server.URL)
+	if !errors.Is(err, context.Canceled) {
+		t.Fatalf("fetchICalFromURL error = %v, want context.Canceled", err)
+	}
+}
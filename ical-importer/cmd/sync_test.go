@@ -0,0 +1,214 @@
+package cmd
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/do2024-2047/CalenDO/ical-importer/internal/importer"
+	"github.com/do2024-2047/CalenDO/ical-importer/internal/metrics"
+	"github.com/do2024-2047/CalenDO/ical-importer/internal/models"
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+// errWriteFailed is the error of the writes fakeDB fails
+var errWriteFailed = errors.New("write failed")
+
+// fakeDB stands in for Postgres: statements are built but never sent. Queries for events
+// find events, other lookups find nothing, writes to failTable fail, and every sync run
+// written is recorded in runs.
+type fakeDB struct {
+	events    []*models.Event
+	failTable string
+	runs      []models.SyncRun
+}
+
+func (f *fakeDB) importer(t *testing.T) *importer.Importer {
+	t.Helper()
+
+	db, err := gorm.Open(postgres.New(postgres.Config{Conn: &fakeConn{}}), &gorm.Config{
+		DryRun:               true,
+		DisableAutomaticPing: true,
+		Logger:               logger.Discard,
+	})
+	if err != nil {
+		t.Fatalf("failed to open the fake database: %v", err)
+	}
+
+	query := func(tx *gorm.DB) {
+		if found, ok := tx.Statement.Dest.(*[]*models.Event); ok && tx.Statement.Table == "events" {
+			*found = f.events
+			return
+		}
+		if tx.Statement.RaiseErrorOnNotFound {
+			tx.AddError(gorm.ErrRecordNotFound)
+		}
+	}
+	fail := func(tx *gorm.DB) {
+		if tx.Statement.Table == f.failTable {
+			tx.AddError(errWriteFailed)
+		}
+	}
+	record := func(tx *gorm.DB) {
+		if run, ok := tx.Statement.Dest.(*models.SyncRun); ok && tx.Error == nil {
+			f.runs = append(f.runs, *run)
+		}
+	}
+	for _, err := range []error{
+		db.Callback().Query().After("gorm:query").Register("test:query", query),
+		db.Callback().Create().Before("gorm:create").Register("test:fail", fail),
+		db.Callback().Update().Before("gorm:update").Register("test:fail", fail),
+		db.Callback().Create().After("gorm:create").Register("test:record", record),
+		db.Callback().Update().After("gorm:update").Register("test:record", record),
+	} {
+		if err != nil {
+			t.Fatalf("failed to register callback: %v", err)
+		}
+	}
+
+	return importer.NewImporter(db)
+}
+
+// fakeConn is the connection of fakeDB. Statements are never sent in dry run mode, but
+// transactions are still begun and committed.
+type fakeConn struct{}
+
+func (*fakeConn) PrepareContext(context.Context, string) (*sql.Stmt, error) {
+	return nil, errors.New("fake connection")
+}
+
+func (*fakeConn) ExecContext(context.Context, string, ...interface{}) (sql.Result, error) {
+	return nil, errors.New("fake connection")
+}
+
+func (*fakeConn) QueryContext(context.Context, string, ...interface{}) (*sql.Rows, error) {
+	return nil, errors.New("fake connection")
+}
+
+func (*fakeConn) QueryRowContext(context.Context, string, ...interface{}) *sql.Row {
+	return nil
+}
+
+func (c *fakeConn) BeginTx(context.Context, *sql.TxOptions) (gorm.ConnPool, error) {
+	return c, nil
+}
+
+func (*fakeConn) Commit() error   { return nil }
+func (*fakeConn) Rollback() error { return nil }
+
+// lastRun returns the sync run as it was last written
+func (f *fakeDB) lastRun(t *testing.T) models.SyncRun {
+	t.Helper()
+	if len(f.runs) == 0 {
+		t.Fatal("no sync run was recorded")
+	}
+	return f.runs[len(f.runs)-1]
+}
+
+// writeFeed writes an iCal feed holding one event per UID and returns its path
+func writeFeed(t *testing.T, uids ...string) string {
+	t.Helper()
+
+	feed := "BEGIN:VCALENDAR\r\nVERSION:2.0\r\nPRODID:-//CalenDO//Test//EN\r\n"
+	for _, uid := range uids {
+		feed += "BEGIN:VEVENT\r\nUID:" + uid + "\r\nSUMMARY:Event " + uid +
+			"\r\nDTSTAMP:20260501T080000Z\r\nDTSTART:20260507T090000Z\r\nDTEND:20260507T100000Z\r\nEND:VEVENT\r\n"
+	}
+	feed += "END:VCALENDAR\r\n"
+
+	path := filepath.Join(t.TempDir(), "feed.ics")
+	if err := os.WriteFile(path, []byte(feed), 0o644); err != nil {
+		t.Fatalf("failed to write feed: %v", err)
+	}
+	return path
+}
+
+// withSyncDelete turns on --sync-delete for the duration of a test
+func withSyncDelete(t *testing.T) {
+	t.Helper()
+	previous := syncDelete
+	syncDelete = true
+	t.Cleanup(func() { syncDelete = previous })
+}
+
+func TestSyncRunRecordsOutcome(t *testing.T) {
+	withSyncDelete(t)
+
+	existing := []*models.Event{
+		{ID: "a_sync-test", UID: "a", PlanningID: "sync-test"},
+		{ID: "b_sync-test", UID: "b", PlanningID: "sync-test"},
+		{ID: "c_sync-test", UID: "c", PlanningID: "sync-test"},
+	}
+
+	tests := []struct {
+		name       string
+		db         *fakeDB
+		feed       []string
+		wantStatus string
+		wantErr    bool
+	}{
+		{name: "succeeded", db: &fakeDB{}, feed: []string{"a", "b"}, wantStatus: models.SyncRunSucceeded},
+		{name: "failed", db: &fakeDB{failTable: "plannings"}, feed: []string{"a", "b"}, wantStatus: models.SyncRunFailed, wantErr: true},
+		{name: "event write failed", db: &fakeDB{failTable: "events"}, feed: []string{"a", "b"}, wantStatus: models.SyncRunFailed, wantErr: true},
+		{name: "change write failed", db: &fakeDB{failTable: "changes"}, feed: []string{"a", "b"}, wantStatus: models.SyncRunFailed, wantErr: true},
+		{name: "aborted", db: &fakeDB{events: existing}, wantStatus: models.SyncRunAborted, wantErr: true},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			err := processICalSourceWithCustomization(context.Background(), tc.db.importer(t), writeFeed(t, tc.feed...),
+				sourceOptions{ID: "sync-test", Policy: importer.DefaultDeletionPolicy()})
+			if (err != nil) != tc.wantErr {
+				t.Fatalf("error = %v, want error: %v", err, tc.wantErr)
+			}
+
+			run := tc.db.lastRun(t)
+			if run.Status != tc.wantStatus {
+				t.Fatalf("run status = %q, want %q", run.Status, tc.wantStatus)
+			}
+			if tc.wantErr && run.Error == "" {
+				t.Fatal("run error was not recorded")
+			}
+			if run.Finished == nil {
+				t.Fatal("run finish time was not recorded")
+			}
+		})
+	}
+}
+
+func TestSyncMetricsCountChanges(t *testing.T) {
+	withSyncDelete(t)
+
+	feed := writeFeed(t, "a", "b")
+	err := processICalSourceWithCustomization(context.Background(), (&fakeDB{}).importer(t), feed,
+		sourceOptions{ID: "sync-metrics", Policy: importer.DefaultDeletionPolicy()})
+	if err != nil {
+		t.Fatalf("sync failed: %v", err)
+	}
+
+	path := filepath.Join(t.TempDir(), "importer.prom")
+	if err := metrics.Flush(metrics.Sink{Textfile: path}); err != nil {
+		t.Fatalf("failed to flush metrics: %v", err)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("failed to read metrics: %v", err)
+	}
+
+	labels := fmt.Sprintf(`planning="sync-metrics",source=%q`, generateSourceID(feed))
+	for _, want := range []string{
+		`calendo_importer_events{action="parsed",` + labels + `} 2`,
+		`calendo_importer_events{action="created",` + labels + `} 2`,
+		`calendo_importer_events{action="updated",` + labels + `} 0`,
+	} {
+		if !strings.Contains(string(data), want) {
+			t.Errorf("metrics missing %q\n%s", want, data)
+		}
+	}
+}
@@ -5,10 +5,11 @@ import (
 	"log"
 	"time"
 
+	"github.com/do2024-2047/CalenDO/ical-importer/internal/logging"
+	"github.com/do2024-2047/CalenDO/ical-importer/internal/tracing"
 	"github.com/spf13/viper"
 	"gorm.io/driver/postgres"
 	"gorm.io/gorm"
-	"gorm.io/gorm/logger"
 )
 
 // Config represents database configuration
@@ -35,15 +36,25 @@ func Initialize() error {
 	dsn := fmt.Sprintf("host=%s port=%d user=%s password=%s dbname=%s sslmode=%s",
 		config.Host, config.Port, config.Username, config.Password, config.DBName, config.SSLMode)
 
-	var err error
+	// Log SQL through slog, at the configured level
+	level, err := logging.ParseLevel(viper.GetString("logging.level"))
+	if err != nil {
+		return err
+	}
+
 	DB, err = gorm.Open(postgres.Open(dsn), &gorm.Config{
-		Logger: logger.Default.LogMode(logger.Info),
+		Logger: logging.GormLogger(level),
 	})
 
 	if err != nil {
 		return fmt.Errorf("failed to connect to database: %w", err)
 	}
 
+	// Record the statements of a sync as child spans of its stages
+	if err := DB.Use(tracing.GormPlugin()); err != nil {
+		return fmt.Errorf("failed to register tracing plugin: %w", err)
+	}
+
 	sqlDB, err := DB.DB()
 	if err != nil {
 		return fmt.Errorf("failed to get underlying sql.DB: %w", err)
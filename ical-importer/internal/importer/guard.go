@@ -0,0 +1,71 @@
+package importer
+
+import (
+	"fmt"
+)
+
+const (
+	// DefaultMaxDeleteRatio is the largest share of a planning's events a sync may delete
+	DefaultMaxDeleteRatio = 0.5
+	// guardMinDeletions is the number of deletions below which the ratio is not checked,
+	// so that ordinary churn in small plannings never trips the guard
+	guardMinDeletions = 5
+)
+
+// DeletionPolicy limits how much of a planning a single sync may delete
+type DeletionPolicy struct {
+	// MaxDeleteRatio is the largest share of existing events a sync may delete, between 0 and 1
+	MaxDeleteRatio float64
+	// AllowEmpty lets a sync remove every event of a planning
+	AllowEmpty bool
+	// Force skips the checks altogether
+	Force bool
+}
+
+// DefaultDeletionPolicy returns the policy used when a source does not configure one
+func DefaultDeletionPolicy() DeletionPolicy {
+	return DeletionPolicy{MaxDeleteRatio: DefaultMaxDeleteRatio}
+}
+
+// MassDeletionError is returned when a sync would delete too much of a planning.
+// Nothing is written when it is returned.
+type MassDeletionError struct {
+	PlanningID string
+	Existing   int
+	Deleting   int
+	Reason     string
+}
+
+func (e *MassDeletionError) Error() string {
+	return fmt.Sprintf("refusing to delete %d of %d events of planning %s: %s (use --force to override)",
+		e.Deleting, e.Existing, e.PlanningID, e.Reason)
+}
+
+// CheckDeletions verifies that deleting the given number of events out of the existing
+// ones complies with the policy, incoming being the number of events in the new feed
+func CheckDeletions(planningID string, existing, incoming, deleting int, policy DeletionPolicy) error {
+	if policy.Force || existing == 0 || deleting == 0 {
+		return nil
+	}
+
+	if incoming == 0 && !policy.AllowEmpty {
+		return &MassDeletionError{
+			PlanningID: planningID,
+			Existing:   existing,
+			Deleting:   deleting,
+			Reason:     "the feed has no events and would leave the planning empty",
+		}
+	}
+
+	ratio := float64(deleting) / float64(existing)
+	if deleting >= guardMinDeletions && ratio > policy.MaxDeleteRatio {
+		return &MassDeletionError{
+			PlanningID: planningID,
+			Existing:   existing,
+			Deleting:   deleting,
+			Reason:     fmt.Sprintf("%.0f%% exceeds the limit of %.0f%%", ratio*100, policy.MaxDeleteRatio*100),
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,49 @@
+package importer
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestCheckDeletions(t *testing.T) {
+	tests := []struct {
+		name      string
+		existing  int
+		incoming  int
+		deleting  int
+		policy    DeletionPolicy
+		wantAbort bool
+	}{
+		{"no deletions", 100, 100, 0, DefaultDeletionPolicy(), false},
+		{"new planning", 0, 20, 0, DefaultDeletionPolicy(), false},
+		{"within ratio", 100, 60, 40, DefaultDeletionPolicy(), false},
+		{"exactly at ratio", 100, 50, 50, DefaultDeletionPolicy(), false},
+		{"above ratio", 100, 40, 60, DefaultDeletionPolicy(), true},
+		{"small planning churn", 4, 1, 3, DefaultDeletionPolicy(), false},
+		{"empty feed", 3, 0, 3, DefaultDeletionPolicy(), true},
+		{"empty feed allowed", 3, 0, 3, DeletionPolicy{MaxDeleteRatio: 1, AllowEmpty: true}, false},
+		{"custom ratio", 100, 80, 20, DeletionPolicy{MaxDeleteRatio: 0.1}, true},
+		{"forced", 100, 0, 100, DeletionPolicy{MaxDeleteRatio: 0.5, Force: true}, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := CheckDeletions("planning-id", tt.existing, tt.incoming, tt.deleting, tt.policy)
+			if !tt.wantAbort {
+				if err != nil {
+					t.Fatalf("CheckDeletions returned error: %v", err)
+				}
+				return
+			}
+
+			var massDeletion *MassDeletionError
+			if !errors.As(err, &massDeletion) {
+				t.Fatalf("CheckDeletions error = %v, want *MassDeletionError", err)
+			}
+			if massDeletion.Existing != tt.existing || massDeletion.Deleting != tt.deleting {
+				t.Fatalf("MassDeletionError counts = %d/%d, want %d/%d",
+					massDeletion.Deleting, massDeletion.Existing, tt.deleting, tt.existing)
+			}
+		})
+	}
+}
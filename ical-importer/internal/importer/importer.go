@@ -1,15 +1,28 @@
 package importer
 
 import (
-	"log"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strconv"
 
 	"github.com/do2024-2047/CalenDO/ical-importer/internal/models"
 	"gorm.io/gorm"
 )
 
+// NotifyChannel is the Postgres channel on which the importer announces new change log entries
+const NotifyChannel = "calendo_changes"
+
+// changeBatchSize is the number of change log entries inserted per statement
+const changeBatchSize = 500
+
 // Importer handles the import of iCal data into the database
 type Importer struct {
 	db *gorm.DB
+	// run is the sync run in progress, stamped on every change recorded meanwhile
+	run *models.SyncRun
 }
 
 // NewImporter creates a new Importer instance
@@ -19,49 +32,183 @@ func NewImporter(db *gorm.DB) *Importer {
 	}
 }
 
-// CreateOrUpdatePlanning creates a new planning or updates an existing one
+// WithContext returns a copy of the importer whose statements carry ctx, so that they are
+// traced as part of the sync ctx belongs to
+func (i *Importer) WithContext(ctx context.Context) *Importer {
+	return &Importer{db: i.db.WithContext(ctx), run: i.run}
+}
+
+// ctx returns the context of the importer's statements, which also carries the attributes
+// of its log records
+func (i *Importer) ctx() context.Context {
+	return i.db.Statement.Context
+}
+
+// CreateOrUpdatePlanning creates a new planning or updates an existing one.
+// A soft-deleted planning with the same ID is restored. The planning and its change log
+// entry are written in one transaction.
 func (i *Importer) CreateOrUpdatePlanning(planning *models.Planning) error {
-	// Check if planning already exists
-	var existing models.Planning
-	result := i.db.Where("id = ?", planning.ID).First(&existing)
+	var change *models.Change
+	err := i.db.Transaction(func(tx *gorm.DB) error {
+		// Check if planning already exists, including soft-deleted ones
+		var existing models.Planning
+		result := tx.Unscoped().Where("id = ?", planning.ID).First(&existing)
 
-	if result.Error == nil {
-		// Planning exists, update it but preserve certain fields
-		planning.Created = existing.Created // Preserve original creation time
-		planning.Color = existing.Color     // Preserve existing color
-		return i.db.Save(planning).Error
-	} else if result.Error == gorm.ErrRecordNotFound {
-		// Planning doesn't exist, create it
-		return i.db.Create(planning).Error
+		if result.Error == nil {
+			// Planning exists, update it but preserve certain fields
+			planning.Created = existing.Created // Preserve original creation time
+			planning.Color = existing.Color     // Preserve existing color
+			if err := tx.Unscoped().Save(planning).Error; err != nil {
+				return err
+			}
+			if existing.DeletedAt.Valid {
+				restored := planningChange(planning.ID, nil)
+				change = &restored
+			} else if planning.Name != existing.Name || planning.Description != existing.Description || planning.Timezone != existing.Timezone {
+				updated := planningChange(planning.ID, &existing)
+				change = &updated
+			}
+		} else if errors.Is(result.Error, gorm.ErrRecordNotFound) {
+			// Planning doesn't exist, create it
+			if err := tx.Create(planning).Error; err != nil {
+				return err
+			}
+			created := planningChange(planning.ID, nil)
+			change = &created
+		} else {
+			return result.Error
+		}
+
+		if change == nil {
+			return nil
+		}
+		return i.insertChanges(tx, []models.Change{*change})
+	})
+	if err != nil {
+		return err
 	}
 
-	return result.Error
+	if change != nil {
+		i.notifyChanges([]models.Change{*change})
+	}
+	return nil
 }
 
 // CreateOrUpdateEvent creates a new event or updates an existing one
 func (i *Importer) CreateOrUpdateEvent(event *models.Event) error {
+	change, _, err := i.saveEvent(event)
+	if err != nil {
+		return err
+	}
+	if change != nil {
+		i.notifyChanges([]models.Change{*change})
+	}
+	return nil
+}
+
+// saveEvent writes an event and its change log entry in one transaction, so that clients
+// syncing from the log never miss a write. It returns the recorded change (nil when the
+// content did not change) and whether the event was live. Listeners are not notified.
+func (i *Importer) saveEvent(event *models.Event) (*models.Change, bool, error) {
+	var change *models.Change
+	var existed bool
+	err := i.db.Transaction(func(tx *gorm.DB) error {
+		var err error
+		change, existed, err = i.upsertEvent(tx, event)
+		if err != nil || change == nil {
+			return err
+		}
+		return i.insertChanges(tx, []models.Change{*change})
+	})
+	if err != nil {
+		return nil, false, err
+	}
+	if change != nil {
+		slog.DebugContext(i.ctx(), "Saved event", "event_uid", event.UID, "event_id", event.ID, "change", change.Type)
+	}
+	return change, existed, nil
+}
+
+// upsertEvent writes an event, restoring it if it was soft-deleted. It returns the
+// change to record (nil when the content did not change) and whether the event was live.
+func (i *Importer) upsertEvent(tx *gorm.DB, event *models.Event) (*models.Change, bool, error) {
 	// Generate composite ID
 	event.ID = models.GenerateEventID(event.UID, event.PlanningID)
 
-	// Check if event already exists
+	// Check if event already exists, including soft-deleted ones
 	var existing models.Event
-	result := i.db.Where("id = ?", event.ID).First(&existing)
+	result := tx.Unscoped().Where("id = ?", event.ID).First(&existing)
 
 	if result.Error == nil {
-		// Event exists, update it
+		// Event exists, update it; saving also clears a soft deletion
 		event.Created = existing.Created // Preserve original creation time
-		return i.db.Save(event).Error
-	} else if result.Error == gorm.ErrRecordNotFound {
+		if err := tx.Unscoped().Save(event).Error; err != nil {
+			return nil, false, err
+		}
+		if existing.DeletedAt.Valid {
+			change := eventChange(models.ChangeEventCreated, event.PlanningID, event.ID, nil)
+			return &change, false, nil
+		}
+		if !existing.ContentEquals(event) {
+			change := eventChange(models.ChangeEventUpdated, event.PlanningID, event.ID, &existing)
+			return &change, true, nil
+		}
+		return nil, true, nil
+	} else if errors.Is(result.Error, gorm.ErrRecordNotFound) {
 		// Event doesn't exist, create it
-		return i.db.Create(event).Error
+		if err := tx.Create(event).Error; err != nil {
+			return nil, false, err
+		}
+		change := eventChange(models.ChangeEventCreated, event.PlanningID, event.ID, nil)
+		return &change, false, nil
 	}
 
-	return result.Error
+	return nil, false, result.Error
 }
 
-// DeleteEventsForPlanning deletes all events for a specific planning
+// DeleteEventsForPlanning soft-deletes all events for a specific planning
 func (i *Importer) DeleteEventsForPlanning(planningID string) error {
-	return i.db.Where("planning_id = ?", planningID).Delete(&models.Event{}).Error
+	var eventIDs []string
+	if err := i.db.Model(&models.Event{}).Where("planning_id = ?", planningID).Pluck("id", &eventIDs).Error; err != nil {
+		return err
+	}
+
+	return i.deleteEvents(planningID, eventIDs)
+}
+
+// deleteEvents soft-deletes events and records their tombstones in the change log within
+// one transaction, so that clients syncing from the log always learn about deletions
+func (i *Importer) deleteEvents(planningID string, eventIDs []string) error {
+	if len(eventIDs) == 0 {
+		return nil
+	}
+
+	var changes []models.Change
+	err := i.db.Transaction(func(tx *gorm.DB) error {
+		var events []*models.Event
+		if err := tx.Where("planning_id = ? AND id IN ?", planningID, eventIDs).Find(&events).Error; err != nil {
+			return err
+		}
+		if len(events) == 0 {
+			return nil
+		}
+
+		if err := tx.Where("planning_id = ? AND id IN ?", planningID, eventIDs).Delete(&models.Event{}).Error; err != nil {
+			return err
+		}
+
+		changes = make([]models.Change, 0, len(events))
+		for _, event := range events {
+			changes = append(changes, eventChange(models.ChangeEventDeleted, planningID, event.ID, event))
+		}
+		return i.insertChanges(tx, changes)
+	})
+	if err != nil {
+		return err
+	}
+
+	i.notifyChanges(changes)
+	return nil
 }
 
 // GetPlanningByID retrieves a planning by its ID
@@ -95,19 +242,41 @@ func (i *Importer) GetEventsByPlanningID(planningID string) ([]*models.Event, er
 	return events, nil
 }
 
-// DeleteEventByUID deletes an event by its UID and planning ID
+// DeleteEventByUID soft-deletes an event by its UID and planning ID
 func (i *Importer) DeleteEventByUID(uid, planningID string) error {
-	eventID := models.GenerateEventID(uid, planningID)
-	return i.db.Where("id = ?", eventID).Delete(&models.Event{}).Error
+	return i.deleteEvents(planningID, []string{models.GenerateEventID(uid, planningID)})
 }
 
-// SyncEventsForPlanning syncs events for a planning, removing events that are no longer in the iCal feed
-func (i *Importer) SyncEventsForPlanning(planningID string, newEvents []*models.Event) error {
-	// Get all existing events for this planning
-	existingEvents, err := i.GetEventsByPlanningID(planningID)
+// CheckSync reports whether syncing the new events into a planning complies with the
+// deletion policy, returning a *MassDeletionError when it does not
+func (i *Importer) CheckSync(planningID, sourceID string, newEvents []*models.Event, policy DeletionPolicy) error {
+	existingEvents, deleteIDs, err := i.deletionDiff(planningID, sourceID, newEvents)
 	if err != nil {
 		return err
 	}
+	if err := CheckDeletions(planningID, len(existingEvents), len(newEvents), len(deleteIDs), policy); err != nil {
+		slog.WarnContext(i.ctx(), "Sync breaks the deletion policy",
+			"existing", len(existingEvents), "incoming", len(newEvents), "deleting", len(deleteIDs), "error", err)
+		return err
+	}
+	slog.DebugContext(i.ctx(), "Sync complies with the deletion policy",
+		"existing", len(existingEvents), "incoming", len(newEvents), "deleting", len(deleteIDs))
+	return nil
+}
+
+// deletionDiff returns the events of a planning owned by a source and the IDs of those missing from the new events
+func (i *Importer) deletionDiff(planningID, sourceID string, newEvents []*models.Event) ([]*models.Event, []string, error) {
+	planningEvents, err := i.GetEventsByPlanningID(planningID)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var existingEvents []*models.Event
+	for _, event := range planningEvents {
+		if event.OwnedBy(sourceID) {
+			existingEvents = append(existingEvents, event)
+		}
+	}
 
 	// Create a map of new event UIDs for quick lookup
 	newEventUIDs := make(map[string]bool)
@@ -116,78 +285,157 @@ func (i *Importer) SyncEventsForPlanning(planningID string, newEvents []*models.
 	}
 
 	// Find events that are in the database but not in the new iCal feed
-	var eventsToDelete []string
+	var deleteIDs []string
 	for _, existingEvent := range existingEvents {
 		if !newEventUIDs[existingEvent.UID] {
-			eventsToDelete = append(eventsToDelete, existingEvent.UID)
+			deleteIDs = append(deleteIDs, models.GenerateEventID(existingEvent.UID, planningID))
 		}
 	}
 
-	// Delete events that are no longer in the iCal feed
-	deleteCount := 0
-	for _, uid := range eventsToDelete {
-		if err := i.DeleteEventByUID(uid, planningID); err != nil {
-			log.Printf("Warning: Failed to delete event %s: %v", uid, err)
-		} else {
-			deleteCount++
-		}
+	return existingEvents, deleteIDs, nil
+}
+
+// SyncEventsForPlanning syncs the events of a source into a planning, removing the events of that source
+// that are no longer in its iCal feed. Nothing is written when the deletions would break the policy.
+func (i *Importer) SyncEventsForPlanning(planningID, sourceID string, newEvents []*models.Event, policy DeletionPolicy) error {
+	existingEvents, deleteIDs, err := i.deletionDiff(planningID, sourceID, newEvents)
+	if err != nil {
+		return err
+	}
+	if err := CheckDeletions(planningID, len(existingEvents), len(newEvents), len(deleteIDs), policy); err != nil {
+		return err
 	}
 
-	if deleteCount > 0 {
-		log.Printf("Deleted %d events no longer in iCal feed", deleteCount)
+	// Delete events that are no longer in the iCal feed
+	if err := i.deleteEvents(planningID, deleteIDs); err != nil {
+		return fmt.Errorf("failed to delete %d events no longer in iCal feed: %w", len(deleteIDs), err)
+	} else if len(deleteIDs) > 0 {
+		slog.InfoContext(i.ctx(), "Deleted events no longer in the iCal feed", "deleted", len(deleteIDs))
 	}
 
-	// Create or update events from the new iCal feed
+	// Create or update events from the new iCal feed, each with its change log entry
+	var changes []models.Change
 	updateCount := 0
 	createCount := 0
 	for _, event := range newEvents {
-		// Generate composite ID
-		event.ID = models.GenerateEventID(event.UID, event.PlanningID)
-
-		var existing models.Event
-		result := i.db.Where("id = ?", event.ID).First(&existing)
-
-		if result.Error == nil {
-			// Event exists, update it
-			event.Created = existing.Created // Preserve original creation time
-			if err := i.db.Save(event).Error; err != nil {
-				log.Printf("Warning: Failed to update event %s: %v", event.UID, err)
-			} else {
-				updateCount++
-			}
-		} else if result.Error == gorm.ErrRecordNotFound {
-			// Event doesn't exist, create it
-			if err := i.db.Create(event).Error; err != nil {
-				log.Printf("Warning: Failed to create event %s: %v", event.UID, err)
-			} else {
-				createCount++
-			}
+		change, existed, err := i.saveEvent(event)
+		if err != nil {
+			// Listeners still learn about the events saved so far
+			i.notifyChanges(changes)
+			slog.ErrorContext(i.ctx(), "Failed to save event", "event_uid", event.UID, "error", err)
+			return fmt.Errorf("failed to save event %s: %w", event.UID, err)
+		}
+		if existed {
+			updateCount++
 		} else {
-			log.Printf("Warning: Database error for event %s: %v", event.UID, result.Error)
+			createCount++
+		}
+		if change != nil {
+			changes = append(changes, *change)
 		}
 	}
 
 	if createCount > 0 || updateCount > 0 {
-		log.Printf("Processed events: %d created, %d updated", createCount, updateCount)
+		slog.InfoContext(i.ctx(), "Processed events", "created", createCount, "updated", updateCount)
+	}
+
+	i.notifyChanges(changes)
+
+	return nil
+}
+
+// eventChange builds a change log entry for an event, with its state before the change
+func eventChange(changeType, planningID, eventID string, before *models.Event) models.Change {
+	change := models.Change{Type: changeType, PlanningID: planningID, EventID: eventID}
+	if before != nil {
+		snapshot := *before
+		snapshot.Planning = nil
+		if data, err := json.Marshal(snapshot); err == nil {
+			change.Before = string(data)
+		}
+	}
+	return change
+}
+
+// planningChange builds a change log entry for a planning, with its state before the change
+func planningChange(planningID string, before *models.Planning) models.Change {
+	change := models.Change{Type: models.ChangePlanningChanged, PlanningID: planningID}
+	if before != nil {
+		if data, err := json.Marshal(before); err == nil {
+			change.Before = string(data)
+		}
+	}
+	return change
+}
+
+// insertChanges stamps changes with the current sync run and inserts them
+func (i *Importer) insertChanges(tx *gorm.DB, changes []models.Change) error {
+	if i.run != nil {
+		for idx := range changes {
+			changes[idx].SyncRunID = &i.run.ID
+		}
 	}
 
+	if err := tx.CreateInBatches(changes, changeBatchSize).Error; err != nil {
+		return err
+	}
+
+	if i.run != nil {
+		for _, change := range changes {
+			switch change.Type {
+			case models.ChangeEventCreated:
+				i.run.Created++
+			case models.ChangeEventUpdated:
+				i.run.Updated++
+			case models.ChangeEventDeleted:
+				i.run.Deleted++
+			}
+		}
+	}
 	return nil
 }
 
+// notifyChanges tells change listeners that entries up to the last of changes were recorded
+func (i *Importer) notifyChanges(changes []models.Change) {
+	if len(changes) == 0 {
+		return
+	}
+
+	lastID := strconv.FormatUint(changes[len(changes)-1].ID, 10)
+	if err := i.db.Exec("SELECT pg_notify(?, ?)", NotifyChannel, lastID).Error; err != nil {
+		slog.WarnContext(i.ctx(), "Failed to notify change listeners", "last_change_id", lastID, "error", err)
+	}
+}
+
 // InitializeTables creates the necessary database tables if they don't exist
 func (i *Importer) InitializeTables() error {
 	// Auto-migrate the tables
 	if err := i.db.AutoMigrate(&models.Planning{}); err != nil {
-		log.Printf("Failed to migrate Planning table: %v", err)
+		slog.Error("Failed to migrate table", "table", "Planning", "error", err)
 		return err
 	}
 
 	if err := i.db.AutoMigrate(&models.Event{}); err != nil {
-		log.Printf("Failed to migrate Event table: %v", err)
+		slog.Error("Failed to migrate table", "table", "Event", "error", err)
+		return err
+	}
+
+	if err := i.db.AutoMigrate(&models.Change{}); err != nil {
+		slog.Error("Failed to migrate table", "table", "Change", "error", err)
 		return err
 	}
 
-	log.Println("Database tables initialized successfully")
+	if err := i.db.AutoMigrate(&models.ChangePurge{}); err != nil {
+		slog.Error("Failed to migrate table", "table", "ChangePurge", "error", err)
+		return err
+	}
+
+	if err := i.db.AutoMigrate(&models.SyncRun{}); err != nil {
+		slog.Error("Failed to migrate table", "table", "SyncRun", "error", err)
+		return err
+	}
+
+	slog.Info("Database tables initialized")
 	return nil
 }
 
@@ -207,5 +455,11 @@ func (i *Importer) GetStats() (map[string]int64, error) {
 	}
 	stats["events"] = eventCount
 
+	var deletedCount int64
+	if err := i.db.Unscoped().Model(&models.Event{}).Where("deleted_at IS NOT NULL").Count(&deletedCount).Error; err != nil {
+		return nil, err
+	}
+	stats["deleted_events"] = deletedCount
+
 	return stats, nil
 }
@@ -0,0 +1,143 @@
+package importer
+
+import (
+	"errors"
+	"sort"
+	"time"
+
+	"github.com/do2024-2047/CalenDO/ical-importer/internal/models"
+	"gorm.io/gorm"
+)
+
+// EventDiff describes an event that a sync would create, update or delete
+type EventDiff struct {
+	ID        string               `json:"id"`
+	UID       string               `json:"uid"`
+	Summary   string               `json:"summary"`
+	StartTime time.Time            `json:"start_time"`
+	Changes   []models.FieldChange `json:"changes,omitempty"`
+}
+
+// SyncPlan lists what syncing a feed into a planning would change, without changing anything
+type SyncPlan struct {
+	PlanningID   string      `json:"planning_id"`
+	PlanningName string      `json:"planning_name"`
+	Source       string      `json:"source"`
+	NewPlanning  bool        `json:"new_planning"`
+	Create       []EventDiff `json:"create"`
+	Update       []EventDiff `json:"update"`
+	Delete       []EventDiff `json:"delete"`
+	Unchanged    int         `json:"unchanged"`
+	// Aborted is the reason the mass-deletion guard would stop the sync, if it would
+	Aborted string `json:"aborted,omitempty"`
+}
+
+// PlanSync compares the new events of a source with the stored events of its planning and returns the changes
+// a sync would make. Deletions are only planned when deleteMissing is set, as SyncEventsForPlanning would do.
+func (i *Importer) PlanSync(planning *models.Planning, source, sourceID string, newEvents []*models.Event, deleteMissing bool, policy DeletionPolicy) (*SyncPlan, error) {
+	var existing models.Planning
+	newPlanning := false
+	if err := i.db.Where("id = ?", planning.ID).First(&existing).Error; err != nil {
+		if !errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, err
+		}
+		newPlanning = true
+	}
+
+	existingEvents, err := i.GetEventsByPlanningID(planning.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	plan := DiffEvents(existingEvents, newEvents, sourceID, deleteMissing)
+	plan.PlanningID = planning.ID
+	plan.PlanningName = planning.Name
+	plan.Source = source
+	plan.NewPlanning = newPlanning
+
+	if deleteMissing {
+		owned := 0
+		for _, event := range existingEvents {
+			if event.OwnedBy(sourceID) {
+				owned++
+			}
+		}
+		err := CheckDeletions(planning.ID, owned, len(newEvents), len(plan.Delete), policy)
+		var massDeletion *MassDeletionError
+		if errors.As(err, &massDeletion) {
+			plan.Aborted = massDeletion.Reason
+		} else if err != nil {
+			return nil, err
+		}
+	}
+
+	return plan, nil
+}
+
+// DiffEvents compares the stored events of a planning with the events of a source's feed. Events are
+// matched by ID; stored events of the source missing from the feed are listed for deletion only when
+// deleteMissing is set. Each list is sorted by start time.
+func DiffEvents(existingEvents, newEvents []*models.Event, sourceID string, deleteMissing bool) *SyncPlan {
+	plan := &SyncPlan{
+		Create: []EventDiff{},
+		Update: []EventDiff{},
+		Delete: []EventDiff{},
+	}
+
+	existingByID := make(map[string]*models.Event, len(existingEvents))
+	for _, event := range existingEvents {
+		existingByID[event.ID] = event
+	}
+
+	// A later event with the same UID overwrites an earlier one when syncing
+	incoming := make(map[string]*models.Event, len(newEvents))
+	var order []string
+	for _, event := range newEvents {
+		id := models.GenerateEventID(event.UID, event.PlanningID)
+		if _, seen := incoming[id]; !seen {
+			order = append(order, id)
+		}
+		incoming[id] = event
+	}
+
+	for _, id := range order {
+		event := incoming[id]
+		current, ok := existingByID[id]
+		if !ok {
+			plan.Create = append(plan.Create, eventDiff(id, event, nil))
+			continue
+		}
+		if changes := current.FieldChanges(event); len(changes) > 0 {
+			plan.Update = append(plan.Update, eventDiff(id, event, changes))
+		} else {
+			plan.Unchanged++
+		}
+	}
+
+	if deleteMissing {
+		for _, event := range existingEvents {
+			if _, ok := incoming[event.ID]; !ok && event.OwnedBy(sourceID) {
+				plan.Delete = append(plan.Delete, eventDiff(event.ID, event, nil))
+			}
+		}
+	}
+
+	for _, list := range [][]EventDiff{plan.Create, plan.Update, plan.Delete} {
+		sort.SliceStable(list, func(a, b int) bool {
+			return list[a].StartTime.Before(list[b].StartTime)
+		})
+	}
+
+	return plan
+}
+
+// eventDiff summarizes an event for a sync plan
+func eventDiff(id string, event *models.Event, changes []models.FieldChange) EventDiff {
+	return EventDiff{
+		ID:        id,
+		UID:       event.UID,
+		Summary:   event.Summary,
+		StartTime: event.StartTime,
+		Changes:   changes,
+	}
+}
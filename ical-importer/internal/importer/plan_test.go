@@ -0,0 +1,74 @@
+package importer
+
+import (
+	"testing"
+	"time"
+
+	"github.com/do2024-2047/CalenDO/ical-importer/internal/models"
+)
+
+func testEvent(uid, summary string, start time.Time) *models.Event {
+	return &models.Event{
+		ID:         models.GenerateEventID(uid, "planning-id"),
+		UID:        uid,
+		PlanningID: "planning-id",
+		Summary:    summary,
+		StartTime:  start,
+		EndTime:    start.Add(time.Hour),
+	}
+}
+
+func TestDiffEvents(t *testing.T) {
+	monday := time.Date(2026, time.May, 4, 9, 0, 0, 0, time.UTC)
+
+	existing := []*models.Event{
+		testEvent("kept", "Lecture", monday),
+		testEvent("renamed", "Lab", monday.Add(24*time.Hour)),
+		testEvent("moved", "Seminar", monday.Add(48*time.Hour)),
+		testEvent("removed", "Cancelled", monday.Add(72*time.Hour)),
+	}
+	incoming := []*models.Event{
+		testEvent("kept", "Lecture", monday),
+		testEvent("renamed", "Lab session", monday.Add(24*time.Hour)),
+		testEvent("moved", "Seminar", monday.Add(50*time.Hour)),
+		testEvent("added", "Exam", monday.Add(96*time.Hour)),
+	}
+
+	plan := DiffEvents(existing, incoming, "source-id", true)
+
+	if plan.Unchanged != 1 {
+		t.Fatalf("Unchanged = %d, want 1", plan.Unchanged)
+	}
+	if len(plan.Create) != 1 || plan.Create[0].UID != "added" {
+		t.Fatalf("Create = %+v, want the added event", plan.Create)
+	}
+	if len(plan.Delete) != 1 || plan.Delete[0].UID != "removed" {
+		t.Fatalf("Delete = %+v, want the removed event", plan.Delete)
+	}
+	if len(plan.Update) != 2 {
+		t.Fatalf("Update = %+v, want 2 events", plan.Update)
+	}
+
+	renamed := plan.Update[0]
+	if renamed.UID != "renamed" || len(renamed.Changes) != 1 || renamed.Changes[0].Field != "summary" ||
+		renamed.Changes[0].Old != "Lab" || renamed.Changes[0].New != "Lab session" {
+		t.Fatalf("first update = %+v, want a summary change of the renamed event", renamed)
+	}
+
+	moved := plan.Update[1]
+	if moved.UID != "moved" || len(moved.Changes) != 2 ||
+		moved.Changes[0].Field != "start_time" || moved.Changes[1].Field != "end_time" {
+		t.Fatalf("second update = %+v, want start and end changes of the moved event", moved)
+	}
+}
+
+func TestDiffEventsWithoutDeletion(t *testing.T) {
+	start := time.Date(2026, time.May, 4, 9, 0, 0, 0, time.UTC)
+	existing := []*models.Event{testEvent("removed", "Cancelled", start)}
+
+	plan := DiffEvents(existing, nil, "source-id", false)
+
+	if len(plan.Delete) != 0 {
+		t.Fatalf("Delete = %+v, want none when deleteMissing is false", plan.Delete)
+	}
+}
@@ -0,0 +1,280 @@
+package importer
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/do2024-2047/CalenDO/ical-importer/internal/models"
+	"gorm.io/gorm"
+)
+
+// BeginRun starts recording a sync run for a planning. Changes recorded until
+// FinishRun is called are attributed to the run.
+func (i *Importer) BeginRun(planningID, source string) (*models.SyncRun, error) {
+	run := &models.SyncRun{
+		PlanningID: planningID,
+		Source:     source,
+		Status:     models.SyncRunRunning,
+	}
+	if err := i.db.Create(run).Error; err != nil {
+		return nil, fmt.Errorf("failed to record sync run: %w", err)
+	}
+
+	i.run = run
+	return run, nil
+}
+
+// FinishRun stores the outcome of the current sync run and stops attributing changes to it.
+// It returns the finished run, or nil when no run was started.
+func (i *Importer) FinishRun(runErr error) *models.SyncRun {
+	run := i.run
+	if run == nil {
+		return nil
+	}
+	i.run = nil
+
+	finished := time.Now()
+	run.Finished = &finished
+	run.Status = models.SyncRunSucceeded
+	if runErr != nil {
+		run.Status = models.SyncRunFailed
+		var massDeletion *MassDeletionError
+		if errors.As(runErr, &massDeletion) {
+			run.Status = models.SyncRunAborted
+		}
+		run.Error = runErr.Error()
+	}
+
+	if err := i.db.Save(run).Error; err != nil {
+		slog.WarnContext(i.ctx(), "Failed to record outcome of sync run", "run_id", run.ID, "error", err)
+	}
+
+	attrs := []any{"run_id", run.ID, "status", run.Status, "created", run.Created, "updated", run.Updated, "deleted", run.Deleted,
+		"duration", finished.Sub(run.Started)}
+	if runErr != nil {
+		slog.WarnContext(i.ctx(), "Sync run finished", append(attrs, "error", runErr)...)
+	} else {
+		slog.InfoContext(i.ctx(), "Sync run finished", attrs...)
+	}
+	return run
+}
+
+// ListRuns returns the most recent sync runs, newest first, optionally for a single planning.
+// A negative limit returns every run.
+func (i *Importer) ListRuns(planningID string, limit int) ([]*models.SyncRun, error) {
+	query := i.db.Order("id DESC").Limit(limit)
+	if planningID != "" {
+		query = query.Where("planning_id = ?", planningID)
+	}
+
+	var runs []*models.SyncRun
+	if err := query.Find(&runs).Error; err != nil {
+		return nil, err
+	}
+	return runs, nil
+}
+
+// RestoreBeforeRun brings a planning and its events back to their state before the
+// given sync run by undoing, newest first, every change recorded by that run and later
+// ones. The undo is itself recorded as a run, so it can be undone in turn.
+// It returns the number of changes made.
+func (i *Importer) RestoreBeforeRun(planningID string, runID uint64) (int, error) {
+	var target models.SyncRun
+	if err := i.db.Where("id = ?", runID).First(&target).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return 0, fmt.Errorf("sync run %d not found; it may have been purged", runID)
+		}
+		return 0, err
+	}
+	if target.PlanningID != planningID {
+		return 0, fmt.Errorf("sync run %d belongs to planning %s, not %s", runID, target.PlanningID, planningID)
+	}
+
+	var history []models.Change
+	if err := i.db.Where("planning_id = ? AND sync_run_id >= ?", planningID, runID).Order("id DESC").Find(&history).Error; err != nil {
+		return 0, fmt.Errorf("failed to read change log: %w", err)
+	}
+	if len(history) == 0 {
+		return 0, nil
+	}
+
+	if _, err := i.BeginRun(planningID, fmt.Sprintf("restore before run %d", runID)); err != nil {
+		return 0, err
+	}
+	restoreRunID := i.run.ID
+
+	var changes []models.Change
+	err := i.db.Transaction(func(tx *gorm.DB) error {
+		for _, entry := range history {
+			var undo *models.Change
+			var err error
+			if entry.EventID != "" {
+				undo, err = undoEventChange(tx, entry)
+			} else {
+				undo, err = undoPlanningChange(tx, entry)
+			}
+			if err != nil {
+				return fmt.Errorf("failed to undo change %d: %w", entry.ID, err)
+			}
+			if undo != nil {
+				changes = append(changes, *undo)
+			}
+		}
+
+		if len(changes) > 0 {
+			if err := i.insertChanges(tx, changes); err != nil {
+				return err
+			}
+		}
+
+		return tx.Model(&models.SyncRun{}).
+			Where("planning_id = ? AND id >= ? AND id <> ?", planningID, runID, restoreRunID).
+			Update("status", models.SyncRunReverted).Error
+	})
+
+	i.FinishRun(err)
+	if err != nil {
+		return 0, err
+	}
+
+	i.notifyChanges(changes)
+	return len(changes), nil
+}
+
+// undoEventChange puts an event back in the state recorded before a change and
+// returns the change describing the undo, or nil when nothing had to change
+func undoEventChange(tx *gorm.DB, entry models.Change) (*models.Change, error) {
+	var current models.Event
+	err := tx.Unscoped().Where("id = ?", entry.EventID).First(&current).Error
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, err
+	}
+	live := err == nil && !current.DeletedAt.Valid
+
+	if entry.Before == "" {
+		// The event did not exist before the change
+		if !live {
+			return nil, nil
+		}
+		if err := tx.Delete(&current).Error; err != nil {
+			return nil, err
+		}
+		change := eventChange(models.ChangeEventDeleted, entry.PlanningID, entry.EventID, &current)
+		return &change, nil
+	}
+
+	var before models.Event
+	if err := json.Unmarshal([]byte(entry.Before), &before); err != nil {
+		return nil, fmt.Errorf("invalid snapshot: %w", err)
+	}
+	// Saving the snapshot also clears a soft deletion
+	if err := tx.Unscoped().Save(&before).Error; err != nil {
+		return nil, err
+	}
+
+	if !live {
+		change := eventChange(models.ChangeEventCreated, entry.PlanningID, entry.EventID, nil)
+		return &change, nil
+	}
+	change := eventChange(models.ChangeEventUpdated, entry.PlanningID, entry.EventID, &current)
+	return &change, nil
+}
+
+// undoPlanningChange puts a planning back in the state recorded before a change and
+// returns the change describing the undo, or nil when nothing had to change
+func undoPlanningChange(tx *gorm.DB, entry models.Change) (*models.Change, error) {
+	var current models.Planning
+	err := tx.Unscoped().Where("id = ?", entry.PlanningID).First(&current).Error
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, err
+	}
+	live := err == nil && !current.DeletedAt.Valid
+
+	if entry.Before == "" {
+		// The planning did not exist before the change
+		if !live {
+			return nil, nil
+		}
+		if err := tx.Delete(&current).Error; err != nil {
+			return nil, err
+		}
+		change := planningChange(entry.PlanningID, &current)
+		return &change, nil
+	}
+
+	var before models.Planning
+	if err := json.Unmarshal([]byte(entry.Before), &before); err != nil {
+		return nil, fmt.Errorf("invalid snapshot: %w", err)
+	}
+	if err := tx.Unscoped().Save(&before).Error; err != nil {
+		return nil, err
+	}
+
+	var previous *models.Planning
+	if live {
+		previous = &current
+	}
+	change := planningChange(entry.PlanningID, previous)
+	return &change, nil
+}
+
+// Purge permanently removes soft-deleted events and plannings, change log entries
+// and sync runs older than the cutoff. It returns the number of rows removed per table.
+func (i *Importer) Purge(cutoff time.Time) (map[string]int64, error) {
+	removed := make(map[string]int64)
+
+	err := i.db.Transaction(func(tx *gorm.DB) error {
+		result := tx.Unscoped().Where("deleted_at < ?", cutoff).Delete(&models.Event{})
+		if result.Error != nil {
+			return result.Error
+		}
+		removed["events"] = result.RowsAffected
+
+		result = tx.Unscoped().Where("deleted_at < ?", cutoff).Delete(&models.Planning{})
+		if result.Error != nil {
+			return result.Error
+		}
+		removed["plannings"] = result.RowsAffected
+
+		// Record how far the log is purged before removing it, so that the API expires
+		// the sync tokens pointing into the removed part
+		var throughID uint64
+		err := tx.Model(&models.Change{}).Where("created < ?", cutoff).
+			Select("COALESCE(MAX(id), 0)").Scan(&throughID).Error
+		if err != nil {
+			return err
+		}
+		purge := models.NewChangePurge(0)
+		if err := tx.Limit(1).Find(purge, purge.ID).Error; err != nil {
+			return err
+		}
+		if throughID > purge.ThroughID {
+			purge.ThroughID = throughID
+			if err := tx.Save(purge).Error; err != nil {
+				return err
+			}
+		}
+
+		result = tx.Where("created < ?", cutoff).Delete(&models.Change{})
+		if result.Error != nil {
+			return result.Error
+		}
+		removed["changes"] = result.RowsAffected
+
+		result = tx.Where("started < ?", cutoff).Delete(&models.SyncRun{})
+		if result.Error != nil {
+			return result.Error
+		}
+		removed["sync_runs"] = result.RowsAffected
+
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return removed, nil
+}
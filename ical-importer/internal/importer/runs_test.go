@@ -0,0 +1,73 @@
+package importer
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/do2024-2047/CalenDO/ical-importer/internal/models"
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+// newDryRunImporter returns an importer whose statements are built but never sent
+func newDryRunImporter(t *testing.T) *Importer {
+	t.Helper()
+
+	db, err := gorm.Open(postgres.Open("host=localhost"), &gorm.Config{
+		DryRun:                 true,
+		DisableAutomaticPing:   true,
+		SkipDefaultTransaction: true,
+		Logger:                 logger.Discard,
+	})
+	if err != nil {
+		t.Fatalf("failed to open the database: %v", err)
+	}
+	return NewImporter(db)
+}
+
+func TestFinishRun(t *testing.T) {
+	massDeletion := &MassDeletionError{PlanningID: "work", Existing: 10, Deleting: 10, Reason: "the feed has no events"}
+
+	tests := []struct {
+		name       string
+		err        error
+		wantStatus string
+	}{
+		{"succeeded", nil, models.SyncRunSucceeded},
+		{"failed", errors.New("failed to sync events: connection reset"), models.SyncRunFailed},
+		{"aborted", massDeletion, models.SyncRunAborted},
+		{"aborted and wrapped", fmt.Errorf("sync aborted: %w", massDeletion), models.SyncRunAborted},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			i := newDryRunImporter(t)
+			i.run = &models.SyncRun{ID: 1, PlanningID: "work", Status: models.SyncRunRunning}
+
+			run := i.FinishRun(tt.err)
+			if run == nil {
+				t.Fatal("FinishRun returned no run")
+			}
+			if run.Status != tt.wantStatus {
+				t.Errorf("status = %q, want %q", run.Status, tt.wantStatus)
+			}
+			if tt.err != nil && run.Error != tt.err.Error() {
+				t.Errorf("error = %q, want %q", run.Error, tt.err.Error())
+			}
+			if run.Finished == nil {
+				t.Error("finish time was not set")
+			}
+			if i.run != nil {
+				t.Error("changes are still attributed to the finished run")
+			}
+		})
+	}
+}
+
+func TestFinishRunWithoutRun(t *testing.T) {
+	if run := newDryRunImporter(t).FinishRun(errors.New("failed")); run != nil {
+		t.Fatalf("FinishRun = %+v, want nil", run)
+	}
+}
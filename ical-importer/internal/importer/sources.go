@@ -0,0 +1,51 @@
+package importer
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/do2024-2047/CalenDO/ical-importer/internal/models"
+)
+
+// DropCrossSourceDuplicates removes from the events of a source those that another source
+// of the same planning already imported, so that merged feeds do not show an event twice
+func (i *Importer) DropCrossSourceDuplicates(planningID, sourceID string, events []*models.Event) ([]*models.Event, error) {
+	var others []*models.Event
+	err := i.db.Where("planning_id = ? AND source_id <> ? AND source_id <> ''", planningID, sourceID).Find(&others).Error
+	if err != nil {
+		return nil, err
+	}
+	return DropDuplicates(others, events), nil
+}
+
+// DropDuplicates returns the events that have no identical counterpart among others.
+// Events are identical when they have the same summary, ignoring case and surrounding
+// spaces, and the same start and end; an event with the same ID is the same event, not a duplicate.
+func DropDuplicates(others, events []*models.Event) []*models.Event {
+	if len(others) == 0 {
+		return events
+	}
+
+	seen := make(map[string]string, len(others))
+	for _, event := range others {
+		seen[duplicateKey(event)] = event.ID
+	}
+
+	kept := make([]*models.Event, 0, len(events))
+	for _, event := range events {
+		if id, ok := seen[duplicateKey(event)]; ok && id != models.GenerateEventID(event.UID, event.PlanningID) {
+			continue
+		}
+		kept = append(kept, event)
+	}
+	return kept
+}
+
+// duplicateKey identifies an event by what makes it look the same to a reader
+func duplicateKey(event *models.Event) string {
+	return fmt.Sprintf("%s|%d|%d",
+		strings.ToLower(strings.TrimSpace(event.Summary)),
+		event.StartTime.Unix(),
+		event.EndTime.Unix(),
+	)
+}
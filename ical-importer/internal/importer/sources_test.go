@@ -0,0 +1,33 @@
+package importer
+
+import (
+	"testing"
+	"time"
+
+	"github.com/do2024-2047/CalenDO/ical-importer/internal/models"
+)
+
+func TestDropDuplicates(t *testing.T) {
+	start := time.Date(2026, time.May, 4, 9, 0, 0, 0, time.UTC)
+
+	others := []*models.Event{
+		testEvent("google-standup", "Standup", start),
+		testEvent("shared", "Review", start.Add(2*time.Hour)),
+	}
+	events := []*models.Event{
+		// Same meeting under another UID, with different case and spacing
+		testEvent("outlook-standup", " standup ", start),
+		// Same summary at another time
+		testEvent("outlook-standup-2", "Standup", start.Add(24*time.Hour)),
+		// Same UID as an event of the other source: the same row, kept
+		testEvent("shared", "Review", start.Add(2*time.Hour)),
+	}
+	// The same meeting as seen in another time zone is still a duplicate
+	events[0].StartTime = start.In(time.FixedZone("CEST", 2*60*60))
+
+	kept := DropDuplicates(others, events)
+
+	if len(kept) != 2 || kept[0].UID != "outlook-standup-2" || kept[1].UID != "shared" {
+		t.Fatalf("kept %+v, want outlook-standup-2 and shared", kept)
+	}
+}
@@ -0,0 +1,141 @@
+// Package logging sets up structured logging with log/slog from the logging section of
+// the configuration.
+package logging
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/spf13/viper"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// Formats of log records
+const (
+	FormatJSON = "json"
+	FormatText = "text"
+)
+
+// Config is the logging section of the configuration
+type Config struct {
+	// Level is the minimum level logged: debug, info, warn or error (default info)
+	Level string `mapstructure:"level"`
+	// Format is json or text (default json)
+	Format string `mapstructure:"format"`
+	// File also receives the logs when set; standard error always does
+	File string `mapstructure:"file"`
+}
+
+// ConfigFromViper reads the logging section of the configuration
+func ConfigFromViper() Config {
+	return Config{
+		Level:  viper.GetString("logging.level"),
+		Format: viper.GetString("logging.format"),
+		File:   viper.GetString("logging.file"),
+	}
+}
+
+// ParseLevel converts a configured level name to a slog level
+func ParseLevel(name string) (slog.Level, error) {
+	switch strings.ToLower(strings.TrimSpace(name)) {
+	case "debug":
+		return slog.LevelDebug, nil
+	case "", "info":
+		return slog.LevelInfo, nil
+	case "warn", "warning":
+		return slog.LevelWarn, nil
+	case "error":
+		return slog.LevelError, nil
+	}
+	return slog.LevelInfo, fmt.Errorf("invalid logging level %q, expected debug, info, warn or error", name)
+}
+
+// Setup installs the configured logger as the slog default. Lines written with the standard
+// log package, by dependencies, go through it at the info level. It returns a function
+// closing the log file, if any.
+func Setup(cfg Config) (func(), error) {
+	level, err := ParseLevel(cfg.Level)
+	if err != nil {
+		return func() {}, err
+	}
+
+	// Standard output is reserved for command output, such as dry run diffs
+	var out io.Writer = os.Stderr
+	closeFile := func() {}
+	if cfg.File != "" {
+		file, err := openLogFile(cfg.File)
+		if err != nil {
+			// Containers often have a read-only filesystem; standard error is enough there
+			fmt.Fprintf(os.Stderr, "Warning: Logging to standard error only: %v\n", err)
+		} else {
+			out = io.MultiWriter(os.Stderr, file)
+			closeFile = func() { file.Close() }
+		}
+	}
+
+	opts := &slog.HandlerOptions{Level: level}
+	var handler slog.Handler
+	switch strings.ToLower(cfg.Format) {
+	case "", FormatJSON:
+		handler = slog.NewJSONHandler(out, opts)
+	case FormatText:
+		handler = slog.NewTextHandler(out, opts)
+	default:
+		closeFile()
+		return func() {}, fmt.Errorf("invalid logging format %q, expected json or text", cfg.Format)
+	}
+
+	slog.SetDefault(slog.New(&contextHandler{Handler: handler}))
+	return closeFile, nil
+}
+
+// openLogFile opens a log file for appending, creating its directory if needed
+func openLogFile(path string) (*os.File, error) {
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return nil, err
+	}
+	return os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o644)
+}
+
+// attrsKey is the context key of the attributes added with With
+type attrsKey struct{}
+
+// With returns a context carrying attributes, given as alternating keys and values like
+// slog.Logger.With, which every record logged with the context includes. Attributes already
+// carried by ctx are kept.
+func With(ctx context.Context, args ...any) context.Context {
+	attrs := append(contextAttrs(ctx), slog.Group("", args...).Value.Group()...)
+	return context.WithValue(ctx, attrsKey{}, attrs)
+}
+
+// contextAttrs returns a copy of the attributes carried by a context
+func contextAttrs(ctx context.Context) []slog.Attr {
+	attrs, _ := ctx.Value(attrsKey{}).([]slog.Attr)
+	return append([]slog.Attr(nil), attrs...)
+}
+
+// contextHandler adds the attributes and the trace of the context to each record
+type contextHandler struct {
+	slog.Handler
+}
+
+func (h *contextHandler) Handle(ctx context.Context, record slog.Record) error {
+	record.AddAttrs(contextAttrs(ctx)...)
+	if span := trace.SpanContextFromContext(ctx); span.IsValid() {
+		record.AddAttrs(slog.String("trace_id", span.TraceID().String()), slog.String("span_id", span.SpanID().String()))
+	}
+	return h.Handler.Handle(ctx, record)
+}
+
+func (h *contextHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return &contextHandler{Handler: h.Handler.WithAttrs(attrs)}
+}
+
+func (h *contextHandler) WithGroup(name string) slog.Handler {
+	return &contextHandler{Handler: h.Handler.WithGroup(name)}
+}
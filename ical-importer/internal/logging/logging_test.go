@@ -0,0 +1,87 @@
+package logging
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"log/slog"
+	"testing"
+
+	"gorm.io/gorm/logger"
+)
+
+func TestParseLevel(t *testing.T) {
+	tests := []struct {
+		name    string
+		want    slog.Level
+		wantErr bool
+	}{
+		{"", slog.LevelInfo, false},
+		{"debug", slog.LevelDebug, false},
+		{"INFO", slog.LevelInfo, false},
+		{"warning", slog.LevelWarn, false},
+		{"error", slog.LevelError, false},
+		{"verbose", slog.LevelInfo, true},
+	}
+
+	for _, tt := range tests {
+		got, err := ParseLevel(tt.name)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("ParseLevel(%q) error = %v, wantErr %v", tt.name, err, tt.wantErr)
+		}
+		if got != tt.want {
+			t.Errorf("ParseLevel(%q) = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestGormLevel(t *testing.T) {
+	tests := []struct {
+		level slog.Level
+		want  logger.LogLevel
+	}{
+		{slog.LevelDebug, logger.Info},
+		{slog.LevelInfo, logger.Warn},
+		{slog.LevelWarn, logger.Warn},
+		{slog.LevelError, logger.Error},
+	}
+
+	for _, tt := range tests {
+		if got := GormLevel(tt.level); got != tt.want {
+			t.Errorf("GormLevel(%v) = %v, want %v", tt.level, got, tt.want)
+		}
+	}
+}
+
+func TestContextAttributes(t *testing.T) {
+	var buf bytes.Buffer
+	logger := slog.New(&contextHandler{Handler: slog.NewJSONHandler(&buf, nil)})
+
+	source := With(context.Background(), "planning_id", "work", "source_id", "a1b2")
+	run := With(source, "run_id", 7)
+	logger.WarnContext(run, "Sync aborted", "error", "too many deletions")
+	logger.InfoContext(source, "Synced events")
+
+	var records []map[string]any
+	for _, line := range bytes.Split(bytes.TrimSpace(buf.Bytes()), []byte("\n")) {
+		var record map[string]any
+		if err := json.Unmarshal(line, &record); err != nil {
+			t.Fatalf("invalid JSON record %q: %v", line, err)
+		}
+		records = append(records, record)
+	}
+	if len(records) != 2 {
+		t.Fatalf("logged %d records, want 2", len(records))
+	}
+
+	want := map[string]any{"level": "WARN", "msg": "Sync aborted", "error": "too many deletions", "planning_id": "work", "source_id": "a1b2", "run_id": float64(7)}
+	for key, value := range want {
+		if records[0][key] != value {
+			t.Errorf("record %s = %v, want %v", key, records[0][key], value)
+		}
+	}
+	// Adding attributes to a context leaves its parent unchanged
+	if _, ok := records[1]["run_id"]; ok || records[1]["planning_id"] != "work" {
+		t.Errorf("record of the source context = %v, want planning_id without run_id", records[1])
+	}
+}
@@ -0,0 +1,128 @@
+// Package metrics collects Prometheus metrics about syncs. The importer runs as a
+// short-lived job, so they are written to a textfile for node_exporter or pushed to
+// a Pushgateway when it finishes instead of being scraped.
+package metrics
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/push"
+)
+
+// namespace prefixes every metric name
+const namespace = "calendo_importer"
+
+// DefaultJob is the Pushgateway job name used when none is configured
+const DefaultJob = "ical-importer"
+
+var (
+	registry = prometheus.NewRegistry()
+
+	syncDuration = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Name:      "sync_duration_seconds",
+		Help:      "Duration of the last sync of a source, fetch included.",
+	}, []string{"planning", "source"})
+
+	fetchBytes = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Name:      "fetch_bytes",
+		Help:      "Size of the iCal feed downloaded by the last sync of a source.",
+	}, []string{"planning", "source"})
+
+	events = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Name:      "events",
+		Help:      "Events handled by the last sync of a source: parsed from the feed (after rules), then created, updated and deleted.",
+	}, []string{"planning", "source", "action"})
+
+	// A counter would restart at zero in every run of the short-lived importer, so the
+	// outcome of the last sync is a gauge, overwritten on each run
+	syncFailed = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Name:      "last_sync_failed",
+		Help:      "1 if the last sync of a source failed or was aborted, 0 if it succeeded.",
+	}, []string{"planning", "source"})
+
+	lastRun = prometheus.NewGauge(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Name:      "last_run_timestamp_seconds",
+		Help:      "Time the importer last finished, as a Unix timestamp.",
+	})
+)
+
+func init() {
+	registry.MustRegister(syncDuration, fetchBytes, events, syncFailed, lastRun)
+}
+
+// Sync describes one sync of a source into a planning
+type Sync struct {
+	PlanningID string
+	SourceID   string
+	Duration   time.Duration
+	// FetchBytes is the size of the downloaded feed; zero for local files
+	FetchBytes int64
+	Parsed     int
+	Created    int
+	Updated    int
+	Deleted    int
+	Err        error
+}
+
+// ObserveSync records the outcome of a sync
+func ObserveSync(s Sync) {
+	syncDuration.WithLabelValues(s.PlanningID, s.SourceID).Set(s.Duration.Seconds())
+	fetchBytes.WithLabelValues(s.PlanningID, s.SourceID).Set(float64(s.FetchBytes))
+
+	counts := map[string]int{"parsed": s.Parsed, "created": s.Created, "updated": s.Updated, "deleted": s.Deleted}
+	for action, count := range counts {
+		events.WithLabelValues(s.PlanningID, s.SourceID, action).Set(float64(count))
+	}
+
+	failed := 0.0
+	if s.Err != nil {
+		failed = 1
+	}
+	syncFailed.WithLabelValues(s.PlanningID, s.SourceID).Set(failed)
+}
+
+// Sink says where Flush sends the metrics; both destinations are optional
+type Sink struct {
+	// Textfile is a file for the node_exporter textfile collector, replaced atomically
+	Textfile string
+	// Pushgateway is the URL of a Pushgateway
+	Pushgateway string
+	// Job is the Pushgateway job name (default DefaultJob)
+	Job string
+}
+
+// Enabled reports whether the sink has a destination
+func (s Sink) Enabled() bool {
+	return s.Textfile != "" || s.Pushgateway != ""
+}
+
+// Flush writes the metrics collected so far to the sink's destinations
+func Flush(sink Sink) error {
+	lastRun.SetToCurrentTime()
+
+	if sink.Textfile != "" {
+		if err := prometheus.WriteToTextfile(sink.Textfile, registry); err != nil {
+			return fmt.Errorf("failed to write metrics to %s: %w", sink.Textfile, err)
+		}
+	}
+
+	if sink.Pushgateway != "" {
+		job := sink.Job
+		if job == "" {
+			job = DefaultJob
+		}
+		// Push replaces the job's metrics, so sources removed from the configuration disappear
+		if err := push.New(sink.Pushgateway, job).Gatherer(registry).Push(); err != nil {
+			return fmt.Errorf("failed to push metrics to %s: %w", sink.Pushgateway, err)
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,49 @@
+package metrics
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestFlushTextfile(t *testing.T) {
+	ObserveSync(Sync{PlanningID: "work", SourceID: "src-a", Duration: 2 * time.Second, FetchBytes: 1024, Parsed: 10, Created: 3})
+	ObserveSync(Sync{PlanningID: "home", SourceID: "src-b", Err: errors.New("HTTP 500")})
+
+	path := filepath.Join(t.TempDir(), "importer.prom")
+	if err := Flush(Sink{Textfile: path}); err != nil {
+		t.Fatalf("Flush() error = %v", err)
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("failed to read textfile: %v", err)
+	}
+	out := string(data)
+
+	for _, want := range []string{
+		`calendo_importer_sync_duration_seconds{planning="work",source="src-a"} 2`,
+		`calendo_importer_fetch_bytes{planning="work",source="src-a"} 1024`,
+		`calendo_importer_events{action="parsed",planning="work",source="src-a"} 10`,
+		`calendo_importer_events{action="created",planning="work",source="src-a"} 3`,
+		`calendo_importer_last_sync_failed{planning="work",source="src-a"} 0`,
+		`calendo_importer_last_sync_failed{planning="home",source="src-b"} 1`,
+		`calendo_importer_last_run_timestamp_seconds`,
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("textfile missing %q\n%s", want, out)
+		}
+	}
+}
+
+func TestSinkEnabled(t *testing.T) {
+	if (Sink{}).Enabled() {
+		t.Error("empty sink should be disabled")
+	}
+	if !(Sink{Pushgateway: "http://pushgateway:9091"}).Enabled() {
+		t.Error("sink with a Pushgateway should be enabled")
+	}
+}
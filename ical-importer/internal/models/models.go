@@ -3,6 +3,8 @@ package models
 import (
 	"fmt"
 	"time"
+
+	"gorm.io/gorm"
 )
 
 // Planning represents a calendar planning instance
@@ -14,6 +16,9 @@ type Planning struct {
 	Created     time.Time `json:"created" gorm:"column:created;autoCreateTime"`
 	Updated     time.Time `json:"updated" gorm:"column:updated;autoUpdateTime"`
 	IsDefault   bool      `json:"is_default" gorm:"column:is_default;default:false"`
+	Timezone    string    `json:"timezone" gorm:"column:timezone"`
+	// DeletedAt marks a soft-deleted planning, kept until purged so it can be restored
+	DeletedAt gorm.DeletedAt `json:"-" gorm:"column:deleted_at;index"`
 }
 
 // TableName specifies the table name for the Planning model
@@ -34,6 +39,14 @@ type Event struct {
 	Summary      string    `json:"summary" gorm:"column:summary"`
 	Location     string    `json:"location" gorm:"column:location"`
 	Description  string    `json:"description" gorm:"column:description"`
+	Status       string    `json:"status" gorm:"column:status"`
+	Transparency string    `json:"transparency" gorm:"column:transparency"`
+	// SourceID identifies the calendar source that imported the event; a sync only deletes its own events
+	SourceID string `json:"source_id" gorm:"column:source_id;index"`
+	// DeletedAt marks a soft-deleted event, kept until purged so it can be restored
+	DeletedAt gorm.DeletedAt `json:"-" gorm:"column:deleted_at;index"`
+	// Categories come from the feed's CATEGORIES for source rules to match on; they are not stored
+	Categories []string `json:"-" gorm:"-"`
 
 	// Relationships
 	Planning *Planning `json:"planning,omitempty" gorm:"foreignKey:PlanningID;references:ID"`
@@ -48,3 +61,132 @@ func (Event) TableName() string {
 func GenerateEventID(uid, planningID string) string {
 	return fmt.Sprintf("%s_%s", uid, planningID)
 }
+
+// Change types recorded in the change log
+const (
+	ChangeEventCreated    = "event.created"
+	ChangeEventUpdated    = "event.updated"
+	ChangeEventDeleted    = "event.deleted"
+	ChangePlanningChanged = "planning.changed"
+)
+
+// Change is an entry of the change log. Each write made by the importer records
+// one, so API clients can be told what changed and resume from where they left off.
+type Change struct {
+	ID         uint64 `json:"id" gorm:"primaryKey;autoIncrement;column:id"`
+	Type       string `json:"type" gorm:"column:type;not null"`
+	PlanningID string `json:"planning_id" gorm:"column:planning_id;not null;index"`
+	EventID    string `json:"event_id,omitempty" gorm:"column:event_id"`
+	// SyncRunID is the sync run that made the change, if any
+	SyncRunID *uint64 `json:"sync_run_id,omitempty" gorm:"column:sync_run_id;index"`
+	// Before is the JSON state of the event or planning before the change,
+	// empty when it did not exist; restores replay it to undo the change
+	Before  string    `json:"-" gorm:"column:before;type:text"`
+	Created time.Time `json:"created" gorm:"column:created;autoCreateTime"`
+}
+
+// TableName specifies the table name for the Change model
+func (Change) TableName() string {
+	return "changes"
+}
+
+// changePurgeID is the ID of the single row of the change_purges table
+const changePurgeID = 1
+
+// ChangePurge records the newest change the purge command removed from the log, so that
+// the API can tell a sync token the log was purged past from a gap in the change IDs.
+type ChangePurge struct {
+	ID        uint      `gorm:"primaryKey;column:id"`
+	ThroughID uint64    `gorm:"column:through_id;not null"`
+	Purged    time.Time `gorm:"column:purged;autoUpdateTime"`
+}
+
+// NewChangePurge returns the purge record for changes up to throughID
+func NewChangePurge(throughID uint64) *ChangePurge {
+	return &ChangePurge{ID: changePurgeID, ThroughID: throughID}
+}
+
+// TableName specifies the table name for the ChangePurge model
+func (ChangePurge) TableName() string {
+	return "change_purges"
+}
+
+// Sync run statuses
+const (
+	SyncRunRunning   = "running"
+	SyncRunSucceeded = "succeeded"
+	SyncRunFailed    = "failed"
+	SyncRunAborted   = "aborted" // Stopped by the mass-deletion guard before writing anything
+	SyncRunReverted  = "reverted"
+)
+
+// SyncRun records one import of a calendar source into a planning.
+// The changes it made reference it, so a planning can be restored to its state before a run.
+type SyncRun struct {
+	ID         uint64     `json:"id" gorm:"primaryKey;autoIncrement;column:id"`
+	PlanningID string     `json:"planning_id" gorm:"column:planning_id;not null;index"`
+	Source     string     `json:"source" gorm:"column:source"`
+	Status     string     `json:"status" gorm:"column:status;not null"`
+	Created    int        `json:"created" gorm:"column:created_count"`
+	Updated    int        `json:"updated" gorm:"column:updated_count"`
+	Deleted    int        `json:"deleted" gorm:"column:deleted_count"`
+	Error      string     `json:"error,omitempty" gorm:"column:error"`
+	Started    time.Time  `json:"started" gorm:"column:started;autoCreateTime"`
+	Finished   *time.Time `json:"finished,omitempty" gorm:"column:finished"`
+}
+
+// TableName specifies the table name for the SyncRun model
+func (SyncRun) TableName() string {
+	return "sync_runs"
+}
+
+// OwnedBy reports whether an event belongs to a source. Events imported before sources
+// were recorded belong to whichever source syncs their planning.
+func (e *Event) OwnedBy(sourceID string) bool {
+	return e.SourceID == "" || e.SourceID == sourceID
+}
+
+// FieldChange describes how one field of an event changes
+type FieldChange struct {
+	Field string `json:"field"`
+	Old   string `json:"old"`
+	New   string `json:"new"`
+}
+
+// FieldChanges lists the content fields that differ between an event and its updated
+// version, in the order they are compared by ContentEquals
+func (e *Event) FieldChanges(updated *Event) []FieldChange {
+	var changes []FieldChange
+	add := func(field, old, new string) {
+		if old != new {
+			changes = append(changes, FieldChange{Field: field, Old: old, New: new})
+		}
+	}
+
+	add("summary", e.Summary, updated.Summary)
+	add("description", e.Description, updated.Description)
+	add("location", e.Location, updated.Location)
+	if !e.StartTime.Equal(updated.StartTime) {
+		add("start_time", e.StartTime.Format(time.RFC3339), updated.StartTime.Format(time.RFC3339))
+	}
+	if !e.EndTime.Equal(updated.EndTime) {
+		add("end_time", e.EndTime.Format(time.RFC3339), updated.EndTime.Format(time.RFC3339))
+	}
+	add("all_day", fmt.Sprint(e.AllDay), fmt.Sprint(updated.AllDay))
+	add("status", e.Status, updated.Status)
+	add("transparency", e.Transparency, updated.Transparency)
+	return changes
+}
+
+// ContentEquals reports whether two events describe the same occurrence,
+// ignoring bookkeeping fields such as timestamps
+func (e *Event) ContentEquals(other *Event) bool {
+	return e.Summary == other.Summary &&
+		e.Description == other.Description &&
+		e.Location == other.Location &&
+		e.StartTime.Equal(other.StartTime) &&
+		e.EndTime.Equal(other.EndTime) &&
+		e.AllDay == other.AllDay &&
+		e.Status == other.Status &&
+		e.Transparency == other.Transparency
+}
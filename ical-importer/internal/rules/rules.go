@@ -0,0 +1,228 @@
+// Package rules filters and transforms the events of a calendar source before they are synced.
+package rules
+
+import (
+	"fmt"
+	"regexp"
+	"time"
+
+	"github.com/do2024-2047/CalenDO/ical-importer/internal/models"
+)
+
+// Rule actions
+const (
+	// ActionInclude keeps only the events that match
+	ActionInclude = "include"
+	// ActionExclude drops the events that match
+	ActionExclude = "exclude"
+	// ActionRewrite replaces Pattern with Replacement in the summary of the events that match
+	ActionRewrite = "rewrite"
+	// ActionStripDescription clears the description of the events that match
+	ActionStripDescription = "strip_description"
+	// ActionShift moves the events that match by Shift
+	ActionShift = "shift"
+	// ActionAllDay turns the events that match into all-day events covering the same days
+	ActionAllDay = "all_day"
+)
+
+// Rule is one step of a source's rules as written in the sync configuration
+type Rule struct {
+	// Match selects the events the rule applies to; an empty match selects every event
+	Match Match `yaml:"match,omitempty"`
+	// Action is what the rule does to the selected events
+	Action string `yaml:"action"`
+	// Pattern and Replacement are used by rewrite; Replacement may refer to groups as $1
+	Pattern     string `yaml:"pattern,omitempty"`
+	Replacement string `yaml:"replacement,omitempty"`
+	// Shift is used by shift, as a Go duration such as 1h or -30m
+	Shift string `yaml:"shift,omitempty"`
+}
+
+// Match holds regular expressions an event must all match
+type Match struct {
+	Summary  string `yaml:"summary,omitempty"`
+	Location string `yaml:"location,omitempty"`
+	// Categories matches when any of the event's categories matches
+	Categories string `yaml:"categories,omitempty"`
+}
+
+// Set is a compiled list of rules, applied in order
+type Set struct {
+	rules []compiledRule
+}
+
+type compiledRule struct {
+	action      string
+	summary     *regexp.Regexp
+	location    *regexp.Regexp
+	categories  *regexp.Regexp
+	pattern     *regexp.Regexp
+	replacement string
+	shift       time.Duration
+}
+
+// Compile validates rules and prepares them to be applied. It returns a nil Set,
+// which leaves events untouched, when there are no rules.
+func Compile(rules []Rule) (*Set, error) {
+	if len(rules) == 0 {
+		return nil, nil
+	}
+
+	set := &Set{rules: make([]compiledRule, 0, len(rules))}
+	for n, rule := range rules {
+		compiled, err := compileRule(rule)
+		if err != nil {
+			return nil, fmt.Errorf("rule %d: %w", n+1, err)
+		}
+		set.rules = append(set.rules, compiled)
+	}
+	return set, nil
+}
+
+func compileRule(rule Rule) (compiledRule, error) {
+	compiled := compiledRule{action: rule.Action, replacement: rule.Replacement}
+
+	var err error
+	if compiled.summary, err = compileOptional("match.summary", rule.Match.Summary); err != nil {
+		return compiled, err
+	}
+	if compiled.location, err = compileOptional("match.location", rule.Match.Location); err != nil {
+		return compiled, err
+	}
+	if compiled.categories, err = compileOptional("match.categories", rule.Match.Categories); err != nil {
+		return compiled, err
+	}
+
+	switch rule.Action {
+	case ActionInclude, ActionExclude, ActionStripDescription, ActionAllDay:
+	case ActionRewrite:
+		if rule.Pattern == "" {
+			return compiled, fmt.Errorf("rewrite needs a pattern")
+		}
+		if compiled.pattern, err = compileOptional("pattern", rule.Pattern); err != nil {
+			return compiled, err
+		}
+	case ActionShift:
+		if compiled.shift, err = time.ParseDuration(rule.Shift); err != nil {
+			return compiled, fmt.Errorf("invalid shift %q: %w", rule.Shift, err)
+		}
+	case "":
+		return compiled, fmt.Errorf("missing action")
+	default:
+		return compiled, fmt.Errorf("unknown action %q", rule.Action)
+	}
+
+	return compiled, nil
+}
+
+func compileOptional(field, expr string) (*regexp.Regexp, error) {
+	if expr == "" {
+		return nil, nil
+	}
+	re, err := regexp.Compile(expr)
+	if err != nil {
+		return nil, fmt.Errorf("invalid %s: %w", field, err)
+	}
+	return re, nil
+}
+
+// Apply runs the rules over the events in order and returns the events that are kept.
+// Kept events are modified in place.
+func (s *Set) Apply(events []*models.Event) []*models.Event {
+	if s == nil {
+		return events
+	}
+
+	kept := make([]*models.Event, 0, len(events))
+	for _, event := range events {
+		if s.apply(event) {
+			kept = append(kept, event)
+		}
+	}
+	return kept
+}
+
+// apply runs the rules over one event and reports whether it is kept
+func (s *Set) apply(event *models.Event) bool {
+	for _, rule := range s.rules {
+		matched := rule.matches(event)
+
+		switch rule.action {
+		case ActionInclude:
+			if !matched {
+				return false
+			}
+		case ActionExclude:
+			if matched {
+				return false
+			}
+		case ActionRewrite:
+			if matched {
+				event.Summary = rule.pattern.ReplaceAllString(event.Summary, rule.replacement)
+			}
+		case ActionStripDescription:
+			if matched {
+				event.Description = ""
+			}
+		case ActionShift:
+			if matched {
+				event.StartTime = event.StartTime.Add(rule.shift)
+				event.EndTime = event.EndTime.Add(rule.shift)
+			}
+		case ActionAllDay:
+			if matched {
+				makeAllDay(event)
+			}
+		}
+	}
+	return true
+}
+
+// matches reports whether an event matches every expression of the rule
+func (r compiledRule) matches(event *models.Event) bool {
+	if r.summary != nil && !r.summary.MatchString(event.Summary) {
+		return false
+	}
+	if r.location != nil && !r.location.MatchString(event.Location) {
+		return false
+	}
+	if r.categories != nil {
+		found := false
+		for _, category := range event.Categories {
+			if r.categories.MatchString(category) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	return true
+}
+
+// makeAllDay turns an event into an all-day event over the days it touches, stored like
+// DATE values from a feed: midnight UTC of the first day to midnight UTC after the last day
+func makeAllDay(event *models.Event) {
+	if event.AllDay {
+		return
+	}
+
+	start := dateOf(event.StartTime)
+	end := dateOf(event.EndTime)
+	// The end is exclusive, so the day an event ends during is included
+	endsAtMidnight := event.EndTime.Hour() == 0 && event.EndTime.Minute() == 0 &&
+		event.EndTime.Second() == 0 && event.EndTime.Nanosecond() == 0
+	if !endsAtMidnight || !end.After(start) {
+		end = end.AddDate(0, 0, 1)
+	}
+
+	event.StartTime = start
+	event.EndTime = end
+	event.AllDay = true
+}
+
+// dateOf returns midnight UTC of the day a time falls on in its own location
+func dateOf(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+}
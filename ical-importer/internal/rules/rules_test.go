@@ -0,0 +1,121 @@
+package rules
+
+import (
+	"testing"
+	"time"
+
+	"github.com/do2024-2047/CalenDO/ical-importer/internal/models"
+)
+
+func testEvents() []*models.Event {
+	start := time.Date(2026, time.May, 4, 9, 0, 0, 0, time.UTC)
+	return []*models.Event{
+		{UID: "lecture", Summary: "CM - Algorithms", Location: "Amphi A", Description: "Teacher: Ada", StartTime: start, EndTime: start.Add(2 * time.Hour)},
+		{UID: "lab", Summary: "TP - Algorithms", Location: "Room 12", Description: "Teacher: Alan", StartTime: start.Add(3 * time.Hour), EndTime: start.Add(5 * time.Hour), Categories: []string{"Lab", "Group B"}},
+		{UID: "meeting", Summary: "Staff meeting", Location: "Room 3", StartTime: start.Add(6 * time.Hour), EndTime: start.Add(7 * time.Hour)},
+	}
+}
+
+func uids(events []*models.Event) []string {
+	result := make([]string, 0, len(events))
+	for _, event := range events {
+		result = append(result, event.UID)
+	}
+	return result
+}
+
+func mustCompile(t *testing.T, rules []Rule) *Set {
+	t.Helper()
+	set, err := Compile(rules)
+	if err != nil {
+		t.Fatalf("Compile returned error: %v", err)
+	}
+	return set
+}
+
+func TestApplyFilters(t *testing.T) {
+	tests := []struct {
+		name  string
+		rules []Rule
+		want  []string
+	}{
+		{"no rules", nil, []string{"lecture", "lab", "meeting"}},
+		{"exclude summary", []Rule{{Action: ActionExclude, Match: Match{Summary: "(?i)meeting"}}}, []string{"lecture", "lab"}},
+		{"include location", []Rule{{Action: ActionInclude, Match: Match{Location: "^Room"}}}, []string{"lab", "meeting"}},
+		{"include category", []Rule{{Action: ActionInclude, Match: Match{Categories: "^Group B$"}}}, []string{"lab"}},
+		{"all expressions must match", []Rule{{Action: ActionExclude, Match: Match{Summary: "Algorithms", Location: "Amphi"}}}, []string{"lab", "meeting"}},
+		{"rules run in order", []Rule{
+			{Action: ActionRewrite, Pattern: "^TP - ", Replacement: "Lab: "},
+			{Action: ActionExclude, Match: Match{Summary: "^TP"}},
+		}, []string{"lecture", "lab", "meeting"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := uids(mustCompile(t, tt.rules).Apply(testEvents()))
+			if len(got) != len(tt.want) {
+				t.Fatalf("kept %v, want %v", got, tt.want)
+			}
+			for n := range got {
+				if got[n] != tt.want[n] {
+					t.Fatalf("kept %v, want %v", got, tt.want)
+				}
+			}
+		})
+	}
+}
+
+func TestApplyTransformations(t *testing.T) {
+	set := mustCompile(t, []Rule{
+		{Action: ActionRewrite, Match: Match{Summary: "^(CM|TP) - "}, Pattern: "^(CM|TP) - (.*)$", Replacement: "$2 ($1)"},
+		{Action: ActionStripDescription},
+		{Action: ActionShift, Match: Match{Location: "Amphi"}, Shift: "-30m"},
+		{Action: ActionAllDay, Match: Match{Summary: "meeting"}},
+	})
+
+	events := set.Apply(testEvents())
+	lecture, lab, meeting := events[0], events[1], events[2]
+
+	if lecture.Summary != "Algorithms (CM)" || lab.Summary != "Algorithms (TP)" {
+		t.Fatalf("summaries = %q, %q, want rewritten", lecture.Summary, lab.Summary)
+	}
+	for _, event := range events {
+		if event.Description != "" {
+			t.Fatalf("description of %s = %q, want stripped", event.UID, event.Description)
+		}
+	}
+
+	wantStart := time.Date(2026, time.May, 4, 8, 30, 0, 0, time.UTC)
+	if !lecture.StartTime.Equal(wantStart) || !lecture.EndTime.Equal(wantStart.Add(2*time.Hour)) {
+		t.Fatalf("lecture = %v - %v, want shifted by -30m", lecture.StartTime, lecture.EndTime)
+	}
+	if !lab.StartTime.Equal(time.Date(2026, time.May, 4, 12, 0, 0, 0, time.UTC)) {
+		t.Fatalf("lab start = %v, want unshifted", lab.StartTime)
+	}
+
+	day := time.Date(2026, time.May, 4, 0, 0, 0, 0, time.UTC)
+	if !meeting.AllDay || !meeting.StartTime.Equal(day) || !meeting.EndTime.Equal(day.AddDate(0, 0, 1)) {
+		t.Fatalf("meeting = %v - %v (all day %v), want the whole day", meeting.StartTime, meeting.EndTime, meeting.AllDay)
+	}
+}
+
+func TestCompileErrors(t *testing.T) {
+	tests := []struct {
+		name string
+		rule Rule
+	}{
+		{"missing action", Rule{}},
+		{"unknown action", Rule{Action: "drop"}},
+		{"invalid regex", Rule{Action: ActionExclude, Match: Match{Summary: "("}}},
+		{"rewrite without pattern", Rule{Action: ActionRewrite}},
+		{"invalid shift", Rule{Action: ActionShift, Shift: "soon"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := Compile([]Rule{tt.rule}); err == nil {
+				t.Fatal("Compile returned no error")
+			}
+		})
+	}
+}
@@ -0,0 +1,35 @@
+package tracing
+
+import (
+	"context"
+	"testing"
+)
+
+func TestSetup(t *testing.T) {
+	tests := []struct {
+		name    string
+		cfg     Config
+		wantErr bool
+	}{
+		{"disabled", Config{SampleRatio: 1}, false},
+		{"none", Config{Exporter: "none", SampleRatio: 1}, false},
+		{"stdout", Config{Exporter: "stdout", ServiceName: DefaultServiceName, SampleRatio: 0.5}, false},
+		{"unknown exporter", Config{Exporter: "zipkin", SampleRatio: 1}, true},
+		{"ratio above one", Config{Exporter: "stdout", SampleRatio: 2}, true},
+		{"negative ratio", Config{Exporter: "stdout", SampleRatio: -0.1}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			shutdown, err := Setup(context.Background(), tt.cfg)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Setup() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err == nil {
+				if err := shutdown(context.Background()); err != nil {
+					t.Errorf("shutdown() error = %v", err)
+				}
+			}
+		})
+	}
+}
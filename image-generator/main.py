@@ -1,4 +1,4 @@
-from fastapi import FastAPI, HTTPException, Query
+from fastapi import FastAPI, Header, HTTPException, Query
 from fastapi.responses import Response
 from typing import List, Optional
 from datetime import datetime, date
@@ -7,6 +7,8 @@ import requests
 from PIL import Image, ImageDraw, ImageFont
 import io
 import os
+import re
+import secrets
 from dataclasses import dataclass
 import logging
 
@@ -19,6 +21,7 @@ app = FastAPI(title="CalenDO Image Generator", version="1.0.0")
 # Configuration
 FRANCE_TZ = pytz.timezone('Europe/Paris')
 BACKEND_URL = os.getenv("BACKEND_URL", "http://localhost:8080")
+TRACEPARENT_PATTERN = re.compile(r"^00-([0-9a-f]{32})-([0-9a-f]{16})-([0-9a-f]{2})$")
 CALENDO_THEME = {
     "primary": "#6B46C1",
     "primary_dark": "#553C9A",
@@ -556,16 +559,25 @@ class DayViewGenerator:
         return image
 
 
-async def fetch_events_from_backend(target_date: date, planning_ids: Optional[List[str]] = None, planning_names: Optional[List[str]] = None) -> List[Event]:
+def trace_headers(traceparent: Optional[str] = None) -> dict:
+    """W3C trace context for backend requests, continuing the caller's trace or starting a sampled one"""
+    if traceparent and TRACEPARENT_PATTERN.match(traceparent.strip().lower()):
+        return {"traceparent": traceparent.strip().lower()}
+    return {"traceparent": f"00-{secrets.token_hex(16)}-{secrets.token_hex(8)}-01"}
+
+
+async def fetch_events_from_backend(target_date: date, planning_ids: Optional[List[str]] = None, planning_names: Optional[List[str]] = None, traceparent: Optional[str] = None) -> List[Event]:
     """Fetch events from the CalenDO backend"""
+    # Both requests belong to the same trace, so the backend spans of one image are found together
+    headers = trace_headers(traceparent)
     try:
         # Fetch events
-        events_response = requests.get(f"{BACKEND_URL}/api/events")
+        events_response = requests.get(f"{BACKEND_URL}/api/events", headers=headers)
         events_response.raise_for_status()
         events_data = events_response.json()
 
         # Fetch plannings
-        plannings_response = requests.get(f"{BACKEND_URL}/api/plannings")
+        plannings_response = requests.get(f"{BACKEND_URL}/api/plannings", headers=headers)
         plannings_response.raise_for_status()
         plannings_data = plannings_response.json()
 
@@ -642,7 +654,8 @@ async def generate_day_view_image(
     width: int = Query(800, description="Image width in pixels"),
     height: int = Query(1000, description="Image height in pixels"),
     planning_id: Optional[List[str]] = Query(None, description="Filter by planning ID(s)"),
-    planning_name: Optional[List[str]] = Query(None, description="Filter by planning name(s)")
+    planning_name: Optional[List[str]] = Query(None, description="Filter by planning name(s)"),
+    traceparent: Optional[str] = Header(None, description="W3C trace context to continue")
 ):
     """Generate a day view image for the specified date"""
     try:
@@ -657,7 +670,7 @@ async def generate_day_view_image(
             target_date = datetime.now(FRANCE_TZ).date()
 
         # Fetch events
-        events = await fetch_events_from_backend(target_date, planning_id, planning_name)
+        events = await fetch_events_from_backend(target_date, planning_id, planning_name, traceparent)
 
         # Generate image
         generator = DayViewGenerator(width=width, height=height)